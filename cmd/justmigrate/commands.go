@@ -0,0 +1,1048 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/database"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/formatter"
+	"woodybriggs/justmigrate/migrate"
+	"woodybriggs/justmigrate/schema"
+)
+
+type Command func(args []string, stdout, stderr io.Writer) int
+
+var commands = map[string]Command{
+	"diff":    diffCommand,
+	"plan":    planCommand,
+	"apply":   applyCommand,
+	"verify":  verifyCommand,
+	"drift":   driftCommand,
+	"inspect": inspectCommand,
+	"fmt":     fmtCommand,
+	"lint":    lintCommand,
+
+	"generate": generateCommand,
+
+	// format is the name fmt had first
+	"format": fmtCommand,
+}
+
+// Options are the flags shared by every command, each command only
+// registers the ones it makes use of.
+type Options struct {
+	Db      string
+	Schema  string
+	From    string
+	To      string
+	Out     string
+	Dialect string
+	Dir     string
+
+	AllowDestructive bool
+	Renames          []diff.Rename
+}
+
+type optionFlag int
+
+const (
+	flagDb optionFlag = 1 << iota
+	flagSchema
+	flagOut
+	flagDialect
+	flagDir
+	flagAllowDestructive
+	flagRename
+	flagFrom
+	flagTo
+)
+
+func newFlagSet(name string, stderr io.Writer, opts *Options, flags optionFlag) *flag.FlagSet {
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.SetOutput(stderr)
+
+	if flags&flagDb != 0 {
+		set.StringVar(&opts.Db, "db", "", "url of the database to migrate, e.g. sqlite:local.db or postgres://localhost/app")
+	}
+	if flags&flagSchema != 0 {
+		set.StringVar(&opts.Schema, "schema", "", "target schema, a file, directory or glob, e.g. ./schema.sql")
+	}
+	if flags&flagFrom != 0 {
+		set.StringVar(&opts.From, "from", "", "schema to diff from: a file, '-', a directory, git:<rev>:<path> or a database url (default --db)")
+	}
+	if flags&flagTo != 0 {
+		set.StringVar(&opts.To, "to", "", "schema to diff to, any source --from takes (default --schema)")
+	}
+	if flags&flagOut != 0 {
+		set.StringVar(&opts.Out, "out", "-", "file to write output to, '-' for stdout")
+	}
+	if flags&flagDialect != 0 {
+		set.StringVar(&opts.Dialect, "dialect", "", "sql dialect of the schema, taken from --db when not given (default sqlite)")
+	}
+	if flags&flagDir != 0 {
+		set.StringVar(&opts.Dir, "dir", "migrations", "directory of migration files")
+	}
+	if flags&flagAllowDestructive != 0 {
+		set.BoolVar(&opts.AllowDestructive, "allow-destructive", false, "allow edits which lose data, such as dropping a table")
+	}
+	if flags&flagRename != 0 {
+		set.Func("rename", "a renamed table old=new or column table.old=new, may be repeated", func(text string) error {
+			rename, err := diff.ParseRename(text)
+			if err != nil {
+				return err
+			}
+			opts.Renames = append(opts.Renames, rename)
+			return nil
+		})
+	}
+
+	return set
+}
+
+func parseFlags(set *flag.FlagSet, args []string, opts *Options, required optionFlag) error {
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	if required&flagDb != 0 && opts.Db == "" {
+		return fmt.Errorf("%w: --db", ErrMissingFlag)
+	}
+	if required&flagSchema != 0 && opts.Schema == "" {
+		return fmt.Errorf("%w: --schema", ErrMissingFlag)
+	}
+	if required&flagFrom != 0 && opts.From == "" && opts.Db == "" {
+		return fmt.Errorf("%w: --from or --db", ErrMissingFlag)
+	}
+	if required&flagTo != 0 && opts.To == "" && opts.Schema == "" {
+		return fmt.Errorf("%w: --to or --schema", ErrMissingFlag)
+	}
+
+	return nil
+}
+
+func openOutput(out string, stdout io.Writer) (io.Writer, func() error, error) {
+	if out == "" || out == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+func fail(stderr io.Writer, command string, err error) int {
+	if !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(stderr, "justmigrate %s: %s\n", command, err)
+	}
+	return ExitError
+}
+
+// schemaDiff is the difference between two schemas, usually the schema in
+// a database and the schema file it is being brought in line with.
+type schemaDiff struct {
+	Differ  diff.Diff
+	Dialect Dialect
+	From    Schema
+	To      Schema
+	Edits   []diff.Edit
+}
+
+// Close closes the databases the schemas were read from.
+func (d schemaDiff) Close() error {
+	for _, db := range []Database{d.From.Db, d.To.Db} {
+		if db != nil {
+			db.Close()
+		}
+	}
+	return nil
+}
+
+// sources are the two sides of the diff, --from and --to, or --db and
+// --schema when they are not given.
+func (opts Options) sources() (Source, Source) {
+	from := Source{Kind: SourceDatabase, Url: opts.Db}
+	if opts.From != "" {
+		from = ParseSource(opts.From)
+	}
+	to := SchemaSource(opts.Schema)
+	if opts.To != "" {
+		to = ParseSource(opts.To)
+	}
+	return from, to
+}
+
+// loadEdits runs the shared front half of diff, plan and apply, reading both
+// schemas and diffing one against the other. a database is opened with
+// open, commands which do not write to it use database.OpenReadOnly.
+func loadEdits(opts Options, open func(rawUrl string) (Database, error)) (schemaDiff, error) {
+	dialect, err := ResolveDialect(opts)
+	if err != nil {
+		return schemaDiff{}, err
+	}
+
+	fromSource, toSource := opts.sources()
+
+	to, err := toSource.Load(dialect, os.Stdin, open)
+	if err != nil {
+		return schemaDiff{}, err
+	}
+
+	from, err := fromSource.Load(dialect, os.Stdin, open)
+	if err != nil {
+		schemaDiff{To: to}.Close()
+		return schemaDiff{}, err
+	}
+
+	d := schemaDiff{
+		Differ:  dialect.Differ(opts.Renames),
+		Dialect: dialect,
+		From:    from,
+		To:      to,
+	}
+
+	d.Edits, err = d.Differ.DiffSchema(from.Statements, to.Statements)
+	if err != nil {
+		d.Close()
+		return schemaDiff{}, err
+	}
+
+	return d, nil
+}
+
+// suggestRenames warns about the removed and added tables and columns which
+// look like they have been renamed.
+func (d schemaDiff) suggestRenames(stderr io.Writer) {
+	for _, rename := range d.Differ.SuggestRenames(d.Edits) {
+		fmt.Fprintf(stderr, "warning: '%s' looks like a rename, confirm it with --rename %s or a '-- justmigrate:%s %s' comment\n",
+			rename, rename, diff.RenamedFrom, rename.From)
+	}
+}
+
+// checkSafety reports the edits which could block the database for a while,
+// and refuses the ones which lose data unless they are allowed, either by
+// allowDestructive or by an annotation in the schema.
+func (d schemaDiff) checkSafety(allowDestructive bool, stderr io.Writer) error {
+	classifications := diff.Classify(d.Edits)
+	ShowWarnings(diff.Cautions(classifications), stderr)
+
+	if allowDestructive {
+		return nil
+	}
+
+	blocked := diff.Blocked(classifications, diff.ApprovedLosses(string(d.To.Source.Raw)))
+	if len(blocked) > 0 {
+		ShowErrors(blocked, stderr)
+		return fmt.Errorf("%w: %d edit(s) would lose data", ErrDestructive, len(blocked))
+	}
+	return nil
+}
+
+// upMigration is the sql which brings the database in line with the schema.
+func (d schemaDiff) upMigration() ([]byte, error) {
+	migration := bytes.Buffer{}
+	if err := generate(d.Dialect, d.Edits, &migration); err != nil {
+		return nil, err
+	}
+	return migration.Bytes(), nil
+}
+
+// downMigration is the sql which undoes upMigration, headed by a warning
+// comment for each piece of data which is lost or converted going down, as
+// checkSafety classifies it, or is not brought back by it.
+func (d schemaDiff) downMigration() ([]byte, error) {
+	edits, warnings, err := diff.Invert(d.Edits, d.From.Statements)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(diff.Unrecoverable(diff.Classify(edits)), warnings...)
+
+	migration := bytes.Buffer{}
+	for _, warning := range warnings {
+		fmt.Fprintf(&migration, "-- WARNING: %s\n", warning)
+	}
+	if len(warnings) > 0 {
+		migration.WriteString("\n")
+	}
+	if err := generate(d.Dialect, edits, &migration); err != nil {
+		return nil, err
+	}
+	return migration.Bytes(), nil
+}
+
+// generate writes the migration which makes edits, along with the warnings
+// of a generator which has any.
+func generate(dialect Dialect, edits []diff.Edit, w io.Writer) error {
+	generator := dialect.NewGenerator(edits)
+	if err := generator.Generate(w); err != nil {
+		return err
+	}
+	if warner, ok := generator.(Warner); ok {
+		ShowWarnings(warner.Warnings(), os.Stderr)
+	}
+	return nil
+}
+
+func changesExitCode(edits []diff.Edit) int {
+	if len(edits) > 0 {
+		return ExitChangesPending
+	}
+	return ExitNoChanges
+}
+
+func diffCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("diff", stderr, &opts, flagDb|flagSchema|flagFrom|flagTo|flagOut|flagDialect|flagRename)
+	if err := parseFlags(set, args, &opts, flagFrom|flagTo); err != nil {
+		return fail(stderr, "diff", err)
+	}
+
+	schemaDiff, err := loadEdits(opts, database.OpenReadOnly)
+	if err != nil {
+		return fail(stderr, "diff", err)
+	}
+	defer schemaDiff.Close()
+	edits := schemaDiff.Edits
+
+	schemaDiff.suggestRenames(stderr)
+
+	out, closeOut, err := openOutput(opts.Out, stdout)
+	if err != nil {
+		return fail(stderr, "diff", err)
+	}
+	defer closeOut()
+
+	for _, edit := range edits {
+		fmt.Fprintln(out, edit.String())
+	}
+
+	return changesExitCode(edits)
+}
+
+func planCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("plan", stderr, &opts, flagDb|flagSchema|flagFrom|flagTo|flagOut|flagDialect|flagAllowDestructive|flagRename)
+	down := set.Bool("down", false, "print the migration which undoes the changes instead")
+	if err := parseFlags(set, args, &opts, flagFrom|flagTo); err != nil {
+		return fail(stderr, "plan", err)
+	}
+
+	schemaDiff, err := loadEdits(opts, database.OpenReadOnly)
+	if err != nil {
+		return fail(stderr, "plan", err)
+	}
+	defer schemaDiff.Close()
+
+	schemaDiff.suggestRenames(stderr)
+	if err := schemaDiff.checkSafety(opts.AllowDestructive, stderr); err != nil {
+		return fail(stderr, "plan", err)
+	}
+
+	out, closeOut, err := openOutput(opts.Out, stdout)
+	if err != nil {
+		return fail(stderr, "plan", err)
+	}
+	defer closeOut()
+
+	migration, err := schemaDiff.upMigration()
+	if *down {
+		migration, err = schemaDiff.downMigration()
+	}
+	if err != nil {
+		return fail(stderr, "plan", err)
+	}
+	if _, err := out.Write(migration); err != nil {
+		return fail(stderr, "plan", err)
+	}
+
+	return changesExitCode(schemaDiff.Edits)
+}
+
+// applyCommand runs either the given migration files, or the migration
+// between --db and --schema, recording each in the history table.
+func applyCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("apply", stderr, &opts, flagDb|flagSchema|flagDialect|flagAllowDestructive|flagRename)
+	id := set.String("id", "", "id to record a --schema migration under (default the current time)")
+	set.Usage = func() {
+		fmt.Fprintln(set.Output(), "usage: justmigrate apply --db <database> (--schema <schema> | <migration.sql>...)")
+		set.PrintDefaults()
+	}
+	if err := parseFlags(set, args, &opts, flagDb); err != nil {
+		return fail(stderr, "apply", err)
+	}
+
+	files := set.Args()
+	if opts.Schema == "" && len(files) == 0 {
+		return fail(stderr, "apply", fmt.Errorf("%w: --schema or migration files", ErrMissingFlag))
+	}
+
+	dialect, err := ResolveDialect(opts)
+	if err != nil {
+		return fail(stderr, "apply", err)
+	}
+
+	migrations := []migrate.Migration{}
+	var db Database
+
+	if len(files) > 0 {
+
+		for _, file := range files {
+			if parsed, ok := migrate.ParseFileName(file); ok && parsed.Direction == migrate.Down {
+				return fail(stderr, "apply", fmt.Errorf("%w: %s", ErrDownMigration, file))
+			}
+			text, err := os.ReadFile(file)
+			if err != nil {
+				return fail(stderr, "apply", err)
+			}
+			migrations = append(migrations, migrate.Migration{
+				Id:  migrate.FileId(file),
+				Sql: string(text),
+			})
+		}
+
+		db, err = database.Open(opts.Db)
+		if err != nil {
+			return fail(stderr, "apply", err)
+		}
+	} else {
+		schemaDiff, err := loadEdits(opts, database.Open)
+		if err != nil {
+			return fail(stderr, "apply", err)
+		}
+		db = schemaDiff.From.Db
+
+		if len(schemaDiff.Edits) == 0 {
+			db.Close()
+			fmt.Fprintln(stdout, "nothing to apply")
+			return ExitNoChanges
+		}
+
+		schemaDiff.suggestRenames(stderr)
+		if err := schemaDiff.checkSafety(opts.AllowDestructive, stderr); err != nil {
+			db.Close()
+			return fail(stderr, "apply", err)
+		}
+
+		up, err := schemaDiff.upMigration()
+		if err != nil {
+			db.Close()
+			return fail(stderr, "apply", err)
+		}
+
+		migration := migrate.Migration{
+			Id:  *id,
+			Sql: string(up),
+		}
+		if migration.Id == "" {
+			// the checksum keeps two different migrations made in the same
+			// second apart.
+			migration.Id = time.Now().UTC().Format("20060102150405") + "_" + migration.Checksum()[:8]
+		}
+		migrations = append(migrations, migration)
+	}
+	defer db.Close()
+
+	// migrations manage their own transactions, so they have to run on the
+	// one connection for a failed migration to be rolled back.
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fail(stderr, "apply", err)
+	}
+	defer conn.Close()
+
+	runner := migrate.NewRunner(conn, Version)
+	runner.Placeholder = dialect.Placeholder
+	for _, migration := range migrations {
+		applied, err := runner.Apply(ctx, migration)
+		if err != nil {
+			return fail(stderr, "apply", err)
+		}
+		if applied {
+			fmt.Fprintf(stdout, "applied %s\n", migration.Id)
+		} else {
+			fmt.Fprintf(stdout, "skipped %s, already applied\n", migration.Id)
+		}
+	}
+
+	return ExitNoChanges
+}
+
+// verifyCommand proves the migration between --db and --schema converges.
+// it is applied to a shadow database, a copy of --db, which is read again
+// and must have nothing left to diff against the schema.
+func verifyCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("verify", stderr, &opts, flagDb|flagSchema|flagFrom|flagTo|flagDialect|flagRename)
+	if err := parseFlags(set, args, &opts, flagFrom|flagTo); err != nil {
+		return fail(stderr, "verify", err)
+	}
+
+	schemaDiff, err := loadEdits(opts, database.OpenReadOnly)
+	if err != nil {
+		return fail(stderr, "verify", err)
+	}
+	defer schemaDiff.Close()
+
+	// the shadow database is sqlite, so only a migration for sqlite can be
+	// run against it.
+	if schemaDiff.Dialect.Name != "sqlite" {
+		return fail(stderr, "verify", fmt.Errorf("%w: the shadow database is sqlite, not %s", ErrCanNotVerify, schemaDiff.Dialect.Name))
+	}
+
+	schemaDiff.suggestRenames(stderr)
+
+	up, err := schemaDiff.upMigration()
+	if err != nil {
+		return fail(stderr, "verify", err)
+	}
+
+	shadow, closeShadow, err := schemaDiff.shadow()
+	if err != nil {
+		return fail(stderr, "verify", err)
+	}
+	defer closeShadow()
+
+	ctx := context.Background()
+	conn, err := shadow.Conn(ctx)
+	if err != nil {
+		return fail(stderr, "verify", err)
+	}
+	runner := migrate.NewRunner(conn, Version)
+	runner.Placeholder = schemaDiff.Dialect.Placeholder
+	_, err = runner.Apply(ctx, migrate.Migration{Id: "verify", Sql: string(up)})
+	conn.Close()
+	if err != nil {
+		return fail(stderr, "verify", fmt.Errorf("the migration fails on the shadow database: %w", err))
+	}
+
+	_, statements, err := AstFromDatabase(schemaDiff.Dialect, shadow)
+	if err != nil {
+		return fail(stderr, "verify", err)
+	}
+
+	differ := schemaDiff.Dialect.Differ(nil)
+	remaining, err := differ.DiffSchema(statements, schemaDiff.To.Statements)
+	if err != nil {
+		return fail(stderr, "verify", err)
+	}
+	if len(remaining) > 0 {
+		for _, edit := range remaining {
+			fmt.Fprintln(stdout, edit.String())
+		}
+		return fail(stderr, "verify", fmt.Errorf("%w: %d edit(s) are left", ErrNotConverged, len(remaining)))
+	}
+
+	fmt.Fprintf(stdout, "verified, %d edit(s) bring the database in line with the schema\n", len(schemaDiff.Edits))
+	return ExitNoChanges
+}
+
+// shadow makes a sqlite database in a temporary file with the schema the
+// diff is from. it is a copy of the database when the diff is from a sqlite
+// database, and is otherwise built from the statements of the schema.
+func (d schemaDiff) shadow() (Database, func(), error) {
+	file, err := os.CreateTemp("", "justmigrate-shadow-*.db")
+	if err != nil {
+		return nil, nil, err
+	}
+	path := file.Name()
+	file.Close()
+
+	sqlite, isSqlite := d.From.Db.(*database.Sqlite)
+	if isSqlite {
+		if err := sqlite.CopyTo(path); err != nil {
+			os.Remove(path)
+			return nil, nil, err
+		}
+	}
+
+	shadow, err := database.Open(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, nil, err
+	}
+	closeShadow := func() {
+		shadow.Close()
+		os.Remove(path)
+	}
+
+	if !isSqlite {
+		script := strings.Builder{}
+		core := d.Dialect.NewFormatter(&script)
+		for _, statement := range d.From.Statements {
+			statement.ToSql(core)
+			core.Rune(';')
+			core.Break()
+		}
+		if _, err := shadow.(*database.Sqlite).Exec(script.String()); err != nil {
+			closeShadow()
+			return nil, nil, fmt.Errorf("can not build the shadow database: %w", err)
+		}
+	}
+
+	return shadow, closeShadow, nil
+}
+
+// driftSummary is what drift prints with --json, for alerting.
+type driftSummary struct {
+	Database    string       `json:"database"`
+	Migrations  string       `json:"migrations"`
+	Replayed    int          `json:"replayed"`
+	Drift       bool         `json:"drift"`
+	Differences []diff.Drift `json:"differences"`
+}
+
+// driftCommand finds the changes made to --db other than by migrations.
+// every up migration in --dir is replayed into an in memory database, which
+// is diffed against --db, and each difference is reported.
+func driftCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("drift", stderr, &opts, flagDb|flagDialect|flagDir)
+	asJson := set.Bool("json", false, "print a summary as json")
+	if err := parseFlags(set, args, &opts, flagDb); err != nil {
+		return fail(stderr, "drift", err)
+	}
+
+	dialect, err := ResolveDialect(opts)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+	if dialect.Name != "sqlite" {
+		return fail(stderr, "drift", fmt.Errorf("%w: they are replayed into sqlite, not %s", ErrCanNotReplay, dialect.Name))
+	}
+
+	files, err := migrate.ReadDir(opts.Dir)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+
+	replay, replayed, err := replayMigrations(dialect, files)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+	defer replay.Close()
+
+	_, expected, err := AstFromDatabase(dialect, replay)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+
+	db, err := database.OpenReadOnly(opts.Db)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+	defer db.Close()
+
+	_, actual, err := AstFromDatabase(dialect, db)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+
+	differ := dialect.Differ(nil)
+	edits, err := differ.DiffSchema(expected, actual)
+	if err != nil {
+		return fail(stderr, "drift", err)
+	}
+
+	drifts := diff.Drifts(edits)
+	ShowWarnings(diff.DriftReports(drifts), stderr)
+
+	summary := driftSummary{
+		Database:    opts.Db,
+		Migrations:  opts.Dir,
+		Replayed:    replayed,
+		Drift:       len(drifts) > 0,
+		Differences: drifts,
+	}
+	if *asJson {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summary); err != nil {
+			return fail(stderr, "drift", err)
+		}
+	} else {
+		fmt.Fprintf(stdout, "%d difference(s) between %s and the %d migration(s) in %s\n", len(drifts), opts.Db, replayed, opts.Dir)
+	}
+
+	return changesExitCode(edits)
+}
+
+// replayMigrations applies the up migrations in files, in order, to a new in
+// memory database.
+func replayMigrations(dialect Dialect, files []migrate.File) (Database, int, error) {
+	db, err := database.Open("sqlite::memory:")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	runner := migrate.NewRunner(conn, Version)
+	runner.Placeholder = dialect.Placeholder
+
+	replayed := 0
+	for _, file := range files {
+		if file.Direction != migrate.Up {
+			continue
+		}
+		text, err := os.ReadFile(file.Path)
+		if err != nil {
+			db.Close()
+			return nil, 0, err
+		}
+		if _, err := runner.Apply(ctx, migrate.Migration{Id: file.Id(), Sql: string(text)}); err != nil {
+			db.Close()
+			return nil, 0, fmt.Errorf("replaying %s: %w", file.Path, err)
+		}
+		replayed++
+	}
+
+	return db, replayed, nil
+}
+
+// generateCommand writes the migration between --db and --schema as the
+// next numbered file in --dir.
+func generateCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("generate", stderr, &opts, flagDb|flagSchema|flagDialect|flagDir|flagAllowDestructive|flagRename)
+	name := set.String("name", "migration", "description to put in the file name")
+	timestamp := set.Bool("timestamp", false, "number the file with the current time instead of sequentially")
+	if err := parseFlags(set, args, &opts, flagDb|flagSchema); err != nil {
+		return fail(stderr, "generate", err)
+	}
+
+	numbering := migrate.NumberSequential
+	if *timestamp {
+		numbering = migrate.NumberTimestamp
+	}
+
+	files, err := migrate.ReadDir(opts.Dir)
+	if err != nil {
+		return fail(stderr, "generate", err)
+	}
+	for _, problem := range migrate.CheckFiles(files, numbering) {
+		fmt.Fprintf(stderr, "justmigrate generate: warning: %s\n", problem)
+	}
+
+	schemaDiff, err := loadEdits(opts, database.OpenReadOnly)
+	if err != nil {
+		return fail(stderr, "generate", err)
+	}
+	defer schemaDiff.Close()
+
+	if len(schemaDiff.Edits) == 0 {
+		fmt.Fprintln(stdout, "nothing to generate")
+		return ExitNoChanges
+	}
+
+	schemaDiff.suggestRenames(stderr)
+	if err := schemaDiff.checkSafety(opts.AllowDestructive, stderr); err != nil {
+		return fail(stderr, "generate", err)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return fail(stderr, "generate", err)
+	}
+
+	number := migrate.NextNumber(files, numbering, time.Now())
+	up := filepath.Join(opts.Dir, migrate.FileName(number, *name, migrate.Up))
+	down := filepath.Join(opts.Dir, migrate.FileName(number, *name, migrate.Down))
+
+	upMigration, err := schemaDiff.upMigration()
+	if err != nil {
+		return fail(stderr, "generate", err)
+	}
+	downMigration, err := schemaDiff.downMigration()
+	if err != nil {
+		return fail(stderr, "generate", err)
+	}
+
+	if err := writeNewFile(up, upMigration); err != nil {
+		return fail(stderr, "generate", err)
+	}
+	if err := writeNewFile(down, downMigration); err != nil {
+		return fail(stderr, "generate", err)
+	}
+
+	fmt.Fprintln(stdout, up)
+	fmt.Fprintln(stdout, down)
+
+	return ExitNoChanges
+}
+
+// writeNewFile is os.WriteFile for a file which must not already exist, so
+// that a migration written by someone else in the meantime is never
+// overwritten.
+func writeNewFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func inspectCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("inspect", stderr, &opts, flagDb|flagOut|flagDialect)
+	if err := parseFlags(set, args, &opts, flagDb); err != nil {
+		return fail(stderr, "inspect", err)
+	}
+
+	if _, err := ResolveDialect(opts); err != nil {
+		return fail(stderr, "inspect", err)
+	}
+
+	db, err := database.OpenReadOnly(opts.Db)
+	if err != nil {
+		return fail(stderr, "inspect", err)
+	}
+	defer db.Close()
+
+	definitions, err := db.ExportDataDefinitions()
+	if err != nil {
+		return fail(stderr, "inspect", err)
+	}
+
+	out, closeOut, err := openOutput(opts.Out, stdout)
+	if err != nil {
+		return fail(stderr, "inspect", err)
+	}
+	defer closeOut()
+
+	io.WriteString(out, definitions)
+
+	return ExitNoChanges
+}
+
+// fmtCommand prints --schema in canonical form, with the same keyword case,
+// quoting and layout throughout and the comments kept where they were.
+func fmtCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("fmt", stderr, &opts, flagSchema|flagOut|flagDialect)
+	check := set.Bool("check", false, "print the name of each schema file which is not formatted and exit with 2, rather than print them")
+	width := set.Int("width", 80, "width statements are fitted into")
+	align := set.Bool("align", true, "line up the names and types of columns")
+
+	style := formatter.Style{
+		KeywordCase:  formatter.KeywordCaseUpper,
+		Quote:        formatter.QuoteAsWritten,
+		KeepComments: true,
+	}
+	set.Func("keyword-case", "case keywords are written in, upper or lower (default upper)", func(text string) error {
+		switch text {
+		case "upper":
+			style.KeywordCase = formatter.KeywordCaseUpper
+		case "lower":
+			style.KeywordCase = formatter.KeywordCaseLower
+		default:
+			return fmt.Errorf("%w: '%s', expected upper or lower", ErrInvalidFlag, text)
+		}
+		return nil
+	})
+	set.Func("quote", "names to quote, always or as-written (default as-written)", func(text string) error {
+		switch text {
+		case "always":
+			style.Quote = formatter.QuoteAlways
+		case "as-written":
+			style.Quote = formatter.QuoteAsWritten
+		default:
+			return fmt.Errorf("%w: '%s', expected always or as-written", ErrInvalidFlag, text)
+		}
+		return nil
+	})
+
+	if err := parseFlags(set, args, &opts, flagSchema); err != nil {
+		return fail(stderr, "fmt", err)
+	}
+	style.AlignColumns = *align
+
+	dialect, err := ResolveDialect(opts)
+	if err != nil {
+		return fail(stderr, "fmt", err)
+	}
+
+	// each file of the schema is formatted on its own, they are printed in
+	// the order they are read in with a blank line between them.
+	files, err := schema.Files(opts.Schema)
+	if err != nil {
+		return fail(stderr, "fmt", err)
+	}
+
+	formatted := bytes.Buffer{}
+	unformatted := []string{}
+	for i, file := range files {
+		result, err := formatSchema(dialect, file, *width, style, stderr)
+		if err != nil {
+			return fail(stderr, "fmt", err)
+		}
+		if string(file.Raw) != string(result) {
+			unformatted = append(unformatted, file.FileName)
+		}
+		if i > 0 {
+			formatted.WriteRune('\n')
+		}
+		formatted.Write(result)
+	}
+
+	if *check {
+		for _, name := range unformatted {
+			fmt.Fprintln(stdout, name)
+		}
+		if len(unformatted) > 0 {
+			return ExitChangesPending
+		}
+		return ExitNoChanges
+	}
+
+	out, closeOut, err := openOutput(opts.Out, stdout)
+	if err != nil {
+		return fail(stderr, "fmt", err)
+	}
+	defer closeOut()
+
+	if _, err := out.Write(formatted.Bytes()); err != nil {
+		return fail(stderr, "fmt", err)
+	}
+
+	return ExitNoChanges
+}
+
+// formatSchema formats the statements of one file of a schema, the errors
+// of a file which does not parse are shown on stderr.
+func formatSchema(dialect Dialect, source luther.SourceCode, width int, style formatter.Style, stderr io.Writer) ([]byte, error) {
+	parser := dialect.NewParser(luther.NewLexer(source))
+	statements := parser.Statements()
+	if errors := parser.Errors(); len(errors) > 0 {
+		ShowErrors(errors, stderr)
+		return nil, ErrParserErrors
+	}
+
+	formatted := bytes.Buffer{}
+	core := dialect.NewFormatter(&formatted).WithMaxWidth(width).WithStyle(style)
+	for i, statement := range statements {
+		// the trivia of a statement starts with the comment on the line of
+		// the `;` before it, which is written before the blank line.
+		core.Trivia(ast.LeadingTrivia(statement))
+		if i > 0 {
+			core.Break()
+			core.Break()
+		}
+		statement.ToSql(core)
+		core.Rune(';')
+	}
+	core.Trivia(parser.Current().LeadingTrivia)
+	core.Break()
+	core.Flush()
+
+	if lost := lostComments(source, formatted.String()); len(lost) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCommentsLost, strings.Join(lost, ", "))
+	}
+	return formatted.Bytes(), nil
+}
+
+// lostComments are the comments in source which are not in formatted, each
+// with the line it is on. they are the comments in a place the formatter
+// does not keep them, such as the middle of an expression.
+func lostComments(source luther.SourceCode, formatted string) []string {
+	kept := map[string]int{}
+	for _, comment := range sourceComments(luther.SourceCode{Raw: []rune(formatted)}) {
+		kept[comment]++
+	}
+
+	raw := string(source.Raw)
+	lost := []string{}
+	cursor := 0
+	for _, comment := range sourceComments(source) {
+		at := cursor + strings.Index(raw[cursor:], comment)
+		cursor = at + len(comment)
+
+		if kept[comment] > 0 {
+			kept[comment]--
+			continue
+		}
+		line := strings.Count(raw[:at], "\n") + 1
+		lost = append(lost, fmt.Sprintf("%s:%d", source.FileName, line))
+	}
+	return lost
+}
+
+// sourceComments are the comments in source, in order.
+func sourceComments(source luther.SourceCode) []string {
+	comments := []string{}
+	lexer := luther.NewLexer(source)
+	for {
+		token := lexer.NextToken()
+		for _, comment := range formatter.Comments(token.LeadingTrivia + token.TrailingTrivia) {
+			comments = append(comments, comment.Text)
+		}
+		if token.Kind == tik.TokenKind_EOF {
+			return comments
+		}
+	}
+}
+
+func lintCommand(args []string, stdout, stderr io.Writer) int {
+	opts := Options{}
+	set := newFlagSet("lint", stderr, &opts, flagSchema|flagDialect)
+	if err := parseFlags(set, args, &opts, flagSchema); err != nil {
+		return fail(stderr, "lint", err)
+	}
+
+	dialect, err := ResolveDialect(opts)
+	if err != nil {
+		return fail(stderr, "lint", err)
+	}
+
+	files, err := schema.Files(opts.Schema)
+	if err != nil {
+		return fail(stderr, "lint", err)
+	}
+
+	errors := []report.Report{}
+	warnings := []report.Report{}
+	statements := []ast.Statement{}
+	for _, file := range files {
+		parser := dialect.NewParser(luther.NewLexer(file))
+		statements = append(statements, parser.Statements()...)
+		errors = append(errors, parser.Errors()...)
+		warnings = append(warnings, parser.Warnings()...)
+	}
+	errors = append(errors, schema.Duplicates(statements)...)
+
+	ShowErrors(errors, stderr)
+	ShowWarnings(warnings, stderr)
+
+	fmt.Fprintf(stdout, "%s: %d error(s), %d warning(s)\n", opts.Schema, len(errors), len(warnings))
+
+	if len(errors) > 0 {
+		return ExitError
+	}
+
+	return ExitNoChanges
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/database"
+	mysql "woodybriggs/justmigrate/dialects/mysql/generator"
+	mysqlparser "woodybriggs/justmigrate/dialects/mysql/parser"
+	postgres "woodybriggs/justmigrate/dialects/postgres/generator"
+	postgresparser "woodybriggs/justmigrate/dialects/postgres/parser"
+	sqlite "woodybriggs/justmigrate/dialects/sqlite/generator"
+	sqliteparser "woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/formatter"
+)
+
+type Database = database.Database
+
+// Introspector is a database which can read its own schema into statements
+// rather than export it as text, with warnings for what it had to guess.
+type Introspector interface {
+	Introspect() ([]ast.Statement, []report.Report, error)
+}
+
+type Parser interface {
+	Statements() []ast.Statement
+	Current() tik.Token
+	Errors() []report.Report
+	Warnings() []report.Report
+}
+
+type Generator interface {
+	Generate(writer io.Writer) error
+}
+
+// Warner is a generator with warnings about the migration it generated.
+type Warner interface {
+	Warnings() []report.Report
+}
+
+// Dialect ties together everything needed to migrate one flavour of sql,
+// a parser for the schema and a generator for the migration. databases are
+// opened by the url given to --db, see database.Register.
+type Dialect struct {
+	Name         string
+	NewParser    func(lexer *luther.Lexer) Parser
+	NewGenerator func(edits []diff.Edit) Generator
+	NewFormatter func(writer io.Writer) *formatter.CoreFormatter
+
+	// NormalizeColumn is the form the differ compares columns in, see
+	// diff.Diff.
+	NormalizeColumn func(column ast.ColumnDefinition) ast.ColumnDefinition
+
+	// Placeholder is the n'th parameter of a query, nil for `$1`.
+	Placeholder func(n int) string
+}
+
+var dialects = map[string]Dialect{
+	"sqlite": {
+		Name: "sqlite",
+		NewParser: func(lexer *luther.Lexer) Parser {
+			return sqliteparser.NewSqliteParser(lexer)
+		},
+		NewGenerator: func(edits []diff.Edit) Generator {
+			return sqlite.NewSqliteGenerator(edits)
+		},
+		NewFormatter: func(writer io.Writer) *formatter.CoreFormatter {
+			return formatter.NewCoreFormatter(writer, 80, "\"\"")
+		},
+	},
+	"postgres": {
+		Name: "postgres",
+		NewParser: func(lexer *luther.Lexer) Parser {
+			return postgresparser.NewPostgresParser(lexer)
+		},
+		NewGenerator: func(edits []diff.Edit) Generator {
+			return postgres.NewPostgresGenerator(edits)
+		},
+		NewFormatter: func(writer io.Writer) *formatter.CoreFormatter {
+			return formatter.NewCoreFormatter(writer, 80, "\"\"")
+		},
+		NormalizeColumn: postgresparser.NormalizeColumn,
+	},
+	"mysql": {
+		Name: "mysql",
+		NewParser: func(lexer *luther.Lexer) Parser {
+			return mysqlparser.NewMysqlParser(lexer)
+		},
+		NewGenerator: func(edits []diff.Edit) Generator {
+			return mysql.NewMysqlGenerator(edits)
+		},
+		NewFormatter: func(writer io.Writer) *formatter.CoreFormatter {
+			return formatter.NewCoreFormatter(writer, 80, "``")
+		},
+		NormalizeColumn: mysqlparser.NormalizeColumn,
+		Placeholder: func(n int) string {
+			return "?"
+		},
+	},
+}
+
+// Differ diffs schemas of the dialect, with renames known on top of the ones
+// annotated in the schema.
+func (dialect Dialect) Differ(renames []diff.Rename) diff.Diff {
+	return diff.Diff{Renames: renames, NormalizeColumn: dialect.NormalizeColumn}
+}
+
+func LookupDialect(name string) (Dialect, error) {
+	dialect, ok := dialects[strings.ToLower(name)]
+	if !ok {
+		names := []string{}
+		for name := range dialects {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return Dialect{}, fmt.Errorf("%w: '%s' (available: %s)", ErrUnknownDialect, name, strings.Join(names, ", "))
+	}
+	return dialect, nil
+}
+
+// ResolveDialect is the dialect named by --dialect, or the dialect of the
+// databases being read when it is not given, or sqlite when neither is.
+func ResolveDialect(opts Options) (Dialect, error) {
+	urls := []string{}
+	if opts.Db != "" {
+		urls = append(urls, opts.Db)
+	}
+	for _, spec := range []string{opts.From, opts.To} {
+		if source := ParseSource(spec); spec != "" && source.Kind == SourceDatabase {
+			urls = append(urls, source.Url)
+		}
+	}
+
+	name := opts.Dialect
+	for _, url := range urls {
+		dbDialect, err := database.DialectOf(url)
+		if err != nil {
+			return Dialect{}, err
+		}
+		if name != "" && !strings.EqualFold(name, dbDialect) {
+			return Dialect{}, fmt.Errorf("%w: %s with a %s database", ErrDialectMismatch, name, dbDialect)
+		}
+		name = dbDialect
+	}
+	if name == "" {
+		name = "sqlite"
+	}
+	return LookupDialect(name)
+}
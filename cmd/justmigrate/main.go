@@ -1,24 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"io"
-	"log"
-	"maps"
 	"os"
-	"slices"
 
 	"woodybriggs/justmigrate/core/ast"
 	"woodybriggs/justmigrate/core/luther"
 	"woodybriggs/justmigrate/core/report"
-	"woodybriggs/justmigrate/database"
-	sqlite "woodybriggs/justmigrate/dialects/sqlite/generator"
-	"woodybriggs/justmigrate/diff"
-	"woodybriggs/justmigrate/parser"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 var (
@@ -26,7 +16,30 @@ var (
 )
 
 var (
-	ErrParserErrors = errors.New("parser has errors")
+	ErrParserErrors    = errors.New("parser has errors")
+	ErrUnknownDialect  = errors.New("unknown dialect")
+	ErrDialectMismatch = errors.New("dialect does not match the database")
+	ErrMissingFlag     = errors.New("missing required flag")
+	ErrDownMigration   = errors.New("down migrations can not be applied")
+	ErrDestructive     = errors.New("refusing destructive changes")
+	ErrDuplicates      = errors.New("objects are defined more than once")
+	ErrNotConverged    = errors.New("the migration does not bring the database in line with the schema")
+	ErrCanNotVerify    = errors.New("can not verify a migration")
+	ErrCanNotReplay    = errors.New("can not replay migrations")
+	ErrInvalidFlag     = errors.New("invalid flag value")
+	ErrCommentsLost    = errors.New("comments can not be kept, move them above the statement or column they are in")
+)
+
+// Version is recorded against every applied migration, release builds set it
+// with -ldflags "-X main.Version=v1.2.3".
+var Version = "dev"
+
+// exit codes, chosen so that scripts and ci can tell the difference between
+// "something went wrong" and "the database is behind the schema".
+const (
+	ExitNoChanges      = 0
+	ExitError          = 1
+	ExitChangesPending = 2
 )
 
 func assert(cond bool, err error) {
@@ -35,11 +48,6 @@ func assert(cond bool, err error) {
 	}
 }
 
-type Database interface {
-	Url() string
-	ExportDataDefinitions() (string, error)
-}
-
 func ShowErrors(errors []report.Report, w io.Writer) {
 	errorRenderer := report.Renderer{}
 	for _, report := range errors {
@@ -54,98 +62,87 @@ func ShowWarnings(warnings []report.Report, w io.Writer) {
 	}
 }
 
-func AstFromDatabase(database Database) (luther.SourceCode, []ast.Statement, error) {
-	source, err := database.ExportDataDefinitions()
-	if err != nil {
-		return luther.SourceCode{}, nil, err
-	}
-
-	lexer := luther.NewLexer(
-		luther.SourceCode{
-			FileName: database.Url(),
-			Raw:      []rune(source),
-		},
-	)
-
-	parser := parser.NewParser(lexer)
+func AstFromSource(dialect Dialect, source luther.SourceCode) (luther.SourceCode, []ast.Statement, error) {
+	parser := dialect.NewParser(luther.NewLexer(source))
 
 	nodes := parser.Statements()
-	errors := slices.Collect(maps.Values(parser.Errors))
+	errors := parser.Errors()
 	if len(errors) > 0 {
 		ShowErrors(errors, os.Stderr)
-		return parser.Lexer.SourceCode, nil, ErrParserErrors
+		return source, nil, ErrParserErrors
 	}
 
-	// warnings := slices.Collect(maps.Values(parser.Warnings))
-	// if len(warnings) > 0 {
-	// 	ShowWarnings(warnings, os.Stderr)
-	// }
-
-	return parser.Lexer.SourceCode, nodes, nil
+	return source, nodes, nil
 }
 
-func AstFromFile(file *os.File) (luther.SourceCode, []ast.Statement, error) {
-	lexer, err := luther.NewLexerFromFile(file)
-	if err != nil {
-		return lexer.SourceCode, nil, err
+func AstFromDatabase(dialect Dialect, database Database) (luther.SourceCode, []ast.Statement, error) {
+	if introspector, ok := database.(Introspector); ok {
+		statements, warnings, err := introspector.Introspect()
+		if err != nil {
+			return luther.SourceCode{}, nil, err
+		}
+		ShowWarnings(warnings, os.Stderr)
+		return luther.SourceCode{FileName: database.Url()}, statements, nil
 	}
 
-	parser := parser.NewParser(lexer)
-
-	nodes := parser.Statements()
-	errors := slices.Collect(maps.Values(parser.Errors))
-
-	if len(errors) > 0 {
-		ShowErrors(errors, os.Stderr)
-		return lexer.SourceCode, nil, ErrParserErrors
+	source, err := database.ExportDataDefinitions()
+	if err != nil {
+		return luther.SourceCode{}, nil, err
 	}
 
-	// warnings := slices.Collect(maps.Values(parser.Warnings))
-	// if len(warnings) > 0 {
-	// 	ShowWarnings(warnings, os.Stderr)
-	// }
-
-	return lexer.SourceCode, nodes, nil
+	return AstFromSource(
+		dialect,
+		luther.SourceCode{
+			FileName: database.Url(),
+			Raw:      []rune(source),
+		},
+	)
 }
 
-func main() {
-
-	var err error
-
-	databaseURL := "/Users/woodybriggs/Projects/ts/currx/database/local.db"
+const usage = `usage: justmigrate <command> [flags]
 
-	conn, err := sql.Open("sqlite3", databaseURL)
-	if err != nil {
-		log.Panicln(err)
-	}
+commands:
+  diff      show the edits needed to bring the database in line with the schema
+  plan      print the sql migration for those edits
+  generate  write the sql migration to the next numbered file in a directory
+  apply     run the sql migration, or migration files, against the database
+  verify    apply the sql migration to a copy of the database and check nothing is left to diff
+  drift     replay the migration files and report how the database differs from them
+  inspect   print the schema currently in the database
+  fmt       print the schema file in canonical form, --check reports whether it is
+  lint      report errors and warnings in the schema file
 
-	db := &database.Sqlite{DB: conn, FileName: databaseURL}
+exit codes:
+  0  no changes
+  1  error
+  2  changes pending
 
-	fileName := "resources/schema.sql"
-	file, err := os.Open(fileName)
-	if err != nil {
-		os.Exit(1)
-	}
+run 'justmigrate <command> -h' for the flags of a command.
+`
 
-	_, dstAst, err := AstFromFile(file)
-	if err != nil {
-		os.Exit(1)
-	}
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	_, srcAst, err := AstFromDatabase(db)
-	if err != nil {
-		os.Exit(1)
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitError
 	}
 
-	differ := diff.Diff{}
-
-	edits, err := differ.DiffSchema(srcAst, dstAst)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	name, args := args[0], args[1:]
+
+	command, ok := commands[name]
+	if !ok {
+		switch name {
+		case "help", "-h", "-help", "--help":
+			fmt.Fprint(stdout, usage)
+			return ExitNoChanges
+		}
+		fmt.Fprintf(stderr, "justmigrate: unknown command '%s'\n\n", name)
+		fmt.Fprint(stderr, usage)
+		return ExitError
 	}
 
-	generate := sqlite.NewSqliteGenerator(edits)
-
-	generate.Generate(os.Stderr)
+	return command(args, stdout, stderr)
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/schema"
+)
+
+type SourceKind int
+
+const (
+	SourceFile SourceKind = iota
+	SourceStdin
+	SourceDir
+	SourceGit
+	SourceDatabase
+)
+
+// Source is somewhere a schema is read from, either side of a diff can be
+// any kind of source.
+type Source struct {
+	Kind SourceKind
+
+	// Path is the file or directory, or the file within the repository for
+	// a git source.
+	Path string
+
+	// Rev is the git revision the file is read at.
+	Rev string
+
+	// Url is the url of the database.
+	Url string
+}
+
+// ParseSource reads where a schema is from:
+//
+//   - `-` is stdin.
+//   - git:<rev>:<path> is a file as it was at a git revision, e.g.
+//     git:main:schema.sql, the path is from the current directory like any
+//     other rather than from the root of the repository.
+//   - a directory is every .sql file in it.
+//   - <name>.sql is a file.
+//   - anything else is a database url, e.g. staging.db or
+//     postgres://localhost/app.
+//
+// a url with a scheme or a query is a database even where the ? of its query
+// would make it a glob, e.g. file:prod.db?mode=ro.
+func ParseSource(spec string) Source {
+	if databaseUrl(spec) {
+		return Source{Kind: SourceDatabase, Url: spec}
+	}
+	source := SchemaSource(spec)
+	if source.Kind == SourceFile && !strings.EqualFold(filepath.Ext(spec), ".sql") {
+		return Source{Kind: SourceDatabase, Url: spec}
+	}
+	return source
+}
+
+// databaseUrl reports whether spec is the url of a database rather than a
+// path, that is it has a scheme, e.g. postgres://localhost/app?sslmode=disable,
+// or a query of its own, e.g. prod.db?mode=ro. a scheme of one letter is the
+// drive of a windows path.
+func databaseUrl(spec string) bool {
+	if strings.HasPrefix(spec, "git:") {
+		return false
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return false
+	}
+	return len(u.Scheme) > 1 || strings.Contains(u.RawQuery, "=")
+}
+
+// SchemaSource reads where a schema is from like ParseSource does, but
+// never takes it for a database, as with --schema.
+func SchemaSource(spec string) Source {
+	if spec == "-" {
+		return Source{Kind: SourceStdin}
+	}
+	if rest, ok := strings.CutPrefix(spec, "git:"); ok {
+		rev, path, _ := strings.Cut(rest, ":")
+		return Source{Kind: SourceGit, Rev: rev, Path: path}
+	}
+	if info, err := os.Stat(spec); (err == nil && info.IsDir()) || strings.ContainsAny(spec, "*?[") {
+		return Source{Kind: SourceDir, Path: spec}
+	}
+	return Source{Kind: SourceFile, Path: spec}
+}
+
+// Label is how the source is named in reports.
+func (source Source) Label() string {
+	switch source.Kind {
+	case SourceStdin:
+		return "<stdin>"
+	case SourceGit:
+		return source.Rev + ":" + source.Path
+	case SourceDatabase:
+		return source.Url
+	default:
+		return source.Path
+	}
+}
+
+// Schema is a schema read from a source. Db is the database it was read
+// from, which is left open for the caller to close, or nil.
+type Schema struct {
+	Source     luther.SourceCode
+	Statements []ast.Statement
+	Db         Database
+}
+
+// Load reads the schema of source, a database is opened with open. files
+// and directories are read with schema.Files, and an object defined more
+// than once is reported.
+func (source Source) Load(dialect Dialect, stdin io.Reader, open func(rawUrl string) (Database, error)) (Schema, error) {
+	switch source.Kind {
+	case SourceDatabase:
+		db, err := open(source.Url)
+		if err != nil {
+			return Schema{}, err
+		}
+		code, statements, err := AstFromDatabase(dialect, db)
+		if err != nil {
+			db.Close()
+			return Schema{}, err
+		}
+		return Schema{Source: code, Statements: statements, Db: db}, nil
+	case SourceFile, SourceDir:
+		return source.loadFiles(dialect)
+	}
+
+	var raw []byte
+	var err error
+	switch source.Kind {
+	case SourceStdin:
+		raw, err = io.ReadAll(stdin)
+	case SourceGit:
+		raw, err = gitShow(source.Rev, source.Path)
+	}
+	if err != nil {
+		return Schema{}, err
+	}
+
+	code, statements, err := AstFromSource(dialect, luther.SourceCode{
+		FileName: source.Label(),
+		Raw:      []rune(string(raw)),
+	})
+	if err != nil {
+		return Schema{}, err
+	}
+	if err := checkDuplicates(statements); err != nil {
+		return Schema{}, err
+	}
+	return Schema{Source: code, Statements: statements}, nil
+}
+
+func (source Source) loadFiles(dialect Dialect) (Schema, error) {
+	files, err := schema.Files(source.Path)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	// the source of the whole schema is only used for the annotations in
+	// it, each file is parsed as a source of its own.
+	result := Schema{Source: luther.SourceCode{FileName: source.Path}}
+	for _, file := range files {
+		_, statements, err := AstFromSource(dialect, file)
+		if err != nil {
+			return Schema{}, err
+		}
+		result.Statements = append(result.Statements, statements...)
+		result.Source.Raw = append(result.Source.Raw, append(file.Raw, '\n')...)
+	}
+
+	if err := checkDuplicates(result.Statements); err != nil {
+		return Schema{}, err
+	}
+	return result, nil
+}
+
+func checkDuplicates(statements []ast.Statement) error {
+	duplicates := schema.Duplicates(statements)
+	if len(duplicates) > 0 {
+		ShowErrors(duplicates, os.Stderr)
+		return fmt.Errorf("%w: %d object(s)", ErrDuplicates, len(duplicates))
+	}
+	return nil
+}
+
+func gitShow(rev, path string) ([]byte, error) {
+	object := gitObject(rev, path)
+	out, err := exec.Command("git", "show", object).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git show %s: %s", object, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// gitObject is the file at path as git show names it. git reads `rev:path`
+// from the root of the repository and `rev:./path` from the current
+// directory, a path is always from the current directory here.
+func gitObject(rev, path string) string {
+	if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		path = "./" + path
+	}
+	return rev + ":" + path
+}
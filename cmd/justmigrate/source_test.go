@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestGitSourceFromCurrentDirectory reads git:<rev>:<path> from the current
+// directory, as any other path is, and not from the root of the repository.
+func TestGitSourceFromCurrentDirectory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		command := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if err := os.Mkdir(filepath.Join(root, "db"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "db", "schema.sql"), []byte("CREATE TABLE t (a integer);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "schema")
+
+	t.Chdir(filepath.Join(root, "db"))
+	for _, path := range []string{"schema.sql", "./schema.sql", "../db/schema.sql"} {
+		source := ParseSource("git:HEAD:" + path)
+		raw, err := gitShow(source.Rev, source.Path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if string(raw) != "CREATE TABLE t (a integer);\n" {
+			t.Errorf("%s: expected the schema, got %q", path, raw)
+		}
+	}
+}
@@ -38,6 +38,7 @@ type Expr interface {
 	AstNode
 	nodeExpression()
 	Eq(other Expr) bool
+	ToSql(f formatter.Formatter)
 }
 
 type Literal interface {
@@ -77,7 +78,7 @@ func (node *Identifier) AsExpr() Expr {
 }
 
 func (node *Identifier) ToSql(f formatter.Formatter) {
-	f.Identifier(node.Text)
+	f.Identifier(node.Text, isQuotedToken(tik.Token(*node)))
 }
 
 type Keyword tik.Token
@@ -88,7 +89,7 @@ func MakeKeyword(token tik.Token) *Keyword {
 }
 
 func (node *Keyword) ToSql(f formatter.Formatter) {
-	f.Text(node.Text)
+	f.Keyword(node.Text)
 }
 
 func (node *Keyword) node() {}
@@ -112,9 +113,9 @@ type IfExists struct {
 }
 
 func (node *IfExists) ToSql(f formatter.Formatter) {
-	f.Text(node.If.Text)
+	f.Keyword(node.If.Text)
 	f.Space()
-	f.Text(node.Exists.Text)
+	f.Keyword(node.Exists.Text)
 }
 
 type DropTable struct {
@@ -126,9 +127,9 @@ func (node *DropTable) node()          {}
 func (node *DropTable) nodeStatement() {}
 
 func (node *DropTable) ToSql(f formatter.Formatter) {
-	f.Text("DROP")
+	f.Keyword("DROP")
 	f.Space()
-	f.Text("TABLE")
+	f.Keyword("TABLE")
 	f.Space()
 	if node.IfExists != nil {
 		node.IfExists.ToSql(f)
@@ -137,6 +138,126 @@ func (node *DropTable) ToSql(f formatter.Formatter) {
 	node.TableIdentifier.ToSql(f)
 }
 
+type DropIndex struct {
+	IfExists        *IfExists
+	IndexIdentifier CatalogObjectIdentifier
+}
+
+func (node *DropIndex) node()          {}
+func (node *DropIndex) nodeStatement() {}
+
+func (node *DropIndex) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("INDEX")
+	f.Space()
+	if node.IfExists != nil {
+		node.IfExists.ToSql(f)
+		f.Space()
+	}
+	node.IndexIdentifier.ToSql(f)
+}
+
+type DropView struct {
+	IfExists       *IfExists
+	ViewIdentifier CatalogObjectIdentifier
+}
+
+func (node *DropView) node()          {}
+func (node *DropView) nodeStatement() {}
+
+func (node *DropView) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("VIEW")
+	f.Space()
+	if node.IfExists != nil {
+		node.IfExists.ToSql(f)
+		f.Space()
+	}
+	node.ViewIdentifier.ToSql(f)
+}
+
+type DropTrigger struct {
+	IfExists          *IfExists
+	TriggerIdentifier CatalogObjectIdentifier
+}
+
+func (node *DropTrigger) node()          {}
+func (node *DropTrigger) nodeStatement() {}
+
+func (node *DropTrigger) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("TRIGGER")
+	f.Space()
+	if node.IfExists != nil {
+		node.IfExists.ToSql(f)
+		f.Space()
+	}
+	node.TriggerIdentifier.ToSql(f)
+}
+
+type DropSchema struct {
+	IfExists   *IfExists
+	SchemaName Identifier
+}
+
+func (node *DropSchema) node()          {}
+func (node *DropSchema) nodeStatement() {}
+
+func (node *DropSchema) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("SCHEMA")
+	f.Space()
+	if node.IfExists != nil {
+		node.IfExists.ToSql(f)
+		f.Space()
+	}
+	node.SchemaName.ToSql(f)
+}
+
+type DropType struct {
+	IfExists       *IfExists
+	TypeIdentifier CatalogObjectIdentifier
+}
+
+func (node *DropType) node()          {}
+func (node *DropType) nodeStatement() {}
+
+func (node *DropType) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("TYPE")
+	f.Space()
+	if node.IfExists != nil {
+		node.IfExists.ToSql(f)
+		f.Space()
+	}
+	node.TypeIdentifier.ToSql(f)
+}
+
+type DropDomain struct {
+	IfExists         *IfExists
+	DomainIdentifier CatalogObjectIdentifier
+}
+
+func (node *DropDomain) node()          {}
+func (node *DropDomain) nodeStatement() {}
+
+func (node *DropDomain) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("DOMAIN")
+	f.Space()
+	if node.IfExists != nil {
+		node.IfExists.ToSql(f)
+		f.Space()
+	}
+	node.DomainIdentifier.ToSql(f)
+}
+
 type AlterTable struct {
 	AlterKeyword    Keyword
 	TableKeyword    Keyword
@@ -148,9 +269,9 @@ func (node *AlterTable) node()          {}
 func (node *AlterTable) nodeStatement() {}
 func (node *AlterTable) ToSql(f formatter.Formatter) {
 	f.Group(func() {
-		f.Text(node.AlterKeyword.Text)
+		f.Keyword(node.AlterKeyword.Text)
 		f.Space()
-		f.Text(node.TableKeyword.Text)
+		f.Keyword(node.TableKeyword.Text)
 		f.Line()
 		f.Indent(func() {
 			node.TableIdentifier.ToSql(f)
@@ -173,9 +294,9 @@ type AddColumn struct {
 }
 
 func (node *AddColumn) ToSql(f formatter.Formatter) {
-	f.Text("ADD")
+	f.Keyword("ADD")
 	f.Space()
-	f.Text("COLUMN")
+	f.Keyword("COLUMN")
 	f.Line()
 	f.Indent(func() {
 		node.ColumnDefinition.ToSql(f)
@@ -192,25 +313,512 @@ type DropColumn struct {
 }
 
 func (node *DropColumn) ToSql(f formatter.Formatter) {
-	f.Text("DROP")
+	f.Keyword("DROP")
 	f.Space()
-	f.Text("COLUMN")
+	f.Keyword("COLUMN")
 	f.Line()
 	f.Indent(func() {
 		node.ColumnName.ToSql(f)
 	})
 }
 
-func (node *DropColumn) node()            {}
-func (node *DropColumn) tableAlteration() {}
-
+func (node *DropColumn) node()            {}
+func (node *DropColumn) tableAlteration() {}
+
+type RenameTable struct {
+	RenameKeyword Keyword
+	ToKeyword     Keyword
+	NewName       Identifier
+}
+
+func (node *RenameTable) ToSql(f formatter.Formatter) {
+	f.Keyword(node.RenameKeyword.Text)
+	f.Space()
+	f.Keyword(node.ToKeyword.Text)
+	f.Line()
+	f.Indent(func() {
+		node.NewName.ToSql(f)
+	})
+}
+
+func (node *RenameTable) node()            {}
+func (node *RenameTable) tableAlteration() {}
+
+type RenameColumn struct {
+	RenameKeyword Keyword
+	ColumnKeyword *Keyword
+	ColumnName    Identifier
+	ToKeyword     Keyword
+	NewName       Identifier
+}
+
+func (node *RenameColumn) ToSql(f formatter.Formatter) {
+	f.Keyword(node.RenameKeyword.Text)
+	f.Space()
+	f.Keyword("COLUMN")
+	f.Line()
+	f.Indent(func() {
+		node.ColumnName.ToSql(f)
+	})
+	f.Line()
+	f.Keyword(node.ToKeyword.Text)
+	f.Line()
+	f.Indent(func() {
+		node.NewName.ToSql(f)
+	})
+}
+
+func (node *RenameColumn) node()            {}
+func (node *RenameColumn) tableAlteration() {}
+
+// AlterColumn changes one thing about an existing column, it is only ever
+// produced by a generator.
+type AlterColumn struct {
+	ColumnName Identifier
+	Alteration ColumnAlteration
+}
+
+func (node *AlterColumn) ToSql(f formatter.Formatter) {
+	f.Keyword("ALTER")
+	f.Space()
+	f.Keyword("COLUMN")
+	f.Line()
+	f.Indent(func() {
+		node.ColumnName.ToSql(f)
+	})
+	f.Line()
+	node.Alteration.ToSql(f)
+}
+
+func (node *AlterColumn) node()            {}
+func (node *AlterColumn) tableAlteration() {}
+
+type ColumnAlteration interface {
+	AstNode
+	columnAlteration()
+	ToSql(f formatter.Formatter)
+}
+
+// DomainAlteration is a change to a domain, which mostly share their syntax
+// with the changes made to a column.
+type DomainAlteration interface {
+	AstNode
+	domainAlteration()
+	ToSql(f formatter.Formatter)
+}
+
+// SetType changes the type of a column, Using converts the existing values
+// when there is no implicit cast from the old type.
+type SetType struct {
+	TypeName  TypeName
+	Collation *Collation
+	Using     Expr
+}
+
+func (node *SetType) ToSql(f formatter.Formatter) {
+	f.Keyword("TYPE")
+	f.Space()
+	node.TypeName.ToSql(f)
+	if node.Collation != nil {
+		f.Space()
+		node.Collation.ToSql(f)
+	}
+	if node.Using != nil {
+		f.Line()
+		f.Keyword("USING")
+		f.Space()
+		node.Using.ToSql(f)
+	}
+}
+
+func (node *SetType) node()             {}
+func (node *SetType) columnAlteration() {}
+
+type SetNotNull struct{}
+
+func (node *SetNotNull) ToSql(f formatter.Formatter) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("NOT")
+	f.Space()
+	f.Keyword("NULL")
+}
+
+func (node *SetNotNull) node()             {}
+func (node *SetNotNull) columnAlteration() {}
+func (node *SetNotNull) domainAlteration() {}
+
+type DropNotNull struct{}
+
+func (node *DropNotNull) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("NOT")
+	f.Space()
+	f.Keyword("NULL")
+}
+
+func (node *DropNotNull) node()             {}
+func (node *DropNotNull) columnAlteration() {}
+func (node *DropNotNull) domainAlteration() {}
+
+type SetDefaultValue struct {
+	Default Expr
+}
+
+func (node *SetDefaultValue) ToSql(f formatter.Formatter) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("DEFAULT")
+	f.Space()
+	node.Default.ToSql(f)
+}
+
+func (node *SetDefaultValue) node()             {}
+func (node *SetDefaultValue) columnAlteration() {}
+func (node *SetDefaultValue) domainAlteration() {}
+
+type DropDefaultValue struct{}
+
+func (node *DropDefaultValue) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("DEFAULT")
+}
+
+func (node *DropDefaultValue) node()             {}
+func (node *DropDefaultValue) columnAlteration() {}
+func (node *DropDefaultValue) domainAlteration() {}
+
+// AddIdentity makes an existing column an identity column.
+type AddIdentity struct {
+	Identity *ColumnConstraint_Identity
+}
+
+func (node *AddIdentity) ToSql(f formatter.Formatter) {
+	f.Keyword("ADD")
+	f.Space()
+	node.Identity.ToSql(f)
+}
+
+func (node *AddIdentity) node()             {}
+func (node *AddIdentity) columnAlteration() {}
+
+// SetIdentity changes whether an identity column can be written to.
+type SetIdentity struct {
+	Identity *ColumnConstraint_Identity
+}
+
+func (node *SetIdentity) ToSql(f formatter.Formatter) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("GENERATED")
+	f.Space()
+	node.Identity.generatedWhen(f)
+}
+
+func (node *SetIdentity) node()             {}
+func (node *SetIdentity) columnAlteration() {}
+
+type DropIdentity struct{}
+
+func (node *DropIdentity) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("IDENTITY")
+}
+
+func (node *DropIdentity) node()             {}
+func (node *DropIdentity) columnAlteration() {}
+
+// AddConstraint adds a constraint to a table or domain, it is only ever
+// produced by a generator.
+type AddConstraint struct {
+	Constraint TableConstraint
+}
+
+func (node *AddConstraint) ToSql(f formatter.Formatter) {
+	f.Keyword("ADD")
+	f.Line()
+	f.Indent(func() {
+		node.Constraint.ToSql(f)
+	})
+}
+
+func (node *AddConstraint) node()             {}
+func (node *AddConstraint) tableAlteration()  {}
+func (node *AddConstraint) domainAlteration() {}
+
+type DropConstraint struct {
+	Name Identifier
+}
+
+func (node *DropConstraint) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("CONSTRAINT")
+	f.Line()
+	f.Indent(func() {
+		node.Name.ToSql(f)
+	})
+}
+
+func (node *DropConstraint) node()             {}
+func (node *DropConstraint) tableAlteration()  {}
+func (node *DropConstraint) domainAlteration() {}
+
+// ModifyColumn replaces the definition of a column in mysql, which has no
+// way to change only one part of it.
+type ModifyColumn struct {
+	ColumnDefinition ColumnDefinition
+}
+
+func (node *ModifyColumn) ToSql(f formatter.Formatter) {
+	f.Keyword("MODIFY")
+	f.Space()
+	f.Keyword("COLUMN")
+	f.Line()
+	f.Indent(func() {
+		node.ColumnDefinition.ToSql(f)
+	})
+}
+
+func (node *ModifyColumn) node()            {}
+func (node *ModifyColumn) tableAlteration() {}
+
+// ChangeColumn renames a column in mysql by restating its whole definition
+// under the new name, unlike RENAME COLUMN it works on every version.
+type ChangeColumn struct {
+	ColumnName       Identifier
+	ColumnDefinition ColumnDefinition
+}
+
+func (node *ChangeColumn) ToSql(f formatter.Formatter) {
+	f.Keyword("CHANGE")
+	f.Space()
+	f.Keyword("COLUMN")
+	f.Line()
+	f.Indent(func() {
+		node.ColumnName.ToSql(f)
+		f.Line()
+		node.ColumnDefinition.ToSql(f)
+	})
+}
+
+func (node *ChangeColumn) node()            {}
+func (node *ChangeColumn) tableAlteration() {}
+
+type DropPrimaryKey struct{}
+
+func (node *DropPrimaryKey) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("PRIMARY")
+	f.Space()
+	f.Keyword("KEY")
+}
+
+func (node *DropPrimaryKey) node()            {}
+func (node *DropPrimaryKey) tableAlteration() {}
+
+// DropTableIndex drops an index, or a unique key, from a table in mysql.
+type DropTableIndex struct {
+	Name Identifier
+}
+
+func (node *DropTableIndex) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("INDEX")
+	f.Line()
+	f.Indent(func() {
+		node.Name.ToSql(f)
+	})
+}
+
+func (node *DropTableIndex) node()            {}
+func (node *DropTableIndex) tableAlteration() {}
+
+type DropForeignKey struct {
+	Name Identifier
+}
+
+func (node *DropForeignKey) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("FOREIGN")
+	f.Space()
+	f.Keyword("KEY")
+	f.Line()
+	f.Indent(func() {
+		node.Name.ToSql(f)
+	})
+}
+
+func (node *DropForeignKey) node()            {}
+func (node *DropForeignKey) tableAlteration() {}
+
+type DropCheck struct {
+	Name Identifier
+}
+
+func (node *DropCheck) ToSql(f formatter.Formatter) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword("CHECK")
+	f.Line()
+	f.Indent(func() {
+		node.Name.ToSql(f)
+	})
+}
+
+func (node *DropCheck) node()            {}
+func (node *DropCheck) tableAlteration() {}
+
+type AlterDomain struct {
+	DomainIdentifier *CatalogObjectIdentifier
+	Alteration       DomainAlteration
+}
+
+func (node *AlterDomain) node()          {}
+func (node *AlterDomain) nodeStatement() {}
+func (node *AlterDomain) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		f.Keyword("ALTER")
+		f.Space()
+		f.Keyword("DOMAIN")
+		f.Line()
+		f.Indent(func() {
+			node.DomainIdentifier.ToSql(f)
+		})
+		f.Line()
+		node.Alteration.ToSql(f)
+	})
+}
+
+// AlterTypeAddValue adds a value to an enum, Before or After place it next
+// to an existing value, otherwise it goes on the end.
+type AlterTypeAddValue struct {
+	TypeIdentifier *CatalogObjectIdentifier
+	Value          LiteralString
+	Before         *LiteralString
+	After          *LiteralString
+}
+
+func (node *AlterTypeAddValue) node()          {}
+func (node *AlterTypeAddValue) nodeStatement() {}
+func (node *AlterTypeAddValue) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		f.Keyword("ALTER")
+		f.Space()
+		f.Keyword("TYPE")
+		f.Line()
+		f.Indent(func() {
+			node.TypeIdentifier.ToSql(f)
+		})
+		f.Line()
+		f.Keyword("ADD")
+		f.Space()
+		f.Keyword("VALUE")
+		f.Space()
+		node.Value.ToSql(f)
+		if node.Before != nil {
+			f.Space()
+			f.Keyword("BEFORE")
+			f.Space()
+			node.Before.ToSql(f)
+		} else if node.After != nil {
+			f.Space()
+			f.Keyword("AFTER")
+			f.Space()
+			node.After.ToSql(f)
+		}
+	})
+}
+
+// InsertSelect copies rows from one table into another, it is only ever
+// produced by a generator so it covers just the `INSERT INTO t (...) SELECT
+// ... FROM s` form.
+type InsertSelect struct {
+	// Conflict is the resolution of a broken constraint, `INSERT OR ROLLBACK`.
+	Conflict        *Keyword
+	TableIdentifier *CatalogObjectIdentifier
+	Columns         []Identifier
+	SelectExprs     []Expr
+	FromTable       *CatalogObjectIdentifier
+}
+
+func (node *InsertSelect) node()          {}
+func (node *InsertSelect) nodeStatement() {}
+
+func (node *InsertSelect) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		f.Keyword("INSERT")
+		f.Space()
+		if node.Conflict != nil {
+			f.Keyword("OR")
+			f.Space()
+			f.Keyword(node.Conflict.Text)
+			f.Space()
+		}
+		f.Keyword("INTO")
+		f.Space()
+		node.TableIdentifier.ToSql(f)
+		f.Space()
+		f.Rune('(')
+		for i, column := range node.Columns {
+			column.ToSql(f)
+			if i < len(node.Columns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+		f.Line()
+		f.Keyword("SELECT")
+		f.Space()
+		for i, expr := range node.SelectExprs {
+			expr.ToSql(f)
+			if i < len(node.SelectExprs)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Line()
+		f.Keyword("FROM")
+		f.Space()
+		node.FromTable.ToSql(f)
+	})
+}
+
 type Pragma struct {
-	Name  CatalogObjectIdentifier
-	Value PragmaValue
+	PragmaKeyword Keyword
+	Name          *CatalogObjectIdentifier
+	Value         PragmaValue
+}
+
+func MakePragma(
+	pragmaKeyword Keyword,
+	name *CatalogObjectIdentifier,
+	value PragmaValue,
+) *Pragma {
+	return &Pragma{
+		PragmaKeyword: pragmaKeyword,
+		Name:          name,
+		Value:         value,
+	}
 }
 
 func (node *Pragma) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Keyword(node.PragmaKeyword.Text)
+	f.Space()
+	node.Name.ToSql(f)
+	if node.Value != nil {
+		f.Space()
+		f.Rune('=')
+		f.Space()
+		node.Value.ToSql(f)
+	}
 }
 
 func (node *Pragma) node()          {}
@@ -219,30 +827,98 @@ func (node *Pragma) nodeStatement() {}
 type PragmaValue interface {
 	AstNode
 	nodePragmaValue()
+	ToSql(f formatter.Formatter)
 }
 
-type BeginTransaction struct{}
+type BeginTransaction struct {
+	BeginKeyword       Keyword
+	TransactionKeyword *Keyword
+}
 
 func (node *BeginTransaction) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Keyword(node.BeginKeyword.Text)
+	if node.TransactionKeyword != nil {
+		f.Space()
+		f.Keyword(node.TransactionKeyword.Text)
+	}
 }
 
 func (node *BeginTransaction) node()          {}
 func (node *BeginTransaction) nodeStatement() {}
 
-type CommitTransaction struct{}
+type CommitTransaction struct {
+	CommitKeyword      Keyword
+	TransactionKeyword *Keyword
+}
 
 func (node *CommitTransaction) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Keyword(node.CommitKeyword.Text)
+	if node.TransactionKeyword != nil {
+		f.Space()
+		f.Keyword(node.TransactionKeyword.Text)
+	}
 }
 
 func (node *CommitTransaction) node()          {}
 func (node *CommitTransaction) nodeStatement() {}
 
-type Select struct{}
+// Select holds the tokens of a select statement verbatim, we only ever need to
+// reproduce them (e.g. for a view) and never need to reason about them.
+type Select struct {
+	Tokens []tik.Token
+}
+
+func MakeSelect(tokens []tik.Token) *Select {
+	return &Select{
+		Tokens: tokens,
+	}
+}
 
 func (node *Select) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	TokensToSql(f, node.Tokens)
+}
+
+func isQuotedToken(token tik.Token) bool {
+	text := token.SourceText()
+	return text != "" && strings.ContainsAny(text[:1], "\"`[")
+}
+
+// TokensToSql writes out tokens that were captured verbatim, whitespace
+// between tokens is collapsed to a single space or line break.
+func TokensToSql(f formatter.Formatter, tokens []tik.Token) {
+	for i, token := range tokens {
+		if i == 0 {
+			f.Trivia(token.LeadingTrivia)
+		} else {
+			trivia := tokens[i-1].TrailingTrivia + token.LeadingTrivia
+			f.Trivia(trivia)
+			if strings.Contains(trivia, "\n") {
+				f.Break()
+			} else if trivia != "" {
+				f.Space()
+			}
+		}
+
+		switch token.Kind {
+		case tik.TokenKind_Identifier:
+			// only names which were quoted in the source are quoted again,
+			// the lexer does not know every keyword a select can contain.
+			if isQuotedToken(token) {
+				f.Identifier(token.Text, true)
+			} else {
+				f.Text(token.Text)
+			}
+		case tik.TokenKind_StringLiteral:
+			f.Rune('\'')
+			f.Text(token.Text)
+			f.Rune('\'')
+		default:
+			f.Text(token.Text)
+		}
+	}
+	if len(tokens) > 0 {
+		f.Trivia(tokens[len(tokens)-1].TrailingTrivia)
+	}
 }
 
 func (node *Select) node()          {}
@@ -285,14 +961,14 @@ func (node *CreateTable) nodeStatement() {}
 
 func (node *CreateTable) ToSql(f formatter.Formatter) {
 	f.Group(func() {
-		f.Text(node.CreateKeyword.Text)
+		f.Keyword(node.CreateKeyword.Text)
 		if node.Temporary != nil {
 			f.Space()
-			f.Text(node.Temporary.Text)
+			f.Keyword(node.Temporary.Text)
 		}
 
 		f.Space()
-		f.Text(node.TableKeyword.Text)
+		f.Keyword(node.TableKeyword.Text)
 
 		if node.IfNotExist != nil {
 			f.Space()
@@ -304,6 +980,7 @@ func (node *CreateTable) ToSql(f formatter.Formatter) {
 		f.Space()
 
 		f.Rune('(')
+		f.Trivia(node.TableDefinition.LParen.TrailingTrivia)
 		f.Break()
 
 		f.Indent(func() {
@@ -311,22 +988,67 @@ func (node *CreateTable) ToSql(f formatter.Formatter) {
 		})
 		f.Break()
 		f.Rune(')')
-		f.Space()
-		if node.TableOptions != nil {
+		if node.TableOptions != nil && !node.TableOptions.IsEmpty() {
+			f.Space()
 			node.TableOptions.ToSql(f)
 		}
 	})
 }
 
 type CreateVirtualTable struct {
-	IfNotExist      AstNode
-	TableIdentifier CatalogObjectIdentifier
+	CreateKeyword   Keyword
+	VirtualKeyword  Keyword
+	TableKeyword    Keyword
+	IfNotExist      *IfNotExists
+	TableIdentifier *CatalogObjectIdentifier
+	UsingKeyword    Keyword
 	ModuleName      Identifier
 	ModuleArgs      []string
 }
 
+func MakeCreateVirtualTable(
+	create Keyword,
+	virtual Keyword,
+	table Keyword,
+	ifNotExists *IfNotExists,
+	tableIdent *CatalogObjectIdentifier,
+	using Keyword,
+	moduleName Identifier,
+	moduleArgs []string,
+) *CreateVirtualTable {
+	return &CreateVirtualTable{
+		CreateKeyword:   create,
+		VirtualKeyword:  virtual,
+		TableKeyword:    table,
+		IfNotExist:      ifNotExists,
+		TableIdentifier: tableIdent,
+		UsingKeyword:    using,
+		ModuleName:      moduleName,
+		ModuleArgs:      moduleArgs,
+	}
+}
+
 func (node *CreateVirtualTable) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Keyword(node.CreateKeyword.Text)
+	f.Space()
+	f.Keyword(node.VirtualKeyword.Text)
+	f.Space()
+	f.Keyword(node.TableKeyword.Text)
+	if node.IfNotExist != nil {
+		f.Space()
+		node.IfNotExist.ToSql(f)
+	}
+	f.Space()
+	node.TableIdentifier.ToSql(f)
+	f.Space()
+	f.Keyword(node.UsingKeyword.Text)
+	f.Space()
+	f.Text(node.ModuleName.Text)
+	if len(node.ModuleArgs) > 0 {
+		f.Rune('(')
+		f.Text(strings.Join(node.ModuleArgs, ", "))
+		f.Rune(')')
+	}
 }
 
 func (node *CreateVirtualTable) node()          {}
@@ -334,17 +1056,91 @@ func (node *CreateVirtualTable) nodeStatement() {}
 
 type CreateIndex struct {
 	CreateKeyword   Keyword
+	Unique          *Keyword
 	IndexKeyword    Keyword
-	Unique          AstNode
-	IfNotExists     AstNode
-	IndexIdentifier CatalogObjectIdentifier
-	OnTable         CatalogObjectIdentifier
+	IfNotExists     *IfNotExists
+	IndexIdentifier *CatalogObjectIdentifier
+	OnKeyword       Keyword
+	OnTable         *CatalogObjectIdentifier
 	IndexedColumns  []IndexedColumn
 	WhereExpr       Expr
+
+	// postgres index method, e.g. gin, left out for the default btree
+	Using *Identifier
+}
+
+func MakeCreateIndex(
+	create Keyword,
+	unique *Keyword,
+	index Keyword,
+	ifNotExists *IfNotExists,
+	indexIdent *CatalogObjectIdentifier,
+	on Keyword,
+	onTable *CatalogObjectIdentifier,
+	indexedColumns []IndexedColumn,
+	whereExpr Expr,
+) *CreateIndex {
+	return &CreateIndex{
+		CreateKeyword:   create,
+		Unique:          unique,
+		IndexKeyword:    index,
+		IfNotExists:     ifNotExists,
+		IndexIdentifier: indexIdent,
+		OnKeyword:       on,
+		OnTable:         onTable,
+		IndexedColumns:  indexedColumns,
+		WhereExpr:       whereExpr,
+	}
+}
+
+func (node *CreateIndex) IsUnique() bool {
+	return node.Unique != nil
 }
 
 func (node *CreateIndex) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Group(func() {
+		f.Keyword(node.CreateKeyword.Text)
+		if node.Unique != nil {
+			f.Space()
+			f.Keyword(node.Unique.Text)
+		}
+		f.Space()
+		f.Keyword(node.IndexKeyword.Text)
+		if node.IfNotExists != nil {
+			f.Space()
+			node.IfNotExists.ToSql(f)
+		}
+		f.Space()
+		node.IndexIdentifier.ToSql(f)
+		f.Line()
+		f.Indent(func() {
+			f.Keyword(node.OnKeyword.Text)
+			f.Space()
+			node.OnTable.ToSql(f)
+			if node.Using != nil {
+				f.Space()
+				f.Keyword("USING")
+				f.Space()
+				f.Text(node.Using.Text)
+			}
+			f.Space()
+			f.Rune('(')
+			for i, col := range node.IndexedColumns {
+				col.ToSql(f)
+				if i < len(node.IndexedColumns)-1 {
+					f.Rune(',')
+					f.Space()
+				}
+			}
+			f.Rune(')')
+			if node.WhereExpr != nil {
+				f.Line()
+				f.Keyword("WHERE")
+				f.Space()
+				node.WhereExpr.ToSql(f)
+			}
+		})
+	})
 }
 
 func (node *CreateIndex) node()          {}
@@ -353,26 +1149,105 @@ func (node *CreateIndex) nodeStatement() {}
 type TriggerTime interface {
 	AstNode
 	triggerTime()
+	ToSql(f formatter.Formatter)
 }
 
 type TriggerEvent interface {
 	AstNode
 	triggerEvent()
+	ToSql(f formatter.Formatter)
 }
 
 type CreateTrigger struct {
 	CreateKeyword     Keyword
-	TriggerKeyword    Keyword
 	Temporary         *Keyword
-	IfNotExists       AstNode
-	TriggerIdentifier CatalogObjectIdentifier
+	TriggerKeyword    Keyword
+	IfNotExists       *IfNotExists
+	TriggerIdentifier *CatalogObjectIdentifier
 	TriggerTime       TriggerTime
 	TriggerEvent      TriggerEvent
-	OnTable           AstNode
+	OnTable           *CatalogObjectIdentifier
+	ForEachRow        bool
+	When              Expr
+
+	// the statements between BEGIN and END are held verbatim
+	Body []tik.Token
+}
+
+func MakeCreateTrigger(
+	create Keyword,
+	temporary *Keyword,
+	trigger Keyword,
+	ifNotExists *IfNotExists,
+	triggerIdent *CatalogObjectIdentifier,
+	triggerTime TriggerTime,
+	triggerEvent TriggerEvent,
+	onTable *CatalogObjectIdentifier,
+	forEachRow bool,
+	when Expr,
+	body []tik.Token,
+) *CreateTrigger {
+	return &CreateTrigger{
+		CreateKeyword:     create,
+		Temporary:         temporary,
+		TriggerKeyword:    trigger,
+		IfNotExists:       ifNotExists,
+		TriggerIdentifier: triggerIdent,
+		TriggerTime:       triggerTime,
+		TriggerEvent:      triggerEvent,
+		OnTable:           onTable,
+		ForEachRow:        forEachRow,
+		When:              when,
+		Body:              body,
+	}
 }
 
 func (node *CreateTrigger) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Group(func() {
+		f.Keyword(node.CreateKeyword.Text)
+		if node.Temporary != nil {
+			f.Space()
+			f.Keyword(node.Temporary.Text)
+		}
+		f.Space()
+		f.Keyword(node.TriggerKeyword.Text)
+		if node.IfNotExists != nil {
+			f.Space()
+			node.IfNotExists.ToSql(f)
+		}
+		f.Space()
+		node.TriggerIdentifier.ToSql(f)
+		f.Line()
+		f.Indent(func() {
+			if node.TriggerTime != nil {
+				node.TriggerTime.ToSql(f)
+				f.Space()
+			}
+			node.TriggerEvent.ToSql(f)
+			f.Space()
+			f.Keyword("ON")
+			f.Space()
+			node.OnTable.ToSql(f)
+			if node.ForEachRow {
+				f.Line()
+				f.Keyword("FOR EACH ROW")
+			}
+			if node.When != nil {
+				f.Line()
+				f.Keyword("WHEN")
+				f.Space()
+				node.When.ToSql(f)
+			}
+		})
+	})
+	f.Break()
+	f.Keyword("BEGIN")
+	f.Break()
+	f.Indent(func() {
+		TokensToSql(f, node.Body)
+	})
+	f.Break()
+	f.Keyword("END")
 }
 
 func (node *CreateTrigger) node()          {}
@@ -384,6 +1259,9 @@ type TriggerTimeBefore struct {
 
 func (node *TriggerTimeBefore) node()        {}
 func (node *TriggerTimeBefore) triggerTime() {}
+func (node *TriggerTimeBefore) ToSql(f formatter.Formatter) {
+	f.Keyword(node.BeforeKeyword.Text)
+}
 
 type TriggerTimeAfter struct {
 	AfterKeyword Keyword
@@ -391,6 +1269,9 @@ type TriggerTimeAfter struct {
 
 func (node *TriggerTimeAfter) node()        {}
 func (node *TriggerTimeAfter) triggerTime() {}
+func (node *TriggerTimeAfter) ToSql(f formatter.Formatter) {
+	f.Keyword(node.AfterKeyword.Text)
+}
 
 type TriggerTimeInsteadOf struct {
 	InsteadKeyword Keyword
@@ -399,6 +1280,11 @@ type TriggerTimeInsteadOf struct {
 
 func (node *TriggerTimeInsteadOf) node()        {}
 func (node *TriggerTimeInsteadOf) triggerTime() {}
+func (node *TriggerTimeInsteadOf) ToSql(f formatter.Formatter) {
+	f.Keyword(node.InsteadKeyword.Text)
+	f.Space()
+	f.Keyword(node.Of.Text)
+}
 
 type TriggerEventDelete struct {
 	DeleteKeyword Keyword
@@ -406,6 +1292,9 @@ type TriggerEventDelete struct {
 
 func (node *TriggerEventDelete) node()         {}
 func (node *TriggerEventDelete) triggerEvent() {}
+func (node *TriggerEventDelete) ToSql(f formatter.Formatter) {
+	f.Keyword(node.DeleteKeyword.Text)
+}
 
 type TriggerEventInsert struct {
 	InsertKeyword Keyword
@@ -413,6 +1302,9 @@ type TriggerEventInsert struct {
 
 func (node *TriggerEventInsert) node()         {}
 func (node *TriggerEventInsert) triggerEvent() {}
+func (node *TriggerEventInsert) ToSql(f formatter.Formatter) {
+	f.Keyword(node.InsertKeyword.Text)
+}
 
 type TriggerEventUpdate struct {
 	UpdateKeyword Keyword
@@ -420,15 +1312,31 @@ type TriggerEventUpdate struct {
 
 func (node *TriggerEventUpdate) node()         {}
 func (node *TriggerEventUpdate) triggerEvent() {}
+func (node *TriggerEventUpdate) ToSql(f formatter.Formatter) {
+	f.Keyword(node.UpdateKeyword.Text)
+}
 
 type TriggerEventUpdateOf struct {
 	UpdateKeyword Keyword
 	Of            Keyword
-	Columns       AstNodeList
+	Columns       []Identifier
 }
 
 func (node *TriggerEventUpdateOf) node()         {}
 func (node *TriggerEventUpdateOf) triggerEvent() {}
+func (node *TriggerEventUpdateOf) ToSql(f formatter.Formatter) {
+	f.Keyword(node.UpdateKeyword.Text)
+	f.Space()
+	f.Keyword(node.Of.Text)
+	f.Space()
+	for i, column := range node.Columns {
+		column.ToSql(f)
+		if i < len(node.Columns)-1 {
+			f.Rune(',')
+			f.Space()
+		}
+	}
+}
 
 type IndexedColumn struct {
 	Subject   Expr
@@ -437,15 +1345,16 @@ type IndexedColumn struct {
 }
 
 func (node *IndexedColumn) ToSql(f formatter.Formatter) {
-	switch typ := node.Subject.(type) {
-	case *Identifier:
-		{
-			typ.ToSql(f)
-		}
-	default:
-		{
-			panic("IndexedColumn.ToSql not implemented for type")
-		}
+	node.Subject.ToSql(f)
+
+	if node.Collation != nil {
+		f.Space()
+		node.Collation.ToSql(f)
+	}
+
+	if node.Order != nil {
+		f.Space()
+		node.Order.ToSql(f)
 	}
 }
 
@@ -456,8 +1365,8 @@ func (node *IndexedColumn) Eq(other *IndexedColumn) bool {
 	result = result && node.Subject.Eq(other.Subject)
 
 	if node.Collation != nil && other.Collation != nil {
-		result = result && node.Collation.Name.Eq(&node.Collation.Name)
-	} else if node.Collation.Name.Eq(other.Collation.Name.AsExpr()) {
+		result = result && node.Collation.Name.Eq(&other.Collation.Name)
+	} else if node.Collation != nil || other.Collation != nil {
 		return false
 	}
 
@@ -471,19 +1380,224 @@ func (node *IndexedColumn) Eq(other *IndexedColumn) bool {
 }
 
 type CreateView struct {
+	CreateKeyword  Keyword
+	Temporary      *Keyword
+	ViewKeyword    Keyword
 	IfNotExists    *IfNotExists
-	ViewIdentifier CatalogObjectIdentifier
+	ViewIdentifier *CatalogObjectIdentifier
 	Columns        []Identifier
-	AsSelect       AstNode
+	AsKeyword      Keyword
+	AsSelect       *Select
+}
+
+func MakeCreateView(
+	create Keyword,
+	temporary *Keyword,
+	view Keyword,
+	ifNotExists *IfNotExists,
+	viewIdent *CatalogObjectIdentifier,
+	columns []Identifier,
+	as Keyword,
+	asSelect *Select,
+) *CreateView {
+	return &CreateView{
+		CreateKeyword:  create,
+		Temporary:      temporary,
+		ViewKeyword:    view,
+		IfNotExists:    ifNotExists,
+		ViewIdentifier: viewIdent,
+		Columns:        columns,
+		AsKeyword:      as,
+		AsSelect:       asSelect,
+	}
 }
 
 func (node *CreateView) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Group(func() {
+		f.Keyword(node.CreateKeyword.Text)
+		if node.Temporary != nil {
+			f.Space()
+			f.Keyword(node.Temporary.Text)
+		}
+		f.Space()
+		f.Keyword(node.ViewKeyword.Text)
+		if node.IfNotExists != nil {
+			f.Space()
+			node.IfNotExists.ToSql(f)
+		}
+		f.Space()
+		node.ViewIdentifier.ToSql(f)
+		if len(node.Columns) > 0 {
+			f.Space()
+			f.Rune('(')
+			for i, column := range node.Columns {
+				column.ToSql(f)
+				if i < len(node.Columns)-1 {
+					f.Rune(',')
+					f.Space()
+				}
+			}
+			f.Rune(')')
+		}
+		f.Space()
+		f.Keyword(node.AsKeyword.Text)
+	})
+	f.Break()
+	f.Indent(func() {
+		node.AsSelect.ToSql(f)
+	})
 }
 
 func (node *CreateView) node()          {}
 func (node *CreateView) nodeStatement() {}
 
+type CreateSchema struct {
+	CreateKeyword Keyword
+	SchemaKeyword Keyword
+	IfNotExists   *IfNotExists
+	SchemaName    Identifier
+}
+
+func MakeCreateSchema(
+	create Keyword,
+	schema Keyword,
+	ifNotExists *IfNotExists,
+	schemaName Identifier,
+) *CreateSchema {
+	return &CreateSchema{
+		CreateKeyword: create,
+		SchemaKeyword: schema,
+		IfNotExists:   ifNotExists,
+		SchemaName:    schemaName,
+	}
+}
+
+func (node *CreateSchema) ToSql(f formatter.Formatter) {
+	f.Keyword(node.CreateKeyword.Text)
+	f.Space()
+	f.Keyword(node.SchemaKeyword.Text)
+	if node.IfNotExists != nil {
+		f.Space()
+		node.IfNotExists.ToSql(f)
+	}
+	f.Space()
+	node.SchemaName.ToSql(f)
+}
+
+func (node *CreateSchema) node()          {}
+func (node *CreateSchema) nodeStatement() {}
+
+// CreateEnum is a postgres enum type, `CREATE TYPE mood AS ENUM ('sad', 'ok')`.
+type CreateEnum struct {
+	CreateKeyword  Keyword
+	TypeKeyword    Keyword
+	TypeIdentifier *CatalogObjectIdentifier
+	AsKeyword      Keyword
+	EnumKeyword    Keyword
+	Values         []LiteralString
+}
+
+func MakeCreateEnum(
+	create Keyword,
+	typ Keyword,
+	typeIdent *CatalogObjectIdentifier,
+	as Keyword,
+	enum Keyword,
+	values []LiteralString,
+) *CreateEnum {
+	return &CreateEnum{
+		CreateKeyword:  create,
+		TypeKeyword:    typ,
+		TypeIdentifier: typeIdent,
+		AsKeyword:      as,
+		EnumKeyword:    enum,
+		Values:         values,
+	}
+}
+
+func (node *CreateEnum) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		f.Keyword(node.CreateKeyword.Text)
+		f.Space()
+		f.Keyword(node.TypeKeyword.Text)
+		f.Space()
+		node.TypeIdentifier.ToSql(f)
+		f.Space()
+		f.Keyword(node.AsKeyword.Text)
+		f.Space()
+		f.Keyword(node.EnumKeyword.Text)
+		f.Space()
+		f.Rune('(')
+		f.Indent(func() {
+			for i, value := range node.Values {
+				value.ToSql(f)
+				if i < len(node.Values)-1 {
+					f.Rune(',')
+					f.Line()
+				}
+			}
+		})
+		f.Rune(')')
+	})
+}
+
+func (node *CreateEnum) node()          {}
+func (node *CreateEnum) nodeStatement() {}
+
+// CreateDomain is a postgres domain, a type with constraints on it. Only the
+// default, not null, check and collate column constraints can be given.
+type CreateDomain struct {
+	CreateKeyword    Keyword
+	DomainKeyword    Keyword
+	DomainIdentifier *CatalogObjectIdentifier
+	AsKeyword        *Keyword
+	TypeName         TypeName
+	Constraints      []ColumnConstraint
+}
+
+func MakeCreateDomain(
+	create Keyword,
+	domain Keyword,
+	domainIdent *CatalogObjectIdentifier,
+	as *Keyword,
+	typeName TypeName,
+	constraints []ColumnConstraint,
+) *CreateDomain {
+	return &CreateDomain{
+		CreateKeyword:    create,
+		DomainKeyword:    domain,
+		DomainIdentifier: domainIdent,
+		AsKeyword:        as,
+		TypeName:         typeName,
+		Constraints:      constraints,
+	}
+}
+
+func (node *CreateDomain) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		f.Keyword(node.CreateKeyword.Text)
+		f.Space()
+		f.Keyword(node.DomainKeyword.Text)
+		f.Space()
+		node.DomainIdentifier.ToSql(f)
+		f.Space()
+		if node.AsKeyword != nil {
+			f.Keyword(node.AsKeyword.Text)
+			f.Space()
+		}
+		node.TypeName.ToSql(f)
+		f.Indent(func() {
+			for _, constraint := range node.Constraints {
+				f.Line()
+				constraint.ToSql(f)
+			}
+		})
+	})
+}
+
+func (node *CreateDomain) node()          {}
+func (node *CreateDomain) nodeStatement() {}
+
 type IfNotExists struct {
 	If     Keyword
 	Not    Keyword
@@ -503,11 +1617,11 @@ func MakeIfNotExists(
 }
 
 func (node *IfNotExists) ToSql(f formatter.Formatter) {
-	f.Text(node.If.Text)
+	f.Keyword(node.If.Text)
 	f.Space()
-	f.Text(node.Not.Text)
+	f.Keyword(node.Not.Text)
 	f.Space()
-	f.Text(node.Exists.Text)
+	f.Keyword(node.Exists.Text)
 }
 
 func (node *IfNotExists) node() {}
@@ -593,31 +1707,42 @@ func MakeTableDefinition(
 func (node *TableDefinition) node() {}
 
 func (node *TableDefinition) ToSql(f formatter.Formatter) {
+	// the names and types of the columns are padded to line up
+	nameWidth, typeWidth := 0, 0
+	for _, col := range node.ColumnDefinitions {
+		nameWidth = max(nameWidth, f.Measure(func() { col.ColumnName.ToSql(f) }))
+		typeWidth = max(typeWidth, f.Measure(func() { col.TypeName.ToSql(f) }))
+	}
+
 	f.Anchor(func() {
-		for i, col := range node.ColumnDefinitions {
-			col.ToSql(f)
-			if i < len(node.ColumnDefinitions)-1 {
+		items := 0
+		separate := func(item AstNode) {
+			if items > 0 {
 				f.Rune(',')
+			}
+			f.Trivia(LeadingTrivia(item))
+			if items > 0 {
 				f.Break()
 			}
+			items++
 		}
-		if len(node.TableConstraints) > 0 {
-			f.Rune(',')
-			f.Break()
+
+		for _, col := range node.ColumnDefinitions {
+			separate(&col)
+			col.toSql(f, nameWidth, typeWidth)
 		}
-		for i, constraint := range node.TableConstraints {
+		for _, constraint := range node.TableConstraints {
+			separate(constraint)
 			constraint.ToSql(f)
-			if i < len(node.TableConstraints)-1 {
-				f.Rune(',')
-				f.Break()
-			}
 		}
+		f.Trivia(node.RParent.LeadingTrivia)
 	})
 }
 
 type TableOptions struct {
 	Strict       *Keyword
 	WithoutRowId *WithoutRowId
+	Options      []TableOption
 }
 
 func MakeTableOptions(strict *Keyword, withoutRowId *WithoutRowId) *TableOptions {
@@ -629,7 +1754,70 @@ func MakeTableOptions(strict *Keyword, withoutRowId *WithoutRowId) *TableOptions
 
 func (node *TableOptions) node() {}
 func (node *TableOptions) ToSql(f formatter.Formatter) {
+	if node.Strict != nil {
+		node.Strict.ToSql(f)
+		if node.WithoutRowId != nil {
+			f.Rune(',')
+			f.Space()
+		}
+	}
+	if node.WithoutRowId != nil {
+		node.WithoutRowId.ToSql(f)
+	}
+	for i, option := range node.Options {
+		if i > 0 {
+			f.Space()
+		}
+		option.ToSql(f)
+	}
+}
 
+func (node *TableOptions) IsEmpty() bool {
+	return node.Strict == nil && node.WithoutRowId == nil && len(node.Options) == 0
+}
+
+// Option returns the value of the option with the name, e.g. ENGINE.
+func (node *TableOptions) Option(name string) (Expr, bool) {
+	if node == nil {
+		return nil, false
+	}
+	for _, option := range node.Options {
+		if strings.EqualFold(option.Name.Text, name) {
+			return option.Value, true
+		}
+	}
+	return nil, false
+}
+
+// TableOption is a `name=value` option following the table definition in
+// mysql, e.g. `ENGINE=InnoDB`. Options with more than one spelling, such as
+// `DEFAULT CHARSET` and `CHARACTER SET`, are named by one of them, Spelling
+// is the words it was written with when there are any.
+type TableOption struct {
+	Default  *Keyword
+	Spelling []Keyword
+	Name     Keyword
+	Value    Expr
+}
+
+func (node *TableOption) node()            {}
+func (node *TableOption) tableAlteration() {}
+func (node *TableOption) ToSql(f formatter.Formatter) {
+	if node.Default != nil {
+		node.Default.ToSql(f)
+		f.Space()
+	}
+	if len(node.Spelling) == 0 {
+		node.Name.ToSql(f)
+	}
+	for i, word := range node.Spelling {
+		if i > 0 {
+			f.Space()
+		}
+		word.ToSql(f)
+	}
+	f.Rune('=')
+	node.Value.ToSql(f)
 }
 
 func (node *TableOptions) IsStrict() bool {
@@ -653,6 +1841,11 @@ func MakeWithoutRowId(without Keyword, rowId Keyword) *WithoutRowId {
 }
 
 func (node *WithoutRowId) node() {}
+func (node *WithoutRowId) ToSql(f formatter.Formatter) {
+	node.Without.ToSql(f)
+	f.Space()
+	node.RowId.ToSql(f)
+}
 
 type ColumnDefinition struct {
 	ColumnName        Identifier
@@ -662,24 +1855,51 @@ type ColumnDefinition struct {
 
 func MakeColumnDefinition(
 	name Identifier,
-	typ Identifier,
+	typ TypeName,
 	constraints []ColumnConstraint,
 ) *ColumnDefinition {
 	return &ColumnDefinition{
-		ColumnName: name,
-		TypeName: TypeName{
-			TypeName: typ,
-		},
+		ColumnName:        name,
+		TypeName:          typ,
 		ColumnConstraints: constraints,
 	}
 }
 
 func (node *ColumnDefinition) node() {}
 
+func (node *ColumnDefinition) IsGenerated() bool {
+	return slices.ContainsFunc(node.ColumnConstraints, func(constraint ColumnConstraint) bool {
+		_, ok := constraint.(*ColumnConstraint_Generated)
+		return ok
+	})
+}
+
 func (node *ColumnDefinition) ToSql(f formatter.Formatter) {
-	node.ColumnName.ToSql(f)
-	f.Space()
-	f.Text(node.TypeName.TypeName.Text)
+	node.toSql(f, 0, 0)
+}
+
+// toSql writes the column with its name and type padded to the widths, so
+// the columns of a table line up.
+func (node *ColumnDefinition) toSql(f formatter.Formatter, nameWidth int, typeWidth int) {
+	hasConstraints := len(node.ColumnConstraints) > 0
+	if node.TypeName.IsEmpty() && !hasConstraints {
+		node.ColumnName.ToSql(f)
+		return
+	}
+
+	f.Pad(nameWidth, func() { node.ColumnName.ToSql(f) })
+
+	if !node.TypeName.IsEmpty() {
+		f.Space()
+		if hasConstraints {
+			f.Pad(typeWidth, func() { node.TypeName.ToSql(f) })
+		} else {
+			node.TypeName.ToSql(f)
+		}
+	} else if typeWidth > 0 {
+		// keep the constraints in line with those of the columns with a type
+		f.Pad(typeWidth+1, func() {})
+	}
 
 	for _, constraint := range node.ColumnConstraints {
 		f.Space()
@@ -687,12 +1907,68 @@ func (node *ColumnDefinition) ToSql(f formatter.Formatter) {
 	}
 }
 
+// TypeName is the declared type of a column. Multi word type names such as
+// `unsigned big int` are held in a single identifier, and any size arguments,
+// e.g. `varchar(255)` or `decimal(10, 2)`, are held in Args. Array is the
+// number of array dimensions, `text[][]` has two.
 type TypeName struct {
 	TypeName Identifier
+	Args     []Expr
+	Array    int
+	// Unsigned is the mysql attribute of a numeric type, `int unsigned`.
+	Unsigned bool
+}
+
+func MakeTypeName(name Identifier, args []Expr) TypeName {
+	return TypeName{
+		TypeName: name,
+		Args:     args,
+	}
 }
 
 func (node *TypeName) node() {}
 
+func (node *TypeName) IsEmpty() bool {
+	return node.TypeName.Text == ""
+}
+
+// timeZoneSuffixes come after the precision of a time type,
+// `timestamp(3) with time zone`.
+var timeZoneSuffixes = []string{" with time zone", " without time zone"}
+
+func (node *TypeName) ToSql(f formatter.Formatter) {
+	name, suffix := node.TypeName.Text, ""
+	for _, timeZone := range timeZoneSuffixes {
+		if before, ok := strings.CutSuffix(name, timeZone); ok && len(node.Args) > 0 {
+			name, suffix = before, timeZone
+		}
+	}
+
+	f.Text(name)
+	if len(node.Args) > 0 {
+		f.Rune('(')
+		for i, arg := range node.Args {
+			arg.ToSql(f)
+			if i < len(node.Args)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+	}
+	if suffix != "" {
+		f.Text(suffix)
+	}
+	if node.Unsigned {
+		f.Space()
+		f.Keyword("unsigned")
+	}
+	for range node.Array {
+		f.Rune('[')
+		f.Rune(']')
+	}
+}
+
 type ConflictClause struct {
 	OnKeyword       Keyword
 	ConflictKeyword Keyword
@@ -715,6 +1991,14 @@ func (node *ConflictClause) Eq(other *ConflictClause) bool {
 	return node.Action.Eq(&other.Action)
 }
 
+func (node *ConflictClause) ToSql(f formatter.Formatter) {
+	node.OnKeyword.ToSql(f)
+	f.Space()
+	node.ConflictKeyword.ToSql(f)
+	f.Space()
+	node.Action.ToSql(f)
+}
+
 type TableConstraint_Check struct {
 	Name         *ConstraintName
 	CheckKeyword Keyword
@@ -758,7 +2042,143 @@ func (node *TableConstraint_Check) Eq(other TableConstraint) bool {
 }
 
 func (node *TableConstraint_Check) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("CHECK")
+	f.Space()
+	f.Rune('(')
+	node.Expr.ToSql(f)
+	f.Rune(')')
+}
+
+type TableConstraint_Unique struct {
+	Name           *ConstraintName
+	UniqueKeyword  Keyword
+	LParen         tik.Token
+	IndexedColumns []IndexedColumn
+	RParen         tik.Token
+	ConflictClause *ConflictClause
+}
+
+func MakeTableConstraintUnique(
+	constraintName *ConstraintName,
+	uniqueKeyword Keyword,
+	lParen tik.Token,
+	indexedColumns []IndexedColumn,
+	rParen tik.Token,
+	conflictClause *ConflictClause,
+) *TableConstraint_Unique {
+	return &TableConstraint_Unique{
+		Name:           constraintName,
+		UniqueKeyword:  uniqueKeyword,
+		LParen:         lParen,
+		IndexedColumns: indexedColumns,
+		RParen:         rParen,
+		ConflictClause: conflictClause,
+	}
+}
+
+func (node *TableConstraint_Unique) node()                {}
+func (node *TableConstraint_Unique) nodeTableConstraint() {}
+func (node *TableConstraint_Unique) Eq(other TableConstraint) bool {
+	if other, ok := other.(*TableConstraint_Unique); ok {
+		return slices.EqualFunc(node.IndexedColumns, other.IndexedColumns, func(a, b IndexedColumn) bool {
+			return a.Eq(&b)
+		})
+	}
+	return false
+}
+
+func (node *TableConstraint_Unique) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		if node.Name != nil {
+			node.Name.ToSql(f)
+			f.Space()
+		}
+
+		f.Keyword("UNIQUE")
+		f.Space()
+		f.Rune('(')
+		for i, col := range node.IndexedColumns {
+			col.ToSql(f)
+			if i < len(node.IndexedColumns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+
+		if node.ConflictClause != nil {
+			f.Space()
+			node.ConflictClause.ToSql(f)
+		}
+	})
+}
+
+// TableConstraint_Index is an index declared in the table definition, which
+// mysql allows as `[FULLTEXT | SPATIAL] {INDEX | KEY} [name] (columns)`.
+type TableConstraint_Index struct {
+	Kind           *Keyword
+	IndexKeyword   Keyword
+	Name           *Identifier
+	IndexedColumns []IndexedColumn
+}
+
+func MakeTableConstraintIndex(
+	kind *Keyword,
+	indexKeyword Keyword,
+	name *Identifier,
+	indexedColumns []IndexedColumn,
+) *TableConstraint_Index {
+	return &TableConstraint_Index{
+		Kind:           kind,
+		IndexKeyword:   indexKeyword,
+		Name:           name,
+		IndexedColumns: indexedColumns,
+	}
+}
+
+func (node *TableConstraint_Index) node()                {}
+func (node *TableConstraint_Index) nodeTableConstraint() {}
+func (node *TableConstraint_Index) Eq(other TableConstraint) bool {
+	if other, ok := other.(*TableConstraint_Index); ok {
+		if node.Name != nil && other.Name != nil {
+			return node.Name.Eq(other.Name)
+		}
+		return slices.EqualFunc(node.IndexedColumns, other.IndexedColumns, func(a, b IndexedColumn) bool {
+			return a.Eq(&b)
+		})
+	}
+	return false
+}
+
+func (node *TableConstraint_Index) ToSql(f formatter.Formatter) {
+	f.Group(func() {
+		if node.Kind != nil {
+			node.Kind.ToSql(f)
+			f.Space()
+		}
+
+		f.Keyword("INDEX")
+		f.Space()
+		if node.Name != nil {
+			node.Name.ToSql(f)
+			f.Space()
+		}
+
+		f.Rune('(')
+		for i, col := range node.IndexedColumns {
+			col.ToSql(f)
+			if i < len(node.IndexedColumns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+	})
 }
 
 type TableConstraint_PrimaryKey struct {
@@ -835,9 +2255,9 @@ func (node *TableConstraint_PrimaryKey) ToSql(f formatter.Formatter) {
 			f.Space()
 		}
 
-		f.Text("PRIMARY")
+		f.Keyword("PRIMARY")
 		f.Space()
-		f.Text("KEY")
+		f.Keyword("KEY")
 		f.Space()
 		f.Rune('(')
 
@@ -845,7 +2265,7 @@ func (node *TableConstraint_PrimaryKey) ToSql(f formatter.Formatter) {
 			col := node.IndexedColumns[0]
 			col.ToSql(f)
 			f.Space()
-			f.Text("AUTOINCREMENT")
+			f.Keyword("AUTOINCREMENT")
 		} else {
 			for i, col := range node.IndexedColumns {
 
@@ -859,6 +2279,11 @@ func (node *TableConstraint_PrimaryKey) ToSql(f formatter.Formatter) {
 		}
 
 		f.Rune(')')
+
+		if node.ConflictClause != nil {
+			f.Space()
+			node.ConflictClause.ToSql(f)
+		}
 	})
 }
 
@@ -926,7 +2351,30 @@ func (node *TableConstraint_ForeignKey) Eq(other TableConstraint) bool {
 }
 
 func (node *TableConstraint_ForeignKey) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Group(func() {
+		if node.Name != nil {
+			node.Name.ToSql(f)
+			f.Space()
+		}
+
+		f.Keyword("FOREIGN")
+		f.Space()
+		f.Keyword("KEY")
+		f.Space()
+		f.Rune('(')
+		for i, col := range node.Columns {
+			col.ToSql(f)
+			if i < len(node.Columns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+		f.Line()
+		f.Indent(func() {
+			node.FkClause.ToSql(f)
+		})
+	})
 }
 
 type IdentifierPair struct {
@@ -986,6 +2434,40 @@ func MakeForeignKeyClause(
 }
 
 func (node *ForeignKeyClause) node() {}
+func (node *ForeignKeyClause) ToSql(f formatter.Formatter) {
+	f.Keyword("REFERENCES")
+	f.Space()
+	node.ForeignTable.ToSql(f)
+	if len(node.ForeignColumns) > 0 {
+		f.Space()
+		f.Rune('(')
+		for i, col := range node.ForeignColumns {
+			col.ToSql(f)
+			if i < len(node.ForeignColumns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+	}
+
+	for _, action := range node.Actions {
+		f.Space()
+		action.ToSql(f)
+	}
+
+	if node.MatchName != nil {
+		f.Space()
+		f.Keyword("MATCH")
+		f.Space()
+		node.MatchName.ToSql(f)
+	}
+
+	if node.Deferrable != nil {
+		f.Space()
+		node.Deferrable.ToSql(f)
+	}
+}
 func (node *ForeignKeyClause) Eq(other *ForeignKeyClause) bool {
 	if len(node.ForeignColumns) != len(other.ForeignColumns) {
 		return false
@@ -1034,9 +2516,23 @@ func MakeForeignKeyDeferrable(notKeyword *Keyword, deferrableKeyword Keyword, in
 }
 
 func (node *ForeignKeyDeferrable) node() {}
+func (node *ForeignKeyDeferrable) ToSql(f formatter.Formatter) {
+	if node.NotKeyword != nil {
+		f.Keyword("NOT")
+		f.Space()
+	}
+	f.Keyword("DEFERRABLE")
+	if node.InitiallyKeyword != nil {
+		f.Space()
+		f.Keyword("INITIALLY")
+		f.Space()
+		node.Deferrable.ToSql(f)
+	}
+}
 
 type ForeignKeyAction interface {
 	nodeForeignKeyAction()
+	ToSql(f formatter.Formatter)
 }
 
 type ForeignKeyDeleteAction struct {
@@ -1064,6 +2560,13 @@ type ForeignKeyUpdateAction struct {
 }
 
 func (node *ForeignKeyDeleteAction) nodeForeignKeyAction() {}
+func (node *ForeignKeyDeleteAction) ToSql(f formatter.Formatter) {
+	f.Keyword("ON")
+	f.Space()
+	f.Keyword("DELETE")
+	f.Space()
+	node.Action.ToSql(f)
+}
 
 func MakeForeignKeyUpdateAction(
 	onKeyword Keyword,
@@ -1078,11 +2581,19 @@ func MakeForeignKeyUpdateAction(
 }
 
 func (node *ForeignKeyUpdateAction) nodeForeignKeyAction() {}
+func (node *ForeignKeyUpdateAction) ToSql(f formatter.Formatter) {
+	f.Keyword("ON")
+	f.Space()
+	f.Keyword("UPDATE")
+	f.Space()
+	node.Action.ToSql(f)
+}
 
 type ForeignKeyActionDo interface {
 	AstNode
 	nodeForeignKeyActionDo()
 	Eq(other ForeignKeyActionDo) bool
+	ToSql(f formatter.Formatter)
 }
 
 type NoAction struct {
@@ -1103,6 +2614,11 @@ func (node *NoAction) Eq(other ForeignKeyActionDo) bool {
 	_, ok := other.(*NoAction)
 	return ok
 }
+func (node *NoAction) ToSql(f formatter.Formatter) {
+	f.Keyword("NO")
+	f.Space()
+	f.Keyword("ACTION")
+}
 
 type Restrict Keyword
 
@@ -1117,6 +2633,9 @@ func (node *Restrict) Eq(other ForeignKeyActionDo) bool {
 	_, ok := other.(*Restrict)
 	return ok
 }
+func (node *Restrict) ToSql(f formatter.Formatter) {
+	f.Keyword("RESTRICT")
+}
 
 type SetNull struct {
 	SetKeyword  Keyword
@@ -1136,6 +2655,11 @@ func (node *SetNull) Eq(other ForeignKeyActionDo) bool {
 	_, ok := other.(*SetNull)
 	return ok
 }
+func (node *SetNull) ToSql(f formatter.Formatter) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("NULL")
+}
 
 type SetDefault struct {
 	SetKeyword     Keyword
@@ -1155,6 +2679,11 @@ func (node *SetDefault) Eq(other ForeignKeyActionDo) bool {
 	_, ok := other.(*SetDefault)
 	return ok
 }
+func (node *SetDefault) ToSql(f formatter.Formatter) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("DEFAULT")
+}
 
 type Cascade Keyword
 
@@ -1169,6 +2698,9 @@ func (node *Cascade) Eq(other ForeignKeyActionDo) bool {
 	_, ok := other.(*Cascade)
 	return ok
 }
+func (node *Cascade) ToSql(f formatter.Formatter) {
+	f.Keyword("CASCADE")
+}
 
 type ConstraintName struct {
 	ConstraintKeyword Keyword
@@ -1238,12 +2770,43 @@ func (node *ColumnConstraint_PrimaryKey) Eq(other ColumnConstraint) bool {
 
 func (node *ColumnConstraint_PrimaryKey) ToSql(f formatter.Formatter) {
 	if node.Name != nil {
-		f.Text("")
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("PRIMARY")
+	f.Space()
+	f.Keyword("KEY")
+
+	if node.Order != nil {
+		f.Space()
+		node.Order.ToSql(f)
+	}
+
+	if node.ConflictClause != nil {
+		f.Space()
+		node.ConflictClause.ToSql(f)
+	}
+
+	if node.AutoIncrement != nil {
+		f.Space()
+		f.Keyword("AUTOINCREMENT")
 	}
 }
 
 type ColumnConstraint_Unique struct {
-	Name *ConstraintName
+	Name           *ConstraintName
+	ConflictClause *ConflictClause
+}
+
+func MakeColumnConstraintUnique(
+	constraintName *ConstraintName,
+	conflictClause *ConflictClause,
+) *ColumnConstraint_Unique {
+	return &ColumnConstraint_Unique{
+		Name:           constraintName,
+		ConflictClause: conflictClause,
+	}
 }
 
 func (node *ColumnConstraint_Unique) node()                 {}
@@ -1254,7 +2817,17 @@ func (node *ColumnConstraint_Unique) Eq(other ColumnConstraint) bool {
 }
 
 func (node *ColumnConstraint_Unique) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("UNIQUE")
+
+	if node.ConflictClause != nil {
+		f.Space()
+		node.ConflictClause.ToSql(f)
+	}
 }
 
 type ColumnConstraint_Collate struct {
@@ -1262,6 +2835,16 @@ type ColumnConstraint_Collate struct {
 	Collate Identifier
 }
 
+func MakeColumnConstraintCollate(
+	constraintName *ConstraintName,
+	collate Identifier,
+) *ColumnConstraint_Collate {
+	return &ColumnConstraint_Collate{
+		Name:    constraintName,
+		Collate: collate,
+	}
+}
+
 func (node *ColumnConstraint_Collate) node()                 {}
 func (node *ColumnConstraint_Collate) nodeColumnConstraint() {}
 func (node *ColumnConstraint_Collate) Eq(other ColumnConstraint) bool {
@@ -1281,11 +2864,29 @@ func (node *ColumnConstraint_Collate) Eq(other ColumnConstraint) bool {
 }
 
 func (node *ColumnConstraint_Collate) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("COLLATE")
+	f.Space()
+	node.Collate.ToSql(f)
 }
 
 type ColumnConstraint_NotNull struct {
-	Name *ConstraintName
+	Name           *ConstraintName
+	ConflictClause *ConflictClause
+}
+
+func MakeColumnConstraintNotNull(
+	constraintName *ConstraintName,
+	conflictClause *ConflictClause,
+) *ColumnConstraint_NotNull {
+	return &ColumnConstraint_NotNull{
+		Name:           constraintName,
+		ConflictClause: conflictClause,
+	}
 }
 
 func (node *ColumnConstraint_NotNull) node()                 {}
@@ -1305,15 +2906,18 @@ func (node *ColumnConstraint_NotNull) Eq(other ColumnConstraint) bool {
 
 func (node *ColumnConstraint_NotNull) ToSql(f formatter.Formatter) {
 	if node.Name != nil {
-		f.Text("CONSTRAINT")
-		f.Space()
 		node.Name.ToSql(f)
 		f.Space()
 	}
 
-	f.Text("NOT")
+	f.Keyword("NOT")
 	f.Space()
-	f.Text("NULL")
+	f.Keyword("NULL")
+
+	if node.ConflictClause != nil {
+		f.Space()
+		node.ConflictClause.ToSql(f)
+	}
 }
 
 type ColumnConstraint_Default struct {
@@ -1321,8 +2925,25 @@ type ColumnConstraint_Default struct {
 	Default Expr
 }
 
+func MakeColumnConstraintDefault(
+	constraintName *ConstraintName,
+	defaultExpr Expr,
+) *ColumnConstraint_Default {
+	return &ColumnConstraint_Default{
+		Name:    constraintName,
+		Default: defaultExpr,
+	}
+}
+
 func (node *ColumnConstraint_Default) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("DEFAULT")
+	f.Space()
+	node.Default.ToSql(f)
 }
 
 func (node *ColumnConstraint_Default) node()                 {}
@@ -1343,11 +2964,41 @@ func (node *ColumnConstraint_Default) Eq(other ColumnConstraint) bool {
 type ColumnConstraint_Generated struct {
 	Name    *ConstraintName
 	As      Expr
-	Storage AstNode
+	Storage *Keyword
+}
+
+func MakeColumnConstraintGenerated(
+	constraintName *ConstraintName,
+	as Expr,
+	storage *Keyword,
+) *ColumnConstraint_Generated {
+	return &ColumnConstraint_Generated{
+		Name:    constraintName,
+		As:      as,
+		Storage: storage,
+	}
 }
 
 func (node *ColumnConstraint_Generated) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("GENERATED")
+	f.Space()
+	f.Keyword("ALWAYS")
+	f.Space()
+	f.Keyword("AS")
+	f.Space()
+	f.Rune('(')
+	node.As.ToSql(f)
+	f.Rune(')')
+
+	if node.Storage != nil {
+		f.Space()
+		node.Storage.ToSql(f)
+	}
 }
 
 func (node *ColumnConstraint_Generated) node()                 {}
@@ -1366,13 +3017,86 @@ func (node *ColumnConstraint_Generated) Eq(other ColumnConstraint) bool {
 	return false
 }
 
+// ColumnConstraint_Identity is a postgres identity column, its values come
+// from a sequence. Always says whether an explicit value may be inserted.
+type ColumnConstraint_Identity struct {
+	Name   *ConstraintName
+	Always bool
+}
+
+func MakeColumnConstraintIdentity(
+	constraintName *ConstraintName,
+	always bool,
+) *ColumnConstraint_Identity {
+	return &ColumnConstraint_Identity{
+		Name:   constraintName,
+		Always: always,
+	}
+}
+
+func (node *ColumnConstraint_Identity) generatedWhen(f formatter.Formatter) {
+	if node.Always {
+		f.Keyword("ALWAYS")
+		return
+	}
+	f.Keyword("BY")
+	f.Space()
+	f.Keyword("DEFAULT")
+}
+
+func (node *ColumnConstraint_Identity) ToSql(f formatter.Formatter) {
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("GENERATED")
+	f.Space()
+	node.generatedWhen(f)
+	f.Space()
+	f.Keyword("AS")
+	f.Space()
+	f.Keyword("IDENTITY")
+}
+
+func (node *ColumnConstraint_Identity) node()                 {}
+func (node *ColumnConstraint_Identity) nodeColumnConstraint() {}
+func (node *ColumnConstraint_Identity) Eq(other ColumnConstraint) bool {
+	if other, ok := other.(*ColumnConstraint_Identity); ok {
+		if node.Name != nil && other.Name != nil {
+			return node.Name.Eq(other.Name)
+		}
+		return node.Name == nil && other.Name == nil
+	}
+	return false
+}
+
 type ColumnConstraint_Check struct {
 	Name  *ConstraintName
 	Check Expr
 }
 
+func MakeColumnConstraintCheck(
+	constraintName *ConstraintName,
+	check Expr,
+) *ColumnConstraint_Check {
+	return &ColumnConstraint_Check{
+		Name:  constraintName,
+		Check: check,
+	}
+}
+
 func (node *ColumnConstraint_Check) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	f.Keyword("CHECK")
+	f.Space()
+	f.Rune('(')
+	node.Check.ToSql(f)
+	f.Rune(')')
 }
 
 func (node *ColumnConstraint_Check) node()                 {}
@@ -1393,7 +3117,143 @@ func (node *ColumnConstraint_Check) Eq(other ColumnConstraint) bool {
 		return node.Check.Eq(other.Check)
 	}
 
-	return true
+	return false
+}
+
+type ColumnConstraint_ForeignKey struct {
+	Name     *ConstraintName
+	FkClause ForeignKeyClause
+}
+
+func MakeColumnConstraintForeignKey(
+	constraintName *ConstraintName,
+	fkClause *ForeignKeyClause,
+) *ColumnConstraint_ForeignKey {
+	return &ColumnConstraint_ForeignKey{
+		Name:     constraintName,
+		FkClause: *fkClause,
+	}
+}
+
+func (node *ColumnConstraint_ForeignKey) node()                 {}
+func (node *ColumnConstraint_ForeignKey) nodeColumnConstraint() {}
+func (node *ColumnConstraint_ForeignKey) Eq(other ColumnConstraint) bool {
+	if other, ok := other.(*ColumnConstraint_ForeignKey); ok {
+		if node.Name != nil && other.Name != nil {
+			return node.Name.Eq(other.Name)
+		} else if node.Name != nil || other.Name != nil {
+			return false
+		}
+		return node.FkClause.Eq(&other.FkClause)
+	}
+	return false
+}
+
+func (node *ColumnConstraint_ForeignKey) ToSql(f formatter.Formatter) {
+	if node.Name != nil {
+		node.Name.ToSql(f)
+		f.Space()
+	}
+
+	node.FkClause.ToSql(f)
+}
+
+// ColumnConstraint_AutoIncrement is the mysql AUTO_INCREMENT attribute,
+// sqlite's AUTOINCREMENT belongs to its primary key instead.
+type ColumnConstraint_AutoIncrement struct {
+	Keyword Keyword
+}
+
+func MakeColumnConstraintAutoIncrement(keyword Keyword) *ColumnConstraint_AutoIncrement {
+	return &ColumnConstraint_AutoIncrement{
+		Keyword: keyword,
+	}
+}
+
+func (node *ColumnConstraint_AutoIncrement) node()                 {}
+func (node *ColumnConstraint_AutoIncrement) nodeColumnConstraint() {}
+func (node *ColumnConstraint_AutoIncrement) Eq(other ColumnConstraint) bool {
+	_, ok := other.(*ColumnConstraint_AutoIncrement)
+	return ok
+}
+
+func (node *ColumnConstraint_AutoIncrement) ToSql(f formatter.Formatter) {
+	f.Keyword("AUTO_INCREMENT")
+}
+
+// ColumnConstraint_OnUpdate is the value mysql sets a column to whenever
+// its row is updated, `ON UPDATE CURRENT_TIMESTAMP`.
+type ColumnConstraint_OnUpdate struct {
+	Value Expr
+}
+
+func MakeColumnConstraintOnUpdate(value Expr) *ColumnConstraint_OnUpdate {
+	return &ColumnConstraint_OnUpdate{
+		Value: value,
+	}
+}
+
+func (node *ColumnConstraint_OnUpdate) node()                 {}
+func (node *ColumnConstraint_OnUpdate) nodeColumnConstraint() {}
+func (node *ColumnConstraint_OnUpdate) Eq(other ColumnConstraint) bool {
+	_, ok := other.(*ColumnConstraint_OnUpdate)
+	return ok
+}
+
+func (node *ColumnConstraint_OnUpdate) ToSql(f formatter.Formatter) {
+	f.Keyword("ON")
+	f.Space()
+	f.Keyword("UPDATE")
+	f.Space()
+	node.Value.ToSql(f)
+}
+
+type ColumnConstraint_Comment struct {
+	Comment LiteralString
+}
+
+func MakeColumnConstraintComment(comment LiteralString) *ColumnConstraint_Comment {
+	return &ColumnConstraint_Comment{
+		Comment: comment,
+	}
+}
+
+func (node *ColumnConstraint_Comment) node()                 {}
+func (node *ColumnConstraint_Comment) nodeColumnConstraint() {}
+func (node *ColumnConstraint_Comment) Eq(other ColumnConstraint) bool {
+	_, ok := other.(*ColumnConstraint_Comment)
+	return ok
+}
+
+func (node *ColumnConstraint_Comment) ToSql(f formatter.Formatter) {
+	f.Keyword("COMMENT")
+	f.Space()
+	node.Comment.ToSql(f)
+}
+
+type ColumnConstraint_CharacterSet struct {
+	CharacterSet Identifier
+}
+
+func MakeColumnConstraintCharacterSet(characterSet Identifier) *ColumnConstraint_CharacterSet {
+	return &ColumnConstraint_CharacterSet{
+		CharacterSet: characterSet,
+	}
+}
+
+func (node *ColumnConstraint_CharacterSet) node()                 {}
+func (node *ColumnConstraint_CharacterSet) nodeColumnConstraint() {}
+func (node *ColumnConstraint_CharacterSet) Eq(other ColumnConstraint) bool {
+	_, ok := other.(*ColumnConstraint_CharacterSet)
+	return ok
+}
+
+func (node *ColumnConstraint_CharacterSet) ToSql(f formatter.Formatter) {
+	f.Keyword("CHARACTER")
+	f.Space()
+	f.Keyword("SET")
+	f.Space()
+	node.CharacterSet.ToSql(f)
 }
 
 type ExprList []Expr
@@ -1419,10 +3279,26 @@ func (node ExprList) Eq(other Expr) bool {
 	return false
 }
 
+func (node ExprList) ToSql(f formatter.Formatter) {
+	f.Rune('(')
+	for i, expr := range node {
+		expr.ToSql(f)
+		if i < len(node)-1 {
+			f.Rune(',')
+			f.Space()
+		}
+	}
+	f.Rune(')')
+}
+
 type LiteralNull struct {
 	Token tik.Token
 }
 
+func (node *LiteralNull) ToSql(f formatter.Formatter) {
+	f.Keyword("NULL")
+}
+
 func (node *LiteralNull) node()           {}
 func (node *LiteralNull) nodeExpression() {}
 func (node *LiteralNull) nodeLiteral()    {}
@@ -1492,7 +3368,7 @@ type LiteralBoolean struct {
 }
 
 func (node *LiteralBoolean) ToSql(f formatter.Formatter) {
-	f.Text(node.Token.Text)
+	f.Keyword(node.Token.Text)
 }
 
 func (node *LiteralBoolean) node()            {}
@@ -1506,8 +3382,33 @@ func (node *LiteralBoolean) Eq(other Expr) bool {
 	return false
 }
 
+var ErrTokenUnconvertableToInteger = errors.New("token is not convertable to integer")
+
 func TokenToLiteralInteger(token tik.Token) (LiteralInteger, error) {
-	panic("not implemented")
+	var value int64
+	var err error
+
+	switch token.Kind {
+	case tik.TokenKind_DecimalNumericLiteral:
+		value, err = strconv.ParseInt(token.Text, 10, 64)
+	case tik.TokenKind_HexNumericLiteral:
+		value, err = strconv.ParseInt(strings.ReplaceAll(token.Text[2:], "_", ""), 16, 64)
+	case tik.TokenKind_BinaryNumericLiteral:
+		value, err = strconv.ParseInt(strings.ReplaceAll(token.Text[2:], "_", ""), 2, 64)
+	case tik.TokenKind_OctalNumericLiteral:
+		value, err = strconv.ParseInt(strings.ReplaceAll(token.Text, "_", ""), 8, 64)
+	default:
+		return LiteralInteger{}, fmt.Errorf("%w: unexpected token: token is %s", ErrTokenUnconvertableToInteger, token.Text)
+	}
+
+	if err != nil {
+		return LiteralInteger{Token: token}, fmt.Errorf("%w: %w :token is %s", ErrTokenUnconvertableToInteger, err, token.Text)
+	}
+
+	return LiteralInteger{
+		Token: token,
+		Value: value,
+	}, nil
 }
 
 type LiteralInteger struct {
@@ -1526,6 +3427,10 @@ func (node *LiteralInteger) Eq(other Expr) bool {
 	return false
 }
 
+func (node *LiteralInteger) ToSql(f formatter.Formatter) {
+	f.Text(node.Token.Text)
+}
+
 type LiteralFloat struct {
 	Token tik.Token
 	Value float64
@@ -1543,6 +3448,10 @@ func (node *LiteralFloat) Eq(other Expr) bool {
 	return false
 }
 
+func (node *LiteralFloat) ToSql(f formatter.Formatter) {
+	f.Text(node.Token.Text)
+}
+
 type LiteralString struct {
 	Token tik.Token
 	Value string
@@ -1559,13 +3468,81 @@ func (node *LiteralString) Eq(other Expr) bool {
 	return false
 }
 
+func (node *LiteralString) ToSql(f formatter.Formatter) {
+	f.Rune('\'')
+	f.Text(strings.ReplaceAll(node.Value, "'", "''"))
+	f.Rune('\'')
+}
+
 type UnaryOperator struct {
 	Operator tik.Token
 	Rhs      Expr
 }
 
+func MakeUnaryOpExpr(op tik.Token, rhs Expr) *UnaryOperator {
+	return &UnaryOperator{
+		Operator: op,
+		Rhs:      rhs,
+	}
+}
+
 func (node *UnaryOperator) node()           {}
 func (node *UnaryOperator) nodeExpression() {}
+func (node *UnaryOperator) Eq(other Expr) bool {
+	if other, ok := other.(*UnaryOperator); ok {
+		return node.Operator.Kind == other.Operator.Kind && node.Rhs.Eq(other.Rhs)
+	}
+	return false
+}
+
+func (node *UnaryOperator) ToSql(f formatter.Formatter) {
+	operatorToSql(f, node.Operator)
+	if node.Operator.Kind >= tik.TokenKindOffset_Keywords {
+		f.Space()
+	}
+	node.Rhs.ToSql(f)
+}
+
+// operatorToSql writes an operator, which is either a symbol or keywords
+// such as `IS NOT`.
+func operatorToSql(f formatter.Formatter, operator tik.Token) {
+	if operator.Kind >= tik.TokenKindOffset_Keywords {
+		f.Keyword(operator.Text)
+	} else {
+		f.Text(operator.Text)
+	}
+}
+
+// Parens is an expression wrapped in parentheses, kept so that the original
+// grouping can be reproduced.
+type Parens struct {
+	LParen tik.Token
+	Expr   Expr
+	RParen tik.Token
+}
+
+func MakeParensExpr(lParen tik.Token, expr Expr, rParen tik.Token) *Parens {
+	return &Parens{
+		LParen: lParen,
+		Expr:   expr,
+		RParen: rParen,
+	}
+}
+
+func (node *Parens) node()           {}
+func (node *Parens) nodeExpression() {}
+func (node *Parens) Eq(other Expr) bool {
+	if other, ok := other.(*Parens); ok {
+		return node.Expr.Eq(other.Expr)
+	}
+	return false
+}
+
+func (node *Parens) ToSql(f formatter.Formatter) {
+	f.Rune('(')
+	node.Expr.ToSql(f)
+	f.Rune(')')
+}
 
 type FunctionCall struct {
 	Name Identifier
@@ -1573,7 +3550,8 @@ type FunctionCall struct {
 }
 
 func (node *FunctionCall) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	f.Text(node.Name.Text)
+	node.Args.ToSql(f)
 }
 
 func (node *FunctionCall) node()           {}
@@ -1588,6 +3566,72 @@ func (node *FunctionCall) Eq(other Expr) bool {
 	return false
 }
 
+// Cast converts an expression to another type, written either as
+// `CAST(x AS t)` or as the postgres shorthand `x::t` when CastKeyword is nil.
+type Cast struct {
+	CastKeyword *Keyword
+	Expr        Expr
+	Type        TypeName
+}
+
+func (node *Cast) ToSql(f formatter.Formatter) {
+	if node.CastKeyword == nil {
+		node.Expr.ToSql(f)
+		f.Text("::")
+		node.Type.ToSql(f)
+		return
+	}
+
+	f.Keyword(node.CastKeyword.Text)
+	f.Rune('(')
+	node.Expr.ToSql(f)
+	f.Space()
+	f.Keyword("AS")
+	f.Space()
+	node.Type.ToSql(f)
+	f.Rune(')')
+}
+
+func (node *Cast) node()           {}
+func (node *Cast) nodeExpression() {}
+func (node *Cast) Eq(other Expr) bool {
+	if other, ok := other.(*Cast); ok {
+		return node.Expr.Eq(other.Expr) &&
+			strings.EqualFold(node.Type.TypeName.Text, other.Type.TypeName.Text) &&
+			slices.EqualFunc(node.Type.Args, other.Type.Args, func(a, b Expr) bool { return a.Eq(b) }) &&
+			node.Type.Array == other.Type.Array
+	}
+	return false
+}
+
+// ArrayConstructor is a postgres array value, `ARRAY['a', 'b']`.
+type ArrayConstructor struct {
+	ArrayKeyword Keyword
+	Elements     ExprList
+}
+
+func (node *ArrayConstructor) ToSql(f formatter.Formatter) {
+	f.Keyword(node.ArrayKeyword.Text)
+	f.Rune('[')
+	for i, element := range node.Elements {
+		element.ToSql(f)
+		if i < len(node.Elements)-1 {
+			f.Rune(',')
+			f.Space()
+		}
+	}
+	f.Rune(']')
+}
+
+func (node *ArrayConstructor) node()           {}
+func (node *ArrayConstructor) nodeExpression() {}
+func (node *ArrayConstructor) Eq(other Expr) bool {
+	if other, ok := other.(*ArrayConstructor); ok {
+		return node.Elements.Eq(other.Elements)
+	}
+	return false
+}
+
 type ColumnName struct {
 	Schema *Identifier
 	Table  *Identifier
@@ -1595,7 +3639,15 @@ type ColumnName struct {
 }
 
 func (node *ColumnName) ToSql(f formatter.Formatter) {
-	panic("not implemented")
+	if node.Schema != nil {
+		node.Schema.ToSql(f)
+		f.Rune('.')
+	}
+	if node.Table != nil {
+		node.Table.ToSql(f)
+		f.Rune('.')
+	}
+	node.Column.ToSql(f)
 }
 
 func (node *ColumnName) node()           {}
@@ -1655,13 +3707,21 @@ func (node *BinaryOp) Eq(other Expr) bool {
 		}
 
 		result := true
-		result = result && node.Lhs.Eq(node.Lhs)
-		result = result && node.Rhs.Eq(node.Rhs)
+		result = result && node.Lhs.Eq(other.Lhs)
+		result = result && node.Rhs.Eq(other.Rhs)
 		return result
 	}
 	return false
 }
 
+func (node *BinaryOp) ToSql(f formatter.Formatter) {
+	node.Lhs.ToSql(f)
+	f.Space()
+	operatorToSql(f, node.Operator)
+	f.Space()
+	node.Rhs.ToSql(f)
+}
+
 type CaseExpression struct {
 	Operand Expr
 	Cases   []WhenThen
@@ -1678,7 +3738,11 @@ func (node *CaseExpression) Eq(other Expr) bool {
 		}
 
 		result := true
-		result = result && node.Operand.Eq(other.Operand)
+		if node.Operand != nil && other.Operand != nil {
+			result = result && node.Operand.Eq(other.Operand)
+		} else if node.Operand != nil || other.Operand != nil {
+			return false
+		}
 
 		for i := range len(node.Cases) {
 			aCase := node.Cases[i]
@@ -1688,19 +3752,52 @@ func (node *CaseExpression) Eq(other Expr) bool {
 			result = result && aCase.Then.Eq(bCase.Then)
 		}
 
-		result = result && node.Else.Eq(other.Else)
+		if node.Else != nil && other.Else != nil {
+			result = result && node.Else.Eq(other.Else)
+		} else if node.Else != nil || other.Else != nil {
+			return false
+		}
 		return result
 	}
 	return false
 
 }
 
+func (node *CaseExpression) ToSql(f formatter.Formatter) {
+	f.Keyword("CASE")
+	if node.Operand != nil {
+		f.Space()
+		node.Operand.ToSql(f)
+	}
+	for _, c := range node.Cases {
+		f.Space()
+		c.ToSql(f)
+	}
+	if node.Else != nil {
+		f.Space()
+		f.Keyword("ELSE")
+		f.Space()
+		node.Else.ToSql(f)
+	}
+	f.Space()
+	f.Keyword("END")
+}
+
 type WhenThen struct {
 	When Expr
 	Then Expr
 }
 
 func (node *WhenThen) node() {}
+func (node *WhenThen) ToSql(f formatter.Formatter) {
+	f.Keyword("WHEN")
+	f.Space()
+	node.When.ToSql(f)
+	f.Space()
+	f.Keyword("THEN")
+	f.Space()
+	node.Then.ToSql(f)
+}
 
 type Collation struct {
 	CollateKeyword Keyword
@@ -1716,3 +3813,10 @@ func MakeCollation(
 		Name:           name,
 	}
 }
+
+func (node *Collation) node() {}
+func (node *Collation) ToSql(f formatter.Formatter) {
+	f.Keyword("COLLATE")
+	f.Space()
+	node.Name.ToSql(f)
+}
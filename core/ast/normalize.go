@@ -0,0 +1,195 @@
+package ast
+
+import (
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+// ExprEqual reports whether two expressions are the same once normalized,
+// whatever the trivia around their tokens, their parentheses, the case of
+// their keywords and unquoted names, and whether a name is quoted. it is what
+// the differ compares defaults, checks, generated columns and the where
+// clauses of indexes with, as the database rarely gives them back written
+// the same way as the schema.
+func ExprEqual(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return exprEqual(NormalizeExpr(a), NormalizeExpr(b))
+}
+
+// NormalizeExpr returns expr without its parentheses, which the tree of the
+// expression already holds the grouping of, `(0)` is `0` and `(a > 1) AND
+// (b > 1)` is `a > 1 AND b > 1`. a column name which is not qualified becomes
+// an identifier. expr itself is left as it is.
+func NormalizeExpr(expr Expr) Expr {
+	switch typ := expr.(type) {
+	case *Parens:
+		return NormalizeExpr(typ.Expr)
+	case *BinaryOp:
+		return &BinaryOp{Operator: typ.Operator, Lhs: NormalizeExpr(typ.Lhs), Rhs: NormalizeExpr(typ.Rhs)}
+	case *UnaryOperator:
+		return &UnaryOperator{Operator: typ.Operator, Rhs: NormalizeExpr(typ.Rhs)}
+	case *ColumnName:
+		if typ.Schema == nil && typ.Table == nil {
+			column := typ.Column
+			return &column
+		}
+		return typ
+	case *FunctionCall:
+		return &FunctionCall{Name: typ.Name, Args: normalizeList(typ.Args)}
+	case ExprList:
+		return normalizeList(typ)
+	case *Cast:
+		return &Cast{CastKeyword: typ.CastKeyword, Expr: NormalizeExpr(typ.Expr), Type: typ.Type}
+	case *ArrayConstructor:
+		return &ArrayConstructor{ArrayKeyword: typ.ArrayKeyword, Elements: normalizeList(typ.Elements)}
+	case *CaseExpression:
+		result := &CaseExpression{Cases: make([]WhenThen, 0, len(typ.Cases))}
+		if typ.Operand != nil {
+			result.Operand = NormalizeExpr(typ.Operand)
+		}
+		for _, c := range typ.Cases {
+			result.Cases = append(result.Cases, WhenThen{When: NormalizeExpr(c.When), Then: NormalizeExpr(c.Then)})
+		}
+		if typ.Else != nil {
+			result.Else = NormalizeExpr(typ.Else)
+		}
+		return result
+	default:
+		return expr
+	}
+}
+
+func normalizeList(list ExprList) ExprList {
+	result := make(ExprList, 0, len(list))
+	for _, expr := range list {
+		result = append(result, NormalizeExpr(expr))
+	}
+	return result
+}
+
+func exprEqual(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	switch a := a.(type) {
+	case *Identifier:
+		b, ok := b.(*Identifier)
+		return ok && identifierEqual(a, b)
+	case *ColumnName:
+		b, ok := b.(*ColumnName)
+		return ok &&
+			identifierEqual(a.Schema, b.Schema) &&
+			identifierEqual(a.Table, b.Table) &&
+			identifierEqual(&a.Column, &b.Column)
+	case *LiteralNull:
+		_, ok := b.(*LiteralNull)
+		return ok
+	case *LiteralBoolean:
+		b, ok := b.(*LiteralBoolean)
+		return ok && a.Value == b.Value
+	case *LiteralInteger:
+		b, ok := b.(*LiteralInteger)
+		return ok && integerEqual(a.Token.Text, b.Token.Text)
+	case *LiteralFloat:
+		b, ok := b.(*LiteralFloat)
+		return ok && floatEqual(a.Token.Text, b.Token.Text)
+	case *LiteralString:
+		b, ok := b.(*LiteralString)
+		return ok && a.Value == b.Value
+	case *UnaryOperator:
+		b, ok := b.(*UnaryOperator)
+		return ok && strings.EqualFold(a.Operator.Text, b.Operator.Text) && exprEqual(a.Rhs, b.Rhs)
+	case *BinaryOp:
+		b, ok := b.(*BinaryOp)
+		return ok &&
+			strings.EqualFold(a.Operator.Text, b.Operator.Text) &&
+			exprEqual(a.Lhs, b.Lhs) &&
+			exprEqual(a.Rhs, b.Rhs)
+	case *Parens:
+		b, ok := b.(*Parens)
+		return ok && exprEqual(a.Expr, b.Expr)
+	case *FunctionCall:
+		b, ok := b.(*FunctionCall)
+		return ok && identifierEqual(&a.Name, &b.Name) && listEqual(a.Args, b.Args)
+	case ExprList:
+		b, ok := b.(ExprList)
+		return ok && listEqual(a, b)
+	case *Cast:
+		b, ok := b.(*Cast)
+		return ok &&
+			exprEqual(a.Expr, b.Expr) &&
+			strings.EqualFold(a.Type.TypeName.Text, b.Type.TypeName.Text) &&
+			listEqual(a.Type.Args, b.Type.Args) &&
+			a.Type.Array == b.Type.Array
+	case *ArrayConstructor:
+		b, ok := b.(*ArrayConstructor)
+		return ok && listEqual(a.Elements, b.Elements)
+	case *CaseExpression:
+		b, ok := b.(*CaseExpression)
+		return ok &&
+			exprEqual(a.Operand, b.Operand) &&
+			slices.EqualFunc(a.Cases, b.Cases, func(a, b WhenThen) bool {
+				return exprEqual(a.When, b.When) && exprEqual(a.Then, b.Then)
+			}) &&
+			exprEqual(a.Else, b.Else)
+	default:
+		return a.Eq(b)
+	}
+}
+
+func listEqual(a, b []Expr) bool {
+	return slices.EqualFunc(a, b, exprEqual)
+}
+
+// identifierEqual compares names, a quoted name keeps its case so is only
+// the same as a name written exactly like it.
+func identifierEqual(a, b *Identifier) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if isQuotedToken(tik.Token(*a)) || isQuotedToken(tik.Token(*b)) {
+		return a.Text == b.Text
+	}
+	return strings.EqualFold(a.Text, b.Text)
+}
+
+// integerEqual compares integers by their value, `0x10` is `16` and `010`
+// is `10`, falling back to their text for one which does not parse.
+func integerEqual(a, b string) bool {
+	x, okA := integerValue(a)
+	y, okB := integerValue(b)
+	if !okA || !okB {
+		return strings.EqualFold(a, b)
+	}
+	return x.Cmp(y) == 0
+}
+
+// integerValue reads an integer literal, unlike go a leading 0 does not make
+// it octal.
+func integerValue(text string) (*big.Int, bool) {
+	text = strings.ReplaceAll(strings.ToLower(text), "_", "")
+	base := 10
+	for prefix, prefixBase := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if strings.HasPrefix(text, prefix) {
+			text, base = text[len(prefix):], prefixBase
+		}
+	}
+	return new(big.Int).SetString(text, base)
+}
+
+// floatEqual compares numbers by their value, `1.0` is `1.00`, falling back
+// to their text for one which does not parse.
+func floatEqual(a, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return x == y
+}
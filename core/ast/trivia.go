@@ -0,0 +1,55 @@
+package ast
+
+// LeadingTrivia is the whitespace and comments before a statement, column
+// definition or table constraint, which is where comments about it are
+// written. It is empty for nodes made up by a generator.
+func LeadingTrivia(node AstNode) string {
+	switch node := node.(type) {
+	case *CreateTable:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateVirtualTable:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateIndex:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateTrigger:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateView:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateSchema:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateEnum:
+		return node.CreateKeyword.LeadingTrivia
+	case *CreateDomain:
+		return node.CreateKeyword.LeadingTrivia
+	case *Pragma:
+		return node.PragmaKeyword.LeadingTrivia
+	case *BeginTransaction:
+		return node.BeginKeyword.LeadingTrivia
+	case *CommitTransaction:
+		return node.CommitKeyword.LeadingTrivia
+	case *ColumnDefinition:
+		return node.ColumnName.LeadingTrivia
+	case *TableConstraint_Check:
+		return constraintTrivia(node.Name, node.CheckKeyword)
+	case *TableConstraint_Unique:
+		return constraintTrivia(node.Name, node.UniqueKeyword)
+	case *TableConstraint_PrimaryKey:
+		return constraintTrivia(node.Name, node.PrimaryKeyword)
+	case *TableConstraint_ForeignKey:
+		return constraintTrivia(node.Name, node.ForeignKeyword)
+	case *TableConstraint_Index:
+		if node.Kind != nil {
+			return node.Kind.LeadingTrivia
+		}
+		return node.IndexKeyword.LeadingTrivia
+	default:
+		return ""
+	}
+}
+
+func constraintTrivia(name *ConstraintName, keyword Keyword) string {
+	if name != nil {
+		return name.ConstraintKeyword.LeadingTrivia
+	}
+	return keyword.LeadingTrivia
+}
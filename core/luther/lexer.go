@@ -3,6 +3,7 @@ package luther
 import (
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"unicode"
 	"woodybriggs/justmigrate/core/tik"
@@ -19,15 +20,29 @@ type LexerData struct {
 	Row int
 }
 
+// Options switch on the parts of the lexer which differ between dialects,
+// the zero value lexes sqlite.
+type Options struct {
+	// SquareBrackets lexes [ and ] as punctuation, e.g. for postgres arrays,
+	// rather than as quotes around an identifier.
+	SquareBrackets bool
+	// DollarQuotes lexes $tag$ ... $tag$ as a string literal.
+	DollarQuotes bool
+	// FoldIdentifiers lower cases identifiers which are not quoted.
+	FoldIdentifiers bool
+}
+
 type Lexer struct {
 	SourceCode
 	LexerData
+	Options
 }
 
 func (l *Lexer) Clone() *Lexer {
 	return &Lexer{
 		SourceCode: l.SourceCode,
 		LexerData:  l.LexerData,
+		Options:    l.Options,
 	}
 }
 
@@ -192,6 +207,70 @@ TriviaLoop:
 	return string(t.SourceCode.Raw[start:end])
 }
 
+// quoted consumes a quoted run of text starting at the opening quote and
+// returns the text between the quotes. A doubled quote, or one preceded by a
+// backslash, does not terminate the run and is kept verbatim in the result.
+func (t *Lexer) quoted(quote rune) string {
+	// eat the opening quote
+	t.eat()
+	start := t.Cur
+	prev := rune(0)
+	for !t.Eof() {
+		if t.currentRune() == quote && prev != '\\' {
+			if p, err := t.peekRune(); err != io.EOF && p == quote {
+				t.eat()
+				prev = t.eat()
+				continue
+			}
+			break
+		}
+		prev = t.eat()
+	}
+	end := t.Cur
+	// eat the closing quote
+	if !t.Eof() {
+		t.eat()
+	}
+	return string(t.Raw[start:end])
+}
+
+// dollarQuoted consumes a dollar quoted string starting at the opening tag and
+// returns the text between the tags, ok is false when the $ does not start a
+// tag, e.g. a positional parameter.
+func (t *Lexer) dollarQuoted() (string, bool) {
+	end := t.Cur + 1
+	for end < len(t.Raw) && t.Raw[end] != '$' {
+		if !(unicode.IsLetter(t.Raw[end]) || t.Raw[end] == '_' || (end > t.Cur+1 && unicode.IsDigit(t.Raw[end]))) {
+			return "", false
+		}
+		end++
+	}
+	if end == len(t.Raw) {
+		return "", false
+	}
+
+	tag := t.Raw[t.Cur : end+1]
+	for range tag {
+		t.eat()
+	}
+
+	start := t.Cur
+	for !t.Eof() {
+		if t.currentRune() == '$' && slices.Equal(t.Raw[t.Cur:min(t.Cur+len(tag), len(t.Raw))], tag) {
+			text := string(t.Raw[start:t.Cur])
+			for range tag {
+				t.eat()
+			}
+			return text, true
+		}
+		if t.eat() == '\n' {
+			t.Bol = t.Cur
+			t.Row += 1
+		}
+	}
+	return string(t.Raw[start:t.Cur]), true
+}
+
 func isIdentifierStart(r rune) bool {
 	return unicode.IsLetter(r) || r == '_'
 }
@@ -218,9 +297,12 @@ func (t *Lexer) decimalNumeric() string {
 			continue
 		}
 
-		if !hasExpon && t.currentRune() == 'e' {
+		if !hasExpon && unicode.ToLower(t.currentRune()) == 'e' {
 			t.eat()
 			hasExpon = true
+			if !t.Eof() && (t.currentRune() == '+' || t.currentRune() == '-') {
+				t.eat()
+			}
 			continue
 		}
 
@@ -231,7 +313,7 @@ func (t *Lexer) decimalNumeric() string {
 		t.eat()
 	}
 
-	if t.currentRune() == 'f' {
+	if !t.Eof() && t.currentRune() == 'f' {
 		t.eat()
 	}
 
@@ -314,7 +396,7 @@ func (t *Lexer) NextToken() (token tik.Token) {
 
 	token.SourceRange.Start = t.Cur
 	switch t.currentRune() {
-	case ';', ',', '(', ')', '=', '-', '*', '/':
+	case ';', ',', '(', ')', '-', '+', '*', '/', '%', '~', '&', '@':
 		{
 			r := t.currentRune()
 			t.eat()
@@ -322,10 +404,34 @@ func (t *Lexer) NextToken() (token tik.Token) {
 			token.Text = string(r)
 			return token
 		}
+	case '=':
+		{
+			t.eat()
+			token.Kind = tik.TokenKind_Equal
+			token.Text = "="
+			if !t.Eof() && t.currentRune() == '=' {
+				t.eat()
+				token.Text = "=="
+			}
+			return token
+		}
+	case '|':
+		{
+			t.eat()
+			if !t.Eof() && t.currentRune() == '|' {
+				t.eat()
+				token.Kind = tik.TokenKind_Concat
+				token.Text = "||"
+				return token
+			}
+			token.Kind = tik.TokenKind_Pipe
+			token.Text = "|"
+			return token
+		}
 	case '!':
 		{
 			t.eat()
-			if t.currentRune() == '=' {
+			if !t.Eof() && t.currentRune() == '=' {
 				t.eat()
 				token.Kind = tik.TokenKind_neq
 				token.Text = "!="
@@ -338,12 +444,18 @@ func (t *Lexer) NextToken() (token tik.Token) {
 	case '>':
 		{
 			t.eat()
-			if t.currentRune() == '=' {
+			if !t.Eof() && t.currentRune() == '=' {
 				t.eat()
 				token.Kind = tik.TokenKind_gte
 				token.Text = ">="
 				return token
 			}
+			if !t.Eof() && t.currentRune() == '>' {
+				t.eat()
+				token.Kind = tik.TokenKind_RShift
+				token.Text = ">>"
+				return token
+			}
 			token.Kind = tik.TokenKind_gt
 			token.Text = ">"
 			return token
@@ -351,10 +463,23 @@ func (t *Lexer) NextToken() (token tik.Token) {
 	case '<':
 		{
 			t.eat()
-			if t.currentRune() == '=' {
+			if !t.Eof() && t.currentRune() == '=' {
 				t.eat()
 				token.Kind = tik.TokenKind_lte
 				token.Text = "<="
+				return token
+			}
+			if !t.Eof() && t.currentRune() == '>' {
+				t.eat()
+				token.Kind = tik.TokenKind_neq
+				token.Text = "<>"
+				return token
+			}
+			if !t.Eof() && t.currentRune() == '<' {
+				t.eat()
+				token.Kind = tik.TokenKind_LShift
+				token.Text = "<<"
+				return token
 			}
 			token.Kind = tik.TokenKind_lt
 			token.Text = "<"
@@ -362,24 +487,24 @@ func (t *Lexer) NextToken() (token tik.Token) {
 		}
 	case '"':
 		{
-			// eat the first "
-			t.eat()
-			start := t.Cur
-			prev := rune(0)
-			for !t.Eof() {
-				if t.currentRune() == '"' && prev != '\\' {
-					break
-				}
-				prev = t.eat()
-			}
-			end := t.Cur
-			// eat the last "
-			t.eat()
 			token.Kind = tik.TokenKind_Identifier
-			token.Text = string(t.Raw[start:end])
+			token.Text = t.quoted('"')
+			return token
+		}
+	case ']':
+		if t.SquareBrackets {
+			t.eat()
+			token.Kind = tik.TokenKind_RBracket
+			token.Text = "]"
 			return token
 		}
 	case '[':
+		if t.SquareBrackets {
+			t.eat()
+			token.Kind = tik.TokenKind_LBracket
+			token.Text = "["
+			return token
+		}
 		{
 			// eat the first [
 			t.eat()
@@ -391,48 +516,42 @@ func (t *Lexer) NextToken() (token tik.Token) {
 				t.eat()
 			}
 			end := t.Cur
-			// eat the last ]
-			t.eat()
+			// eat the last ], which an unterminated name does not have.
+			if !t.Eof() {
+				t.eat()
+			}
 			token.Kind = tik.TokenKind_Identifier
 			token.Text = string(t.Raw[start:end])
 			return token
 		}
 	case '`':
 		{
-			// eat the first `
-			t.eat()
-			start := t.Cur
-			prev := rune(0)
-			for !t.Eof() {
-				if t.currentRune() == '`' && prev != '\\' {
-					break
-				}
-				prev = t.eat()
-			}
-			end := t.Cur
-			// eat the last `
-			t.eat()
 			token.Kind = tik.TokenKind_Identifier
-			token.Text = string(t.Raw[start:end])
+			token.Text = t.quoted('`')
 			return token
 		}
+	case ':':
+		{
+			if p, err := t.peekRune(); err != io.EOF && p == ':' {
+				t.eat()
+				t.eat()
+				token.Kind = tik.TokenKind_DoubleColon
+				token.Text = "::"
+				return token
+			}
+		}
+	case '$':
+		if t.DollarQuotes {
+			if text, ok := t.dollarQuoted(); ok {
+				token.Kind = tik.TokenKind_StringLiteral
+				token.Text = text
+				return token
+			}
+		}
 	case '\'':
 		{
-			// eat the first '
-			t.eat()
 			token.Kind = tik.TokenKind_StringLiteral
-			start := t.Cur
-			prev := rune(0)
-			for !t.Eof() {
-				if t.currentRune() == '\'' && prev != '\\' {
-					break
-				}
-				prev = t.eat()
-			}
-			// eat the last '
-			t.eat()
-			end := t.Cur
-			token.Text = string(t.Raw[start:end])
+			token.Text = t.quoted('\'')
 			return token
 		}
 	case '.':
@@ -449,7 +568,9 @@ func (t *Lexer) NextToken() (token tik.Token) {
 		}
 	case '0':
 		{
-			switch p, err := t.peekRune(); err != io.EOF {
+			switch p, err := t.peekRune(); {
+			case err == io.EOF:
+				break
 			case unicode.ToLower(p) == 'x':
 				{
 					token.Kind = tik.TokenKind_HexNumericLiteral
@@ -485,8 +606,13 @@ func (t *Lexer) NextToken() (token tik.Token) {
 		if kind, ok := tik.KeywordIndex.GetValue(strings.ToLower(token.Text)); ok {
 			token.Kind = kind
 		}
+		if t.FoldIdentifiers && token.Kind == tik.TokenKind_Identifier {
+			token.Text = strings.ToLower(token.Text)
+		}
 		return token
 	}
 
+	token.Kind = tik.TokenKind_Error
+	token.Text = string(t.eat())
 	return token
 }
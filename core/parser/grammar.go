@@ -0,0 +1,1025 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+var ErrNotImplemented = errors.New("not implemented")
+
+// Dialect is the part of the grammar a database writes its own way. The
+// productions of Grammar reach these through the dialect rather than calling
+// them directly, so that a dialect which overrides one is the one used.
+type Dialect interface {
+	PrattParser
+	Identifier() ast.Identifier
+	CatalogObjectIdentifier() *ast.CatalogObjectIdentifier
+	Expr(minBindingPower int) ast.Expr
+	TypeName() ast.TypeName
+	StringLiteral() *ast.LiteralString
+	IndexedColumn(allowExpressions bool) ast.IndexedColumn
+	IsTableConstraintStart(token tik.Token) bool
+	ColumnConstraint() ast.ColumnConstraint
+	TableConstraint() ast.TableConstraint
+	Statement() ast.Statement
+}
+
+// Grammar is the sql shared between the dialects, from the statement loop
+// down to the constraints of a column and the terms of an expression. The
+// parser of a dialect embeds it and overrides a production by declaring a
+// method of the same name.
+type Grammar struct {
+	*Parser
+	dialect Dialect
+}
+
+func NewGrammar(lexer *luther.Lexer, dialect Dialect) *Grammar {
+	return &Grammar{
+		Parser:  NewParser(lexer),
+		dialect: dialect,
+	}
+}
+
+// IsWord reports whether the current token is the unquoted word, the
+// keywords only some dialects use, e.g. schema or engine, are lexed as
+// identifiers.
+func (p *Grammar) IsWord(word string) bool {
+	return IsWord(p.Current(), word)
+}
+
+func IsWord(token tik.Token, word string) bool {
+	return token.Kind == tik.TokenKind_Identifier && strings.EqualFold(token.SourceText(), word)
+}
+
+func (p *Grammar) ExpectWord(word string) ast.Keyword {
+	if !p.IsWord(word) {
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   fmt.Sprintf("expected '%s' got '%s'", word, p.Current().DebugString()),
+					},
+				}),
+		)
+	}
+	// the word is a keyword here, so it is written the way it was rather
+	// than folded like a name.
+	token := p.Current()
+	token.Text = token.SourceText()
+	p.Advance()
+	return ast.Keyword(token)
+}
+
+func (p *Grammar) Statements() []ast.Statement {
+	statements := []ast.Statement{}
+
+	for !p.EndOfFile() {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					if err, isErr := r.(error); isErr && errors.Is(err, ErrNotImplemented) {
+						debug.PrintStack()
+						os.Exit(2)
+					}
+					p.Synchronize([]tik.TokenKind{';'})
+				}
+			}()
+
+			statement := p.dialect.Statement()
+			if statement != nil {
+				statements = append(statements, statement)
+			}
+
+			// if this fails/panics, the defer block above handles it too.
+			p.Separator(';')
+		}()
+	}
+
+	return statements
+}
+
+func (p *Grammar) BeginStatement() ast.Statement {
+	p.PushParseContext("begin statement")
+	defer p.PopParseContext()
+
+	beginKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_BEGIN))
+
+	var transactionKeyword *ast.Keyword = nil
+	if token, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_TRANSACTION); ok {
+		transactionKeyword = ast.MakeKeyword(token)
+	}
+
+	return &ast.BeginTransaction{
+		BeginKeyword:       beginKeyword,
+		TransactionKeyword: transactionKeyword,
+	}
+}
+
+func (p *Grammar) CommitStatement() ast.Statement {
+	p.PushParseContext("commit statement")
+	defer p.PopParseContext()
+
+	commitKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_COMMIT))
+
+	var transactionKeyword *ast.Keyword = nil
+	if token, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_TRANSACTION); ok {
+		transactionKeyword = ast.MakeKeyword(token)
+	}
+
+	return &ast.CommitTransaction{
+		CommitKeyword:      commitKeyword,
+		TransactionKeyword: transactionKeyword,
+	}
+}
+
+func (p *Grammar) MaybeIfNotExists() *ast.IfNotExists {
+	if p.Current().Kind != tik.TokenKind_Keyword_IF {
+		return nil
+	}
+	ifKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_IF))
+	notKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_NOT))
+	existsKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_EXISTS))
+
+	return ast.MakeIfNotExists(ifKeyword, notKeyword, existsKeyword)
+}
+
+func (p *Grammar) TableConstraints() []ast.TableConstraint {
+	p.PushParseContext("table constraints")
+	defer p.PopParseContext()
+
+	result := []ast.TableConstraint{}
+
+	for !p.EndOfFile() {
+		if p.Current().Kind == ')' {
+			break
+		} else if p.Current().Kind == ',' {
+			p.Separator(',')
+			continue
+		} else {
+			tableConstraint := p.dialect.TableConstraint()
+			result = append(result, tableConstraint)
+		}
+	}
+
+	return result
+}
+
+func (p *Grammar) TableConstraint_PrimaryKey(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("primary key table constraint")
+	defer p.PopParseContext()
+
+	primaryKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_PRIMARY))
+	keyKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_KEY))
+
+	lParen, indexedCols, rParen := p.IndexedColumns(false)
+
+	return ast.MakeTableConstraintPrimaryKey(
+		constraintName,
+		primaryKeyword,
+		keyKeyword,
+		lParen,
+		indexedCols,
+		rParen,
+		nil,
+		nil,
+	)
+}
+
+func (p *Grammar) TableConstraint_Unique(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("unique table constraint")
+	defer p.PopParseContext()
+
+	uniqueKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_UNIQUE))
+
+	lParen, indexedCols, rParen := p.IndexedColumns(false)
+
+	return ast.MakeTableConstraintUnique(
+		constraintName,
+		uniqueKeyword,
+		lParen,
+		indexedCols,
+		rParen,
+		nil,
+	)
+}
+
+// IndexedColumns parses the parenthesised columns of an index or of a
+// primary key or unique constraint.
+func (p *Grammar) IndexedColumns(allowExpressions bool) (tik.Token, []ast.IndexedColumn, tik.Token) {
+	lParen := p.Expect('(')
+
+	indexedCols := []ast.IndexedColumn{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ')' {
+			break
+		} else if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else {
+			indexedCols = append(indexedCols, p.dialect.IndexedColumn(allowExpressions))
+		}
+	}
+
+	rParen := p.Expect(')')
+
+	return lParen, indexedCols, rParen
+}
+
+func (p *Grammar) IndexedColumn(allowExpressions bool) ast.IndexedColumn {
+	p.PushParseContext("indexed column")
+	defer p.PopParseContext()
+
+	var expr ast.Expr = nil
+	if allowExpressions {
+		expr = Unparen(p.dialect.Expr(0))
+	} else {
+		tmp := p.dialect.Identifier()
+		expr = &tmp
+	}
+
+	collation := p.MaybeCollation()
+
+	order := p.MaybeOrderKeyword()
+
+	return ast.IndexedColumn{
+		Subject:   expr,
+		Collation: collation,
+		Order:     order,
+	}
+}
+
+func (p *Grammar) TableConstraint_ForeignKey(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("foreign key table constraint")
+	defer p.PopParseContext()
+
+	foreign := ast.Keyword(p.Expect(tik.TokenKind_Keyword_FOREIGN))
+	key := ast.Keyword(p.Expect(tik.TokenKind_Keyword_KEY))
+
+	lParen := p.Expect('(')
+
+	columnNames := []ast.Identifier{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else if p.Current().Kind == ')' {
+			break
+		} else {
+			columnName := p.dialect.Identifier()
+			columnNames = append(columnNames, columnName)
+		}
+	}
+
+	rParen := p.Expect(')')
+
+	fkClause := p.ForeignKeyClause()
+	return ast.MakeTableConstraintForeignKey(
+		constraintName,
+		foreign,
+		key,
+		lParen,
+		columnNames,
+		rParen,
+		fkClause,
+	)
+}
+
+func (p *Grammar) ForeignKeyClause() *ast.ForeignKeyClause {
+	p.PushParseContext("foreign key clause")
+	defer p.PopParseContext()
+
+	referencesKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_REFERENCES))
+
+	foreignTable := p.dialect.CatalogObjectIdentifier()
+
+	// the referenced columns may be omitted, in which case the primary key of
+	// the foreign table is used.
+	var lParen, rParen tik.Token
+	columns := []ast.Identifier{}
+
+	if p.Current().Kind == '(' {
+		lParen = p.Expect('(')
+
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				column := p.dialect.Identifier()
+				columns = append(columns, column)
+			}
+		}
+
+		rParen = p.Expect(')')
+	}
+
+	var deferrable *ast.ForeignKeyDeferrable = nil
+	var matchName *ast.Identifier = nil
+	actions := []ast.ForeignKeyAction{}
+
+	for !p.EndOfFile() {
+		if p.Current().Kind == tik.TokenKind_Keyword_ON {
+			action := p.ForeignKeyAction()
+			actions = append(actions, action)
+		} else if p.Current().Kind == tik.TokenKind_Keyword_MATCH {
+			p.Advance()
+			ident := p.dialect.Identifier()
+			matchName = &ident
+		} else if p.Current().Kind == tik.TokenKind_Keyword_NOT && p.Peeked().Kind == tik.TokenKind_Keyword_DEFERRABLE {
+			// any other NOT is the column constraint after the clause,
+			// `REFERENCES t (id) NOT NULL`.
+			deferrable = p.ForeignKeyDeferrable()
+		} else if p.Current().Kind == tik.TokenKind_Keyword_DEFERRABLE {
+			deferrable = p.ForeignKeyDeferrable()
+		} else {
+			break
+		}
+	}
+
+	return ast.MakeForeignKeyClause(
+		referencesKeyword,
+		*foreignTable,
+		lParen,
+		columns,
+		rParen,
+		actions,
+		matchName,
+		deferrable,
+	)
+}
+
+func (p *Grammar) ForeignKeyAction() ast.ForeignKeyAction {
+	onKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ON))
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_UPDATE:
+		updateKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_UPDATE))
+		do := p.ForeignKeyActionDo()
+		return ast.MakeForeignKeyUpdateAction(
+			onKeyword,
+			updateKeyword,
+			do,
+		)
+	case tik.TokenKind_Keyword_DELETE:
+		deleteKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_DELETE))
+		do := p.ForeignKeyActionDo()
+		return ast.MakeForeignKeyDeleteAction(
+			onKeyword,
+			deleteKeyword,
+			do,
+		)
+	default:
+		p.ReportError(
+			report.NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   "here",
+					},
+				}).
+				WithMessage("unexpected token when parsing foreign key action"),
+		)
+		return nil
+	}
+}
+
+func (p *Grammar) ForeignKeyActionDo() ast.ForeignKeyActionDo {
+	if p.Current().Kind == tik.TokenKind_Keyword_SET {
+		setKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_SET))
+		if p.Current().Kind == tik.TokenKind_Keyword_NULL {
+			nullKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_NULL))
+			return ast.MakeForeignKeyActionSetNull(setKeyword, nullKeyword)
+		} else if p.Current().Kind == tik.TokenKind_Keyword_DEFAULT {
+			defaultKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_DEFAULT))
+			return ast.MakeForeignKeyActionSetDefault(setKeyword, defaultKeyword)
+		} else {
+			p.ReportError(
+				report.NewReport("parse error").
+					WithLabels([]report.Label{
+						{
+							Source: p.Current().SourceCode,
+							Range:  p.Current().SourceRange,
+							Note:   "here",
+						},
+					}).
+					WithMessage("expected 'null' or 'default' for set action of foreign key action"),
+			)
+			return nil
+		}
+	} else if p.Current().Kind == tik.TokenKind_Keyword_NO {
+		noKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_NO))
+		actionKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ACTION))
+		return ast.MakeForeignKeyActionNoAction(noKeyword, actionKeyword)
+	} else if p.Current().Kind == tik.TokenKind_Keyword_RESTRICT {
+		restrictKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_RESTRICT))
+		return ast.MakeForeignKeyActionRestrict(restrictKeyword)
+	} else if p.Current().Kind == tik.TokenKind_Keyword_CASCADE {
+		cascadeKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CASCADE))
+		return ast.MakeForeignKeyActionCascade(cascadeKeyword)
+	} else {
+		p.ReportError(
+			report.NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   "here",
+					},
+				}).
+				WithMessage("expected action: one of ('set null', 'set default', 'no action', 'restrict', 'cascade') for foreign key action"),
+		)
+		return nil
+	}
+}
+
+func (p *Grammar) ForeignKeyDeferrable() *ast.ForeignKeyDeferrable {
+	var notKeyword *ast.Keyword = nil
+	if p.Current().Kind == tik.TokenKind_Keyword_NOT {
+		notKeyword = ast.MakeKeyword(p.Current())
+		p.Advance()
+	}
+
+	deferrableKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_DEFERRABLE))
+
+	var initiallyKeyword *ast.Keyword = nil
+	var initiallyValue *ast.Keyword = nil
+	if p.Current().Kind == tik.TokenKind_Keyword_INITIALLY {
+		initiallyKeyword = ast.MakeKeyword(p.Current())
+		p.Advance()
+
+		switch p.Current().Kind {
+		case tik.TokenKind_Keyword_DEFERRED:
+			initiallyValue = ast.MakeKeyword(p.Current())
+			p.Advance()
+		case tik.TokenKind_Keyword_IMMEDIATE:
+			initiallyValue = ast.MakeKeyword(p.Current())
+			p.Advance()
+		default:
+			p.ReportError(
+				report.
+					NewReport("parse errors").
+					WithLabels([]report.Label{
+						{
+							Source: p.Current().SourceCode,
+							Range:  p.Current().SourceRange,
+							Note:   "here",
+						},
+					}).
+					WithMessage("expected value for 'deferrable initially' one of ('deferred' or 'immediate')"),
+			)
+			return nil
+		}
+	}
+
+	return ast.MakeForeignKeyDeferrable(
+		notKeyword,
+		deferrableKeyword,
+		initiallyKeyword,
+		initiallyValue,
+	)
+}
+
+func (p *Grammar) TableConstraint_Check(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("check table constraint")
+	defer p.PopParseContext()
+
+	checkKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CHECK))
+
+	lParen := p.Expect('(')
+
+	expr := Unparen(p.dialect.Expr(0))
+
+	rParen := p.Expect(')')
+
+	return ast.MakeTableConstraintCheck(
+		constraintName,
+		checkKeyword,
+		lParen,
+		expr,
+		rParen,
+	)
+}
+
+func (p *Grammar) ColumnDefinitions() []ast.ColumnDefinition {
+	p.PushParseContext("column definitions")
+	defer p.PopParseContext()
+
+	definitions := []ast.ColumnDefinition{}
+
+	for !p.EndOfFile() {
+		if p.Current().Kind == ',' {
+			p.Separator(',')
+			continue
+		} else if p.Current().Kind == ')' {
+			break
+		} else if p.dialect.IsTableConstraintStart(p.Current()) {
+			break
+		}
+
+		columnDef := p.ColumnDefinition()
+		definitions = append(definitions, *columnDef)
+	}
+
+	return definitions
+}
+
+func (p *Grammar) IsTableConstraintStart(token tik.Token) bool {
+	switch token.Kind {
+	case tik.TokenKind_Keyword_CONSTRAINT,
+		tik.TokenKind_Keyword_PRIMARY,
+		tik.TokenKind_Keyword_FOREIGN,
+		tik.TokenKind_Keyword_UNIQUE,
+		tik.TokenKind_Keyword_CHECK:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Grammar) ColumnDefinition() *ast.ColumnDefinition {
+	p.PushParseContext("column definition")
+	defer p.PopParseContext()
+
+	columnName := p.dialect.Identifier()
+	typeName := p.dialect.TypeName()
+	columnConstraints := p.ColumnConstraints()
+
+	return ast.MakeColumnDefinition(
+		columnName,
+		typeName,
+		columnConstraints,
+	)
+}
+
+// ColumnConstraints parses the constraints of a column up to the end of its
+// definition. A dialect's ColumnConstraint returns nil for an attribute of
+// the column which is not kept.
+func (p *Grammar) ColumnConstraints() []ast.ColumnConstraint {
+	p.PushParseContext("column constraints")
+	defer p.PopParseContext()
+
+	result := []ast.ColumnConstraint{}
+
+	for p.Current().Kind != ',' && p.Current().Kind != ')' && p.Current().Kind != ';' && !p.EndOfFile() {
+		// NULL says the column is nullable, which it is anyway.
+		if p.Current().Kind == tik.TokenKind_Keyword_NULL {
+			p.Advance()
+			continue
+		}
+		columnConstraint := p.dialect.ColumnConstraint()
+		if columnConstraint != nil {
+			result = append(result, columnConstraint)
+		}
+	}
+
+	return result
+}
+
+func (p *Grammar) ColumnConstraint_PrimaryKey(constraintName *ast.ConstraintName) *ast.ColumnConstraint_PrimaryKey {
+	p.PushParseContext("primary key column constraint")
+	defer p.PopParseContext()
+
+	primaryKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_PRIMARY))
+	keyKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_KEY))
+
+	return ast.MakeColumnConstraintPrimaryKey(
+		constraintName,
+		primaryKeyword,
+		keyKeyword,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+func (p *Grammar) ColumnConstraint_NotNull(constraintName *ast.ConstraintName) *ast.ColumnConstraint_NotNull {
+	p.PushParseContext("not null column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_NOT)
+	p.Expect(tik.TokenKind_Keyword_NULL)
+
+	return ast.MakeColumnConstraintNotNull(
+		constraintName,
+		nil,
+	)
+}
+
+func (p *Grammar) ColumnConstraint_Default(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Default {
+	p.PushParseContext("default column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_DEFAULT)
+
+	defaultExpr := Unparen(p.dialect.Expr(0))
+
+	return ast.MakeColumnConstraintDefault(
+		constraintName,
+		defaultExpr,
+	)
+}
+
+func (p *Grammar) ColumnConstraint_Unique(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Unique {
+	p.PushParseContext("unique column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_UNIQUE)
+
+	return ast.MakeColumnConstraintUnique(
+		constraintName,
+		nil,
+	)
+}
+
+func (p *Grammar) ColumnConstraint_Collate(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Collate {
+	p.PushParseContext("collate column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_COLLATE)
+
+	collationName := p.dialect.Identifier()
+
+	return ast.MakeColumnConstraintCollate(
+		constraintName,
+		collationName,
+	)
+}
+
+func (p *Grammar) ColumnConstraint_Check(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Check {
+	p.PushParseContext("check column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_CHECK)
+	p.Expect('(')
+
+	expr := Unparen(p.dialect.Expr(0))
+
+	p.Expect(')')
+
+	return ast.MakeColumnConstraintCheck(
+		constraintName,
+		expr,
+	)
+}
+
+func (p *Grammar) ColumnConstraint_ForeignKey(constraintName *ast.ConstraintName) *ast.ColumnConstraint_ForeignKey {
+	p.PushParseContext("foreign key column constraint")
+	defer p.PopParseContext()
+
+	fkClause := p.ForeignKeyClause()
+
+	return ast.MakeColumnConstraintForeignKey(
+		constraintName,
+		fkClause,
+	)
+}
+
+// ColumnConstraint_Generated parses a computed column, `GENERATED ALWAYS AS
+// (expr)` or just `AS (expr)`, which is VIRTUAL unless it is STORED.
+func (p *Grammar) ColumnConstraint_Generated(constraintName *ast.ConstraintName) ast.ColumnConstraint {
+	p.PushParseContext("generated column constraint")
+	defer p.PopParseContext()
+
+	if _, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_GENERATED); ok {
+		p.Expect(tik.TokenKind_Keyword_ALWAYS)
+	}
+
+	p.Expect(tik.TokenKind_Keyword_AS)
+
+	p.Expect('(')
+	expr := Unparen(p.dialect.Expr(0))
+	p.Expect(')')
+
+	var storage *ast.Keyword = nil
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_VIRTUAL, tik.TokenKind_Keyword_STORED:
+		storage = ast.MakeKeyword(p.Current())
+		p.Advance()
+	}
+
+	return ast.MakeColumnConstraintGenerated(
+		constraintName,
+		expr,
+		storage,
+	)
+}
+
+func (p *Grammar) MaybeConstraintName() *ast.ConstraintName {
+	p.PushParseContext("constraint name")
+	defer p.PopParseContext()
+
+	if p.Current().Kind != tik.TokenKind_Keyword_CONSTRAINT {
+		return nil
+	}
+	constraintKeyword := ast.Keyword(p.Current())
+	p.Advance()
+
+	name := p.dialect.Identifier()
+
+	return &ast.ConstraintName{
+		ConstraintKeyword: constraintKeyword,
+		Name:              name,
+	}
+}
+
+func (p *Grammar) MaybeOrderKeyword() *ast.Keyword {
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_ASC:
+		fallthrough
+	case tik.TokenKind_Keyword_DESC:
+		order := ast.MakeKeyword(p.Current())
+		p.Advance()
+		return order
+	default:
+		return nil
+	}
+}
+
+// Unparen removes the parentheses around a whole expression, which are
+// redundant inside of the parentheses of a check or index, and which
+// postgres and mysql add to the expressions they print back out.
+func Unparen(expr ast.Expr) ast.Expr {
+	for {
+		parens, ok := expr.(*ast.Parens)
+		if !ok {
+			return expr
+		}
+		expr = parens.Expr
+	}
+}
+
+func (p *Grammar) Expr(minBindingPower int) ast.Expr {
+	return p.Parser.Expr(minBindingPower, p.dialect)
+}
+
+// prefixBindingPower is the binding power of the unary operators, NOT binds
+// looser than the comparison operators so `NOT a = b` is `NOT (a = b)`.
+func prefixBindingPower(token tik.Token) int {
+	switch token.Kind {
+	case tik.TokenKind_Keyword_NOT:
+		return 35
+	default:
+		return 130
+	}
+}
+
+func (p *Grammar) Term() ast.Expr {
+	p.PushParseContext("expression term")
+	defer p.PopParseContext()
+
+	switch token := p.Current(); token.Kind {
+	case tik.TokenKind_StringLiteral:
+		return p.dialect.StringLiteral()
+	case tik.TokenKind_DecimalNumericLiteral,
+		tik.TokenKind_HexNumericLiteral,
+		tik.TokenKind_BinaryNumericLiteral,
+		tik.TokenKind_OctalNumericLiteral:
+		return p.NumericLiteral()
+	case tik.TokenKind_Keyword_NULL:
+		p.Advance()
+		return &ast.LiteralNull{Token: token}
+	case tik.TokenKind_Keyword_TRUE, tik.TokenKind_Keyword_FALSE:
+		p.Advance()
+		return &ast.LiteralBoolean{
+			Token: token,
+			Value: token.Kind == tik.TokenKind_Keyword_TRUE,
+		}
+	case '-', '+', '~', tik.TokenKind_Keyword_NOT:
+		p.Advance()
+		rhs := p.dialect.Expr(prefixBindingPower(token))
+		return ast.MakeUnaryOpExpr(token, rhs)
+	case '(':
+		lParen := p.Expect('(')
+		expr := p.dialect.Expr(0)
+		if p.Current().Kind == ',' {
+			list := ast.ExprList{expr}
+			for p.Current().Kind == ',' {
+				p.Advance()
+				list = append(list, p.dialect.Expr(0))
+			}
+			p.Expect(')')
+			return list
+		}
+		rParen := p.Expect(')')
+		return ast.MakeParensExpr(lParen, expr, rParen)
+	case tik.TokenKind_Keyword_CASE:
+		return p.CaseExpr()
+	case tik.TokenKind_Keyword_CAST:
+		// cast is the name of a column as well as a function in the
+		// dialects which do not reserve it.
+		if p.Peeked().Kind == '(' {
+			return p.CastExpr()
+		}
+		return p.IdentifierTerm()
+	case tik.TokenKind_Identifier:
+		return p.IdentifierTerm()
+	default:
+		if p.IsFallbackKeyword() {
+			return p.IdentifierTerm()
+		}
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   "expected expression",
+					},
+				}),
+		)
+		return nil
+	}
+}
+
+func (p *Grammar) CastExpr() ast.Expr {
+	p.PushParseContext("cast expression")
+	defer p.PopParseContext()
+
+	castKeyword := ast.MakeKeyword(p.Expect(tik.TokenKind_Keyword_CAST))
+	p.Expect('(')
+	expr := p.dialect.Expr(0)
+	p.Expect(tik.TokenKind_Keyword_AS)
+	typeName := p.dialect.TypeName()
+	p.Expect(')')
+
+	return &ast.Cast{
+		CastKeyword: castKeyword,
+		Expr:        expr,
+		Type:        typeName,
+	}
+}
+
+// IdentifierTerm parses an expression beginning with a name, a plain column
+// reference, a qualified column reference, or a function call.
+func (p *Grammar) IdentifierTerm() ast.Expr {
+	ident := p.dialect.Identifier()
+
+	switch p.Current().Kind {
+	case '(':
+		p.Advance()
+		args := ast.ExprList{}
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				args = append(args, p.dialect.Expr(0))
+			}
+		}
+		p.Expect(')')
+		return &ast.FunctionCall{
+			Name: ident,
+			Args: args,
+		}
+	case '.':
+		p.Advance()
+		tableOrColumn := p.dialect.Identifier()
+		if p.Current().Kind != '.' {
+			return &ast.ColumnName{
+				Schema: nil,
+				Table:  &ident,
+				Column: tableOrColumn,
+			}
+		}
+		p.Advance()
+		column := p.dialect.Identifier()
+		return &ast.ColumnName{
+			Schema: &ident,
+			Table:  &tableOrColumn,
+			Column: column,
+		}
+	default:
+		return &ident
+	}
+}
+
+func (p *Grammar) CaseExpr() ast.Expr {
+	p.PushParseContext("case expression")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_CASE)
+
+	var operand ast.Expr = nil
+	if p.Current().Kind != tik.TokenKind_Keyword_WHEN {
+		operand = p.dialect.Expr(0)
+	}
+
+	cases := []ast.WhenThen{}
+	for p.Current().Kind == tik.TokenKind_Keyword_WHEN {
+		p.Advance()
+		when := p.dialect.Expr(0)
+		p.Expect(tik.TokenKind_Keyword_THEN)
+		then := p.dialect.Expr(0)
+		cases = append(cases, ast.WhenThen{
+			When: when,
+			Then: then,
+		})
+	}
+
+	var elseExpr ast.Expr = nil
+	if p.Current().Kind == tik.TokenKind_Keyword_ELSE {
+		p.Advance()
+		elseExpr = p.dialect.Expr(0)
+	}
+
+	p.Expect(tik.TokenKind_Keyword_END)
+
+	return &ast.CaseExpression{
+		Operand: operand,
+		Cases:   cases,
+		Else:    elseExpr,
+	}
+}
+
+func (p *Grammar) StringLiteral() *ast.LiteralString {
+	token := p.Expect(tik.TokenKind_StringLiteral)
+	return &ast.LiteralString{
+		Token: token,
+		Value: strings.ReplaceAll(token.Text, "''", "'"),
+	}
+}
+
+func (p *Grammar) NumericLiteral() ast.Expr {
+	token := p.Current()
+	p.Advance()
+
+	if token.Kind == tik.TokenKind_DecimalNumericLiteral && strings.ContainsAny(token.Text, ".eE") {
+		value, err := strconv.ParseFloat(token.Text, 64)
+		if err != nil {
+			p.ReportError(
+				report.
+					NewReport("parse error").
+					WithLabels([]report.Label{
+						{
+							Source: token.SourceCode,
+							Range:  token.SourceRange,
+							Note:   "unable to parse numeric literal as a float",
+						},
+					}),
+			)
+		}
+		return &ast.LiteralFloat{
+			Token: token,
+			Value: value,
+		}
+	}
+
+	literal, err := ast.TokenToLiteralInteger(token)
+	if err != nil {
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: token.SourceCode,
+						Range:  token.SourceRange,
+						Note:   "unable to parse numeric literal as an integer",
+					},
+				}),
+		)
+	}
+	return &literal
+}
+
+func (p *Grammar) OperatorBindingPower(token tik.Token) (bp ast.BindingPower, found bool) {
+	switch token.Kind {
+	case tik.TokenKind_Keyword_OR:
+		return ast.BindingPower{L: 10, R: 11}, true
+	case tik.TokenKind_Keyword_AND:
+		return ast.BindingPower{L: 20, R: 21}, true
+	case '=', tik.TokenKind_neq,
+		tik.TokenKind_Keyword_IS,
+		tik.TokenKind_Keyword_IN,
+		tik.TokenKind_Keyword_LIKE:
+		return ast.BindingPower{L: 40, R: 41}, true
+	case '<', '>', tik.TokenKind_lte, tik.TokenKind_gte:
+		return ast.BindingPower{L: 50, R: 51}, true
+	case '&', '|', tik.TokenKind_LShift, tik.TokenKind_RShift:
+		return ast.BindingPower{L: 60, R: 61}, true
+	case '+', '-':
+		return ast.BindingPower{L: 70, R: 71}, true
+	case '*', '/', '%':
+		return ast.BindingPower{L: 80, R: 81}, true
+	case tik.TokenKind_Concat:
+		return ast.BindingPower{L: 90, R: 91}, true
+	default:
+		return ast.BindingPower{}, false
+	}
+}
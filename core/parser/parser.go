@@ -19,9 +19,10 @@ type ParseContext struct {
 }
 
 type Parser struct {
-	lexer        *luther.Lexer
-	currentToken tik.Token
-	peekedToken  tik.Token
+	lexer         *luther.Lexer
+	previousToken tik.Token
+	currentToken  tik.Token
+	peekedToken   tik.Token
 
 	errors   map[tik.TextRange]report.Report
 	warnings map[tik.TextRange]report.Report
@@ -46,10 +47,31 @@ func NewParser(lexer *luther.Lexer) *Parser {
 }
 
 func (p *Parser) Errors() []report.Report {
-	return slices.Collect(maps.Values(p.errors))
+	return reportsInSourceOrder(p.errors)
+}
+
+func (p *Parser) Warnings() []report.Report {
+	return reportsInSourceOrder(p.warnings)
+}
+
+func reportsInSourceOrder(reports map[tik.TextRange]report.Report) []report.Report {
+	ranges := slices.SortedFunc(maps.Keys(reports), func(a, b tik.TextRange) int {
+		return a.Start - b.Start
+	})
+
+	result := make([]report.Report, 0, len(ranges))
+	for _, textRange := range ranges {
+		result = append(result, reports[textRange])
+	}
+	return result
+}
+
+func (p *Parser) SourceCode() luther.SourceCode {
+	return p.lexer.SourceCode
 }
 
 func (p *Parser) Advance() {
+	p.previousToken = p.currentToken
 	p.currentToken = p.lexer.NextToken()
 	p.peekedToken = p.lexer.PeekToken()
 }
@@ -125,6 +147,25 @@ func (p *Parser) Expect(kind tik.TokenKind) tik.Token {
 	return p.currentToken
 }
 
+// Separator expects a token the ast does not keep, such as a `,` or `;`, and
+// hands its comments on to the next token so that they are not lost.
+func (p *Parser) Separator(kind tik.TokenKind) {
+	token := p.Expect(kind)
+	if token.SourceRange != p.currentToken.SourceRange {
+		p.currentToken.LeadingTrivia = token.LeadingTrivia + token.TrailingTrivia + p.currentToken.LeadingTrivia
+	}
+}
+
+// Closing expects the token which closes a list, such as the `)` of a table
+// definition, and gives it the comment on the same line as the end of the
+// last item, `name text -- the name`.
+func (p *Parser) Closing(kind tik.TokenKind) tik.Token {
+	trailing := p.previousToken.TrailingTrivia
+	token := p.Expect(kind)
+	token.LeadingTrivia = trailing + token.LeadingTrivia
+	return token
+}
+
 func (p *Parser) Current() tik.Token {
 	return p.currentToken
 }
@@ -133,6 +174,25 @@ func (p *Parser) Peeked() tik.Token {
 	return p.peekedToken
 }
 
+// Lookahead returns the token n places after the current token without
+// consuming anything, Lookahead(1) is equivalent to Peeked.
+func (p *Parser) Lookahead(n int) tik.Token {
+	lexer := p.lexer.Clone()
+	token := p.currentToken
+	for range n {
+		token = lexer.NextToken()
+	}
+	return token
+}
+
+func (p *Parser) MaybeTokenKind(kind tik.TokenKind) (tik.Token, bool) {
+	if token := p.currentToken; token.Kind == kind {
+		p.Advance()
+		return token, true
+	}
+	return tik.Token{}, false
+}
+
 func (p *Parser) ReportError(report *report.Report) {
 
 	if _, has := p.errors[p.currentToken.SourceRange]; has {
@@ -202,15 +262,31 @@ func (p *Parser) CatalogObjectIdentifier() *ast.CatalogObjectIdentifier {
 func (p *Parser) Identifier() ast.Identifier {
 	p.PushParseContext("identifier")
 	defer p.PopParseContext()
+
+	if p.IsFallbackKeyword() {
+		token := p.Current()
+		token.Kind = tik.TokenKind_Identifier
+		p.Advance()
+		return ast.Identifier(token)
+	}
+
 	return ast.Identifier(p.Expect(tik.TokenKind_Identifier))
 }
 
+// IsFallbackKeyword reports whether the current token is a keyword that can
+// stand in for an identifier.
+func (p *Parser) IsFallbackKeyword() bool {
+	_, ok := tik.FallbackKeywords[p.Current().Kind]
+	return ok
+}
+
 func (p *Parser) MaybeCollation() *ast.Collation {
 
 	if p.Current().Kind != tik.TokenKind_Keyword_COLLATE {
 		return nil
 	}
 	collateKeyword := ast.Keyword(p.Current())
+	p.Advance()
 	name := p.Identifier()
 
 	return ast.MakeCollation(
@@ -219,6 +295,40 @@ func (p *Parser) MaybeCollation() *ast.Collation {
 	)
 }
 
+// ParseFragment parses the whole of the source with production, for sql which
+// is not a statement, e.g. the conversion of a column. A production gives up
+// on an error it can not recover from by panicking with the report, which
+// Statements catches for a statement and which is returned here along with
+// every other error.
+func ParseFragment[T any](p *Parser, production func() T) (result T, errors []report.Report) {
+	defer func() {
+		switch r := recover().(type) {
+		case nil:
+		case report.Report, *report.Report:
+			var none T
+			result, errors = none, p.Errors()
+		default:
+			panic(r)
+		}
+	}()
+
+	result = production()
+	if !p.EndOfFile() {
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.currentToken.SourceCode,
+						Range:  p.currentToken.SourceRange,
+						Note:   fmt.Sprintf("unexpected '%s' after the end", p.currentToken.DebugString()),
+					},
+				}),
+		)
+	}
+	return result, p.Errors()
+}
+
 type PrattParser interface {
 	Term() ast.Expr
 	OperatorBindingPower(token tik.Token) (bp ast.BindingPower, found bool)
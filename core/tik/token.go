@@ -2,7 +2,6 @@ package tik
 
 import (
 	"fmt"
-	"os"
 )
 
 type TokenKind int
@@ -17,13 +16,27 @@ var TokenKindDebugString = map[TokenKind]string{
 	'=':                             "equal",
 	'+':                             "plus",
 	'-':                             "minus",
+	'*':                             "star",
+	'/':                             "slash",
+	'%':                             "percent",
+	'~':                             "tilde",
+	'&':                             "ampersand",
+	'|':                             "pipe",
 	'`':                             "backtic",
 	'>':                             "greater-than",
 	'<':                             "less-than",
 	'!':                             "not",
+	'@':                             "at",
+	'[':                             "l-bracket",
+	']':                             "r-bracket",
 	TokenKind_neq:                   "not-equal",
 	TokenKind_gte:                   "greater-than-equal",
 	TokenKind_lte:                   "less-than-equal",
+	TokenKind_Concat:                "concat",
+	TokenKind_LShift:                "left-shift",
+	TokenKind_RShift:                "right-shift",
+	TokenKind_DoubleColon:           "double-colon",
+	TokenKind_Error:                 "error",
 	TokenKind_Identifier:            "identifier",
 	TokenKind_DecimalNumericLiteral: "decimal-numeric-literal",
 	TokenKind_HexNumericLiteral:     "hex-numeric-literal",
@@ -59,10 +72,20 @@ const (
 	TokenKind_SemiColon TokenKind = ';'
 	TokenKind_Plus      TokenKind = '+'
 	TokenKind_Minus     TokenKind = '-'
+	TokenKind_Star      TokenKind = '*'
+	TokenKind_Slash     TokenKind = '/'
+	TokenKind_Percent   TokenKind = '%'
+	TokenKind_Tilde     TokenKind = '~'
+	TokenKind_At        TokenKind = '@'
+	TokenKind_Ampersand TokenKind = '&'
+	TokenKind_Pipe      TokenKind = '|'
+	TokenKind_Equal     TokenKind = '='
 	TokenKind_Backtic   TokenKind = '`'
 	TokenKind_gt        TokenKind = '>'
 	TokenKind_lt        TokenKind = '<'
 	TokenKind_not       TokenKind = '!'
+	TokenKind_LBracket  TokenKind = '['
+	TokenKind_RBracket  TokenKind = ']'
 )
 
 const (
@@ -78,6 +101,10 @@ const (
 	TokenKind_neq TokenKind = iota + 1 + TokenKindOffset_Misc
 	TokenKind_gte
 	TokenKind_lte
+	TokenKind_Concat
+	TokenKind_LShift
+	TokenKind_RShift
+	TokenKind_DoubleColon
 )
 
 const (
@@ -161,6 +188,26 @@ const (
 
 	TokenKind_Keyword_ADD
 	TokenKind_Keyword_DROP
+
+	TokenKind_Keyword_AND
+	TokenKind_Keyword_OR
+	TokenKind_Keyword_IS
+	TokenKind_Keyword_LIKE
+	TokenKind_Keyword_GLOB
+	TokenKind_Keyword_REGEXP
+	TokenKind_Keyword_CAST
+	TokenKind_Keyword_COLUMN
+	TokenKind_Keyword_RENAME
+	TokenKind_Keyword_TO
+
+	TokenKind_Keyword_BEFORE
+	TokenKind_Keyword_AFTER
+	TokenKind_Keyword_INSTEAD
+	TokenKind_Keyword_OF
+	TokenKind_Keyword_INSERT
+	TokenKind_Keyword_FOR
+	TokenKind_Keyword_EACH
+	TokenKind_Keyword_ROW
 )
 
 const (
@@ -235,6 +282,24 @@ const (
 	Keyword_USING         string = "using"
 	Keyword_WHERE         string = "where"
 	Keyword_SELECT        string = "select"
+	Keyword_AND           string = "and"
+	Keyword_OR            string = "or"
+	Keyword_IS            string = "is"
+	Keyword_LIKE          string = "like"
+	Keyword_GLOB          string = "glob"
+	Keyword_REGEXP        string = "regexp"
+	Keyword_CAST          string = "cast"
+	Keyword_COLUMN        string = "column"
+	Keyword_RENAME        string = "rename"
+	Keyword_TO            string = "to"
+	Keyword_BEFORE        string = "before"
+	Keyword_AFTER         string = "after"
+	Keyword_INSTEAD       string = "instead"
+	Keyword_OF            string = "of"
+	Keyword_INSERT        string = "insert"
+	Keyword_FOR           string = "for"
+	Keyword_EACH          string = "each"
+	Keyword_ROW           string = "row"
 )
 
 type MapIndex[TKey comparable, TVal comparable] struct {
@@ -333,7 +398,76 @@ var KeywordIndex = NewIndex[string, TokenKind]().
 	Add(Keyword_ELSE, TokenKind_Keyword_ELSE).
 	Add(Keyword_END, TokenKind_Keyword_END).
 	Add(Keyword_USING, TokenKind_Keyword_USING).
-	Add(Keyword_WHERE, TokenKind_Keyword_WHERE)
+	Add(Keyword_WHERE, TokenKind_Keyword_WHERE).
+	Add(Keyword_AND, TokenKind_Keyword_AND).
+	Add(Keyword_OR, TokenKind_Keyword_OR).
+	Add(Keyword_IS, TokenKind_Keyword_IS).
+	Add(Keyword_LIKE, TokenKind_Keyword_LIKE).
+	Add(Keyword_GLOB, TokenKind_Keyword_GLOB).
+	Add(Keyword_REGEXP, TokenKind_Keyword_REGEXP).
+	Add(Keyword_CAST, TokenKind_Keyword_CAST).
+	Add(Keyword_COLUMN, TokenKind_Keyword_COLUMN).
+	Add(Keyword_RENAME, TokenKind_Keyword_RENAME).
+	Add(Keyword_TO, TokenKind_Keyword_TO).
+	Add(Keyword_BEFORE, TokenKind_Keyword_BEFORE).
+	Add(Keyword_AFTER, TokenKind_Keyword_AFTER).
+	Add(Keyword_INSTEAD, TokenKind_Keyword_INSTEAD).
+	Add(Keyword_OF, TokenKind_Keyword_OF).
+	Add(Keyword_INSERT, TokenKind_Keyword_INSERT).
+	Add(Keyword_FOR, TokenKind_Keyword_FOR).
+	Add(Keyword_EACH, TokenKind_Keyword_EACH).
+	Add(Keyword_ROW, TokenKind_Keyword_ROW)
+
+// FallbackKeywords are keywords which sqlite will happily accept as an
+// identifier when they appear where a name is expected, e.g. a column named `key`.
+var FallbackKeywords = map[TokenKind]bool{
+	TokenKind_Keyword_ABORT:     true,
+	TokenKind_Keyword_ACTION:    true,
+	TokenKind_Keyword_AFTER:     true,
+	TokenKind_Keyword_ALWAYS:    true,
+	TokenKind_Keyword_ASC:       true,
+	TokenKind_Keyword_BEFORE:    true,
+	TokenKind_Keyword_BEGIN:     true,
+	TokenKind_Keyword_CASCADE:   true,
+	TokenKind_Keyword_CAST:      true,
+	TokenKind_Keyword_COLUMN:    true,
+	TokenKind_Keyword_CONFLICT:  true,
+	TokenKind_Keyword_DEFERRED:  true,
+	TokenKind_Keyword_DESC:      true,
+	TokenKind_Keyword_EACH:      true,
+	TokenKind_Keyword_END:       true,
+	TokenKind_Keyword_EXPLAIN:   true,
+	TokenKind_Keyword_FAIL:      true,
+	TokenKind_Keyword_FOR:       true,
+	TokenKind_Keyword_GENERATED: true,
+	TokenKind_Keyword_GLOB:      true,
+	TokenKind_Keyword_IGNORE:    true,
+	TokenKind_Keyword_IMMEDIATE: true,
+	TokenKind_Keyword_INITIALLY: true,
+	TokenKind_Keyword_INSTEAD:   true,
+	TokenKind_Keyword_KEY:       true,
+	TokenKind_Keyword_LIKE:      true,
+	TokenKind_Keyword_MATCH:     true,
+	TokenKind_Keyword_NO:        true,
+	TokenKind_Keyword_OF:        true,
+	TokenKind_Keyword_PLAN:      true,
+	TokenKind_Keyword_PRAMGA:    true,
+	TokenKind_Keyword_QUERY:     true,
+	TokenKind_Keyword_REGEXP:    true,
+	TokenKind_Keyword_RENAME:    true,
+	TokenKind_Keyword_REPLACE:   true,
+	TokenKind_Keyword_RESTRICT:  true,
+	TokenKind_Keyword_ROLLBACK:  true,
+	TokenKind_Keyword_ROW:       true,
+	TokenKind_Keyword_ROWID:     true,
+	TokenKind_Keyword_STORED:    true,
+	TokenKind_Keyword_STRICT:    true,
+	TokenKind_Keyword_TEMPORARY: true,
+	TokenKind_Keyword_TRIGGER:   true,
+	TokenKind_Keyword_VIEW:      true,
+	TokenKind_Keyword_VIRTUAL:   true,
+	TokenKind_Keyword_WITHOUT:   true,
+}
 
 var ConstaintKeywords = map[TokenKind]bool{
 	TokenKind_Keyword_CONSTRAINT: true,
@@ -377,6 +511,15 @@ func (t Token) String() string {
 	return fmt.Sprintf("%s%s%s", t.LeadingTrivia, t.Text, t.TrailingTrivia)
 }
 
+// SourceText is the token exactly as it was written, including any quotes
+// which are not part of Text.
+func (t Token) SourceText() string {
+	if t.SourceRange.Start < 0 || t.SourceRange.End > len(t.SourceCode.Raw) || t.SourceRange.Start >= t.SourceRange.End {
+		return t.Text
+	}
+	return string(t.SourceCode.Raw[t.SourceRange.Start:t.SourceRange.End])
+}
+
 func (t Token) DebugString() string {
 	if str, ok := KeywordIndex.GetKey(t.Kind); ok {
 		return str
@@ -413,10 +556,7 @@ func (t Token) InsertionCost() Cost {
 	case TokenKind_Identifier:
 		return CostMid
 	default:
-
-		fmt.Printf("insertion cost not defined for '%s'", t.String())
-		os.Exit(1)
-		return 0
+		return CostHigh
 	}
 }
 
@@ -433,7 +573,9 @@ func (t Token) DeletionCost() Cost {
 		return CostMid
 	case TokenKind_Identifier:
 		return CostMid
+	case TokenKind_Error:
+		return CostLow
 	default:
-		panic(fmt.Sprintf("insertion cost not defined for '%s'", t.String()))
+		return CostHigh
 	}
 }
@@ -0,0 +1,510 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	Dsn string
+	*sql.DB
+}
+
+func init() {
+	Register("postgres", "postgres", OpenPostgres)
+	Register("postgresql", "postgres", OpenPostgres)
+}
+
+// OpenPostgres opens a `postgres://` url, lib/pq takes it as it is. a read
+// only database has its transactions made read only by the server.
+func OpenPostgres(u *url.URL, readOnly bool) (Database, error) {
+	dsn := *u
+	if readOnly {
+		query := dsn.Query()
+		query.Set("default_transaction_read_only", "on")
+		dsn.RawQuery = query.Encode()
+	}
+
+	conn, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	return &Postgres{Dsn: u.Redacted(), DB: conn}, nil
+}
+
+func (postgres *Postgres) Url() string {
+	return postgres.Dsn
+}
+
+// PostgresCatalog is the part of pg_catalog which makes up a schema, it is
+// kept apart from the queries which fill it so that it can be rendered
+// without a database.
+type PostgresCatalog struct {
+	Schemas []string         `json:"schemas"`
+	Enums   []PostgresEnum   `json:"enums"`
+	Domains []PostgresDomain `json:"domains"`
+	Tables  []PostgresTable  `json:"tables"`
+	Indexes []PostgresIndex  `json:"indexes"`
+	Views   []PostgresView   `json:"views"`
+}
+
+type PostgresEnum struct {
+	Schema string   `json:"schema"`
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type PostgresDomain struct {
+	Schema      string               `json:"schema"`
+	Name        string               `json:"name"`
+	Type        string               `json:"type"`
+	NotNull     bool                 `json:"not_null"`
+	Default     string               `json:"default"`
+	Constraints []PostgresConstraint `json:"constraints"`
+}
+
+type PostgresTable struct {
+	Schema      string               `json:"schema"`
+	Name        string               `json:"name"`
+	Columns     []PostgresColumn     `json:"columns"`
+	Constraints []PostgresConstraint `json:"constraints"`
+}
+
+type PostgresColumn struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	NotNull bool   `json:"not_null"`
+	Default string `json:"default"`
+	// Identity is pg_attribute.attidentity, 'a' for always and 'd' for by
+	// default.
+	Identity string `json:"identity"`
+	// Generated is pg_attribute.attgenerated, 's' for stored, when it is
+	// set Default is the generation expression.
+	Generated string `json:"generated"`
+}
+
+type PostgresConstraint struct {
+	Name string `json:"name"`
+	// Type is pg_constraint.contype, one of p, u, f or c.
+	Type       string   `json:"type"`
+	Columns    []string `json:"columns"`
+	Definition string   `json:"definition"`
+}
+
+type PostgresIndex struct {
+	Schema     string `json:"schema"`
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+type PostgresView struct {
+	Schema     string `json:"schema"`
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// objects in these schemas belong to postgres rather than the schema.
+const userSchemas = `n.nspname not like 'pg\_%' and n.nspname <> 'information_schema'`
+
+func (postgres *Postgres) ExportDataDefinitions() (string, error) {
+	catalog, err := postgres.Catalog()
+	if err != nil {
+		return "", err
+	}
+	return RenderCatalog(catalog), nil
+}
+
+// Catalog reads pg_catalog in one transaction, so that every query sees the
+// schema as it was at the first even while it is being migrated.
+func (postgres *Postgres) Catalog() (PostgresCatalog, error) {
+	catalog := PostgresCatalog{}
+	err := postgres.snapshot(func(snapshot *postgresSnapshot) error {
+		return snapshot.catalog(&catalog)
+	})
+	return catalog, err
+}
+
+func (snapshot *postgresSnapshot) catalog(catalog *PostgresCatalog) error {
+	err := snapshot.query(`select n.nspname from pg_namespace n where `+userSchemas+` and n.nspname <> 'public' order by n.nspname`, func(rows *sql.Rows) error {
+		schema := ""
+		if err := rows.Scan(&schema); err != nil {
+			return err
+		}
+		catalog.Schemas = append(catalog.Schemas, schema)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = snapshot.query(`select n.nspname, t.typname, array(select e.enumlabel from pg_enum e where e.enumtypid = t.oid order by e.enumsortorder)
+from pg_type t join pg_namespace n on n.oid = t.typnamespace
+where t.typtype = 'e' and `+userSchemas+`
+order by n.nspname, t.typname`, func(rows *sql.Rows) error {
+		enum := PostgresEnum{}
+		if err := rows.Scan(&enum.Schema, &enum.Name, pq.Array(&enum.Values)); err != nil {
+			return err
+		}
+		catalog.Enums = append(catalog.Enums, enum)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	domainIndex := map[string]int{}
+	err = snapshot.query(`select n.nspname, t.typname, format_type(t.typbasetype, t.typtypmod), t.typnotnull, coalesce(t.typdefault, '')
+from pg_type t join pg_namespace n on n.oid = t.typnamespace
+where t.typtype = 'd' and `+userSchemas+`
+order by n.nspname, t.typname`, func(rows *sql.Rows) error {
+		domain := PostgresDomain{}
+		if err := rows.Scan(&domain.Schema, &domain.Name, &domain.Type, &domain.NotNull, &domain.Default); err != nil {
+			return err
+		}
+		domainIndex[domain.Schema+"."+domain.Name] = len(catalog.Domains)
+		catalog.Domains = append(catalog.Domains, domain)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = snapshot.query(`select n.nspname, t.typname, con.conname, con.contype, pg_get_constraintdef(con.oid)
+from pg_constraint con join pg_type t on t.oid = con.contypid join pg_namespace n on n.oid = t.typnamespace
+where con.contype = 'c' and `+userSchemas+`
+order by n.nspname, t.typname, con.conname`, func(rows *sql.Rows) error {
+		schema, name := "", ""
+		constraint := PostgresConstraint{}
+		if err := rows.Scan(&schema, &name, &constraint.Name, &constraint.Type, &constraint.Definition); err != nil {
+			return err
+		}
+		if i, ok := domainIndex[schema+"."+name]; ok {
+			catalog.Domains[i].Constraints = append(catalog.Domains[i].Constraints, constraint)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tableIndex := map[string]int{}
+	err = snapshot.query(`select n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull, coalesce(pg_get_expr(d.adbin, d.adrelid), ''), a.attidentity, a.attgenerated
+from pg_attribute a
+join pg_class c on c.oid = a.attrelid
+join pg_namespace n on n.oid = c.relnamespace
+left join pg_attrdef d on d.adrelid = a.attrelid and d.adnum = a.attnum
+where c.relkind in ('r', 'p') and a.attnum > 0 and not a.attisdropped and c.relname <> $1 and `+userSchemas+`
+order by n.nspname, c.relname, a.attnum`, func(rows *sql.Rows) error {
+		schema, name := "", ""
+		column := PostgresColumn{}
+		if err := rows.Scan(&schema, &name, &column.Name, &column.Type, &column.NotNull, &column.Default, &column.Identity, &column.Generated); err != nil {
+			return err
+		}
+		i, ok := tableIndex[schema+"."+name]
+		if !ok {
+			i = len(catalog.Tables)
+			tableIndex[schema+"."+name] = i
+			catalog.Tables = append(catalog.Tables, PostgresTable{Schema: schema, Name: name})
+		}
+		catalog.Tables[i].Columns = append(catalog.Tables[i].Columns, column)
+		return nil
+	}, HistoryTable)
+	if err != nil {
+		return err
+	}
+
+	err = snapshot.query(`select n.nspname, c.relname, con.conname, con.contype, pg_get_constraintdef(con.oid),
+	array(select a.attname from unnest(con.conkey) with ordinality k(attnum, i) join pg_attribute a on a.attrelid = con.conrelid and a.attnum = k.attnum order by k.i)
+from pg_constraint con
+join pg_class c on c.oid = con.conrelid
+join pg_namespace n on n.oid = c.relnamespace
+where con.contype in ('p', 'u', 'f', 'c') and `+userSchemas+`
+order by n.nspname, c.relname, con.conname`, func(rows *sql.Rows) error {
+		schema, name := "", ""
+		constraint := PostgresConstraint{}
+		if err := rows.Scan(&schema, &name, &constraint.Name, &constraint.Type, &constraint.Definition, pq.Array(&constraint.Columns)); err != nil {
+			return err
+		}
+		if i, ok := tableIndex[schema+"."+name]; ok {
+			catalog.Tables[i].Constraints = append(catalog.Tables[i].Constraints, constraint)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// indexes made for a primary key or unique constraint come with the
+	// constraint.
+	err = snapshot.query(`select n.nspname, i.relname, pg_get_indexdef(i.oid)
+from pg_index x
+join pg_class i on i.oid = x.indexrelid
+join pg_class c on c.oid = x.indrelid
+join pg_namespace n on n.oid = c.relnamespace
+where not exists (select 1 from pg_constraint con where con.conindid = x.indexrelid) and c.relname <> $1 and `+userSchemas+`
+order by n.nspname, i.relname`, func(rows *sql.Rows) error {
+		index := PostgresIndex{}
+		if err := rows.Scan(&index.Schema, &index.Name, &index.Definition); err != nil {
+			return err
+		}
+		catalog.Indexes = append(catalog.Indexes, index)
+		return nil
+	}, HistoryTable)
+	if err != nil {
+		return err
+	}
+
+	err = snapshot.query(`select n.nspname, c.relname, pg_get_viewdef(c.oid)
+from pg_class c join pg_namespace n on n.oid = c.relnamespace
+where c.relkind = 'v' and `+userSchemas+`
+order by n.nspname, c.relname`, func(rows *sql.Rows) error {
+		view := PostgresView{}
+		if err := rows.Scan(&view.Schema, &view.Name, &view.Definition); err != nil {
+			return err
+		}
+		catalog.Views = append(catalog.Views, view)
+		return nil
+	})
+
+	return err
+}
+
+// postgresSnapshot is one repeatable read transaction, every query made
+// through it sees the catalog as it was at the first.
+type postgresSnapshot struct {
+	ctx  context.Context
+	conn *sql.Conn
+}
+
+// snapshot runs read in a read only, repeatable read transaction, which
+// never blocks a migration and is never blocked by one.
+func (postgres *Postgres) snapshot(read func(snapshot *postgresSnapshot) error) error {
+	ctx := context.Background()
+	conn, err := postgres.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "begin isolation level repeatable read, read only"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "rollback")
+
+	return read(&postgresSnapshot{ctx: ctx, conn: conn})
+}
+
+func (snapshot *postgresSnapshot) query(query string, scan func(rows *sql.Rows) error, args ...any) error {
+	rows, err := snapshot.conn.QueryContext(snapshot.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// RenderCatalog writes the catalog back out as ddl the postgres parser reads,
+// the same way a person would write it. Names postgres made up are left out,
+// so are NOT NULLs implied by a primary key, identity or serial.
+func RenderCatalog(catalog PostgresCatalog) string {
+	builder := strings.Builder{}
+
+	for _, schema := range catalog.Schemas {
+		fmt.Fprintf(&builder, "CREATE SCHEMA %s;\n\n", quoteIdent(schema))
+	}
+
+	for _, enum := range catalog.Enums {
+		values := []string{}
+		for _, value := range enum.Values {
+			values = append(values, quoteLiteral(value))
+		}
+		fmt.Fprintf(&builder, "CREATE TYPE %s AS ENUM (%s);\n\n", qualifiedName(enum.Schema, enum.Name), strings.Join(values, ", "))
+	}
+
+	for _, domain := range catalog.Domains {
+		fmt.Fprintf(&builder, "CREATE DOMAIN %s AS %s", qualifiedName(domain.Schema, domain.Name), domain.Type)
+		if domain.NotNull {
+			builder.WriteString(" NOT NULL")
+		}
+		if domain.Default != "" {
+			fmt.Fprintf(&builder, " DEFAULT %s", domain.Default)
+		}
+		for _, constraint := range domain.Constraints {
+			builder.WriteString(" ")
+			builder.WriteString(constraintDefinition(constraint, domain.Name+"_check"))
+		}
+		builder.WriteString(";\n\n")
+	}
+
+	for _, table := range catalog.Tables {
+		renderTable(&builder, table)
+	}
+
+	for _, index := range catalog.Indexes {
+		builder.WriteString(index.Definition)
+		builder.WriteString(";\n\n")
+	}
+
+	for _, view := range catalog.Views {
+		definition := strings.TrimSuffix(strings.TrimSpace(view.Definition), ";")
+		fmt.Fprintf(&builder, "CREATE VIEW %s AS\n%s;\n\n", qualifiedName(view.Schema, view.Name), definition)
+	}
+
+	return builder.String()
+}
+
+func renderTable(builder *strings.Builder, table PostgresTable) {
+	// a primary key, unique or foreign key on one column and a check which
+	// uses one column are written on the column.
+	columnConstraints := map[string][]PostgresConstraint{}
+	tableConstraints := []PostgresConstraint{}
+	for _, constraint := range table.Constraints {
+		if len(constraint.Columns) == 1 {
+			column := constraint.Columns[0]
+			columnConstraints[column] = append(columnConstraints[column], constraint)
+		} else {
+			tableConstraints = append(tableConstraints, constraint)
+		}
+	}
+
+	lines := []string{}
+	for _, column := range table.Columns {
+		constraints := columnConstraints[column.Name]
+		line := quoteIdent(column.Name) + " " + columnType(table, column)
+
+		isPrimaryKey := slices.ContainsFunc(constraints, func(constraint PostgresConstraint) bool {
+			return constraint.Type == "p"
+		})
+
+		switch {
+		case column.Generated == "s":
+			line += fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", column.Default)
+		case column.Identity == "a":
+			line += " GENERATED ALWAYS AS IDENTITY"
+		case column.Identity == "d":
+			line += " GENERATED BY DEFAULT AS IDENTITY"
+		case column.Default != "" && !isSerial(table, column):
+			line += " DEFAULT " + column.Default
+		}
+
+		if column.NotNull && !isPrimaryKey && column.Identity == "" && !isSerial(table, column) {
+			line += " NOT NULL"
+		}
+
+		for _, constraint := range constraints {
+			line += " " + constraintDefinition(constraint, defaultConstraintName(table.Name, constraint))
+		}
+
+		lines = append(lines, line)
+	}
+
+	for _, constraint := range tableConstraints {
+		lines = append(lines, constraintDefinition(constraint, defaultConstraintName(table.Name, constraint)))
+	}
+
+	fmt.Fprintf(builder, "CREATE TABLE %s (\n    %s\n);\n\n", qualifiedName(table.Schema, table.Name), strings.Join(lines, ",\n    "))
+}
+
+// constraintDefinition is the definition as pg_get_constraintdef gives it,
+// on a column the column list is dropped.
+func constraintDefinition(constraint PostgresConstraint, defaultName string) string {
+	definition := constraint.Definition
+	if len(constraint.Columns) == 1 {
+		column := "(" + quoteIdentIfNeeded(constraint.Columns[0]) + ")"
+		switch constraint.Type {
+		case "p":
+			definition = "PRIMARY KEY"
+		case "u":
+			definition = "UNIQUE"
+		case "f":
+			definition = strings.TrimPrefix(definition, "FOREIGN KEY "+column+" ")
+		}
+	}
+
+	if constraint.Name == defaultName {
+		return definition
+	}
+	return "CONSTRAINT " + quoteIdent(constraint.Name) + " " + definition
+}
+
+// defaultConstraintName is the name postgres gives a constraint which was
+// not given one, without the truncation postgres does on long names.
+func defaultConstraintName(table string, constraint PostgresConstraint) string {
+	switch constraint.Type {
+	case "p":
+		return table + "_pkey"
+	case "u":
+		return strings.Join(append([]string{table}, constraint.Columns...), "_") + "_key"
+	case "f":
+		return strings.Join(append([]string{table}, constraint.Columns...), "_") + "_fkey"
+	case "c":
+		if len(constraint.Columns) == 1 {
+			return table + "_" + constraint.Columns[0] + "_check"
+		}
+		return table + "_check"
+	}
+	return ""
+}
+
+// isSerial reports whether the column's default is the sequence a serial
+// column is given.
+func isSerial(table PostgresTable, column PostgresColumn) bool {
+	sequence := table.Name + "_" + column.Name + "_seq"
+	if table.Schema != "public" {
+		sequence = table.Schema + "." + sequence
+	}
+	return column.Default == fmt.Sprintf("nextval('%s'::regclass)", sequence)
+}
+
+func columnType(table PostgresTable, column PostgresColumn) string {
+	if !isSerial(table, column) {
+		return column.Type
+	}
+	switch column.Type {
+	case "smallint":
+		return "smallserial"
+	case "bigint":
+		return "bigserial"
+	default:
+		return "serial"
+	}
+}
+
+func qualifiedName(schema, name string) string {
+	if schema == "" || schema == "public" {
+		return quoteIdent(name)
+	}
+	return quoteIdent(schema) + "." + quoteIdent(name)
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteIdentIfNeeded quotes a name the way pg_get_constraintdef does, only
+// when it would not read back the same unquoted.
+func quoteIdentIfNeeded(name string) string {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '_' {
+			return quoteIdent(name)
+		}
+	}
+	return name
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+	"woodybriggs/justmigrate/core/luther"
+	postgres "woodybriggs/justmigrate/dialects/postgres/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden schemas in testdata")
+
+// TestRenderCatalog renders a catalog captured from pg_catalog and compares it
+// with schema.sql, run with -update to accept the new output.
+func TestRenderCatalog(t *testing.T) {
+	raw, err := os.ReadFile("testdata/postgres/catalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := PostgresCatalog{}
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := RenderCatalog(catalog)
+
+	golden := "testdata/postgres/schema.sql"
+	if *update {
+		if err := os.WriteFile(golden, []byte(schema), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema != string(expected) {
+		t.Fatalf("schema does not match %s:\n%s", golden, schema)
+	}
+
+	parser := postgres.NewPostgresParser(luther.NewLexer(luther.SourceCode{
+		FileName: golden,
+		Raw:      []rune(schema),
+	}))
+	parser.Statements()
+	if errors := parser.Errors(); len(errors) > 0 {
+		t.Fatalf("rendered schema does not parse: %v", errors)
+	}
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownScheme = errors.New("unknown database url scheme")
+
+// Database is a database whose schema can be read and migrated.
+type Database interface {
+	Url() string
+	ExportDataDefinitions() (string, error)
+	Conn(ctx context.Context) (*sql.Conn, error)
+	Close() error
+}
+
+// Factory opens a database from a url with a scheme it was registered for,
+// a read only database must refuse to be written to.
+type Factory func(url *url.URL, readOnly bool) (Database, error)
+
+type driver struct {
+	dialect string
+	factory Factory
+}
+
+var drivers = map[string]driver{}
+
+// Register makes factory the way to open a url with the given scheme, the
+// databases it opens speak dialect. registering a scheme twice panics, like
+// sql.Register does.
+func Register(scheme, dialect string, factory Factory) {
+	scheme = strings.ToLower(scheme)
+	if _, ok := drivers[scheme]; ok {
+		panic("database: Register called twice for scheme " + scheme)
+	}
+	drivers[scheme] = driver{dialect: dialect, factory: factory}
+}
+
+// Open opens the database at rawUrl with the factory registered for its
+// scheme. a url without a scheme is the path of a sqlite database.
+func Open(rawUrl string) (Database, error) {
+	u, driver, err := lookup(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	return driver.factory(u, false)
+}
+
+// OpenReadOnly opens the database at rawUrl like Open does, but so that
+// reading its schema can never write to it, for commands which only read.
+func OpenReadOnly(rawUrl string) (Database, error) {
+	u, driver, err := lookup(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	return driver.factory(u, true)
+}
+
+// DialectOf is the dialect of the database at rawUrl, without opening it.
+func DialectOf(rawUrl string) (string, error) {
+	_, driver, err := lookup(rawUrl)
+	if err != nil {
+		return "", err
+	}
+	return driver.dialect, nil
+}
+
+func lookup(rawUrl string) (*url.URL, driver, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, driver{}, err
+	}
+	if u.Scheme == "" {
+		u = &url.URL{Scheme: "sqlite", Opaque: u.Path, RawQuery: u.RawQuery}
+	}
+
+	driver, ok := drivers[strings.ToLower(u.Scheme)]
+	if !ok {
+		schemes := []string{}
+		for scheme := range drivers {
+			schemes = append(schemes, scheme)
+		}
+		slices.Sort(schemes)
+		return nil, driver, fmt.Errorf("%w: '%s' (available: %s)", ErrUnknownScheme, u.Scheme, strings.Join(schemes, ", "))
+	}
+
+	return u, driver, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenSqlite(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open("sqlite://" + fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.(*Sqlite).Exec("create table users (id integer primary key)"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	for _, url := range []string{fileName, "sqlite:" + fileName, "file:" + fileName + "?mode=ro"} {
+		db, err := Open(url)
+		if err != nil {
+			t.Fatalf("%s: %s", url, err)
+		}
+		definitions, err := db.ExportDataDefinitions()
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", url, err)
+		}
+		if definitions == "" {
+			t.Errorf("%s: expected the users table", url)
+		}
+	}
+
+	readOnly, err := Open("file:" + fileName + "?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readOnly.Close()
+	if _, err := readOnly.(*Sqlite).Exec("create table posts (id integer)"); err == nil {
+		t.Error("expected a read only database to refuse a write")
+	}
+}
+
+// TestOpenMemory checks an in memory database stays the same database from
+// one statement to the next.
+func TestOpenMemory(t *testing.T) {
+	db, err := Open("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.(*Sqlite).Exec("create table users (id integer primary key)"); err != nil {
+		t.Fatal(err)
+	}
+	definitions, err := db.ExportDataDefinitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if definitions == "" {
+		t.Error("expected the users table")
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	tests := map[string]string{
+		"./local.db":                      "sqlite",
+		"sqlite:///var/app.db":            "sqlite",
+		"file:local.db?mode=ro":           "sqlite",
+		"postgres://localhost/app":        "postgres",
+		"postgresql://localhost/app":      "postgres",
+		"mysql://root@localhost:3306/app": "mysql",
+	}
+	for url, expected := range tests {
+		dialect, err := DialectOf(url)
+		if err != nil {
+			t.Errorf("%s: %s", url, err)
+		} else if dialect != expected {
+			t.Errorf("%s: expected %s, got %s", url, expected, dialect)
+		}
+	}
+
+	if _, err := DialectOf("oracle://localhost/app"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("expected ErrUnknownScheme, got %v", err)
+	}
+}
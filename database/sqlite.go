@@ -1,17 +1,75 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"net/url"
 	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
+// HistoryTable records the migrations applied to a database, it belongs to
+// just migrate and is never part of a schema.
+const HistoryTable = "_justmigrate_history"
+
+// DefaultBusyTimeout is how long reading the schema waits for a writer to
+// let go of the database before giving up.
+const DefaultBusyTimeout = 5 * time.Second
+
+var ErrDatabaseBusy = errors.New("database is locked")
+
 type Sqlite struct {
 	FileName string
 	*sql.DB
 }
 
+func init() {
+	Register("sqlite", "sqlite", OpenSqlite)
+	Register("file", "sqlite", OpenSqlite)
+}
+
+// OpenSqlite opens `sqlite:///abs/path.db`, `sqlite:local.db`,
+// `sqlite::memory:` or a sqlite uri like `file:local.db?immutable=1`. the
+// query of the url is passed on to sqlite, with a `_busy_timeout` of
+// DefaultBusyTimeout unless it has one. a read only database is opened with
+// `mode=ro` unless the url says otherwise.
+func OpenSqlite(u *url.URL, readOnly bool) (Database, error) {
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+
+	query := u.Query()
+	memory := path == ":memory:" || query.Get("mode") == "memory"
+	if readOnly && !memory && !query.Has("mode") && !query.Has("immutable") {
+		query.Set("mode", "ro")
+	}
+	if !query.Has("_busy_timeout") && !query.Has("_timeout") {
+		query.Set("_busy_timeout", fmt.Sprint(DefaultBusyTimeout.Milliseconds()))
+	}
+
+	dsn := "file:" + path
+	if len(query) > 0 {
+		dsn += "?" + query.Encode()
+	}
+
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// every connection to an in memory database is a database of its own.
+	if memory {
+		conn.SetMaxOpenConns(1)
+	}
+
+	return &Sqlite{FileName: path, DB: conn}, nil
+}
+
 func (sqlite *Sqlite) Url() string {
 	return sqlite.FileName
 }
@@ -24,34 +82,115 @@ type schemaRow struct {
 	Sql       sql.NullString
 }
 
+// ExportDataDefinitions writes out the text sqlite keeps of each table,
+// index, view and trigger.
 func (sqlite *Sqlite) ExportDataDefinitions() (string, error) {
 	builder := strings.Builder{}
 
-	rows, err := sqlite.Query("select type, name, tbl_name, rootpage, sql from sqlite_schema;")
+	err := sqlite.snapshot(func(snapshot *sqliteSnapshot) error {
+		rows, err := snapshot.schemaRows()
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if row.Sql.Valid {
+				builder.WriteString("/* ")
+				builder.WriteString(fmt.Sprintf("%s: %s", row.Type.String, row.Name.String))
+				builder.WriteString(" */\n")
+				builder.WriteString(row.Sql.String)
+				builder.WriteRune(';')
+				builder.WriteRune('\n')
+				builder.WriteRune('\n')
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return "", err
 	}
 
-	ok := rows.Next()
-	for ok {
-		row := &schemaRow{}
-		err := rows.Scan(&row.Type, &row.Name, &row.TableName, &row.RootPage, &row.Sql)
-		if err != nil {
-			log.Panicln(err)
-		}
+	return builder.String(), nil
+}
 
-		if row.Sql.Valid {
-			builder.WriteString("/* ")
-			builder.WriteString(fmt.Sprintf("%s: %s", row.Type.String, row.Name.String))
-			builder.WriteString(" */\n")
-			builder.WriteString(row.Sql.String)
-			builder.WriteRune(';')
-			builder.WriteRune('\n')
-			builder.WriteRune('\n')
-		}
+// CopyTo writes a copy of the database to path, which must not exist or be
+// empty. VACUUM INTO reads the database in one transaction, so the copy is
+// of one moment, and works on a database opened read only.
+func (sqlite *Sqlite) CopyTo(path string) error {
+	_, err := sqlite.Exec("vacuum into ?", path)
+	return sqlite.busy(err)
+}
 
-		ok = rows.Next()
+// sqliteSnapshot is one read transaction, every query made through it sees
+// the database as it was at the first.
+type sqliteSnapshot struct {
+	ctx  context.Context
+	conn *sql.Conn
+}
+
+// snapshot runs read in a read transaction on a connection which can not
+// write, so the schema is read from one moment even while it is being
+// migrated. sqlite takes no lock until the first read and a reader never
+// blocks a writer of a database in wal mode, otherwise a writer is kept
+// waiting until read returns.
+func (sqlite *Sqlite) snapshot(read func(snapshot *sqliteSnapshot) error) error {
+	ctx := context.Background()
+	conn, err := sqlite.Conn(ctx)
+	if err != nil {
+		return sqlite.busy(err)
 	}
+	defer conn.Close()
 
-	return builder.String(), nil
+	if _, err := conn.ExecContext(ctx, "pragma query_only = on"); err != nil {
+		return err
+	}
+	// the connection goes back to the pool, where it may be used to write.
+	defer conn.ExecContext(ctx, "pragma query_only = off")
+
+	if _, err := conn.ExecContext(ctx, "begin deferred"); err != nil {
+		return sqlite.busy(err)
+	}
+	defer conn.ExecContext(ctx, "rollback")
+
+	return sqlite.busy(read(&sqliteSnapshot{ctx: ctx, conn: conn}))
+}
+
+// busy explains an error from sqlite giving up on a locked database.
+func (sqlite *Sqlite) busy(err error) error {
+	sqliteErr := sqlite3.Error{}
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+		return fmt.Errorf("%w: %s is still being written to, a longer _busy_timeout waits for longer: %s", ErrDatabaseBusy, sqlite.Url(), err)
+	}
+	return err
+}
+
+func (snapshot *sqliteSnapshot) query(query string, scan func(rows *sql.Rows) error, args ...any) error {
+	rows, err := snapshot.conn.QueryContext(snapshot.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// schemaRows reads the rows of sqlite_schema which make up the schema, the
+// tables of sqlite and the history table are left out.
+func (snapshot *sqliteSnapshot) schemaRows() ([]schemaRow, error) {
+	rows := []schemaRow{}
+	err := snapshot.query("select type, name, tbl_name, rootpage, sql from sqlite_schema where name not like 'sqlite\\_%' escape '\\' and tbl_name != ?;", func(r *sql.Rows) error {
+		row := schemaRow{}
+		if err := r.Scan(&row.Type, &row.Name, &row.TableName, &row.RootPage, &row.Sql); err != nil {
+			return err
+		}
+		rows = append(rows, row)
+		return nil
+	}, HistoryTable)
+	return rows, err
 }
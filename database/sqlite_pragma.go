@@ -0,0 +1,1041 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+	sqliteparser "woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/formatter"
+)
+
+var ErrIntrospection = errors.New("can not read the schema of the database")
+
+// SqliteTable is what the pragmas of sqlite know about a table. They know
+// nothing of checks, collations, conflict clauses or the expressions of
+// generated columns and of indexes, which only the text of the schema has.
+type SqliteTable struct {
+	Name         string             `json:"name"`
+	Strict       bool               `json:"strict"`
+	WithoutRowId bool               `json:"without_rowid"`
+	Columns      []SqliteColumn     `json:"columns"`
+	Uniques      [][]string         `json:"uniques"`
+	ForeignKeys  []SqliteForeignKey `json:"foreign_keys"`
+	Indexes      []SqliteIndex      `json:"indexes"`
+}
+
+type SqliteColumn struct {
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	NotNull bool    `json:"not_null"`
+	Default *string `json:"default"`
+
+	// PrimaryKey is the position of the column in the primary key, from 1,
+	// or 0 when it is not part of it.
+	PrimaryKey int `json:"primary_key"`
+
+	// Generated is "stored" or "virtual" for a generated column.
+	Generated string `json:"generated"`
+}
+
+type SqliteForeignKey struct {
+	From     []string `json:"from"`
+	Table    string   `json:"table"`
+	To       []string `json:"to"`
+	OnUpdate string   `json:"on_update"`
+	OnDelete string   `json:"on_delete"`
+}
+
+// SqliteIndex is an index made with CREATE INDEX. A column indexed by an
+// expression has no name, and Partial is all that is known of a WHERE.
+type SqliteIndex struct {
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique"`
+	Partial bool     `json:"partial"`
+	Columns []string `json:"columns"`
+}
+
+// Introspect reads the tables and indexes of the database from the pragmas
+// of sqlite. The text of the schema is parsed to check the pragmas against,
+// a difference is a warning, and for what the pragmas do not know, see
+// sqliteTableAst. Views and triggers are only known by their text.
+func (sqlite *Sqlite) Introspect() ([]ast.Statement, []report.Report, error) {
+	var statements []ast.Statement
+	var warnings []report.Report
+	err := sqlite.snapshot(func(snapshot *sqliteSnapshot) error {
+		var err error
+		statements, warnings, err = snapshot.introspect(sqlite.Url())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return statements, warnings, nil
+}
+
+func (snapshot *sqliteSnapshot) introspect(url string) ([]ast.Statement, []report.Report, error) {
+	rows, err := snapshot.schemaRows()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tables := map[string]SqliteTable{}
+	statements := []ast.Statement{}
+	warnings := []report.Report{}
+
+	for _, row := range rows {
+		if !row.Sql.Valid {
+			continue
+		}
+
+		source := luther.SourceCode{
+			FileName: fmt.Sprintf("%s: %s", url, row.Name.String),
+			Raw:      []rune(row.Sql.String + ";"),
+		}
+		parsed, parseErrors := parseSqlite(source)
+		unreadable := fmt.Errorf("%w: %s '%s' can not be parsed\n%s", ErrIntrospection, row.Type.String, row.Name.String, renderReports(parseErrors))
+
+		var statement ast.Statement
+		var facts, parsedFacts []string
+		switch {
+		case row.Type.String == "table" && !isVirtualTable(row.Sql.String):
+			table, err := snapshot.pragmaTable(row.Name.String)
+			if err != nil {
+				return nil, nil, err
+			}
+			tables[strings.ToLower(table.Name)] = table
+
+			written, _ := parsed.(*ast.CreateTable)
+			if written == nil && slices.ContainsFunc(table.Columns, func(column SqliteColumn) bool { return column.Generated != "" }) {
+				// the pragmas do not know the expression of a generated
+				// column.
+				return nil, nil, unreadable
+			}
+			createTable, err := sqliteTableAst(table, written)
+			if err != nil {
+				return nil, nil, err
+			}
+			statement = createTable
+
+			if facts, err = table.facts(); err != nil {
+				return nil, nil, err
+			}
+			if written != nil {
+				if parsedFacts, err = sqliteTableFromAst(written).facts(); err != nil {
+					return nil, nil, err
+				}
+			}
+		case row.Type.String == "index":
+			table := tables[strings.ToLower(row.TableName.String)]
+			i := slices.IndexFunc(table.Indexes, func(index SqliteIndex) bool {
+				return strings.EqualFold(index.Name, row.Name.String)
+			})
+			if i < 0 {
+				break
+			}
+			index := table.Indexes[i]
+
+			written, _ := parsed.(*ast.CreateIndex)
+			if written == nil && (index.Partial || slices.Contains(index.Columns, "")) {
+				// the pragmas do not know the expressions of an index.
+				return nil, nil, unreadable
+			}
+			statement = sqliteIndexAst(table.Name, index, written)
+
+			facts = index.facts()
+			if written != nil {
+				parsedFacts = sqliteIndexFromAst(written).facts()
+			}
+		}
+
+		if statement == nil {
+			if len(parseErrors) > 0 {
+				return nil, nil, unreadable
+			}
+			statements = append(statements, parsed)
+			continue
+		}
+
+		if len(parseErrors) > 0 {
+			notes := []string{"it is read from the pragmas of sqlite, which do not know its checks, collations, conflict clauses or the names of its constraints"}
+			for _, err := range parseErrors {
+				notes = append(notes, err.Message)
+			}
+			warnings = append(warnings, *report.
+				NewReport("warning").
+				WithMessage(fmt.Sprintf("%s '%s' can not be parsed", row.Type.String, row.Name.String)).
+				WithNotes(notes))
+		} else if mismatches := compareFacts(facts, parsedFacts); len(mismatches) > 0 {
+			warnings = append(warnings, *report.
+				NewReport("warning").
+				WithMessage(fmt.Sprintf("%s '%s' does not read the same as sqlite reads it", row.Type.String, row.Name.String)).
+				WithLabels([]report.Label{{
+					Source: source,
+					Range:  tik.TextRange{Start: 0, End: len(source.Raw)},
+				}}).
+				WithNotes(mismatches))
+		}
+
+		statements = append(statements, statement)
+	}
+
+	return statements, warnings, nil
+}
+
+// pragmaTable reads a table from pragma table_list, table_xinfo,
+// index_list, index_xinfo and foreign_key_list.
+func (snapshot *sqliteSnapshot) pragmaTable(name string) (SqliteTable, error) {
+	table := SqliteTable{Name: name}
+
+	err := snapshot.query("select strict, wr from pragma_table_list(?)", func(rows *sql.Rows) error {
+		return rows.Scan(&table.Strict, &table.WithoutRowId)
+	}, name)
+	if err != nil {
+		return table, err
+	}
+
+	err = snapshot.query("select name, type, \"notnull\", dflt_value, pk, hidden from pragma_table_xinfo(?) order by cid", func(rows *sql.Rows) error {
+		column := SqliteColumn{}
+		var dflt sql.NullString
+		var hidden int
+		if err := rows.Scan(&column.Name, &column.Type, &column.NotNull, &dflt, &column.PrimaryKey, &hidden); err != nil {
+			return err
+		}
+		if dflt.Valid {
+			column.Default = &dflt.String
+		}
+		switch hidden {
+		case 2:
+			column.Generated = "virtual"
+		case 3:
+			column.Generated = "stored"
+		}
+		table.Columns = append(table.Columns, column)
+		return nil
+	}, name)
+	if err != nil {
+		return table, err
+	}
+
+	type indexRow struct {
+		name    string
+		unique  bool
+		origin  string
+		partial bool
+	}
+	indexes := []indexRow{}
+	err = snapshot.query("select name, \"unique\", origin, partial from pragma_index_list(?) order by seq desc", func(rows *sql.Rows) error {
+		index := indexRow{}
+		if err := rows.Scan(&index.name, &index.unique, &index.origin, &index.partial); err != nil {
+			return err
+		}
+		indexes = append(indexes, index)
+		return nil
+	}, name)
+	if err != nil {
+		return table, err
+	}
+
+	for _, index := range indexes {
+		columns := []string{}
+		err := snapshot.query("select name from pragma_index_xinfo(?) where key = 1 order by seqno", func(rows *sql.Rows) error {
+			var column sql.NullString
+			if err := rows.Scan(&column); err != nil {
+				return err
+			}
+			// an expression has no name.
+			columns = append(columns, column.String)
+			return nil
+		}, index.name)
+		if err != nil {
+			return table, err
+		}
+
+		switch index.origin {
+		case "u":
+			table.Uniques = append(table.Uniques, columns)
+		case "c":
+			table.Indexes = append(table.Indexes, SqliteIndex{
+				Name:    index.name,
+				Unique:  index.unique,
+				Partial: index.partial,
+				Columns: columns,
+			})
+		}
+	}
+
+	// the columns of a foreign key are a row each, numbered from the last
+	// foreign key of the table.
+	lastId := -1
+	err = snapshot.query("select id, \"table\", \"from\", \"to\", on_update, on_delete from pragma_foreign_key_list(?) order by id desc, seq", func(rows *sql.Rows) error {
+		var id int
+		var from string
+		var to sql.NullString
+		foreignKey := SqliteForeignKey{}
+		if err := rows.Scan(&id, &foreignKey.Table, &from, &to, &foreignKey.OnUpdate, &foreignKey.OnDelete); err != nil {
+			return err
+		}
+		if id != lastId {
+			table.ForeignKeys = append(table.ForeignKeys, foreignKey)
+			lastId = id
+		}
+		last := &table.ForeignKeys[len(table.ForeignKeys)-1]
+		last.From = append(last.From, from)
+		// the columns are left out when they are the primary key of the
+		// referenced table.
+		if to.Valid {
+			last.To = append(last.To, to.String)
+		}
+		return nil
+	}, name)
+
+	return table, err
+}
+
+func isVirtualTable(sql string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.Join(strings.Fields(sql), " ")), "CREATE VIRTUAL")
+}
+
+// parseSqlite parses the one statement of source.
+func parseSqlite(source luther.SourceCode) (ast.Statement, []report.Report) {
+	parser := sqliteparser.NewSqliteParser(luther.NewLexer(source))
+	statements := parser.Statements()
+	if errors := parser.Errors(); len(errors) > 0 {
+		return nil, errors
+	}
+	if len(statements) != 1 {
+		return nil, []report.Report{*report.NewReport("parse error").WithMessage("expected one statement")}
+	}
+	return statements[0], nil
+}
+
+func renderReports(reports []report.Report) string {
+	renderer := report.Renderer{}
+	builder := strings.Builder{}
+	for _, report := range reports {
+		builder.WriteString(renderer.Render(report))
+	}
+	return builder.String()
+}
+
+// sqliteTableAst is the table the pragmas describe. written is the table as
+// its text parses, or nil when it does not, and gives what the pragmas do
+// not know: the names of its constraints, its checks and collations, the
+// expressions of its generated columns, its conflict clauses, autoincrement,
+// and whether a constraint on one column is written on the column or on the
+// table.
+func sqliteTableAst(table SqliteTable, written *ast.CreateTable) (*ast.CreateTable, error) {
+	var writtenTable *ast.TableDefinition
+	if written != nil {
+		writtenTable = written.TableDefinition
+	}
+
+	primaryKey := table.primaryKey()
+	_, primaryKeyOnTable := writtenTableConstraint(writtenTable, func(*ast.TableConstraint_PrimaryKey) bool { return true })
+	primaryKeyOnTable = primaryKeyOnTable || len(primaryKey) > 1
+
+	definition := &ast.TableDefinition{}
+	for _, column := range table.Columns {
+		writtenColumn := writtenColumnDefinition(writtenTable, column.Name)
+
+		typeName := ast.TypeName{}
+		if column.Type != "" {
+			var err error
+			typeName, err = parseSqliteFragment(column.Type, func(p *sqliteparser.SqliteParser) ast.TypeName { return p.TypeName() })
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		constraints := []ast.ColumnConstraint{}
+		if column.PrimaryKey > 0 && !primaryKeyOnTable {
+			primaryKey := &ast.ColumnConstraint_PrimaryKey{
+				PrimaryKeyword: keyword(tik.TokenKind_Keyword_PRIMARY, "PRIMARY"),
+				KeyKeyword:     keyword(tik.TokenKind_Keyword_KEY, "KEY"),
+			}
+			if written, ok := writtenColumnConstraint[*ast.ColumnConstraint_PrimaryKey](writtenColumn); ok {
+				primaryKey.Name = written.Name
+				primaryKey.Order = written.Order
+				primaryKey.ConflictClause = written.ConflictClause
+				primaryKey.AutoIncrement = written.AutoIncrement
+			}
+			constraints = append(constraints, primaryKey)
+		}
+
+		// the columns of the primary key of a strict table or a table
+		// without a rowid are not null whether they say so or not.
+		writtenNotNull, hasNotNull := writtenColumnConstraint[*ast.ColumnConstraint_NotNull](writtenColumn)
+		implied := column.PrimaryKey > 0 && (table.Strict || table.WithoutRowId)
+		if column.NotNull && (hasNotNull || !implied) {
+			notNull := &ast.ColumnConstraint_NotNull{}
+			if hasNotNull {
+				notNull.Name = writtenNotNull.Name
+				notNull.ConflictClause = writtenNotNull.ConflictClause
+			}
+			constraints = append(constraints, notNull)
+		}
+
+		if column.Default != nil {
+			value, err := sqliteDefault(*column.Default)
+			if err != nil {
+				return nil, err
+			}
+			defaultValue := &ast.ColumnConstraint_Default{Default: value}
+			if written, ok := writtenColumnConstraint[*ast.ColumnConstraint_Default](writtenColumn); ok {
+				defaultValue.Name = written.Name
+			}
+			constraints = append(constraints, defaultValue)
+		}
+
+		for _, unique := range table.Uniques {
+			if !isColumnConstraint(unique, column.Name, writtenTable, isWrittenUnique) {
+				continue
+			}
+			uniqueConstraint := &ast.ColumnConstraint_Unique{}
+			if written, ok := writtenColumnConstraint[*ast.ColumnConstraint_Unique](writtenColumn); ok {
+				uniqueConstraint.Name = written.Name
+				uniqueConstraint.ConflictClause = written.ConflictClause
+			}
+			constraints = append(constraints, uniqueConstraint)
+		}
+
+		for _, foreignKey := range table.ForeignKeys {
+			if !isColumnConstraint(foreignKey.From, column.Name, writtenTable, isWrittenForeignKey) {
+				continue
+			}
+			foreignKeyConstraint := &ast.ColumnConstraint_ForeignKey{}
+			var writtenClause *ast.ForeignKeyClause
+			if written, ok := writtenColumnConstraint[*ast.ColumnConstraint_ForeignKey](writtenColumn); ok {
+				foreignKeyConstraint.Name = written.Name
+				writtenClause = &written.FkClause
+			}
+			clause, err := foreignKeyClause(foreignKey, writtenClause)
+			if err != nil {
+				return nil, err
+			}
+			foreignKeyConstraint.FkClause = clause
+			constraints = append(constraints, foreignKeyConstraint)
+		}
+
+		if writtenColumn != nil {
+			for _, constraint := range writtenColumn.ColumnConstraints {
+				switch constraint.(type) {
+				case *ast.ColumnConstraint_Collate, *ast.ColumnConstraint_Check, *ast.ColumnConstraint_Generated:
+					constraints = append(constraints, constraint)
+				}
+			}
+
+			// the constraints are in the order they are written in.
+			slices.SortStableFunc(constraints, func(a, b ast.ColumnConstraint) int {
+				return writtenPosition(writtenColumn.ColumnConstraints, a) - writtenPosition(writtenColumn.ColumnConstraints, b)
+			})
+		}
+
+		definition.ColumnDefinitions = append(definition.ColumnDefinitions, ast.ColumnDefinition{
+			ColumnName:        identifier(column.Name),
+			TypeName:          typeName,
+			ColumnConstraints: constraints,
+		})
+	}
+
+	if len(primaryKey) > 0 && primaryKeyOnTable {
+		constraint := &ast.TableConstraint_PrimaryKey{
+			PrimaryKeyword: keyword(tik.TokenKind_Keyword_PRIMARY, "PRIMARY"),
+			KeyKeyword:     keyword(tik.TokenKind_Keyword_KEY, "KEY"),
+		}
+		var writtenColumns []ast.IndexedColumn
+		if written, ok := writtenTableConstraint(writtenTable, func(*ast.TableConstraint_PrimaryKey) bool { return true }); ok {
+			constraint.Name = written.Name
+			constraint.AutoIncrement = written.AutoIncrement
+			constraint.ConflictClause = written.ConflictClause
+			writtenColumns = written.IndexedColumns
+		}
+		constraint.IndexedColumns = indexedColumns(primaryKey, writtenColumns)
+		definition.TableConstraints = append(definition.TableConstraints, constraint)
+	}
+
+	for _, unique := range table.Uniques {
+		if len(unique) == 1 && isColumnConstraint(unique, unique[0], writtenTable, isWrittenUnique) {
+			continue
+		}
+		constraint := &ast.TableConstraint_Unique{
+			UniqueKeyword: keyword(tik.TokenKind_Keyword_UNIQUE, "UNIQUE"),
+		}
+		var writtenColumns []ast.IndexedColumn
+		if written, ok := writtenTableConstraint(writtenTable, func(written *ast.TableConstraint_Unique) bool {
+			return sameNames(unique, indexedColumnNames(written.IndexedColumns))
+		}); ok {
+			constraint.Name = written.Name
+			constraint.ConflictClause = written.ConflictClause
+			writtenColumns = written.IndexedColumns
+		}
+		constraint.IndexedColumns = indexedColumns(unique, writtenColumns)
+		definition.TableConstraints = append(definition.TableConstraints, constraint)
+	}
+
+	for _, foreignKey := range table.ForeignKeys {
+		if len(foreignKey.From) == 1 && isColumnConstraint(foreignKey.From, foreignKey.From[0], writtenTable, isWrittenForeignKey) {
+			continue
+		}
+		constraint := &ast.TableConstraint_ForeignKey{
+			ForeignKeyword: keyword(tik.TokenKind_Keyword_FOREIGN, "FOREIGN"),
+			KeyKeyword:     keyword(tik.TokenKind_Keyword_KEY, "KEY"),
+		}
+		for _, column := range foreignKey.From {
+			constraint.Columns = append(constraint.Columns, identifier(column))
+		}
+		var writtenClause *ast.ForeignKeyClause
+		if written, ok := writtenTableConstraint(writtenTable, func(written *ast.TableConstraint_ForeignKey) bool {
+			return sameNames(foreignKey.From, identifierNames(written.Columns))
+		}); ok {
+			constraint.Name = written.Name
+			writtenClause = &written.FkClause
+		}
+		clause, err := foreignKeyClause(foreignKey, writtenClause)
+		if err != nil {
+			return nil, err
+		}
+		constraint.FkClause = clause
+		definition.TableConstraints = append(definition.TableConstraints, constraint)
+	}
+
+	if writtenTable != nil {
+		for _, constraint := range writtenTable.TableConstraints {
+			if _, ok := constraint.(*ast.TableConstraint_Check); ok {
+				definition.TableConstraints = append(definition.TableConstraints, constraint)
+			}
+		}
+	}
+
+	var options *ast.TableOptions
+	if table.Strict || table.WithoutRowId {
+		options = &ast.TableOptions{}
+		if table.Strict {
+			strict := keyword(tik.TokenKind_Keyword_STRICT, "STRICT")
+			options.Strict = &strict
+		}
+		if table.WithoutRowId {
+			options.WithoutRowId = ast.MakeWithoutRowId(
+				keyword(tik.TokenKind_Keyword_WITHOUT, "WITHOUT"),
+				keyword(tik.TokenKind_Keyword_ROWID, "ROWID"),
+			)
+		}
+	}
+
+	return ast.MakeCreateTable(
+		keyword(tik.TokenKind_Keyword_CREATE, "CREATE"),
+		nil,
+		keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+		nil,
+		ast.MakeCatalogObjectIdentifier(nil, identifier(table.Name)),
+		definition,
+		options,
+	), nil
+}
+
+// sqliteIndexAst is the index the pragmas describe. written is the index as
+// its text parses, or nil, and gives the expressions the index is on and
+// its WHERE, which the pragmas do not know, as well as the collation and
+// order of its columns.
+func sqliteIndexAst(table string, index SqliteIndex, written *ast.CreateIndex) *ast.CreateIndex {
+	var unique *ast.Keyword
+	if index.Unique {
+		keyword := keyword(tik.TokenKind_Keyword_UNIQUE, "UNIQUE")
+		unique = &keyword
+	}
+
+	var writtenColumns []ast.IndexedColumn
+	var where ast.Expr
+	if written != nil {
+		writtenColumns = written.IndexedColumns
+		if index.Partial {
+			where = written.WhereExpr
+		}
+	}
+
+	return ast.MakeCreateIndex(
+		keyword(tik.TokenKind_Keyword_CREATE, "CREATE"),
+		unique,
+		keyword(tik.TokenKind_Keyword_INDEX, "INDEX"),
+		nil,
+		ast.MakeCatalogObjectIdentifier(nil, identifier(index.Name)),
+		keyword(tik.TokenKind_Keyword_ON, "ON"),
+		ast.MakeCatalogObjectIdentifier(nil, identifier(table)),
+		indexedColumns(index.Columns, writtenColumns),
+		where,
+	)
+}
+
+// indexedColumns are the columns of an index or a key, an expression has no
+// name and is the written one in its place.
+func indexedColumns(names []string, written []ast.IndexedColumn) []ast.IndexedColumn {
+	columns := []ast.IndexedColumn{}
+	for i, name := range names {
+		if i < len(written) && strings.EqualFold(indexedColumnName(written[i]), name) {
+			columns = append(columns, written[i])
+			continue
+		}
+		column := identifier(name)
+		columns = append(columns, ast.IndexedColumn{Subject: &column})
+	}
+	return columns
+}
+
+// foreignKeyClause is the REFERENCES of a foreign key. An action which does
+// nothing is left out, unless it is written.
+func foreignKeyClause(foreignKey SqliteForeignKey, written *ast.ForeignKeyClause) (ast.ForeignKeyClause, error) {
+	clause := ast.ForeignKeyClause{
+		ReferencesKeyword: keyword(tik.TokenKind_Keyword_REFERENCES, "REFERENCES"),
+		ForeignTable:      *ast.MakeCatalogObjectIdentifier(nil, identifier(foreignKey.Table)),
+	}
+	for _, column := range foreignKey.To {
+		clause.ForeignColumns = append(clause.ForeignColumns, identifier(column))
+	}
+
+	writtenUpdate, writtenDelete := -1, -1
+	if written != nil {
+		clause.MatchName = written.MatchName
+		clause.Deferrable = written.Deferrable
+		writtenUpdate = slices.IndexFunc(written.Actions, func(action ast.ForeignKeyAction) bool {
+			_, ok := action.(*ast.ForeignKeyUpdateAction)
+			return ok
+		})
+		writtenDelete = slices.IndexFunc(written.Actions, func(action ast.ForeignKeyAction) bool {
+			_, ok := action.(*ast.ForeignKeyDeleteAction)
+			return ok
+		})
+	}
+
+	update, err := foreignKeyActionDo(foreignKey.OnUpdate, writtenUpdate >= 0)
+	if err != nil {
+		return clause, err
+	}
+	delete, err := foreignKeyActionDo(foreignKey.OnDelete, writtenDelete >= 0)
+	if err != nil {
+		return clause, err
+	}
+
+	on := keyword(tik.TokenKind_Keyword_ON, "ON")
+	actions := []ast.ForeignKeyAction{}
+	if update != nil {
+		actions = append(actions, ast.MakeForeignKeyUpdateAction(on, keyword(tik.TokenKind_Keyword_UPDATE, "UPDATE"), update))
+	}
+	if delete != nil {
+		actions = append(actions, ast.MakeForeignKeyDeleteAction(on, keyword(tik.TokenKind_Keyword_DELETE, "DELETE"), delete))
+	}
+	if len(actions) == 2 && writtenDelete >= 0 && writtenDelete < writtenUpdate {
+		slices.Reverse(actions)
+	}
+	clause.Actions = actions
+
+	return clause, nil
+}
+
+func foreignKeyActionDo(action string, written bool) (ast.ForeignKeyActionDo, error) {
+	if !written && orNoAction(action) == "NO ACTION" {
+		return nil, nil
+	}
+	return parseSqliteFragment(orNoAction(action), func(p *sqliteparser.SqliteParser) ast.ForeignKeyActionDo { return p.ForeignKeyActionDo() })
+}
+
+// sqliteDefault is a default the way it is written after DEFAULT, sqlite
+// keeps it without the parentheses around an expression.
+func sqliteDefault(value string) (ast.Expr, error) {
+	term := func(p *sqliteparser.SqliteParser) ast.Expr { return p.Term() }
+	if expr, err := parseSqliteFragment(value, term); err == nil {
+		return expr, nil
+	}
+	return parseSqliteFragment("("+value+")", term)
+}
+
+// parseSqliteFragment parses value, a piece of sql the pragmas hold as text
+// such as a type or a default, with production.
+func parseSqliteFragment[T any](value string, production func(p *sqliteparser.SqliteParser) T) (T, error) {
+	p := sqliteparser.NewSqliteParser(luther.NewLexer(luther.SourceCode{Raw: []rune(value)}))
+	result, errors := parser.ParseFragment(p.Parser, func() T { return production(p) })
+	if len(errors) > 0 {
+		return result, fmt.Errorf("%w: '%s' can not be parsed\n%s", ErrIntrospection, value, renderReports(errors))
+	}
+	return result, nil
+}
+
+func writtenColumnDefinition(written *ast.TableDefinition, name string) *ast.ColumnDefinition {
+	if written == nil {
+		return nil
+	}
+	for i := range written.ColumnDefinitions {
+		if strings.EqualFold(written.ColumnDefinitions[i].ColumnName.Text, name) {
+			return &written.ColumnDefinitions[i]
+		}
+	}
+	return nil
+}
+
+func writtenColumnConstraint[T ast.ColumnConstraint](written *ast.ColumnDefinition) (T, bool) {
+	if written != nil {
+		for _, constraint := range written.ColumnConstraints {
+			if constraint, ok := constraint.(T); ok {
+				return constraint, true
+			}
+		}
+	}
+	var none T
+	return none, false
+}
+
+func writtenTableConstraint[T ast.TableConstraint](written *ast.TableDefinition, match func(T) bool) (T, bool) {
+	if written != nil {
+		for _, constraint := range written.TableConstraints {
+			if constraint, ok := constraint.(T); ok && match(constraint) {
+				return constraint, true
+			}
+		}
+	}
+	var none T
+	return none, false
+}
+
+// isColumnConstraint reports whether a unique or foreign key on columns is
+// written on column, which is where a constraint on just the one column goes
+// unless it is written on the table.
+func isColumnConstraint(columns []string, column string, written *ast.TableDefinition, writtenOnTable func(ast.TableConstraint, []string) bool) bool {
+	if len(columns) != 1 || !strings.EqualFold(columns[0], column) {
+		return false
+	}
+	return written == nil || !slices.ContainsFunc(written.TableConstraints, func(constraint ast.TableConstraint) bool {
+		return writtenOnTable(constraint, columns)
+	})
+}
+
+func isWrittenUnique(constraint ast.TableConstraint, columns []string) bool {
+	unique, ok := constraint.(*ast.TableConstraint_Unique)
+	return ok && sameNames(columns, indexedColumnNames(unique.IndexedColumns))
+}
+
+func isWrittenForeignKey(constraint ast.TableConstraint, columns []string) bool {
+	foreignKey, ok := constraint.(*ast.TableConstraint_ForeignKey)
+	return ok && sameNames(columns, identifierNames(foreignKey.Columns))
+}
+
+// writtenPosition is where the first constraint of the kind of constraint
+// is written, after all of them when it is not.
+func writtenPosition(written []ast.ColumnConstraint, constraint ast.ColumnConstraint) int {
+	i := slices.IndexFunc(written, func(written ast.ColumnConstraint) bool {
+		return fmt.Sprintf("%T", written) == fmt.Sprintf("%T", constraint)
+	})
+	if i < 0 {
+		return len(written)
+	}
+	return i
+}
+
+func sameNames(a, b []string) bool {
+	return slices.EqualFunc(a, b, strings.EqualFold)
+}
+
+func indexedColumnNames(columns []ast.IndexedColumn) []string {
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, indexedColumnName(column))
+	}
+	return names
+}
+
+func identifierNames(identifiers []ast.Identifier) []string {
+	names := []string{}
+	for _, identifier := range identifiers {
+		names = append(names, identifier.Text)
+	}
+	return names
+}
+
+func identifier(name string) ast.Identifier {
+	return ast.Identifier(tik.Token{
+		Kind: tik.TokenKind_Identifier,
+		Text: name,
+	})
+}
+
+func keyword(kind tik.TokenKind, text string) ast.Keyword {
+	return ast.Keyword(tik.Token{
+		Kind: kind,
+		Text: text,
+	})
+}
+
+func (table SqliteTable) primaryKey() []string {
+	columns := []SqliteColumn{}
+	for _, column := range table.Columns {
+		if column.PrimaryKey > 0 {
+			columns = append(columns, column)
+		}
+	}
+	slices.SortFunc(columns, func(a, b SqliteColumn) int { return a.PrimaryKey - b.PrimaryKey })
+
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// facts are the things about a table which both the pragmas and the text
+// of the schema know, one per line, so the two can be compared.
+func (table SqliteTable) facts() ([]string, error) {
+	facts := []string{}
+	if table.Strict {
+		facts = append(facts, "strict")
+	}
+	if table.WithoutRowId {
+		facts = append(facts, "without rowid")
+	}
+
+	for _, column := range table.Columns {
+		fact := fmt.Sprintf("column %s %s", strings.ToLower(column.Name), normalizeType(column.Type))
+		if column.NotNull {
+			fact += " not null"
+		}
+		if column.Default != nil {
+			value, err := normalizeDefault(*column.Default)
+			if err != nil {
+				return nil, err
+			}
+			fact += " default " + value
+		}
+		if column.Generated != "" {
+			fact += " generated " + column.Generated
+		}
+		facts = append(facts, fact)
+	}
+
+	if primaryKey := table.primaryKey(); len(primaryKey) > 0 {
+		facts = append(facts, fmt.Sprintf("primary key (%s)", lowerJoin(primaryKey)))
+	}
+	for _, unique := range table.Uniques {
+		facts = append(facts, fmt.Sprintf("unique (%s)", lowerJoin(unique)))
+	}
+	for _, foreignKey := range table.ForeignKeys {
+		facts = append(facts, fmt.Sprintf("foreign key (%s) references %s (%s) on update %s on delete %s",
+			lowerJoin(foreignKey.From),
+			strings.ToLower(foreignKey.Table),
+			lowerJoin(foreignKey.To),
+			orNoAction(foreignKey.OnUpdate),
+			orNoAction(foreignKey.OnDelete),
+		))
+	}
+
+	return facts, nil
+}
+
+func (index SqliteIndex) facts() []string {
+	columns := []string{}
+	for _, column := range index.Columns {
+		if column == "" {
+			column = "<expression>"
+		}
+		columns = append(columns, strings.ToLower(column))
+	}
+
+	fact := "index " + strings.ToLower(index.Name)
+	if index.Unique {
+		fact += " unique"
+	}
+	fact += " (" + strings.Join(columns, ", ") + ")"
+	if index.Partial {
+		fact += " partial"
+	}
+	return []string{fact}
+}
+
+// compareFacts lists the facts which only one side knows.
+func compareFacts(pragma, text []string) []string {
+	if pragma == nil || text == nil {
+		return nil
+	}
+
+	mismatches := []string{}
+	for _, fact := range pragma {
+		if !slices.Contains(text, fact) {
+			mismatches = append(mismatches, "sqlite has "+fact)
+		}
+	}
+	for _, fact := range text {
+		if !slices.Contains(pragma, fact) {
+			mismatches = append(mismatches, "the text has "+fact)
+		}
+	}
+	return mismatches
+}
+
+func lowerJoin(names []string) string {
+	return strings.ToLower(strings.Join(names, ", "))
+}
+
+func orNoAction(action string) string {
+	if action == "" {
+		return "NO ACTION"
+	}
+	return strings.ToUpper(action)
+}
+
+// normalizeType ignores the case and spacing of a type, sqlite keeps the
+// type as it was written.
+func normalizeType(typ string) string {
+	return strings.ToLower(strings.Join(strings.Fields(typ), ""))
+}
+
+// normalizeDefault reads a default through the parser, so that it is
+// written the same way whichever side it came from.
+func normalizeDefault(value string) (string, error) {
+	expr, err := sqliteDefault(value)
+	if err != nil {
+		return "", err
+	}
+	return exprText(expr), nil
+}
+
+func exprText(expr ast.Expr) string {
+	for {
+		parens, ok := expr.(*ast.Parens)
+		if !ok {
+			break
+		}
+		expr = parens.Expr
+	}
+
+	builder := strings.Builder{}
+	expr.ToSql(formatter.NewCoreFormatter(&builder, 1<<30, "\"\""))
+	return builder.String()
+}
+
+// sqliteTableFromAst is what the pragmas would say about a table, were it
+// created from its text.
+func sqliteTableFromAst(createTable *ast.CreateTable) SqliteTable {
+	table := SqliteTable{Name: createTable.TableIdentifier.ObjectName.Text}
+	if options := createTable.TableOptions; options != nil {
+		table.Strict = options.IsStrict()
+		table.WithoutRowId = options.IsWithoutRowId()
+	}
+
+	definition := createTable.TableDefinition
+	for _, def := range definition.ColumnDefinitions {
+		column := SqliteColumn{
+			Name: def.ColumnName.Text,
+			Type: exprTypeText(def.TypeName),
+		}
+		for _, constraint := range def.ColumnConstraints {
+			switch constraint := constraint.(type) {
+			case *ast.ColumnConstraint_PrimaryKey:
+				column.PrimaryKey = 1
+			case *ast.ColumnConstraint_NotNull:
+				column.NotNull = true
+			case *ast.ColumnConstraint_Default:
+				text := exprText(constraint.Default)
+				column.Default = &text
+			case *ast.ColumnConstraint_Unique:
+				table.Uniques = append(table.Uniques, []string{def.ColumnName.Text})
+			case *ast.ColumnConstraint_ForeignKey:
+				table.ForeignKeys = append(table.ForeignKeys, foreignKeyFromAst([]ast.Identifier{def.ColumnName}, constraint.FkClause))
+			case *ast.ColumnConstraint_Generated:
+				column.Generated = "virtual"
+				if constraint.Storage != nil {
+					column.Generated = strings.ToLower(constraint.Storage.Text)
+				}
+			}
+		}
+		table.Columns = append(table.Columns, column)
+	}
+
+	for _, constraint := range definition.TableConstraints {
+		switch constraint := constraint.(type) {
+		case *ast.TableConstraint_PrimaryKey:
+			for i, indexed := range constraint.IndexedColumns {
+				name := indexedColumnName(indexed)
+				for j := range table.Columns {
+					if strings.EqualFold(table.Columns[j].Name, name) {
+						table.Columns[j].PrimaryKey = i + 1
+					}
+				}
+			}
+		case *ast.TableConstraint_Unique:
+			unique := []string{}
+			for _, indexed := range constraint.IndexedColumns {
+				unique = append(unique, indexedColumnName(indexed))
+			}
+			table.Uniques = append(table.Uniques, unique)
+		case *ast.TableConstraint_ForeignKey:
+			table.ForeignKeys = append(table.ForeignKeys, foreignKeyFromAst(constraint.Columns, constraint.FkClause))
+		}
+	}
+
+	// the columns of the primary key of a strict table or a table without a
+	// rowid are not null whether they say so or not.
+	if table.Strict || table.WithoutRowId {
+		for i := range table.Columns {
+			if table.Columns[i].PrimaryKey > 0 {
+				table.Columns[i].NotNull = true
+			}
+		}
+	}
+
+	return table
+}
+
+func sqliteIndexFromAst(createIndex *ast.CreateIndex) SqliteIndex {
+	index := SqliteIndex{
+		Name:    createIndex.IndexIdentifier.ObjectName.Text,
+		Unique:  createIndex.IsUnique(),
+		Partial: createIndex.WhereExpr != nil,
+	}
+	for _, indexed := range createIndex.IndexedColumns {
+		index.Columns = append(index.Columns, indexedColumnName(indexed))
+	}
+	return index
+}
+
+func foreignKeyFromAst(columns []ast.Identifier, clause ast.ForeignKeyClause) SqliteForeignKey {
+	foreignKey := SqliteForeignKey{Table: clause.ForeignTable.ObjectName.Text}
+	for _, column := range columns {
+		foreignKey.From = append(foreignKey.From, column.Text)
+	}
+	for _, column := range clause.ForeignColumns {
+		foreignKey.To = append(foreignKey.To, column.Text)
+	}
+	for _, action := range clause.Actions {
+		switch action := action.(type) {
+		case *ast.ForeignKeyUpdateAction:
+			foreignKey.OnUpdate = strings.ToUpper(sqlText(action.Action))
+		case *ast.ForeignKeyDeleteAction:
+			foreignKey.OnDelete = strings.ToUpper(sqlText(action.Action))
+		}
+	}
+	return foreignKey
+}
+
+// indexedColumnName is the name of an indexed column, or nothing for an
+// expression.
+func indexedColumnName(indexed ast.IndexedColumn) string {
+	if ident, ok := indexed.Subject.(*ast.Identifier); ok {
+		return ident.Text
+	}
+	return ""
+}
+
+func exprTypeText(typeName ast.TypeName) string {
+	if typeName.IsEmpty() {
+		return ""
+	}
+	return sqlText(&typeName)
+}
+
+func sqlText(node interface{ ToSql(f formatter.Formatter) }) string {
+	builder := strings.Builder{}
+	node.ToSql(formatter.NewCoreFormatter(&builder, 1<<30, "\"\""))
+	return builder.String()
+}
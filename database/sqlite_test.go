@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+	sqliteparser "woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/internal/testschema"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openSqlite(t *testing.T, schema string) *Sqlite {
+	fileName := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return &Sqlite{FileName: fileName, DB: db}
+}
+
+// TestIntrospect reads a schema sqlite and the parser agree on, there should
+// be nothing to warn about.
+func TestIntrospect(t *testing.T) {
+	sqlite := openSqlite(t, `
+		create table users (
+			id integer primary key,
+			email text not null unique,
+			created_at text default (datetime('now')),
+			score real default -1,
+			nick varchar(255)
+		);
+		create table posts (
+			id integer,
+			user_id integer not null references users (id) on delete cascade,
+			title text default 'untitled',
+			primary key (id, user_id),
+			unique (user_id, title)
+		) strict;
+		create index posts_title on posts (title);
+		create unique index users_email_lower on users (lower(email));
+	`)
+
+	statements, warnings, err := sqlite.Introspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("expected no warnings, got:\n%s", renderReports(warnings))
+	}
+	if len(statements) != 4 {
+		t.Errorf("expected 4 statements, got %d", len(statements))
+	}
+}
+
+// TestIntrospectFromPragmas reads tables from the pragmas with what only the
+// text knows taken from it, they should be the same as the schema.
+func TestIntrospectFromPragmas(t *testing.T) {
+	schema := `
+		create table users (
+			id integer constraint users_pk primary key autoincrement,
+			email text collate nocase not null,
+			age integer check (age > 0),
+			constraint users_email unique (email)
+		);
+		create table posts (
+			id integer primary key,
+			user_id integer references users (id) on update no action on delete cascade,
+			parent_id integer,
+			title text not null on conflict replace default 'untitled',
+			constraint posts_parent foreign key (parent_id) references posts (id) deferrable initially deferred,
+			check (length(title) < 100)
+		);
+		create index posts_title on posts (title collate nocase desc) where title <> '';
+	`
+	sqlite := openSqlite(t, schema)
+
+	statements, warnings, err := sqlite.Introspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("expected no warnings, got:\n%s", renderReports(warnings))
+	}
+
+	differ := diff.Diff{}
+	edits, err := differ.DiffSchema(testschema.Parse(t, sqliteparser.NewSqliteParser, schema), statements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) > 0 {
+		t.Errorf("expected no edits, got %v", edits)
+	}
+}
+
+// TestIntrospectInternalTables leaves out the tables sqlite keeps for itself,
+// but not a table whose name merely looks like one of them.
+func TestIntrospectInternalTables(t *testing.T) {
+	sqlite := openSqlite(t, `
+		create table counters (id integer primary key autoincrement);
+		create table sqliteXfoo (a integer);
+		insert into counters default values;
+	`)
+
+	statements, _, err := sqlite.Introspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, statement := range statements {
+		names = append(names, statement.(*ast.CreateTable).TableIdentifier.ObjectName.Text)
+	}
+	if !slices.Equal(names, []string{"counters", "sqliteXfoo"}) {
+		t.Errorf("expected counters and sqliteXfoo, got %v", names)
+	}
+}
+
+// TestIntrospectFallback reads a table the parser can not, it is read from
+// the pragmas instead with a warning.
+func TestIntrospectFallback(t *testing.T) {
+	sqlite := openSqlite(t, `
+		create table things (
+			id integer primary key,
+			a integer not null check (a between 1 and 2)
+		);
+	`)
+
+	statements, warnings, err := sqlite.Introspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(statements))
+	}
+	if !slices.ContainsFunc(warnings, func(warning report.Report) bool {
+		return strings.Contains(warning.Message, "can not be parsed")
+	}) {
+		t.Errorf("expected a warning that things can not be parsed, got:\n%s", renderReports(warnings))
+	}
+}
+
+func TestCompareFacts(t *testing.T) {
+	pragma := []string{"column a integer", "primary key (a)"}
+	text := []string{"column a integer not null", "primary key (a)"}
+
+	mismatches := compareFacts(pragma, text)
+	expected := []string{"sqlite has column a integer", "the text has column a integer not null"}
+	if !slices.Equal(mismatches, expected) {
+		t.Errorf("expected %v, got %v", expected, mismatches)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	db, err := OpenReadOnly(filepath.Join(t.TempDir(), "missing.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExportDataDefinitions(); err == nil {
+		t.Error("expected a read only database which does not exist to not be created")
+	}
+
+	existing := openSqlite(t, "create table users (id integer primary key);")
+	readOnly, err := OpenReadOnly(existing.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readOnly.Close()
+
+	if _, err := readOnly.ExportDataDefinitions(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readOnly.(*Sqlite).Exec("create table posts (id integer)"); err == nil {
+		t.Error("expected a read only database to refuse a write")
+	}
+}
+
+// TestSnapshotBusy reads a database another connection has locked, it gives
+// up after the busy timeout rather than waiting forever.
+func TestSnapshotBusy(t *testing.T) {
+	writer := openSqlite(t, "create table users (id integer primary key);")
+	conn, err := writer.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(context.Background(), "begin exclusive"); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.ExecContext(context.Background(), "rollback")
+
+	db, err := OpenReadOnly(writer.FileName + "?_busy_timeout=50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExportDataDefinitions(); !errors.Is(err, ErrDatabaseBusy) {
+		t.Errorf("expected ErrDatabaseBusy, got %v", err)
+	}
+}
+
+// TestSnapshotWrite checks the connection used to read the schema can be
+// written with afterwards.
+func TestSnapshotWrite(t *testing.T) {
+	db, err := Open("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, _, err := db.(*Sqlite).Introspect(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.(*Sqlite).Exec("create table users (id integer primary key)"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCopyTo copies a database opened read only.
+func TestCopyTo(t *testing.T) {
+	original := openSqlite(t, "create table users (id integer primary key);")
+	readOnly, err := OpenReadOnly(original.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readOnly.Close()
+
+	path := filepath.Join(t.TempDir(), "copy.db")
+	if err := readOnly.(*Sqlite).CopyTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+
+	expected, _ := original.ExportDataDefinitions()
+	definitions, err := copied.ExportDataDefinitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if definitions != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, definitions)
+	}
+}
@@ -0,0 +1,33 @@
+CREATE SCHEMA "billing";
+
+CREATE TYPE "billing"."status" AS ENUM ('open', 'paid', 'it''s late');
+
+CREATE DOMAIN "email" AS text NOT NULL CHECK ((VALUE ~~ '%@%'::text));
+
+CREATE TABLE "users" (
+    "id" serial PRIMARY KEY,
+    "email" email NOT NULL UNIQUE,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "tags" text[]
+);
+
+CREATE TABLE "billing"."invoices" (
+    "id" bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    "user_id" integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "status" billing.status DEFAULT 'open'::billing.status NOT NULL,
+    "net" numeric(10,2) NOT NULL CONSTRAINT "positive_net" CHECK ((net > (0)::numeric)),
+    "gross" numeric(10,2) GENERATED ALWAYS AS ((net * 1.2)) STORED,
+    CHECK ((gross >= net))
+);
+
+CREATE INDEX users_created_at_idx ON public.users USING btree (created_at DESC);
+
+CREATE INDEX invoices_open_idx ON billing.invoices USING btree (user_id) WHERE (status = 'open'::billing.status);
+
+CREATE VIEW "open_invoices" AS
+SELECT id,
+    user_id,
+    gross
+   FROM billing.invoices
+  WHERE (status = 'open'::billing.status);
+
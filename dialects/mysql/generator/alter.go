@@ -0,0 +1,285 @@
+package mysql
+
+import (
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/diff"
+)
+
+// dropTableConstraint drops a constraint of the table edit changes, mysql
+// drops everything but a primary key by name.
+func dropTableConstraint(edit *diff.EditModifyTable, constraint ast.TableConstraint) (ast.TableAlteration, error) {
+	switch constraint := constraint.(type) {
+	case *ast.TableConstraint_ForeignKey:
+		if constraint.Name != nil {
+			return &ast.DropForeignKey{Name: constraint.Name.Name}, nil
+		}
+	case *ast.TableConstraint_PrimaryKey:
+		return &ast.DropPrimaryKey{}, nil
+	case *ast.TableConstraint_Unique:
+		if constraint.Name != nil {
+			return &ast.DropTableIndex{Name: constraint.Name.Name}, nil
+		}
+	case *ast.TableConstraint_Index:
+		if constraint.Name != nil {
+			return &ast.DropTableIndex{Name: *constraint.Name}, nil
+		}
+	case *ast.TableConstraint_Check:
+		if constraint.Name != nil {
+			return &ast.DropCheck{Name: constraint.Name.Name}, nil
+		}
+	}
+	return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+}
+
+func isForeignKey(constraint ast.TableConstraint) bool {
+	_, ok := constraint.(*ast.TableConstraint_ForeignKey)
+	return ok
+}
+
+// alterTable makes the edits to a table with ALTER TABLE. foreign keys are
+// dropped before the indexes they use and added after them, so mysql never
+// makes an index of its own for one. renames go before adding columns as a
+// column may be added under the name another was renamed away from, they
+// restate the column's definition so there is nothing left to modify.
+func alterTable(edit *diff.EditModifyTable) ([]ast.Statement, error) {
+	dropForeignKeys := []ast.TableAlteration{}
+	dropConstraints := []ast.TableAlteration{}
+	renames := []ast.TableAlteration{}
+	dropColumns := []ast.TableAlteration{}
+	addColumns := []ast.TableAlteration{}
+	modifyColumns := []ast.TableAlteration{}
+	addConstraints := []ast.TableAlteration{}
+	addForeignKeys := []ast.TableAlteration{}
+	options := []ast.TableAlteration{}
+
+	for _, e := range edit.Edits {
+		switch typ := e.(type) {
+		case *diff.EditRenameColumn:
+			column, ok := columnDefinition(edit.Result, typ.To)
+			if !ok {
+				return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+			}
+			renames = append(renames, &ast.ChangeColumn{
+				ColumnName:       typ.From,
+				ColumnDefinition: withoutKeys(column),
+			})
+		case *diff.EditRemoveColumn:
+			dropColumns = append(dropColumns, &ast.DropColumn{
+				DropKeyword:   keyword(tik.TokenKind_Keyword_DROP, "DROP"),
+				ColumnKeyword: columnKeyword(),
+				ColumnName:    typ.ColumnName,
+			})
+		case *diff.EditAddColumn:
+			addColumns = append(addColumns, &ast.AddColumn{
+				AddKeyword:       keyword(tik.TokenKind_Keyword_ADD, "ADD"),
+				ColumnKeyword:    columnKeyword(),
+				ColumnDefinition: typ.ColumnDefinition,
+			})
+		case *diff.EditModifyColumn:
+			column := typ.Result.ColumnName
+			modify := false
+			for _, e := range typ.Edits {
+				switch change := e.(type) {
+				case *diff.EditChangeColumnType:
+					// MODIFY COLUMN converts the values the way mysql does,
+					// there is nowhere to give an expression of one's own.
+					if change.Using != "" {
+						return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+					}
+					modify = true
+				case *diff.EditRemoveColumnConstraint:
+					switch change.ColumnConstraint.(type) {
+					case *ast.ColumnConstraint_ForeignKey:
+						// mysql ignores a foreign key on a column, so there is
+						// nothing to change.
+					case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Unique, *ast.ColumnConstraint_Check:
+						drop, err := dropConstraint(edit, column, change.ColumnConstraint)
+						if err != nil {
+							return nil, err
+						}
+						dropConstraints = append(dropConstraints, drop)
+					default:
+						modify = true
+					}
+				case *diff.EditAddColumnConstraint:
+					switch constraint := change.ColumnConstraint.(type) {
+					case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Unique, *ast.ColumnConstraint_Check:
+						addConstraints = append(addConstraints, addConstraint(column, constraint))
+					case *ast.ColumnConstraint_ForeignKey:
+					default:
+						modify = true
+					}
+				case *diff.EditModifyColumnConstraint:
+					switch change.Result.(type) {
+					case *ast.ColumnConstraint_ForeignKey:
+					case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Unique, *ast.ColumnConstraint_Check:
+						drop, err := dropConstraint(edit, column, change.Target)
+						if err != nil {
+							return nil, err
+						}
+						dropConstraints = append(dropConstraints, drop)
+						addConstraints = append(addConstraints, addConstraint(column, change.Result))
+					default:
+						modify = true
+					}
+				}
+			}
+			if modify && !renamed(edit, column) {
+				modifyColumns = append(modifyColumns, &ast.ModifyColumn{ColumnDefinition: withoutKeys(*typ.Result)})
+			}
+		case *diff.EditRemoveTableConstraint:
+			drop, err := dropTableConstraint(edit, typ.TableConstraint)
+			if err != nil {
+				return nil, err
+			}
+			if isForeignKey(typ.TableConstraint) {
+				dropForeignKeys = append(dropForeignKeys, drop)
+			} else {
+				dropConstraints = append(dropConstraints, drop)
+			}
+		case *diff.EditAddTableConstraint:
+			if isForeignKey(typ.TableConstraint) {
+				addForeignKeys = append(addForeignKeys, &ast.AddConstraint{Constraint: typ.TableConstraint})
+			} else {
+				addConstraints = append(addConstraints, &ast.AddConstraint{Constraint: typ.TableConstraint})
+			}
+		case *diff.EditModifyTableConstraint:
+			drop, err := dropTableConstraint(edit, typ.Target)
+			if err != nil {
+				return nil, err
+			}
+			if isForeignKey(typ.Target) {
+				dropForeignKeys = append(dropForeignKeys, drop)
+				addForeignKeys = append(addForeignKeys, &ast.AddConstraint{Constraint: typ.Result})
+			} else {
+				dropConstraints = append(dropConstraints, drop)
+				addConstraints = append(addConstraints, &ast.AddConstraint{Constraint: typ.Result})
+			}
+		case *diff.EditSetTableOption:
+			// an option the desired schema stopped naming is left as it is.
+			if typ.To == nil {
+				continue
+			}
+			options = append(options, &ast.TableOption{
+				Name:  keyword(tik.TokenKind_Identifier, typ.Name),
+				Value: typ.To,
+			})
+		default:
+			return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+		}
+	}
+
+	statements := []ast.Statement{}
+	for _, alteration := range slices.Concat(dropForeignKeys, dropConstraints, renames, dropColumns, addColumns, modifyColumns, addConstraints, addForeignKeys, options) {
+		statements = append(statements, alter(edit.Target.TableIdentifier, alteration))
+	}
+
+	return statements, nil
+}
+
+// columnDefinition is the definition of the column named name in table.
+func columnDefinition(table *ast.CreateTable, name ast.Identifier) (ast.ColumnDefinition, bool) {
+	for _, column := range table.TableDefinition.ColumnDefinitions {
+		if strings.EqualFold(column.ColumnName.Text, name.Text) {
+			return column, true
+		}
+	}
+	return ast.ColumnDefinition{}, false
+}
+
+// renamed reports whether column is the new name of a column renamed by
+// edit.
+func renamed(edit *diff.EditModifyTable, column ast.Identifier) bool {
+	return slices.ContainsFunc(edit.Edits, func(e diff.Edit) bool {
+		rename, ok := e.(*diff.EditRenameColumn)
+		return ok && strings.EqualFold(rename.To.Text, column.Text)
+	})
+}
+
+// withoutKeys is a column definition without the constraints mysql keeps as
+// indexes or on the table, MODIFY COLUMN would add them a second time.
+func withoutKeys(column ast.ColumnDefinition) ast.ColumnDefinition {
+	column.ColumnConstraints = slices.DeleteFunc(slices.Clone(column.ColumnConstraints), func(constraint ast.ColumnConstraint) bool {
+		switch constraint.(type) {
+		case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Unique, *ast.ColumnConstraint_Check, *ast.ColumnConstraint_ForeignKey:
+			return true
+		default:
+			return false
+		}
+	})
+	return column
+}
+
+func columnKeyword() *ast.Keyword {
+	column := keyword(tik.TokenKind_Keyword_COLUMN, "COLUMN")
+	return &column
+}
+
+// addConstraint adds a constraint of a column as the same constraint on the
+// table, a unique key is named after the column as mysql would name it.
+func addConstraint(column ast.Identifier, constraint ast.ColumnConstraint) *ast.AddConstraint {
+	columns := []ast.IndexedColumn{{Subject: &column}}
+
+	var tableConstraint ast.TableConstraint
+	switch constraint := constraint.(type) {
+	case *ast.ColumnConstraint_PrimaryKey:
+		tableConstraint = ast.MakeTableConstraintPrimaryKey(
+			nil,
+			keyword(tik.TokenKind_Keyword_PRIMARY, "PRIMARY"),
+			keyword(tik.TokenKind_Keyword_KEY, "KEY"),
+			tik.Token{},
+			columns,
+			tik.Token{},
+			nil,
+			nil,
+		)
+	case *ast.ColumnConstraint_Unique:
+		tableConstraint = ast.MakeTableConstraintUnique(
+			&ast.ConstraintName{
+				ConstraintKeyword: keyword(tik.TokenKind_Keyword_CONSTRAINT, "CONSTRAINT"),
+				Name:              constraintName(constraint.Name, column),
+			},
+			keyword(tik.TokenKind_Keyword_UNIQUE, "UNIQUE"),
+			tik.Token{},
+			columns,
+			tik.Token{},
+			nil,
+		)
+	case *ast.ColumnConstraint_Check:
+		tableConstraint = ast.MakeTableConstraintCheck(
+			constraint.Name,
+			keyword(tik.TokenKind_Keyword_CHECK, "CHECK"),
+			tik.Token{},
+			constraint.Check,
+			tik.Token{},
+		)
+	}
+
+	return &ast.AddConstraint{Constraint: tableConstraint}
+}
+
+func dropConstraint(edit diff.Edit, column ast.Identifier, constraint ast.ColumnConstraint) (ast.TableAlteration, error) {
+	switch constraint := constraint.(type) {
+	case *ast.ColumnConstraint_PrimaryKey:
+		return &ast.DropPrimaryKey{}, nil
+	case *ast.ColumnConstraint_Unique:
+		return &ast.DropTableIndex{Name: constraintName(constraint.Name, column)}, nil
+	case *ast.ColumnConstraint_Check:
+		if constraint.Name != nil {
+			return &ast.DropCheck{Name: constraint.Name.Name}, nil
+		}
+	}
+	return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+}
+
+// constraintName is the name of a constraint, or the name mysql gives a
+// unique key which was not named, the name of its column.
+func constraintName(name *ast.ConstraintName, column ast.Identifier) ast.Identifier {
+	if name != nil {
+		return name.Name
+	}
+	return identifier(column.Text)
+}
@@ -0,0 +1,285 @@
+package mysql
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/formatter"
+)
+
+type MysqlGenerator struct {
+	edits []diff.Edit
+}
+
+func NewMysqlGenerator(edits []diff.Edit) *MysqlGenerator {
+	return &MysqlGenerator{
+		edits: edits,
+	}
+}
+
+// Migration is what a generator produces, the statements of the migration
+// and the sql they render to.
+type Migration struct {
+	Statements []ast.Statement
+	Sql        string
+}
+
+// Generate writes the migration to writer.
+func (gen *MysqlGenerator) Generate(writer io.Writer) error {
+	migration, err := gen.Migration()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(writer, migration.Sql)
+	return err
+}
+
+// Migration builds the statements which make the edits, an edit it does not
+// know how to make is returned as a *diff.EditError.
+func (gen *MysqlGenerator) Migration() (*Migration, error) {
+
+	// mysql changes tables in place like postgres does, but it commits
+	// after every statement that changes the schema, so the migration is
+	// not wrapped in a transaction. statements are emitted in three phases:
+	//   1. views which are going away or changing
+	//   2. tables, and the indexes on them
+	//   3. views which are new or changed
+	drops := []*ast.CreateView{}
+	tables := []ast.Statement{}
+	creates := []*ast.CreateView{}
+
+	for _, edit := range gen.edits {
+		switch typ := edit.(type) {
+		case *diff.EditAddTable:
+			{
+				tables = append(tables, typ.CreateTable)
+			}
+		case *diff.EditRemoveTable:
+			{
+				tables = append(tables, dropTable(typ.TableIdentifier))
+			}
+		case *diff.EditRenameTable:
+			{
+				tables = append(tables, renameTable(typ.From, typ.To))
+			}
+		case *diff.EditModifyTable:
+			{
+				statements, err := alterTable(typ)
+				if err != nil {
+					return nil, err
+				}
+				tables = slices.Concat(tables, statements)
+			}
+		case *diff.EditAddIndex:
+			{
+				tables = append(tables, addIndex(typ.CreateIndex))
+			}
+		case *diff.EditRemoveIndex:
+			{
+				tables = append(tables, dropIndex(typ.CreateIndex))
+			}
+		case *diff.EditReplaceIndex:
+			{
+				tables = append(tables, dropIndex(typ.Target), addIndex(typ.Result))
+			}
+		case *diff.EditAddView:
+			{
+				creates = append(creates, typ.CreateView)
+			}
+		case *diff.EditRemoveView:
+			{
+				drops = append(drops, typ.CreateView)
+			}
+		case *diff.EditReplaceView:
+			{
+				drops = append(drops, typ.Target)
+				creates = append(creates, typ.Result)
+			}
+
+		default:
+			{
+				return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+			}
+		}
+	}
+
+	statements := []ast.Statement{}
+
+	// views are dropped before any view they select from, and created after.
+	dropOrder := orderViews(drops)
+	slices.Reverse(dropOrder)
+	for _, view := range dropOrder {
+		statements = append(statements, dropView(view))
+	}
+
+	statements = slices.Concat(statements, tables)
+
+	for _, view := range orderViews(creates) {
+		statements = append(statements, view)
+	}
+
+	sql := strings.Builder{}
+	core := formatter.NewCoreFormatter(&sql, 80, "``")
+
+	for _, statement := range statements {
+		statement.ToSql(core)
+		core.Rune(';')
+		core.Break()
+		core.Break()
+	}
+
+	return &Migration{
+		Statements: statements,
+		Sql:        sql.String(),
+	}, nil
+}
+
+// orderViews sorts views so that every view comes after the views it selects
+// from, a view which appears more than once is kept once.
+func orderViews(views []*ast.CreateView) []*ast.CreateView {
+	seen := map[string]bool{}
+	unique := []*ast.CreateView{}
+	for _, view := range views {
+		name := strings.ToLower(view.ViewIdentifier.ObjectName.Text)
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, view)
+		}
+	}
+
+	result := make([]*ast.CreateView, 0, len(unique))
+	placed := make([]bool, len(unique))
+
+	selectsFrom := func(view, other *ast.CreateView) bool {
+		return slices.ContainsFunc(view.AsSelect.Tokens, func(token tik.Token) bool {
+			return token.Kind == tik.TokenKind_Identifier &&
+				strings.EqualFold(token.Text, other.ViewIdentifier.ObjectName.Text)
+		})
+	}
+
+	for len(result) < len(unique) {
+		progressed := false
+		for i, view := range unique {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for j, other := range unique {
+				if i != j && !placed[j] && selectsFrom(view, other) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				result = append(result, view)
+				placed[i] = true
+				progressed = true
+			}
+		}
+		if !progressed {
+			for i, view := range unique {
+				if !placed[i] {
+					result = append(result, view)
+					placed[i] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func renameTable(from, to *ast.CatalogObjectIdentifier) *ast.AlterTable {
+	return alter(from, &ast.RenameTable{
+		RenameKeyword: keyword(tik.TokenKind_Keyword_RENAME, "RENAME"),
+		ToKeyword:     keyword(tik.TokenKind_Keyword_TO, "TO"),
+		NewName:       to.ObjectName,
+	})
+}
+
+// addIndex adds an index with ALTER TABLE, mysql keeps the kind of a
+// fulltext or spatial index where postgres keeps its method.
+func addIndex(index *ast.CreateIndex) *ast.AlterTable {
+	name := index.IndexIdentifier.ObjectName
+
+	if index.IsUnique() {
+		return alter(index.OnTable, &ast.AddConstraint{
+			Constraint: ast.MakeTableConstraintUnique(
+				&ast.ConstraintName{
+					ConstraintKeyword: keyword(tik.TokenKind_Keyword_CONSTRAINT, "CONSTRAINT"),
+					Name:              name,
+				},
+				keyword(tik.TokenKind_Keyword_UNIQUE, "UNIQUE"),
+				tik.Token{},
+				index.IndexedColumns,
+				tik.Token{},
+				nil,
+			),
+		})
+	}
+
+	var kind *ast.Keyword = nil
+	if index.Using != nil {
+		using := keyword(tik.TokenKind_Identifier, strings.ToUpper(index.Using.Text))
+		kind = &using
+	}
+	return alter(index.OnTable, &ast.AddConstraint{
+		Constraint: ast.MakeTableConstraintIndex(kind, keyword(tik.TokenKind_Keyword_INDEX, "INDEX"), &name, index.IndexedColumns),
+	})
+}
+
+// dropIndex drops an index from its table, the name of an index is only
+// unique within its table in mysql.
+func dropIndex(index *ast.CreateIndex) *ast.AlterTable {
+	return alter(index.OnTable, &ast.DropTableIndex{Name: index.IndexIdentifier.ObjectName})
+}
+
+func dropView(view *ast.CreateView) *ast.DropView {
+	return &ast.DropView{
+		IfExists:       ifExists(),
+		ViewIdentifier: *view.ViewIdentifier,
+	}
+}
+
+// dropTable does not use IF EXISTS, a migration which expects to drop a
+// table that is not there has been generated against a different database
+// and should fail.
+func dropTable(tableIdentifier *ast.CatalogObjectIdentifier) *ast.DropTable {
+	return &ast.DropTable{
+		TableIdentifier: *tableIdentifier,
+	}
+}
+
+func alter(table *ast.CatalogObjectIdentifier, alteration ast.TableAlteration) *ast.AlterTable {
+	return &ast.AlterTable{
+		AlterKeyword:    keyword(tik.TokenKind_Keyword_ALTER, "ALTER"),
+		TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+		TableIdentifier: table,
+		Alteration:      alteration,
+	}
+}
+
+func identifier(name string) ast.Identifier {
+	return ast.Identifier(tik.Token{
+		Text: name,
+		Kind: tik.TokenKind_Identifier,
+	})
+}
+
+func keyword(kind tik.TokenKind, text string) ast.Keyword {
+	return ast.Keyword(tik.Token{
+		Text: text,
+		Kind: kind,
+	})
+}
+
+func ifExists() *ast.IfExists {
+	return &ast.IfExists{
+		If:     keyword(tik.TokenKind_Keyword_IF, "IF"),
+		Exists: keyword(tik.TokenKind_Keyword_EXISTS, "EXISTS"),
+	}
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	mysqlparser "woodybriggs/justmigrate/dialects/mysql/parser"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/internal/testmigration"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden migrations in testdata")
+
+func generate(t *testing.T, from, to string) (string, error) {
+	return testmigration.Generate(t, mysqlparser.NewMysqlParser, NewMysqlGenerator, diff.Diff{NormalizeColumn: mysqlparser.NormalizeColumn}, from, to)
+}
+
+// TestGolden generates the migration from current.sql to desired.sql for
+// each directory in testdata and compares it with migration.sql, run with
+// -update to accept the new output.
+func TestGolden(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			current, err := os.ReadFile(filepath.Join(dir, "current.sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			desired, err := os.ReadFile(filepath.Join(dir, "desired.sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sql, err := generate(t, string(current), string(desired))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(dir, "migration.sql")
+			if *update {
+				if err := os.WriteFile(golden, []byte(sql), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != string(expected) {
+				t.Fatalf("migration does not match %s:\n%s", golden, sql)
+			}
+		})
+	}
+}
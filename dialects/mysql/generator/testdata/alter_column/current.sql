@@ -0,0 +1,7 @@
+CREATE TABLE users (
+    id int unsigned PRIMARY KEY AUTO_INCREMENT,
+    email varchar(100) NOT NULL,
+    nickname varchar(50),
+    is_admin boolean NOT NULL DEFAULT false,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+CREATE TABLE users (
+    id int unsigned PRIMARY KEY AUTO_INCREMENT,
+    email varchar(255) NOT NULL UNIQUE,
+    nickname varchar(50) COMMENT 'shown to other users',
+    is_admin boolean NOT NULL DEFAULT true,
+    updated_at datetime ON UPDATE CURRENT_TIMESTAMP
+);
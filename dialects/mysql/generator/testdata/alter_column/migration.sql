@@ -0,0 +1,18 @@
+ALTER TABLE `users` DROP COLUMN `created_at`;
+
+ALTER TABLE
+    `users`
+ADD COLUMN
+    `updated_at` datetime ON UPDATE CURRENT_TIMESTAMP();
+
+ALTER TABLE `users` MODIFY COLUMN `email` varchar(255) NOT NULL;
+
+ALTER TABLE
+    `users`
+MODIFY COLUMN
+    `nickname` varchar(50) COMMENT 'shown to other users';
+
+ALTER TABLE `users` MODIFY COLUMN `is_admin` tinyint(1) NOT NULL DEFAULT '1';
+
+ALTER TABLE `users` ADD CONSTRAINT `email` UNIQUE (`email`);
+
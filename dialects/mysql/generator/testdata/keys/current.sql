@@ -0,0 +1,12 @@
+CREATE TABLE users (
+    id int PRIMARY KEY,
+    name varchar(100)
+);
+
+CREATE TABLE notes (
+    id int,
+    user_id int,
+    body text,
+    CONSTRAINT notes_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CHECK (id > 0)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE users (
+    id int PRIMARY KEY,
+    name varchar(100),
+    INDEX (name)
+);
+
+CREATE TABLE notes (
+    id int PRIMARY KEY,
+    user_id int,
+    body text,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CHECK (id > 0)
+);
+
+CREATE FULLTEXT INDEX body_search ON notes (body);
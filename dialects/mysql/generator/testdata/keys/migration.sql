@@ -0,0 +1,15 @@
+ALTER TABLE `users` ADD INDEX `name` (`name`);
+
+ALTER TABLE `notes` DROP FOREIGN KEY `notes_user`;
+
+ALTER TABLE `notes` DROP INDEX `notes_user`;
+
+ALTER TABLE `notes` ADD PRIMARY KEY (`id`);
+
+ALTER TABLE `notes` ADD INDEX `user_id` (`user_id`);
+
+ALTER TABLE `notes` ADD FULLTEXT INDEX `body_search` (`body`);
+
+ALTER TABLE `notes` ADD CONSTRAINT `notes_ibfk_1` FOREIGN KEY (`user_id`)
+        REFERENCES `users` (`id`) ON DELETE CASCADE;
+
@@ -0,0 +1,5 @@
+CREATE TABLE users (
+    id int unsigned PRIMARY KEY AUTO_INCREMENT,
+    name varchar(50) NOT NULL,
+    bio text
+);
@@ -0,0 +1,7 @@
+CREATE TABLE users (
+    id int unsigned PRIMARY KEY AUTO_INCREMENT,
+    -- justmigrate:renamed-from name
+    full_name varchar(100) NOT NULL,
+    -- justmigrate:renamed-from bio
+    about text
+);
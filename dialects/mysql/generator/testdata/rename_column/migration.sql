@@ -0,0 +1,4 @@
+ALTER TABLE `users` CHANGE COLUMN `name` `full_name` varchar(100) NOT NULL;
+
+ALTER TABLE `users` CHANGE COLUMN `bio` `about` text;
+
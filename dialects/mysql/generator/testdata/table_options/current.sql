@@ -0,0 +1,3 @@
+CREATE TABLE events (
+    id bigint PRIMARY KEY
+) ENGINE=MyISAM;
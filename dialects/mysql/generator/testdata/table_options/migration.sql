@@ -0,0 +1,4 @@
+ALTER TABLE `events` ENGINE=`InnoDB`;
+
+ALTER TABLE `events` CHARSET=`utf8mb4`;
+
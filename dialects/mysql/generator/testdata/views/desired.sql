@@ -0,0 +1,8 @@
+CREATE TABLE orders (
+    id int PRIMARY KEY,
+    status varchar(10)
+);
+
+CREATE VIEW open_orders AS SELECT id, status FROM orders WHERE status <> 'paid';
+
+CREATE VIEW open_order_count AS SELECT count(*) AS n FROM open_orders;
@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS `open_orders`;
+
+CREATE VIEW `open_orders` AS
+    SELECT id, status FROM orders WHERE status <> 'paid';
+
@@ -0,0 +1,427 @@
+package mysql
+
+import (
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+func (p *MysqlParser) CreateStatement() ast.Statement {
+	p.PushParseContext("create statement")
+	defer p.PopParseContext()
+
+	switch peeked := p.Peeked(); peeked.Kind {
+	case tik.TokenKind_Keyword_TABLE:
+		return p.CreateTableStatement()
+	case tik.TokenKind_Keyword_VIEW, tik.TokenKind_Keyword_OR:
+		return p.CreateViewStatement()
+	case tik.TokenKind_Keyword_INDEX:
+		return p.CreateIndexStatement()
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.CreateIndexStatement()
+	default:
+		if parser.IsWord(peeked, "fulltext") || parser.IsWord(peeked, "spatial") {
+			return p.CreateIndexStatement()
+		} else if parser.IsWord(peeked, "algorithm") || parser.IsWord(peeked, "definer") || parser.IsWord(peeked, "sql") {
+			return p.CreateViewStatement()
+		}
+
+		err := report.
+			NewReport("parse error").
+			WithLabels([]report.Label{
+				{
+					Source: p.Current().SourceCode,
+					Range:  p.Current().SourceRange,
+					Note:   "unknown token for create statement",
+				},
+			})
+		p.ReportError(err)
+		return nil
+	}
+}
+
+func (p *MysqlParser) CreateTableStatement() *ast.CreateTable {
+	p.PushParseContext("create table statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	var temporaryKeyword *ast.Keyword = nil
+	if token, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_TEMPORARY); ok {
+		temporaryKeyword = ast.MakeKeyword(token)
+	}
+
+	tableKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_TABLE))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	tableDefinition := p.TableDefinition(tableIdent.ObjectName.Text)
+
+	tableOptions := p.TableOptions()
+
+	return ast.MakeCreateTable(
+		createKeyword,
+		temporaryKeyword,
+		tableKeyword,
+		ifnotexists,
+		tableIdent,
+		tableDefinition,
+		tableOptions,
+	)
+}
+
+// tableOptionNames maps each spelling of a table option onto the one it is
+// named by, `DEFAULT CHARSET` and `CHARACTER SET` are the same option.
+var tableOptionNames = map[string]string{
+	"engine":             "ENGINE",
+	"charset":            "CHARSET",
+	"character set":      "CHARSET",
+	"collate":            "COLLATE",
+	"comment":            "COMMENT",
+	"row_format":         "ROW_FORMAT",
+	"auto_increment":     "AUTO_INCREMENT",
+	"key_block_size":     "KEY_BLOCK_SIZE",
+	"stats_persistent":   "STATS_PERSISTENT",
+	"stats_auto_recalc":  "STATS_AUTO_RECALC",
+	"stats_sample_pages": "STATS_SAMPLE_PAGES",
+	"pack_keys":          "PACK_KEYS",
+	"checksum":           "CHECKSUM",
+	"compression":        "COMPRESSION",
+	"encryption":         "ENCRYPTION",
+	"min_rows":           "MIN_ROWS",
+	"max_rows":           "MAX_ROWS",
+	"avg_row_length":     "AVG_ROW_LENGTH",
+}
+
+// TableOptions parses the options after the table definition. The
+// AUTO_INCREMENT option is the next value of the counter rather than part of
+// the schema, so it is dropped.
+func (p *MysqlParser) TableOptions() *ast.TableOptions {
+	p.PushParseContext("table options")
+	defer p.PopParseContext()
+
+	options := []ast.TableOption{}
+
+	for !p.EndOfFile() && p.Current().Kind != ';' {
+		if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		}
+
+		var defaultKeyword *ast.Keyword = nil
+		if p.Current().Kind == tik.TokenKind_Keyword_DEFAULT {
+			keyword := ast.Keyword(p.Current())
+			defaultKeyword = &keyword
+			p.Advance()
+		}
+
+		nameToken := p.Current()
+		p.Advance()
+		words := []ast.Keyword{ast.Keyword(nameToken)}
+		spelling := strings.ToLower(nameToken.SourceText())
+		if spelling == "character" {
+			words = append(words, ast.Keyword(p.Expect(tik.TokenKind_Keyword_SET)))
+			spelling = "character set"
+		}
+
+		name, ok := tableOptionNames[spelling]
+		if !ok {
+			p.ReportError(
+				report.
+					NewReport("parse error").
+					WithLabels([]report.Label{
+						{
+							Source: nameToken.SourceCode,
+							Range:  nameToken.SourceRange,
+							Note:   "unknown table option",
+						},
+					}),
+			)
+			return nil
+		}
+
+		if p.Current().Kind == '=' {
+			p.Advance()
+		}
+
+		var value ast.Expr = nil
+		switch p.Current().Kind {
+		case tik.TokenKind_StringLiteral:
+			value = p.StringLiteral()
+		case tik.TokenKind_DecimalNumericLiteral:
+			value = p.NumericLiteral()
+		default:
+			// engines, character sets and collations are not case sensitive,
+			// they are compared as such rather than written in one case.
+			ident := p.Identifier()
+			value = &ident
+		}
+
+		if name == "AUTO_INCREMENT" {
+			continue
+		}
+
+		nameToken.Text = name
+		options = append(options, ast.TableOption{
+			Default:  defaultKeyword,
+			Spelling: words,
+			Name:     ast.Keyword(nameToken),
+			Value:    value,
+		})
+	}
+
+	return &ast.TableOptions{Options: options}
+}
+
+// CreateViewStatement parses a view, the algorithm, definer and sql security
+// mysql prints in front of a view are not part of its definition and are
+// skipped.
+func (p *MysqlParser) CreateViewStatement() *ast.CreateView {
+	p.PushParseContext("create view statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	// a view is always created from scratch, so OR REPLACE changes nothing.
+	if _, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_OR); ok {
+		p.Expect(tik.TokenKind_Keyword_REPLACE)
+	}
+
+	for !p.EndOfFile() && p.Current().Kind != tik.TokenKind_Keyword_VIEW {
+		if p.IsWord("sql") {
+			p.Advance()
+			p.ExpectWord("security")
+			p.Advance()
+			continue
+		}
+		if !p.IsWord("algorithm") && !p.IsWord("definer") {
+			break
+		}
+		p.Advance()
+		if p.Current().Kind == '=' {
+			p.Advance()
+		}
+		p.Advance()
+		// a definer is `user`@`host`
+		if p.Current().Kind == tik.TokenKind_At {
+			p.Advance()
+			p.Advance()
+		}
+	}
+
+	viewKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_VIEW))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	viewIdent := p.CatalogObjectIdentifier()
+
+	columnNames := []ast.Identifier{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				columnName := p.Identifier()
+				columnNames = append(columnNames, columnName)
+			}
+		}
+		p.Expect(')')
+	}
+
+	asKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_AS))
+
+	selectStmt := p.SelectStatement()
+
+	return ast.MakeCreateView(
+		createKeyword,
+		nil,
+		viewKeyword,
+		ifnotexists,
+		viewIdent,
+		columnNames,
+		asKeyword,
+		selectStmt,
+	)
+}
+
+// SelectStatement collects the tokens of a select statement up until the end
+// of the statement, the select itself is never interpreted.
+func (p *MysqlParser) SelectStatement() *ast.Select {
+	p.PushParseContext("select statement")
+	defer p.PopParseContext()
+
+	tokens := []tik.Token{p.Expect(tik.TokenKind_Keyword_SELECT)}
+
+	depth := 0
+	for !p.EndOfFile() {
+		if p.Current().Kind == ';' && depth == 0 {
+			break
+		} else if p.Current().Kind == ')' && depth == 0 {
+			break
+		} else if p.Current().Kind == '(' {
+			depth++
+		} else if p.Current().Kind == ')' {
+			depth--
+		}
+		tokens = append(tokens, p.Current())
+		p.Advance()
+	}
+
+	return ast.MakeSelect(tokens)
+}
+
+// CreateIndexStatement parses `CREATE [UNIQUE | FULLTEXT | SPATIAL] INDEX`,
+// the kind of a fulltext or spatial index is kept in Using.
+func (p *MysqlParser) CreateIndexStatement() *ast.CreateIndex {
+	p.PushParseContext("create index statement")
+	defer p.PopParseContext()
+
+	var uniqueKeyword *ast.Keyword = nil
+	var kind *ast.Identifier = nil
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	if token, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_UNIQUE); ok {
+		uniqueKeyword = ast.MakeKeyword(token)
+	} else if p.IsWord("fulltext") || p.IsWord("spatial") {
+		ident := ast.Identifier(p.Current())
+		ident.Text = strings.ToLower(ident.Text)
+		kind = &ident
+		p.Advance()
+	}
+
+	indexKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_INDEX))
+
+	indexIdent := p.CatalogObjectIdentifier()
+
+	p.MaybeIndexType()
+
+	onKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ON))
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	_, indexedColumns, _ := p.IndexedColumns(true)
+
+	p.MaybeIndexType()
+
+	createIndex := ast.MakeCreateIndex(
+		createKeyword,
+		uniqueKeyword,
+		indexKeyword,
+		nil,
+		indexIdent,
+		onKeyword,
+		tableIdent,
+		indexedColumns,
+		nil,
+	)
+	createIndex.Using = kind
+
+	return createIndex
+}
+
+// foldIndexes moves each CREATE INDEX onto the table it indexes, which is
+// where mysql keeps it and where SHOW CREATE TABLE prints it. An index which
+// can be used by a foreign key replaces the one mysql made for it.
+func foldIndexes(statements []ast.Statement) []ast.Statement {
+	tables := map[string]*ast.CreateTable{}
+	for _, statement := range statements {
+		if table, ok := statement.(*ast.CreateTable); ok {
+			tables[strings.ToLower(table.TableIdentifier.ObjectName.Text)] = table
+		}
+	}
+
+	return slices.DeleteFunc(statements, func(statement ast.Statement) bool {
+		index, ok := statement.(*ast.CreateIndex)
+		if !ok {
+			return false
+		}
+		table, ok := tables[strings.ToLower(index.OnTable.ObjectName.Text)]
+		if !ok {
+			return false
+		}
+
+		definition := table.TableDefinition
+		name := index.IndexIdentifier.ObjectName
+
+		var constraint ast.TableConstraint
+		if index.IsUnique() {
+			constraint = ast.MakeTableConstraintUnique(
+				&ast.ConstraintName{
+					ConstraintKeyword: ast.Keyword{Kind: tik.TokenKind_Keyword_CONSTRAINT, Text: "CONSTRAINT"},
+					Name:              name,
+				},
+				*index.Unique,
+				tik.Token{},
+				index.IndexedColumns,
+				tik.Token{},
+				nil,
+			)
+		} else {
+			var kind *ast.Keyword = nil
+			if index.Using != nil {
+				kind = &ast.Keyword{Kind: tik.TokenKind_Identifier, Text: strings.ToUpper(index.Using.Text)}
+			}
+			constraint = ast.MakeTableConstraintIndex(kind, index.IndexKeyword, &name, index.IndexedColumns)
+		}
+
+		if added, ok := constraint.(*ast.TableConstraint_Index); !ok || added.Kind == nil {
+			definition.TableConstraints = slices.DeleteFunc(definition.TableConstraints, func(other ast.TableConstraint) bool {
+				return isForeignKeyIndex(other, definition.TableConstraints) && coversIndex(index.IndexedColumns, other)
+			})
+		}
+
+		definition.TableConstraints = append(definition.TableConstraints, constraint)
+		definition.TableConstraints = moveColumnConstraints(definition.ColumnDefinitions, definition.TableConstraints)
+		return true
+	})
+}
+
+// isForeignKeyIndex is whether constraint is the index mysql made for a
+// foreign key, which is named after it and indexes just its columns.
+func isForeignKeyIndex(constraint ast.TableConstraint, tableConstraints []ast.TableConstraint) bool {
+	index, ok := constraint.(*ast.TableConstraint_Index)
+	if !ok || index.Kind != nil || index.Name == nil {
+		return false
+	}
+	return slices.ContainsFunc(tableConstraints, func(other ast.TableConstraint) bool {
+		foreignKey, ok := other.(*ast.TableConstraint_ForeignKey)
+		return ok &&
+			foreignKey.Name != nil &&
+			strings.EqualFold(foreignKey.Name.Name.Text, index.Name.Text) &&
+			len(foreignKey.Columns) == len(index.IndexedColumns) &&
+			beginsWith(index.IndexedColumns, foreignKey.Columns)
+	})
+}
+
+// coversIndex is whether an index on columns begins with every column of the
+// index constraint.
+func coversIndex(columns []ast.IndexedColumn, constraint ast.TableConstraint) bool {
+	index := constraint.(*ast.TableConstraint_Index)
+	identifiers := []ast.Identifier{}
+	for _, column := range index.IndexedColumns {
+		ident, ok := indexedColumnSubject(column)
+		if !ok {
+			return false
+		}
+		identifiers = append(identifiers, *ident)
+	}
+	return beginsWith(columns, identifiers)
+}
+
+// MaybeIndexType skips `USING BTREE` or `USING HASH`, innodb only has btree
+// indexes and uses one whichever is asked for.
+func (p *MysqlParser) MaybeIndexType() {
+	if _, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_USING); ok {
+		p.Identifier()
+	}
+}
@@ -0,0 +1,781 @@
+package mysql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+type MysqlParser struct {
+	*parser.Grammar
+}
+
+func NewMysqlParser(lexer *luther.Lexer) *MysqlParser {
+	result := &MysqlParser{}
+	result.Grammar = parser.NewGrammar(lexer, result)
+	return result
+}
+
+func (p *MysqlParser) TableDefinition(table string) *ast.TableDefinition {
+	p.PushParseContext("table definition")
+	defer p.PopParseContext()
+
+	lParen := p.Expect('(')
+
+	columnDefs := p.ColumnDefinitions()
+	tableConstraints := p.TableConstraints()
+
+	rParen := p.Closing(')')
+
+	tableConstraints = slices.Concat(moveColumnChecks(columnDefs), tableConstraints)
+	tableConstraints = addForeignKeyIndexes(tableConstraints)
+	tableConstraints = nameTableConstraints(table, tableConstraints)
+	tableConstraints = moveColumnConstraints(columnDefs, tableConstraints)
+
+	return ast.MakeTableDefinition(
+		lParen,
+		columnDefs,
+		tableConstraints,
+		rParen,
+	)
+}
+
+// moveColumnChecks takes the checks off of the columns, mysql keeps every
+// check on the table and numbers the ones written on a column first.
+func moveColumnChecks(columnDefs []ast.ColumnDefinition) []ast.TableConstraint {
+	checks := []ast.TableConstraint{}
+	for i, def := range columnDefs {
+		columnDefs[i].ColumnConstraints = slices.DeleteFunc(def.ColumnConstraints, func(constraint ast.ColumnConstraint) bool {
+			check, ok := constraint.(*ast.ColumnConstraint_Check)
+			if ok {
+				checks = append(checks, ast.MakeTableConstraintCheck(
+					check.Name,
+					ast.Keyword{Kind: tik.TokenKind_Keyword_CHECK, Text: "CHECK"},
+					tik.Token{},
+					check.Check,
+					tik.Token{},
+				))
+			}
+			return ok
+		})
+	}
+	return checks
+}
+
+// nameTableConstraints gives the constraints and indexes without a name the
+// name mysql gives them, so they are the same as the named ones which come
+// back out of the database. Checks and foreign keys are numbered in the
+// order they appear, an index or unique key is named after its first column.
+func nameTableConstraints(table string, tableConstraints []ast.TableConstraint) []ast.TableConstraint {
+	checks, foreignKeys := 0, 0
+	for _, constraint := range tableConstraints {
+		switch constraint := constraint.(type) {
+		case *ast.TableConstraint_Check:
+			checks++
+			if constraint.Name == nil {
+				constraint.Name = constraintName(fmt.Sprintf("%s_chk_%d", table, checks))
+			}
+		case *ast.TableConstraint_ForeignKey:
+			foreignKeys++
+			if constraint.Name == nil {
+				constraint.Name = constraintName(fmt.Sprintf("%s_ibfk_%d", table, foreignKeys))
+			}
+		case *ast.TableConstraint_Unique:
+			if name, ok := firstColumnName(constraint.IndexedColumns); ok && constraint.Name == nil {
+				constraint.Name = constraintName(name)
+			}
+		case *ast.TableConstraint_Index:
+			if name, ok := firstColumnName(constraint.IndexedColumns); ok && constraint.Name == nil {
+				ident := ast.Identifier{Kind: tik.TokenKind_Identifier, Text: name}
+				constraint.Name = &ident
+			}
+		}
+	}
+	return tableConstraints
+}
+
+func constraintName(name string) *ast.ConstraintName {
+	return &ast.ConstraintName{
+		ConstraintKeyword: ast.Keyword{Kind: tik.TokenKind_Keyword_CONSTRAINT, Text: "CONSTRAINT"},
+		Name:              ast.Identifier{Kind: tik.TokenKind_Identifier, Text: name},
+	}
+}
+
+func firstColumnName(columns []ast.IndexedColumn) (string, bool) {
+	if len(columns) == 0 {
+		return "", false
+	}
+	ident, ok := indexedColumnSubject(columns[0])
+	if !ok {
+		return "", false
+	}
+	return ident.Text, true
+}
+
+// addForeignKeyIndexes adds the index mysql creates for a foreign key whose
+// columns do not begin an index already, it is named after the constraint
+// when the constraint was named and after its first column when not.
+func addForeignKeyIndexes(tableConstraints []ast.TableConstraint) []ast.TableConstraint {
+	for _, constraint := range tableConstraints {
+		foreignKey, ok := constraint.(*ast.TableConstraint_ForeignKey)
+		if !ok {
+			continue
+		}
+
+		indexed := slices.ContainsFunc(tableConstraints, func(constraint ast.TableConstraint) bool {
+			switch constraint := constraint.(type) {
+			case *ast.TableConstraint_PrimaryKey:
+				return beginsWith(constraint.IndexedColumns, foreignKey.Columns)
+			case *ast.TableConstraint_Unique:
+				return beginsWith(constraint.IndexedColumns, foreignKey.Columns)
+			case *ast.TableConstraint_Index:
+				return constraint.Kind == nil && beginsWith(constraint.IndexedColumns, foreignKey.Columns)
+			default:
+				return false
+			}
+		})
+		if indexed {
+			continue
+		}
+
+		columns := []ast.IndexedColumn{}
+		for _, column := range foreignKey.Columns {
+			column := column
+			columns = append(columns, ast.IndexedColumn{Subject: &column})
+		}
+		var name *ast.Identifier = nil
+		if foreignKey.Name != nil {
+			name = &foreignKey.Name.Name
+		}
+		tableConstraints = append(tableConstraints, ast.MakeTableConstraintIndex(
+			nil,
+			ast.Keyword{Kind: tik.TokenKind_Keyword_KEY, Text: "KEY"},
+			name,
+			columns,
+		))
+	}
+	return tableConstraints
+}
+
+func beginsWith(indexed []ast.IndexedColumn, columns []ast.Identifier) bool {
+	if len(indexed) < len(columns) {
+		return false
+	}
+	for i, column := range columns {
+		ident, ok := indexedColumnSubject(indexed[i])
+		if !ok || !strings.EqualFold(ident.Text, column.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// moveColumnConstraints moves a primary key on one column, and a unique key
+// on one column named after it, onto the column. mysql does not remember
+// which way they were written and prints them as table constraints.
+func moveColumnConstraints(columnDefs []ast.ColumnDefinition, tableConstraints []ast.TableConstraint) []ast.TableConstraint {
+	return slices.DeleteFunc(tableConstraints, func(constraint ast.TableConstraint) bool {
+		var column string
+		var moved ast.ColumnConstraint
+
+		switch constraint := constraint.(type) {
+		case *ast.TableConstraint_PrimaryKey:
+			name, ok := indexedColumnName(constraint.IndexedColumns)
+			if !ok {
+				return false
+			}
+			column = name
+			moved = ast.MakeColumnConstraintPrimaryKey(nil, constraint.PrimaryKeyword, constraint.KeyKeyword, nil, nil, nil)
+		case *ast.TableConstraint_Unique:
+			name, ok := indexedColumnName(constraint.IndexedColumns)
+			if !ok || constraint.Name == nil || constraint.Name.Name.Text != name {
+				return false
+			}
+			column = name
+			moved = ast.MakeColumnConstraintUnique(nil, nil)
+		default:
+			return false
+		}
+
+		i := slices.IndexFunc(columnDefs, func(def ast.ColumnDefinition) bool {
+			return strings.EqualFold(def.ColumnName.Text, column)
+		})
+		if i < 0 {
+			return false
+		}
+		columnDefs[i].ColumnConstraints = append(columnDefs[i].ColumnConstraints, moved)
+		return true
+	})
+}
+
+func indexedColumnName(columns []ast.IndexedColumn) (string, bool) {
+	if len(columns) != 1 || columns[0].Collation != nil || columns[0].Order != nil {
+		return "", false
+	}
+	ident, ok := columns[0].Subject.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+	return ident.Text, true
+}
+
+// indexedColumnSubject is the column of an indexed column, which may be only
+// a prefix of the column, `name(10)`.
+func indexedColumnSubject(column ast.IndexedColumn) (*ast.Identifier, bool) {
+	switch subject := column.Subject.(type) {
+	case *ast.Identifier:
+		return subject, true
+	case *ast.FunctionCall:
+		return &subject.Name, true
+	default:
+		return nil, false
+	}
+}
+
+// TableConstraint parses a table constraint or one of the indexes mysql
+// allows in the table definition.
+func (p *MysqlParser) TableConstraint() ast.TableConstraint {
+	p.PushParseContext("table constraint")
+	defer p.PopParseContext()
+
+	constraintName := p.MaybeConstraintName()
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_PRIMARY:
+		return p.TableConstraint_PrimaryKey(constraintName)
+	case tik.TokenKind_Keyword_FOREIGN:
+		return p.TableConstraint_ForeignKey(constraintName)
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.TableConstraint_Unique(constraintName)
+	case tik.TokenKind_Keyword_CHECK:
+		return p.TableConstraint_Check(constraintName)
+	case tik.TokenKind_Keyword_INDEX, tik.TokenKind_Keyword_KEY:
+		return p.TableConstraint_Index()
+	default:
+		if p.IsWord("fulltext") || p.IsWord("spatial") {
+			return p.TableConstraint_Index()
+		}
+		err := report.
+			NewReport("parse error").
+			WithLabels([]report.Label{
+				{
+					Source: p.Current().SourceCode,
+					Range:  p.Current().SourceRange,
+					Note:   "unexpected token for table constraint",
+				},
+			})
+		p.ReportError(err)
+		return nil
+	}
+}
+
+func (p *MysqlParser) TableConstraint_PrimaryKey(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("primary key table constraint")
+	defer p.PopParseContext()
+
+	primaryKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_PRIMARY))
+	keyKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_KEY))
+
+	p.MaybeIndexType()
+
+	lParen, indexedCols, rParen := p.IndexedColumns(false)
+
+	p.MaybeIndexType()
+
+	// the name of a primary key is always PRIMARY, so a name is dropped.
+	return ast.MakeTableConstraintPrimaryKey(
+		nil,
+		primaryKeyword,
+		keyKeyword,
+		lParen,
+		indexedCols,
+		rParen,
+		nil,
+		nil,
+	)
+}
+
+// TableConstraint_Unique parses `UNIQUE [INDEX | KEY] [name] (columns)`, the
+// name of the index is kept as the name of the constraint, which is the same
+// thing in mysql.
+func (p *MysqlParser) TableConstraint_Unique(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("unique table constraint")
+	defer p.PopParseContext()
+
+	uniqueKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_UNIQUE))
+
+	if p.Current().Kind == tik.TokenKind_Keyword_INDEX || p.Current().Kind == tik.TokenKind_Keyword_KEY {
+		p.Advance()
+	}
+
+	if p.Current().Kind != '(' && p.Current().Kind != tik.TokenKind_Keyword_USING {
+		name := p.Identifier()
+		if constraintName == nil {
+			constraintName = &ast.ConstraintName{
+				ConstraintKeyword: ast.Keyword{Kind: tik.TokenKind_Keyword_CONSTRAINT, Text: "CONSTRAINT"},
+			}
+		}
+		constraintName.Name = name
+	}
+
+	p.MaybeIndexType()
+
+	lParen, indexedCols, rParen := p.IndexedColumns(false)
+
+	p.MaybeIndexType()
+
+	return ast.MakeTableConstraintUnique(
+		constraintName,
+		uniqueKeyword,
+		lParen,
+		indexedCols,
+		rParen,
+		nil,
+	)
+}
+
+// TableConstraint_Index parses `[FULLTEXT | SPATIAL] {INDEX | KEY} [name]
+// (columns)`.
+func (p *MysqlParser) TableConstraint_Index() ast.TableConstraint {
+	p.PushParseContext("index table constraint")
+	defer p.PopParseContext()
+
+	var kind *ast.Keyword = nil
+	if p.IsWord("fulltext") || p.IsWord("spatial") {
+		kind = ast.MakeKeyword(p.Current())
+		kind.Text = strings.ToUpper(kind.Text)
+		p.Advance()
+	}
+
+	indexKeyword := ast.Keyword(p.Current())
+	if p.Current().Kind == tik.TokenKind_Keyword_INDEX || p.Current().Kind == tik.TokenKind_Keyword_KEY {
+		p.Advance()
+	} else {
+		p.Expect(tik.TokenKind_Keyword_INDEX)
+	}
+
+	var name *ast.Identifier = nil
+	if p.Current().Kind != '(' && p.Current().Kind != tik.TokenKind_Keyword_USING {
+		ident := p.Identifier()
+		name = &ident
+	}
+
+	p.MaybeIndexType()
+
+	_, indexedCols, _ := p.IndexedColumns(false)
+
+	p.MaybeIndexType()
+
+	return ast.MakeTableConstraintIndex(
+		kind,
+		indexKeyword,
+		name,
+		indexedCols,
+	)
+}
+
+// IndexedColumn parses a column of an index, which in mysql may be a prefix
+// of the column, `name(10)`, held as a call of the column, or an expression
+// in parentheses.
+func (p *MysqlParser) IndexedColumn(allowExpressions bool) ast.IndexedColumn {
+	p.PushParseContext("indexed column")
+	defer p.PopParseContext()
+
+	var expr ast.Expr = nil
+	if p.Current().Kind == '(' || allowExpressions && p.Current().Kind != tik.TokenKind_Identifier {
+		expr = parser.Unparen(p.Expr(0))
+	} else {
+		ident := p.Identifier()
+		expr = &ident
+		if p.Current().Kind == '(' {
+			p.Advance()
+			length := p.NumericLiteral()
+			p.Expect(')')
+			expr = &ast.FunctionCall{
+				Name: ident,
+				Args: ast.ExprList{length},
+			}
+		}
+	}
+
+	collation := p.MaybeCollation()
+
+	order := p.MaybeOrderKeyword()
+
+	return ast.IndexedColumn{
+		Subject:   expr,
+		Collation: collation,
+		Order:     order,
+	}
+}
+
+// IsTableConstraintStart adds the indexes mysql declares inside of a table to
+// the table constraints of the shared grammar.
+func (p *MysqlParser) IsTableConstraintStart(token tik.Token) bool {
+	switch token.Kind {
+	case tik.TokenKind_Keyword_INDEX, tik.TokenKind_Keyword_KEY:
+		return true
+	default:
+		return parser.IsWord(token, "fulltext") ||
+			parser.IsWord(token, "spatial") ||
+			p.Grammar.IsTableConstraintStart(token)
+	}
+}
+
+// NormalizeColumn is the column the way mysql prints it back out, the form
+// the differ compares columns in. A primary key is not null whether it says
+// so or not, DEFAULT NULL is what a column is anyway, a number or boolean
+// default is printed as a string, and now() is printed as CURRENT_TIMESTAMP.
+func NormalizeColumn(column ast.ColumnDefinition) ast.ColumnDefinition {
+	isPrimaryKey := slices.ContainsFunc(column.ColumnConstraints, func(constraint ast.ColumnConstraint) bool {
+		_, ok := constraint.(*ast.ColumnConstraint_PrimaryKey)
+		return ok
+	})
+
+	constraints := []ast.ColumnConstraint{}
+	for _, constraint := range column.ColumnConstraints {
+		switch typ := constraint.(type) {
+		case *ast.ColumnConstraint_NotNull:
+			if isPrimaryKey {
+				continue
+			}
+		case *ast.ColumnConstraint_Default:
+			value := typ.Default
+			switch literal := typ.Default.(type) {
+			case *ast.LiteralNull:
+				continue
+			case *ast.LiteralInteger:
+				value = stringLiteral(literal.Token, literal.Token.Text)
+			case *ast.LiteralFloat:
+				value = stringLiteral(literal.Token, literal.Token.Text)
+			case *ast.LiteralBoolean:
+				if literal.Value {
+					value = stringLiteral(literal.Token, "1")
+				} else {
+					value = stringLiteral(literal.Token, "0")
+				}
+			default:
+				value = currentTimestamp(typ.Default)
+			}
+			constraint = ast.MakeColumnConstraintDefault(typ.Name, value)
+		case *ast.ColumnConstraint_OnUpdate:
+			constraint = ast.MakeColumnConstraintOnUpdate(currentTimestamp(typ.Value))
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	column.ColumnConstraints = constraints
+	return column
+}
+
+func stringLiteral(token tik.Token, value string) *ast.LiteralString {
+	token.Kind = tik.TokenKind_StringLiteral
+	token.Text = value
+	return &ast.LiteralString{
+		Token: token,
+		Value: value,
+	}
+}
+
+// currentTimestamp writes the synonyms of CURRENT_TIMESTAMP as a call of it,
+// keeping the precision, `now(3)` is `CURRENT_TIMESTAMP(3)`. A bare name
+// would be written back out quoted, as a column.
+func currentTimestamp(expr ast.Expr) ast.Expr {
+	var name *ast.Identifier = nil
+	args := ast.ExprList{}
+
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		name = expr
+	case *ast.FunctionCall:
+		name = &expr.Name
+		args = expr.Args
+	default:
+		return expr
+	}
+
+	switch strings.ToLower(name.Text) {
+	case "now", "current_timestamp", "localtime", "localtimestamp":
+	default:
+		return expr
+	}
+
+	ident := *name
+	ident.Kind = tik.TokenKind_Identifier
+	ident.Text = "CURRENT_TIMESTAMP"
+	return &ast.FunctionCall{
+		Name: ident,
+		Args: args,
+	}
+}
+
+// typeAliases maps the other names of the built in types onto the name
+// mysql prints them as, so `integer` and `int` are the same type.
+var typeAliases = map[string]string{
+	"integer":           "int",
+	"int4":              "int",
+	"int8":              "bigint",
+	"int2":              "smallint",
+	"int1":              "tinyint",
+	"int3":              "mediumint",
+	"middleint":         "mediumint",
+	"dec":               "decimal",
+	"numeric":           "decimal",
+	"fixed":             "decimal",
+	"double precision":  "double",
+	"real":              "double",
+	"float8":            "double",
+	"float4":            "float",
+	"character":         "char",
+	"character varying": "varchar",
+	"char varying":      "varchar",
+	"long varchar":      "mediumtext",
+	"long":              "mediumtext",
+}
+
+// integerTypes have a display width which mysql no longer prints, apart
+// from tinyint(1) which is how a boolean is written.
+var integerTypes = []string{"tinyint", "smallint", "mediumint", "int", "bigint"}
+
+func (p *MysqlParser) TypeName() ast.TypeName {
+	p.PushParseContext("type name")
+	defer p.PopParseContext()
+
+	if p.Current().Kind != tik.TokenKind_Identifier {
+		return ast.TypeName{}
+	}
+
+	typeToken := p.Expect(tik.TokenKind_Identifier)
+	typeToken.Text = strings.ToLower(typeToken.Text)
+
+	for p.IsWord("precision") || p.IsWord("varying") || (typeToken.Text == "long" && p.IsWord("varchar")) {
+		next := p.Expect(tik.TokenKind_Identifier)
+		typeToken.Text = typeToken.Text + " " + strings.ToLower(next.Text)
+		typeToken.SourceRange.End = next.SourceRange.End
+		typeToken.TrailingTrivia = next.TrailingTrivia
+	}
+
+	args := []ast.Expr{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				args = append(args, p.Expr(0))
+			}
+		}
+		p.Expect(')')
+	}
+
+	switch typeToken.Text {
+	case "bool", "boolean":
+		typeToken.Text = "tinyint"
+		args = []ast.Expr{&ast.LiteralInteger{Token: tik.Token{Kind: tik.TokenKind_DecimalNumericLiteral, Text: "1"}, Value: 1}}
+	case "serial":
+		// serial is bigint unsigned not null auto_increment unique, only the
+		// type is kept here.
+		typeToken.Text = "bigint"
+	}
+	if alias, ok := typeAliases[typeToken.Text]; ok {
+		typeToken.Text = alias
+	}
+
+	if slices.Contains(integerTypes, typeToken.Text) && !(typeToken.Text == "tinyint" && isOne(args)) {
+		args = []ast.Expr{}
+	}
+
+	typeName := ast.MakeTypeName(ast.Identifier(typeToken), args)
+
+	for p.IsWord("unsigned") || p.IsWord("signed") || p.IsWord("zerofill") {
+		if p.IsWord("unsigned") {
+			typeName.Unsigned = true
+		} else if p.IsWord("zerofill") {
+			// zerofill is deprecated and implies unsigned.
+			typeName.Unsigned = true
+		}
+		p.Advance()
+	}
+
+	return typeName
+}
+
+func isOne(args []ast.Expr) bool {
+	if len(args) != 1 {
+		return false
+	}
+	literal, ok := args[0].(*ast.LiteralInteger)
+	return ok && literal.Value == 1
+}
+
+func (p *MysqlParser) ColumnConstraint() ast.ColumnConstraint {
+	p.PushParseContext("column constraint")
+	defer p.PopParseContext()
+
+	constraintName := p.MaybeConstraintName()
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_PRIMARY, tik.TokenKind_Keyword_KEY:
+		return p.ColumnConstraint_PrimaryKey(constraintName)
+	case tik.TokenKind_Keyword_NOT:
+		return p.ColumnConstraint_NotNull(constraintName)
+	case tik.TokenKind_Keyword_DEFAULT:
+		return p.ColumnConstraint_Default(constraintName)
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.ColumnConstraint_Unique(constraintName)
+	case tik.TokenKind_Keyword_COLLATE:
+		return p.ColumnConstraint_Collate(constraintName)
+	case tik.TokenKind_Keyword_CHECK:
+		return p.ColumnConstraint_Check(constraintName)
+	case tik.TokenKind_Keyword_REFERENCES:
+		return p.ColumnConstraint_ForeignKey(constraintName)
+	case tik.TokenKind_Keyword_GENERATED, tik.TokenKind_Keyword_AS:
+		return p.ColumnConstraint_Generated(constraintName)
+	case tik.TokenKind_Keyword_ON:
+		return p.ColumnConstraint_OnUpdate()
+	default:
+		if p.IsWord("visible") || p.IsWord("invisible") {
+			// whether a column is visible to SELECT * is not part of its
+			// definition.
+			p.Advance()
+			return nil
+		} else if p.IsWord("auto_increment") {
+			keyword := p.ExpectWord("auto_increment")
+			return ast.MakeColumnConstraintAutoIncrement(keyword)
+		} else if p.IsWord("comment") {
+			p.Advance()
+			return ast.MakeColumnConstraintComment(*p.StringLiteral())
+		} else if p.IsWord("character") || p.IsWord("charset") {
+			return p.ColumnConstraint_CharacterSet()
+		}
+
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   "unexpected token at start of column constraint",
+					},
+				}),
+		)
+		return nil
+	}
+}
+
+func (p *MysqlParser) ColumnConstraint_OnUpdate() *ast.ColumnConstraint_OnUpdate {
+	p.PushParseContext("on update column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_ON)
+	p.Expect(tik.TokenKind_Keyword_UPDATE)
+
+	value := p.Expr(0)
+
+	return ast.MakeColumnConstraintOnUpdate(value)
+}
+
+func (p *MysqlParser) ColumnConstraint_CharacterSet() *ast.ColumnConstraint_CharacterSet {
+	p.PushParseContext("character set column constraint")
+	defer p.PopParseContext()
+
+	if p.IsWord("character") {
+		p.Advance()
+		p.Expect(tik.TokenKind_Keyword_SET)
+	} else {
+		p.ExpectWord("charset")
+	}
+
+	characterSet := p.Identifier()
+	characterSet.Text = strings.ToLower(characterSet.Text)
+
+	return ast.MakeColumnConstraintCharacterSet(characterSet)
+}
+
+// ColumnConstraint_PrimaryKey parses `[PRIMARY] KEY`, on a column KEY on its
+// own is a primary key.
+func (p *MysqlParser) ColumnConstraint_PrimaryKey(constraintName *ast.ConstraintName) *ast.ColumnConstraint_PrimaryKey {
+	p.PushParseContext("primary key column constraint")
+	defer p.PopParseContext()
+
+	primaryKeyword := ast.Keyword{Kind: tik.TokenKind_Keyword_PRIMARY, Text: "PRIMARY"}
+	if p.Current().Kind == tik.TokenKind_Keyword_PRIMARY {
+		primaryKeyword = ast.Keyword(p.Expect(tik.TokenKind_Keyword_PRIMARY))
+	}
+	keyKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_KEY))
+
+	return ast.MakeColumnConstraintPrimaryKey(
+		nil,
+		primaryKeyword,
+		keyKeyword,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+func (p *MysqlParser) ColumnConstraint_Unique(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Unique {
+	p.PushParseContext("unique column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_UNIQUE)
+	p.MaybeTokenKind(tik.TokenKind_Keyword_KEY)
+
+	// the index of a unique column is named after the column, whatever the
+	// constraint is called.
+	return ast.MakeColumnConstraintUnique(
+		nil,
+		nil,
+	)
+}
+
+// ColumnConstraint_ForeignKey parses a foreign key on a column, which mysql
+// accepts and then ignores, so it is warned about.
+func (p *MysqlParser) ColumnConstraint_ForeignKey(constraintName *ast.ConstraintName) *ast.ColumnConstraint_ForeignKey {
+	start := p.Current()
+
+	foreignKey := p.Grammar.ColumnConstraint_ForeignKey(constraintName)
+
+	p.ReportWarning(
+		report.
+			NewReport("ignored foreign key").
+			WithLabels([]report.Label{
+				{
+					Source: start.SourceCode,
+					Range:  start.SourceRange,
+					Note:   "mysql ignores a foreign key on a column, write it as FOREIGN KEY (column) REFERENCES in the table",
+				},
+			}),
+	)
+
+	return foreignKey
+}
+
+// stringEscapes are the backslash escapes of a mysql string.
+var stringEscapes = strings.NewReplacer(
+	`\'`, "'",
+	`\"`, `"`,
+	`\\`, `\`,
+	`\n`, "\n",
+	`\r`, "\r",
+	`\t`, "\t",
+	`\0`, "\x00",
+	"''", "'",
+)
+
+func (p *MysqlParser) StringLiteral() *ast.LiteralString {
+	token := p.Expect(tik.TokenKind_StringLiteral)
+	return &ast.LiteralString{
+		Token: token,
+		Value: stringEscapes.Replace(token.Text),
+	}
+}
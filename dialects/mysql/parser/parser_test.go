@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/formatter"
+)
+
+func makeParser(input string) *MysqlParser {
+
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		panic("unable to get caller info")
+	}
+	funcInfo := runtime.FuncForPC(pc)
+	file, _ := funcInfo.FileLine(pc)
+
+	lex := luther.NewLexer(luther.SourceCode{
+		FileName: fmt.Sprintf("%s/%s", file, funcInfo.Name()),
+		Raw:      []rune(input),
+	})
+
+	return NewMysqlParser(lex)
+}
+
+func parseFile(t *testing.T, name string) []ast.Statement {
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := NewMysqlParser(luther.NewLexer(luther.SourceCode{
+		FileName: name,
+		Raw:      []rune(string(raw)),
+	}))
+	statements := parser.Statements()
+	if errors := parser.Errors(); len(errors) > 0 {
+		t.Fatalf("unexpected parse errors in %s: %v", name, errors)
+	}
+	return statements
+}
+
+func sqlText(node interface{ ToSql(f formatter.Formatter) }) string {
+	builder := strings.Builder{}
+	node.ToSql(formatter.NewCoreFormatter(&builder, 1000, "``"))
+	return builder.String()
+}
+
+// TestShowCreateTable diffs the tables as SHOW CREATE TABLE prints them
+// against the same tables as they were written, which should be the same.
+func TestShowCreateTable(t *testing.T) {
+	printed := parseFile(t, "testdata/show_create.sql")
+	written := parseFile(t, "testdata/schema.sql")
+
+	edits, err := (&diff.Diff{NormalizeColumn: NormalizeColumn}).DiffSchema(printed, written)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the printed view is only parsed, the schema does not have it.
+	if len(edits) != 1 {
+		t.Fatalf("expected only the view to be removed, got %v", edits)
+	}
+	if _, ok := edits[0].(*diff.EditRemoveView); !ok {
+		t.Fatalf("expected only the view to be removed, got %v", edits)
+	}
+}
+
+// TestColumnDefinitions normalizes columns the way mysql prints them.
+func TestColumnDefinitions(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"id integer(11) unsigned zerofill", "`id` int unsigned"},
+		{"flag bool DEFAULT true", "`flag` tinyint(1) DEFAULT '1'"},
+		{"seen datetime(3) DEFAULT NULL ON UPDATE now(3)", "`seen` datetime(3) ON UPDATE CURRENT_TIMESTAMP(3)"},
+		{"total numeric(10, 2) DEFAULT 1.5", "`total` decimal(10, 2) DEFAULT '1.5'"},
+		{"full_name varchar(20) AS (concat(first, last)) STORED", "`full_name` varchar(20) GENERATED ALWAYS AS (concat(`first`, `last`)) STORED"},
+		{"id bigint KEY AUTO_INCREMENT", "`id` bigint PRIMARY KEY AUTO_INCREMENT"},
+		{"id int PRIMARY KEY NOT NULL", "`id` int PRIMARY KEY"},
+	}
+
+	for _, c := range cases {
+		parser := makeParser(c.input)
+		column := parser.ColumnDefinition()
+		if errors := parser.Errors(); len(errors) > 0 {
+			t.Fatalf("unexpected parse errors for %q: %v", c.input, errors)
+		}
+		normalized := NormalizeColumn(*column)
+		if actual := sqlText(&normalized); actual != c.expected {
+			t.Errorf("expected %q to be %q, got %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+// TestColumnDefinitionsAsWritten keeps the clauses mysql would print some
+// other way, it is the differ which compares them normalized.
+func TestColumnDefinitionsAsWritten(t *testing.T) {
+	input := "seen datetime DEFAULT NULL ON UPDATE now() NOT NULL PRIMARY KEY"
+	parser := makeParser(input)
+	column := parser.ColumnDefinition()
+	if errors := parser.Errors(); len(errors) > 0 {
+		t.Fatalf("unexpected parse errors for %q: %v", input, errors)
+	}
+
+	expected := "`seen` datetime DEFAULT NULL ON UPDATE now() NOT NULL PRIMARY KEY"
+	if actual := sqlText(column); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestForeignKeyOnColumnIsWarned(t *testing.T) {
+	parser := makeParser("CREATE TABLE orders (user_id int REFERENCES users (id));")
+	parser.Statements()
+
+	if len(parser.Warnings()) != 1 {
+		t.Fatalf("expected a warning, got %v", parser.Warnings())
+	}
+}
@@ -0,0 +1,31 @@
+-- the schema as a person writes it
+CREATE TABLE users (
+    id int unsigned PRIMARY KEY AUTO_INCREMENT,
+    email varchar(255) NOT NULL UNIQUE,
+    name varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+    is_admin boolean NOT NULL DEFAULT false,
+    created_at timestamp NOT NULL DEFAULT now(),
+    updated_at timestamp NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    bio text COMMENT 'what they\'re about',
+    INDEX (created_at)
+) ENGINE=InnoDB;
+
+CREATE TABLE orders (
+    id bigint NOT NULL AUTO_INCREMENT,
+    user_id int unsigned NOT NULL,
+    total decimal(10, 2) NOT NULL DEFAULT 0.00 CHECK (total >= 0),
+    status enum('open', 'paid') NOT NULL DEFAULT 'open',
+    PRIMARY KEY (id),
+    UNIQUE KEY user_status (user_id, status),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE notes (
+    id integer PRIMARY KEY,
+    order_id bigint,
+    body text,
+    CONSTRAINT notes_order FOREIGN KEY (order_id) REFERENCES orders (id)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX order_recent ON notes (order_id, id);
+CREATE FULLTEXT INDEX body_search ON notes (body);
@@ -0,0 +1,36 @@
+-- the same schema as SHOW CREATE TABLE prints it
+CREATE TABLE `users` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `email` varchar(255) NOT NULL,
+  `name` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+  `is_admin` tinyint(1) NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+  `bio` text COMMENT 'what they''re about',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `email` (`email`),
+  KEY `created_at` (`created_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE `orders` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL,
+  `total` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `status` enum('open','paid') NOT NULL DEFAULT 'open',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `user_status` (`user_id`,`status`),
+  CONSTRAINT `orders_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `orders_chk_1` CHECK ((`total` >= 0))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE `notes` (
+  `id` int NOT NULL,
+  `order_id` bigint DEFAULT NULL,
+  `body` text,
+  PRIMARY KEY (`id`),
+  KEY `order_recent` (`order_id`,`id`),
+  FULLTEXT KEY `body_search` (`body`),
+  CONSTRAINT `notes_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`%` SQL SECURITY DEFINER VIEW `open_orders` AS select `orders`.`id` AS `id` from `orders` where (`orders`.`status` = 'open');
@@ -0,0 +1,440 @@
+package postgres
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/tik"
+	postgres "woodybriggs/justmigrate/dialects/postgres/parser"
+	"woodybriggs/justmigrate/diff"
+)
+
+// alterTable makes the edits to a table with ALTER TABLE. constraints are
+// dropped first and added last so that they never refer to a column which
+// is not there, renames go before adding columns as a column may be added
+// under the name another was renamed away from.
+func alterTable(edit *diff.EditModifyTable) ([]ast.Statement, error) {
+	table := edit.Result
+	tableName := table.TableIdentifier.ObjectName.Text
+
+	dropConstraints := []ast.TableAlteration{}
+	renames := []ast.TableAlteration{}
+	dropColumns := []ast.TableAlteration{}
+	addColumns := []ast.TableAlteration{}
+	alterColumns := []ast.TableAlteration{}
+	addConstraints := []ast.TableAlteration{}
+
+	for _, e := range edit.Edits {
+		switch typ := e.(type) {
+		case *diff.EditRenameColumn:
+			renames = append(renames, &ast.RenameColumn{
+				RenameKeyword: keyword(tik.TokenKind_Keyword_RENAME, "RENAME"),
+				ColumnKeyword: columnKeyword(),
+				ColumnName:    typ.From,
+				ToKeyword:     keyword(tik.TokenKind_Keyword_TO, "TO"),
+				NewName:       typ.To,
+			})
+		case *diff.EditRemoveColumn:
+			dropColumns = append(dropColumns, &ast.DropColumn{
+				DropKeyword:   keyword(tik.TokenKind_Keyword_DROP, "DROP"),
+				ColumnKeyword: columnKeyword(),
+				ColumnName:    typ.ColumnName,
+			})
+		case *diff.EditAddColumn:
+			addColumns = append(addColumns, &ast.AddColumn{
+				AddKeyword:       keyword(tik.TokenKind_Keyword_ADD, "ADD"),
+				ColumnKeyword:    columnKeyword(),
+				ColumnDefinition: typ.ColumnDefinition,
+			})
+		case *diff.EditModifyColumn:
+			column := typ.Result.ColumnName
+			for _, e := range typ.Edits {
+				switch change := e.(type) {
+				case *diff.EditChangeColumnType:
+					if postgres.IsSerial(change.From) || postgres.IsSerial(change.To) {
+						return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+					}
+					using, err := convert(column, change, renamed(edit, column))
+					if err != nil {
+						return nil, &diff.EditError{Edit: edit, Err: err}
+					}
+					alterColumn := setType(column, typ.Result)
+					alterColumn.Alteration.(*ast.SetType).Using = using
+					alterColumns = append(alterColumns, alterColumn)
+				case *diff.EditRemoveColumnConstraint:
+					switch constraint := change.ColumnConstraint.(type) {
+					case *ast.ColumnConstraint_NotNull:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.DropNotNull{}))
+					case *ast.ColumnConstraint_Default:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.DropDefaultValue{}))
+					case *ast.ColumnConstraint_Identity:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.DropIdentity{}))
+					case *ast.ColumnConstraint_Collate:
+						alterColumns = append(alterColumns, setType(column, typ.Result))
+					case *ast.ColumnConstraint_Generated:
+						return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+					default:
+						dropConstraints = append(dropConstraints, dropConstraint(tableName, column.Text, constraint))
+					}
+				case *diff.EditAddColumnConstraint:
+					switch constraint := change.ColumnConstraint.(type) {
+					case *ast.ColumnConstraint_NotNull:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.SetNotNull{}))
+					case *ast.ColumnConstraint_Default:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.SetDefaultValue{Default: constraint.Default}))
+					case *ast.ColumnConstraint_Identity:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.AddIdentity{Identity: constraint}))
+					case *ast.ColumnConstraint_Collate:
+						alterColumns = append(alterColumns, setType(column, typ.Result))
+					case *ast.ColumnConstraint_Generated:
+						return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+					default:
+						addConstraints = append(addConstraints, addConstraint(column, constraint))
+					}
+				case *diff.EditModifyColumnConstraint:
+					switch constraint := change.Result.(type) {
+					case *ast.ColumnConstraint_Default:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.SetDefaultValue{Default: constraint.Default}))
+					case *ast.ColumnConstraint_Identity:
+						alterColumns = append(alterColumns, alterColumn(column, &ast.SetIdentity{Identity: constraint}))
+					case *ast.ColumnConstraint_Collate:
+						alterColumns = append(alterColumns, setType(column, typ.Result))
+					case *ast.ColumnConstraint_NotNull:
+						// only the name of a not null constraint can differ, which
+						// postgres does not keep.
+					case *ast.ColumnConstraint_Generated:
+						return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+					default:
+						dropConstraints = append(dropConstraints, dropConstraint(tableName, column.Text, change.Target))
+						addConstraints = append(addConstraints, addConstraint(column, constraint))
+					}
+				}
+			}
+		case *diff.EditRemoveTableConstraint:
+			name, ok := tableConstraintName(tableName, typ.TableConstraint)
+			if !ok {
+				return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+			}
+			dropConstraints = append(dropConstraints, &ast.DropConstraint{Name: name})
+		case *diff.EditAddTableConstraint:
+			addConstraints = append(addConstraints, &ast.AddConstraint{Constraint: typ.TableConstraint})
+		case *diff.EditModifyTableConstraint:
+			name, ok := tableConstraintName(tableName, typ.Target)
+			if !ok {
+				return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+			}
+			dropConstraints = append(dropConstraints, &ast.DropConstraint{Name: name})
+			addConstraints = append(addConstraints, &ast.AddConstraint{Constraint: typ.Result})
+		}
+	}
+
+	// the type and the collation of a column are changed together, so a
+	// column with both changing would otherwise be altered twice. the one
+	// made for the type is kept, as it is the one with the conversion.
+	typeSet := map[string]bool{}
+	alterColumns = slices.DeleteFunc(alterColumns, func(alteration ast.TableAlteration) bool {
+		alterColumn := alteration.(*ast.AlterColumn)
+		if _, ok := alterColumn.Alteration.(*ast.SetType); !ok {
+			return false
+		}
+		if typeSet[alterColumn.ColumnName.Text] {
+			return true
+		}
+		typeSet[alterColumn.ColumnName.Text] = true
+		return false
+	})
+
+	statements := []ast.Statement{}
+	for _, alteration := range slices.Concat(dropConstraints, renames, dropColumns, addColumns, alterColumns, addConstraints) {
+		statements = append(statements, &ast.AlterTable{
+			AlterKeyword:    keyword(tik.TokenKind_Keyword_ALTER, "ALTER"),
+			TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+			TableIdentifier: edit.Target.TableIdentifier,
+			Alteration:      alteration,
+		})
+	}
+
+	return statements, nil
+}
+
+func columnKeyword() *ast.Keyword {
+	column := keyword(tik.TokenKind_Keyword_COLUMN, "COLUMN")
+	return &column
+}
+
+func alterColumn(column ast.Identifier, alteration ast.ColumnAlteration) *ast.AlterColumn {
+	return &ast.AlterColumn{
+		ColumnName: column,
+		Alteration: alteration,
+	}
+}
+
+// setType sets the type of a column along with its collation, which
+// postgres only lets you change as part of the type.
+func setType(column ast.Identifier, desired *ast.ColumnDefinition) *ast.AlterColumn {
+	setType := &ast.SetType{TypeName: desired.TypeName}
+	for _, constraint := range desired.ColumnConstraints {
+		if collate, ok := constraint.(*ast.ColumnConstraint_Collate); ok {
+			setType.Collation = ast.MakeCollation(keyword(tik.TokenKind_Keyword_COLLATE, "COLLATE"), collate.Collate)
+		}
+	}
+	return alterColumn(column, setType)
+}
+
+// convert is the expression the values of a column whose type changes are
+// converted with, the one given by a using annotation or else a CAST to the
+// new type. changing only the length or precision of a type needs no
+// conversion. the annotation reads the column by the name it has in the
+// current database, which it no longer has once a rename has been made.
+func convert(column ast.Identifier, change *diff.EditChangeColumnType, renamed bool) (ast.Expr, error) {
+	if change.Using == "" {
+		if strings.EqualFold(change.From.TypeName.Text, change.To.TypeName.Text) {
+			return nil, nil
+		}
+		castKeyword := keyword(tik.TokenKind_Keyword_CAST, "CAST")
+		return &ast.Cast{
+			CastKeyword: &castKeyword,
+			Expr:        &column,
+			Type:        change.To,
+		}, nil
+	}
+	if renamed {
+		return nil, diff.ErrUnsupportedEdit
+	}
+	return parseExpr(change.Using)
+}
+
+func parseExpr(text string) (ast.Expr, error) {
+	p := postgres.NewPostgresParser(luther.NewLexer(luther.SourceCode{
+		FileName: "using",
+		Raw:      []rune(text),
+	}))
+	expr, errors := parser.ParseFragment(p.Parser, func() ast.Expr { return p.Expr(0) })
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("%w: %s", diff.ErrInvalidConversion, text)
+	}
+	return expr, nil
+}
+
+// renamed reports whether column is the new name of a column renamed by
+// edit.
+func renamed(edit *diff.EditModifyTable, column ast.Identifier) bool {
+	return slices.ContainsFunc(edit.Edits, func(e diff.Edit) bool {
+		rename, ok := e.(*diff.EditRenameColumn)
+		return ok && strings.EqualFold(rename.To.Text, column.Text)
+	})
+}
+
+// addConstraint adds a constraint of a column as the same constraint on the
+// table, which is the only way postgres can add one to an existing column.
+func addConstraint(column ast.Identifier, constraint ast.ColumnConstraint) *ast.AddConstraint {
+	columns := []ast.IndexedColumn{{Subject: &column}}
+
+	var tableConstraint ast.TableConstraint
+	switch constraint := constraint.(type) {
+	case *ast.ColumnConstraint_PrimaryKey:
+		tableConstraint = ast.MakeTableConstraintPrimaryKey(
+			constraint.Name,
+			keyword(tik.TokenKind_Keyword_PRIMARY, "PRIMARY"),
+			keyword(tik.TokenKind_Keyword_KEY, "KEY"),
+			tik.Token{},
+			columns,
+			tik.Token{},
+			nil,
+			nil,
+		)
+	case *ast.ColumnConstraint_Unique:
+		tableConstraint = ast.MakeTableConstraintUnique(
+			constraint.Name,
+			keyword(tik.TokenKind_Keyword_UNIQUE, "UNIQUE"),
+			tik.Token{},
+			columns,
+			tik.Token{},
+			nil,
+		)
+	case *ast.ColumnConstraint_ForeignKey:
+		tableConstraint = ast.MakeTableConstraintForeignKey(
+			constraint.Name,
+			keyword(tik.TokenKind_Keyword_FOREIGN, "FOREIGN"),
+			keyword(tik.TokenKind_Keyword_KEY, "KEY"),
+			tik.Token{},
+			[]ast.Identifier{column},
+			tik.Token{},
+			&constraint.FkClause,
+		)
+	case *ast.ColumnConstraint_Check:
+		tableConstraint = ast.MakeTableConstraintCheck(
+			constraint.Name,
+			keyword(tik.TokenKind_Keyword_CHECK, "CHECK"),
+			tik.Token{},
+			constraint.Check,
+			tik.Token{},
+		)
+	}
+
+	return &ast.AddConstraint{Constraint: tableConstraint}
+}
+
+func dropConstraint(table, column string, constraint ast.ColumnConstraint) *ast.DropConstraint {
+	switch constraint := constraint.(type) {
+	case *ast.ColumnConstraint_PrimaryKey:
+		return &ast.DropConstraint{Name: constraintName(constraint.Name, table, "pkey")}
+	case *ast.ColumnConstraint_Unique:
+		return &ast.DropConstraint{Name: constraintName(constraint.Name, table, column, "key")}
+	case *ast.ColumnConstraint_ForeignKey:
+		return &ast.DropConstraint{Name: constraintName(constraint.Name, table, column, "fkey")}
+	case *ast.ColumnConstraint_Check:
+		return &ast.DropConstraint{Name: constraintName(constraint.Name, table, column, "check")}
+	default:
+		return nil
+	}
+}
+
+// tableConstraintName is the name of a table constraint, the name postgres
+// gives to an unnamed check depends on the columns its expression uses, so
+// it is not known.
+func tableConstraintName(table string, constraint ast.TableConstraint) (ast.Identifier, bool) {
+	switch constraint := constraint.(type) {
+	case *ast.TableConstraint_PrimaryKey:
+		return constraintName(constraint.Name, table, "pkey"), true
+	case *ast.TableConstraint_Unique:
+		parts := []string{table}
+		for _, column := range constraint.IndexedColumns {
+			ident, ok := column.Subject.(*ast.Identifier)
+			if !ok {
+				return ast.Identifier{}, false
+			}
+			parts = append(parts, ident.Text)
+		}
+		return constraintName(constraint.Name, append(parts, "key")...), true
+	case *ast.TableConstraint_ForeignKey:
+		parts := []string{table}
+		for _, column := range constraint.Columns {
+			parts = append(parts, column.Text)
+		}
+		return constraintName(constraint.Name, append(parts, "fkey")...), true
+	case *ast.TableConstraint_Check:
+		if constraint.Name == nil {
+			return ast.Identifier{}, false
+		}
+		return constraint.Name.Name, true
+	default:
+		return ast.Identifier{}, false
+	}
+}
+
+// constraintName is the name of a constraint, or the name postgres gives a
+// constraint which was not named, e.g. users_email_key.
+func constraintName(name *ast.ConstraintName, parts ...string) ast.Identifier {
+	if name != nil {
+		return name.Name
+	}
+	return identifier(strings.Join(parts, "_"))
+}
+
+// orderDrops drops views first, then indexes. views are dropped before any
+// view they select from.
+func orderDrops(drops []ast.Statement) []ast.Statement {
+	views := []*ast.CreateView{}
+	indexes := []ast.Statement{}
+
+	for _, drop := range drops {
+		switch typ := drop.(type) {
+		case *ast.CreateView:
+			views = append(views, typ)
+		default:
+			indexes = append(indexes, typ)
+		}
+	}
+
+	result := []ast.Statement{}
+	ordered := orderViews(views)
+	slices.Reverse(ordered)
+	for _, view := range ordered {
+		result = append(result, dropView(view))
+	}
+
+	return slices.Concat(result, indexes)
+}
+
+// orderCreates creates indexes first, then views. views are created after
+// any view they select from.
+func orderCreates(creates []ast.Statement) []ast.Statement {
+	indexes := []ast.Statement{}
+	views := []*ast.CreateView{}
+
+	for _, create := range creates {
+		switch typ := create.(type) {
+		case *ast.CreateView:
+			views = append(views, typ)
+		default:
+			indexes = append(indexes, typ)
+		}
+	}
+
+	result := indexes
+	for _, view := range orderViews(views) {
+		result = append(result, view)
+	}
+
+	return result
+}
+
+// orderViews sorts views so that every view comes after the views it selects
+// from, a view which appears more than once, e.g. recreated for two tables,
+// is kept once.
+func orderViews(views []*ast.CreateView) []*ast.CreateView {
+	seen := map[string]bool{}
+	unique := []*ast.CreateView{}
+	for _, view := range views {
+		name := view.ViewIdentifier.FullyQualifiedName(postgres.DefaultSchema)
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, view)
+		}
+	}
+
+	result := make([]*ast.CreateView, 0, len(unique))
+	placed := make([]bool, len(unique))
+
+	selectsFrom := func(view, other *ast.CreateView) bool {
+		return slices.ContainsFunc(view.AsSelect.Tokens, func(token tik.Token) bool {
+			return token.Kind == tik.TokenKind_Identifier &&
+				strings.EqualFold(token.Text, other.ViewIdentifier.ObjectName.Text)
+		})
+	}
+
+	for len(result) < len(unique) {
+		progressed := false
+		for i, view := range unique {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for j, other := range unique {
+				if i != j && !placed[j] && selectsFrom(view, other) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				result = append(result, view)
+				placed[i] = true
+				progressed = true
+			}
+		}
+		if !progressed {
+			for i, view := range unique {
+				if !placed[i] {
+					result = append(result, view)
+					placed[i] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
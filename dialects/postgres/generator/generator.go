@@ -0,0 +1,441 @@
+package postgres
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/formatter"
+)
+
+type PostgresGenerator struct {
+	edits []diff.Edit
+}
+
+func NewPostgresGenerator(edits []diff.Edit) *PostgresGenerator {
+	return &PostgresGenerator{
+		edits: edits,
+	}
+}
+
+// Migration is what a generator produces, the statements of the migration
+// and the sql they render to.
+type Migration struct {
+	Statements []ast.Statement
+	Sql        string
+}
+
+// Generate writes the migration to writer.
+func (gen *PostgresGenerator) Generate(writer io.Writer) error {
+	migration, err := gen.Migration()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(writer, migration.Sql)
+	return err
+}
+
+// Migration builds the statements which make the edits, an edit it does not
+// know how to make is returned as a *diff.EditError.
+func (gen *PostgresGenerator) Migration() (*Migration, error) {
+
+	// postgres can change almost everything about a table in place, so unlike
+	// sqlite no table is ever rebuilt. statements are emitted in five phases
+	// so that nothing is created before the things it depends on, or dropped
+	// after them:
+	//   1. views and indexes which are going away or changing
+	//   2. schemas, enums and domains which are new or changed
+	//   3. tables
+	//   4. indexes and views which are new or changed
+	//   5. domains, enums and schemas which are going away
+	drops := []ast.Statement{}
+	types := []ast.Statement{}
+	tables := []ast.Statement{}
+	creates := []ast.Statement{}
+	typeDrops := []ast.Statement{}
+
+	for _, edit := range gen.edits {
+		switch typ := edit.(type) {
+		case *diff.EditAddSchema:
+			{
+				types = append(types, typ.CreateSchema)
+			}
+		case *diff.EditRemoveSchema:
+			{
+				typeDrops = append(typeDrops, &ast.DropSchema{SchemaName: typ.SchemaName})
+			}
+		case *diff.EditAddEnum:
+			{
+				types = append(types, typ.CreateEnum)
+			}
+		case *diff.EditRemoveEnum:
+			{
+				typeDrops = append(typeDrops, &ast.DropType{TypeIdentifier: *typ.TypeIdentifier})
+			}
+		case *diff.EditReplaceEnum:
+			{
+				statements, err := alterEnum(typ)
+				if err != nil {
+					return nil, err
+				}
+				types = slices.Concat(types, statements)
+			}
+		case *diff.EditAddDomain:
+			{
+				types = append(types, typ.CreateDomain)
+			}
+		case *diff.EditRemoveDomain:
+			{
+				typeDrops = append(typeDrops, &ast.DropDomain{DomainIdentifier: *typ.DomainIdentifier})
+			}
+		case *diff.EditReplaceDomain:
+			{
+				statements, err := alterDomain(typ)
+				if err != nil {
+					return nil, err
+				}
+				types = slices.Concat(types, statements)
+			}
+		case *diff.EditAddTable:
+			{
+				tables = append(tables, typ.CreateTable)
+			}
+		case *diff.EditRemoveTable:
+			{
+				tables = append(tables, dropTable(typ.TableIdentifier))
+			}
+		case *diff.EditRenameTable:
+			{
+				if !isSameSchema(typ.From, typ.To) {
+					return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+				}
+				tables = append(tables, renameTable(typ.From, typ.To))
+			}
+		case *diff.EditModifyTable:
+			{
+				statements, err := alterTable(typ)
+				if err != nil {
+					return nil, err
+				}
+				tables = slices.Concat(tables, statements)
+
+				// a view stops a column it selects from changing type, so
+				// it has to be dropped and created again around the change.
+				if changesColumnType(typ) {
+					for _, dependent := range typ.Dependents {
+						if view, ok := dependent.(*ast.CreateView); ok {
+							drops = append(drops, view)
+							creates = append(creates, view)
+						}
+					}
+				}
+			}
+		case *diff.EditAddIndex:
+			{
+				creates = append(creates, typ.CreateIndex)
+			}
+		case *diff.EditRemoveIndex:
+			{
+				drops = append(drops, dropIndex(typ.CreateIndex))
+			}
+		case *diff.EditReplaceIndex:
+			{
+				drops = append(drops, dropIndex(typ.Target))
+				creates = append(creates, typ.Result)
+			}
+		case *diff.EditAddView:
+			{
+				creates = append(creates, typ.CreateView)
+			}
+		case *diff.EditRemoveView:
+			{
+				drops = append(drops, typ.CreateView)
+			}
+		case *diff.EditReplaceView:
+			{
+				drops = append(drops, typ.Target)
+				creates = append(creates, typ.Result)
+			}
+
+		default:
+			{
+				return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+			}
+		}
+	}
+
+	// schemas are dropped last, after everything that was in them.
+	slices.SortStableFunc(typeDrops, func(a, b ast.Statement) int {
+		_, aIsSchema := a.(*ast.DropSchema)
+		_, bIsSchema := b.(*ast.DropSchema)
+		switch {
+		case aIsSchema == bIsSchema:
+			return 0
+		case aIsSchema:
+			return 1
+		default:
+			return -1
+		}
+	})
+
+	statements := slices.Concat(
+		orderDrops(drops),
+		types,
+		tables,
+		orderCreates(creates),
+		typeDrops,
+	)
+
+	if len(statements) > 0 {
+		statements = inTransaction(statements)
+	}
+
+	sql := strings.Builder{}
+	core := formatter.NewCoreFormatter(&sql, 80, "\"\"")
+
+	for _, statement := range statements {
+		statement.ToSql(core)
+		core.Rune(';')
+		core.Break()
+		core.Break()
+	}
+
+	return &Migration{
+		Statements: statements,
+		Sql:        sql.String(),
+	}, nil
+}
+
+// inTransaction wraps the migration in a transaction, postgres can roll back
+// changes to the schema so a migration which fails part way changes nothing.
+func inTransaction(statements []ast.Statement) []ast.Statement {
+	begin := &ast.BeginTransaction{
+		BeginKeyword: keyword(tik.TokenKind_Keyword_BEGIN, "BEGIN"),
+	}
+	commit := &ast.CommitTransaction{
+		CommitKeyword: keyword(tik.TokenKind_Keyword_COMMIT, "COMMIT"),
+	}
+
+	return slices.Concat([]ast.Statement{begin}, statements, []ast.Statement{commit})
+}
+
+// alterEnum adds the new values of an enum. postgres can not remove or
+// reorder the values of an enum, so any other change is unsupported. a value
+// added in a transaction can not be used until it is committed.
+func alterEnum(edit *diff.EditReplaceEnum) ([]ast.Statement, error) {
+	current := edit.Target.Values
+	desired := edit.Result.Values
+
+	// the current values have to still be there, in the same order.
+	next := 0
+	for _, value := range desired {
+		if next < len(current) && value.Value == current[next].Value {
+			next++
+		}
+	}
+	if next < len(current) {
+		return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+	}
+
+	statements := []ast.Statement{}
+	next = 0
+	for i, value := range desired {
+		if next < len(current) && value.Value == current[next].Value {
+			next++
+			continue
+		}
+
+		addValue := &ast.AlterTypeAddValue{
+			TypeIdentifier: edit.Result.TypeIdentifier,
+			Value:          value,
+		}
+		if i > 0 {
+			addValue.After = &desired[i-1]
+		} else if len(current) > 0 {
+			addValue.Before = &current[0]
+		}
+		if i == len(desired)-1 {
+			addValue.After = nil
+		}
+
+		statements = append(statements, addValue)
+	}
+
+	return statements, nil
+}
+
+// alterDomain changes the default, not null and check constraints of a
+// domain, changing its type means recreating it and everything using it.
+func alterDomain(edit *diff.EditReplaceDomain) ([]ast.Statement, error) {
+	domain := edit.Result.DomainIdentifier
+	if !strings.EqualFold(sqlText(&edit.Target.TypeName), sqlText(&edit.Result.TypeName)) {
+		return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+	}
+
+	alterations := []ast.DomainAlteration{}
+
+	removed, added, modified := constraintChanges(edit.Target.Constraints, edit.Result.Constraints)
+
+	for _, constraint := range removed {
+		switch constraint := constraint.(type) {
+		case *ast.ColumnConstraint_NotNull:
+			alterations = append(alterations, &ast.DropNotNull{})
+		case *ast.ColumnConstraint_Default:
+			alterations = append(alterations, &ast.DropDefaultValue{})
+		case *ast.ColumnConstraint_Check:
+			alterations = append(alterations, &ast.DropConstraint{
+				Name: constraintName(constraint.Name, domain.ObjectName.Text, "check"),
+			})
+		default:
+			return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+		}
+	}
+
+	for _, constraint := range slices.Concat(added, modified) {
+		switch constraint := constraint.(type) {
+		case *ast.ColumnConstraint_NotNull:
+			alterations = append(alterations, &ast.SetNotNull{})
+		case *ast.ColumnConstraint_Default:
+			alterations = append(alterations, &ast.SetDefaultValue{Default: constraint.Default})
+		case *ast.ColumnConstraint_Check:
+			alterations = append(alterations, &ast.AddConstraint{
+				Constraint: ast.MakeTableConstraintCheck(constraint.Name, keyword(tik.TokenKind_Keyword_CHECK, "CHECK"), tik.Token{}, constraint.Check, tik.Token{}),
+			})
+		default:
+			return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
+		}
+	}
+
+	statements := []ast.Statement{}
+	for _, alteration := range alterations {
+		statements = append(statements, &ast.AlterDomain{
+			DomainIdentifier: domain,
+			Alteration:       alteration,
+		})
+	}
+	return statements, nil
+}
+
+// constraintChanges splits the changes to the constraints of a domain into
+// the ones which are removed, added and changed in place. a check can not be
+// changed in place, a changed check is a different check.
+func constraintChanges(current, desired []ast.ColumnConstraint) (removed, added, modified []ast.ColumnConstraint) {
+	for _, constraint := range current {
+		if !slices.ContainsFunc(desired, func(other ast.ColumnConstraint) bool { return isSameConstraint(constraint, other) }) {
+			removed = append(removed, constraint)
+		}
+	}
+
+	for _, constraint := range desired {
+		match := slices.IndexFunc(current, func(other ast.ColumnConstraint) bool { return isSameConstraint(constraint, other) })
+		if match < 0 {
+			added = append(added, constraint)
+		} else if sqlText(constraint) != sqlText(current[match]) {
+			modified = append(modified, constraint)
+		}
+	}
+
+	return removed, added, modified
+}
+
+func isSameConstraint(a, b ast.ColumnConstraint) bool {
+	if fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) {
+		return false
+	}
+	if _, ok := a.(*ast.ColumnConstraint_Check); ok {
+		return sqlText(a) == sqlText(b)
+	}
+	return true
+}
+
+func changesColumnType(edit *diff.EditModifyTable) bool {
+	for _, edit := range edit.Edits {
+		if modify, ok := edit.(*diff.EditModifyColumn); ok {
+			for _, edit := range modify.Edits {
+				if _, ok := edit.(*diff.EditChangeColumnType); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isSameSchema(a, b *ast.CatalogObjectIdentifier) bool {
+	if a.SchemaName == nil || b.SchemaName == nil {
+		return a.SchemaName == nil && b.SchemaName == nil
+	}
+	return a.SchemaName.Text == b.SchemaName.Text
+}
+
+func renameTable(from, to *ast.CatalogObjectIdentifier) *ast.AlterTable {
+	return &ast.AlterTable{
+		AlterKeyword:    keyword(tik.TokenKind_Keyword_ALTER, "ALTER"),
+		TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+		TableIdentifier: from,
+		Alteration: &ast.RenameTable{
+			RenameKeyword: keyword(tik.TokenKind_Keyword_RENAME, "RENAME"),
+			ToKeyword:     keyword(tik.TokenKind_Keyword_TO, "TO"),
+			NewName:       to.ObjectName,
+		},
+	}
+}
+
+// dropIndex drops an index from the schema of its table, which is where
+// postgres always creates it.
+func dropIndex(index *ast.CreateIndex) *ast.DropIndex {
+	return &ast.DropIndex{
+		IfExists: ifExists(),
+		IndexIdentifier: *ast.MakeCatalogObjectIdentifier(
+			index.OnTable.SchemaName,
+			index.IndexIdentifier.ObjectName,
+		),
+	}
+}
+
+func dropView(view *ast.CreateView) *ast.DropView {
+	return &ast.DropView{
+		IfExists:       ifExists(),
+		ViewIdentifier: *view.ViewIdentifier,
+	}
+}
+
+// dropTable does not use IF EXISTS, a migration which expects to drop a
+// table that is not there has been generated against a different database
+// and should fail.
+func dropTable(tableIdentifier *ast.CatalogObjectIdentifier) *ast.DropTable {
+	return &ast.DropTable{
+		TableIdentifier: *tableIdentifier,
+	}
+}
+
+func identifier(name string) ast.Identifier {
+	return ast.Identifier(tik.Token{
+		Text: name,
+		Kind: tik.TokenKind_Identifier,
+	})
+}
+
+func keyword(kind tik.TokenKind, text string) ast.Keyword {
+	return ast.Keyword(tik.Token{
+		Text: text,
+		Kind: kind,
+	})
+}
+
+func ifExists() *ast.IfExists {
+	return &ast.IfExists{
+		If:     keyword(tik.TokenKind_Keyword_IF, "IF"),
+		Exists: keyword(tik.TokenKind_Keyword_EXISTS, "EXISTS"),
+	}
+}
+
+func sqlText(node interface{ ToSql(f formatter.Formatter) }) string {
+	builder := strings.Builder{}
+	node.ToSql(formatter.NewCoreFormatter(&builder, 1<<30, "\"\""))
+	return builder.String()
+}
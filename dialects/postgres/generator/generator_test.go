@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	postgresparser "woodybriggs/justmigrate/dialects/postgres/parser"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/internal/testmigration"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden migrations in testdata")
+
+func generate(t *testing.T, from, to string) (string, error) {
+	return testmigration.Generate(t, postgresparser.NewPostgresParser, NewPostgresGenerator, diff.Diff{NormalizeColumn: postgresparser.NormalizeColumn}, from, to)
+}
+
+// TestGolden generates the migration from current.sql to desired.sql for
+// each directory in testdata and compares it with migration.sql, run with
+// -update to accept the new output.
+func TestGolden(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			current, err := os.ReadFile(filepath.Join(dir, "current.sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			desired, err := os.ReadFile(filepath.Join(dir, "desired.sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sql, err := generate(t, string(current), string(desired))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(dir, "migration.sql")
+			if *update {
+				if err := os.WriteFile(golden, []byte(sql), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != string(expected) {
+				t.Fatalf("migration does not match %s:\n%s", golden, sql)
+			}
+		})
+	}
+}
+
+// TestImpliedClausesAreNotChanges writes out what postgres leaves out of the
+// catalog, which the differ compares without.
+func TestImpliedClausesAreNotChanges(t *testing.T) {
+	sql, err := generate(t,
+		"CREATE TABLE users (id serial PRIMARY KEY, name text DEFAULT 'x');",
+		"CREATE TABLE users (id serial PRIMARY KEY NOT NULL, name text DEFAULT 'x'::text);",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "" {
+		t.Fatalf("expected no migration, got:\n%s", sql)
+	}
+}
+
+func TestRemovingEnumValueIsUnsupported(t *testing.T) {
+	_, err := generate(t,
+		"CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy');",
+		"CREATE TYPE mood AS ENUM ('sad', 'happy');",
+	)
+
+	if !errors.Is(err, diff.ErrUnsupportedEdit) {
+		t.Fatalf("expected an unsupported edit, got %v", err)
+	}
+}
+
+func TestChangingSerialIsUnsupported(t *testing.T) {
+	_, err := generate(t,
+		"CREATE TABLE users (id serial PRIMARY KEY);",
+		"CREATE TABLE users (id bigserial PRIMARY KEY);",
+	)
+
+	if !errors.Is(err, diff.ErrUnsupportedEdit) {
+		t.Fatalf("expected an unsupported edit, got %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE users (
+    id integer PRIMARY KEY,
+    name varchar(50),
+    status text DEFAULT 'new',
+    score int,
+    ticket bigint
+);
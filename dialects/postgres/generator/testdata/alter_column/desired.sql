@@ -0,0 +1,8 @@
+CREATE TABLE users (
+    id integer PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    status text,
+    score int DEFAULT 0,
+    ticket bigint GENERATED BY DEFAULT AS IDENTITY,
+    email text
+);
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE "users" ADD COLUMN "email" text;
+
+ALTER TABLE "users" ALTER COLUMN "name" TYPE character varying(100);
+
+ALTER TABLE "users" ALTER COLUMN "name" SET NOT NULL;
+
+ALTER TABLE "users" ALTER COLUMN "status" DROP DEFAULT;
+
+ALTER TABLE "users" ALTER COLUMN "score" SET DEFAULT 0;
+
+ALTER TABLE "users" ALTER COLUMN "ticket" ADD GENERATED BY DEFAULT AS IDENTITY;
+
+COMMIT;
+
@@ -0,0 +1,8 @@
+CREATE TABLE teams (id integer PRIMARY KEY);
+CREATE TABLE users (
+    id integer PRIMARY KEY,
+    team_id integer REFERENCES teams (id),
+    email text,
+    age integer,
+    CONSTRAINT adult CHECK (age >= 18)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE teams (id integer PRIMARY KEY);
+CREATE TABLE users (
+    id integer PRIMARY KEY,
+    team_id integer,
+    email text UNIQUE,
+    age integer,
+    CONSTRAINT adult CHECK (age >= 21),
+    UNIQUE (team_id, email)
+);
+CREATE INDEX users_email_lower ON users USING hash (lower(email));
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE "users" DROP CONSTRAINT "users_team_id_fkey";
+
+ALTER TABLE "users" DROP CONSTRAINT "adult";
+
+ALTER TABLE "users" ADD UNIQUE ("email");
+
+ALTER TABLE "users" ADD UNIQUE ("team_id", "email");
+
+ALTER TABLE "users" ADD CONSTRAINT "adult" CHECK ("age" >= 21);
+
+CREATE INDEX "users_email_lower" ON "users" USING hash (lower("email"));
+
+COMMIT;
+
@@ -0,0 +1,6 @@
+CREATE TABLE products (
+    id integer PRIMARY KEY,
+    code varchar(10),
+    price text,
+    stock text
+);
@@ -0,0 +1,7 @@
+CREATE TABLE products (
+    id integer PRIMARY KEY,
+    code varchar(20),
+    -- justmigrate:using replace(price, '$', '')::numeric(10, 2)
+    price numeric(10, 2),
+    stock integer
+);
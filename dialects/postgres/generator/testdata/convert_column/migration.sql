@@ -0,0 +1,20 @@
+BEGIN;
+
+ALTER TABLE "products" ALTER COLUMN "code" TYPE character varying(20);
+
+ALTER TABLE
+    "products"
+ALTER COLUMN
+    "price"
+TYPE numeric(10, 2)
+USING replace("price", '$', '')::numeric(10, 2);
+
+ALTER TABLE
+    "products"
+ALTER COLUMN
+    "stock"
+TYPE integer
+USING CAST("stock" AS integer);
+
+COMMIT;
+
@@ -0,0 +1 @@
+CREATE TYPE mood AS ENUM ('sad', 'happy');
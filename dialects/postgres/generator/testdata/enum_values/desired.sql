@@ -0,0 +1 @@
+CREATE TYPE mood AS ENUM ('awful', 'sad', 'ok', 'happy', 'ecstatic');
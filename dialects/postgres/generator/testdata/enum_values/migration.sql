@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TYPE "mood" ADD VALUE 'awful' BEFORE 'sad';
+
+ALTER TYPE "mood" ADD VALUE 'ok' AFTER 'sad';
+
+ALTER TYPE "mood" ADD VALUE 'ecstatic';
+
+COMMIT;
+
@@ -0,0 +1,4 @@
+CREATE SCHEMA legacy;
+CREATE TYPE legacy.colour AS ENUM ('red', 'green');
+CREATE DOMAIN positive AS integer CHECK (VALUE > 0);
+CREATE TABLE legacy.paint (id serial PRIMARY KEY, colour legacy.colour);
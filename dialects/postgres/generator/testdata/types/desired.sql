@@ -0,0 +1,10 @@
+CREATE SCHEMA billing;
+CREATE DOMAIN positive AS integer NOT NULL DEFAULT 1 CHECK (VALUE > 0);
+CREATE DOMAIN email AS text CHECK (VALUE LIKE '%@%');
+CREATE TYPE billing.status AS ENUM ('open', 'paid');
+CREATE TABLE billing.invoices (
+    id bigserial PRIMARY KEY,
+    status billing.status NOT NULL DEFAULT 'open',
+    amount positive,
+    contact email
+);
@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE SCHEMA "billing";
+
+CREATE TYPE "billing"."status" AS ENUM ('open', 'paid');
+
+CREATE DOMAIN "email" AS text CHECK ("value" LIKE '%@%');
+
+ALTER DOMAIN "positive" SET NOT NULL;
+
+ALTER DOMAIN "positive" SET DEFAULT 1;
+
+DROP TABLE "legacy"."paint";
+
+CREATE TABLE "billing"."invoices" (
+    "id" bigserial PRIMARY KEY,
+    "status" billing.status NOT NULL DEFAULT 'open',
+    "amount" positive,
+    "contact" email
+);
+
+DROP TYPE "legacy"."colour";
+
+DROP SCHEMA "legacy";
+
+COMMIT;
+
@@ -0,0 +1,3 @@
+CREATE TABLE orders (id integer PRIMARY KEY, total numeric(12, 2));
+CREATE VIEW order_totals AS SELECT id, total FROM orders;
+CREATE VIEW big_orders AS SELECT id FROM order_totals WHERE total > 100;
@@ -0,0 +1,16 @@
+BEGIN;
+
+DROP VIEW IF EXISTS "big_orders";
+
+DROP VIEW IF EXISTS "order_totals";
+
+ALTER TABLE "orders" ALTER COLUMN "total" TYPE numeric(12, 2);
+
+CREATE VIEW "order_totals" AS
+    SELECT id, total FROM orders;
+
+CREATE VIEW "big_orders" AS
+    SELECT id FROM order_totals WHERE total > 100;
+
+COMMIT;
+
@@ -0,0 +1,297 @@
+package postgres
+
+import (
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+func (p *PostgresParser) CreateStatement() ast.Statement {
+	p.PushParseContext("create statement")
+	defer p.PopParseContext()
+
+	switch peeked := p.Peeked(); peeked.Kind {
+	case tik.TokenKind_Keyword_TABLE:
+		return p.CreateTableStatement()
+	case tik.TokenKind_Keyword_VIEW, tik.TokenKind_Keyword_OR:
+		return p.CreateViewStatement()
+	case tik.TokenKind_Keyword_INDEX:
+		isUnique := false
+		return p.CreateIndexStatement(isUnique)
+	case tik.TokenKind_Keyword_UNIQUE:
+		isUnique := true
+		return p.CreateIndexStatement(isUnique)
+	default:
+		if parser.IsWord(peeked, "schema") {
+			return p.CreateSchemaStatement()
+		} else if parser.IsWord(peeked, "type") {
+			return p.CreateEnumStatement()
+		} else if parser.IsWord(peeked, "domain") {
+			return p.CreateDomainStatement()
+		}
+
+		err := report.
+			NewReport("parse error").
+			WithLabels([]report.Label{
+				{
+					Source: p.Current().SourceCode,
+					Range:  p.Current().SourceRange,
+					Note:   "unknown token for create statement",
+				},
+			})
+		p.ReportError(err)
+		return nil
+	}
+}
+
+func (p *PostgresParser) CreateSchemaStatement() *ast.CreateSchema {
+	p.PushParseContext("create schema statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+	schemaKeyword := p.ExpectWord("schema")
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	schemaName := p.Identifier()
+
+	return ast.MakeCreateSchema(
+		createKeyword,
+		schemaKeyword,
+		ifnotexists,
+		schemaName,
+	)
+}
+
+// CreateEnumStatement parses `CREATE TYPE name AS ENUM (...)`, enums are the
+// only kind of type which is supported.
+func (p *PostgresParser) CreateEnumStatement() *ast.CreateEnum {
+	p.PushParseContext("create type statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+	typeKeyword := p.ExpectWord("type")
+
+	typeIdent := p.CatalogObjectIdentifier()
+
+	asKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_AS))
+	enumKeyword := p.ExpectWord("enum")
+
+	p.Expect('(')
+
+	values := []ast.LiteralString{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else if p.Current().Kind == ')' {
+			break
+		} else {
+			values = append(values, *p.StringLiteral())
+		}
+	}
+
+	p.Expect(')')
+
+	return ast.MakeCreateEnum(
+		createKeyword,
+		typeKeyword,
+		typeIdent,
+		asKeyword,
+		enumKeyword,
+		values,
+	)
+}
+
+func (p *PostgresParser) CreateDomainStatement() *ast.CreateDomain {
+	p.PushParseContext("create domain statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+	domainKeyword := p.ExpectWord("domain")
+
+	domainIdent := p.CatalogObjectIdentifier()
+
+	var asKeyword *ast.Keyword = nil
+	if token, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_AS); ok {
+		asKeyword = ast.MakeKeyword(token)
+	}
+
+	typeName := p.TypeName()
+	constraints := p.ColumnConstraints()
+
+	return ast.MakeCreateDomain(
+		createKeyword,
+		domainKeyword,
+		domainIdent,
+		asKeyword,
+		typeName,
+		constraints,
+	)
+}
+
+func (p *PostgresParser) CreateTableStatement() *ast.CreateTable {
+	p.PushParseContext("create table statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	tableKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_TABLE))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	tableDefinition := p.TableDefinition()
+
+	return ast.MakeCreateTable(
+		createKeyword,
+		nil,
+		tableKeyword,
+		ifnotexists,
+		tableIdent,
+		tableDefinition,
+		nil,
+	)
+}
+
+func (p *PostgresParser) CreateViewStatement() *ast.CreateView {
+	p.PushParseContext("create view statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	// a view is always created from scratch, so OR REPLACE changes nothing.
+	if _, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_OR); ok {
+		p.Expect(tik.TokenKind_Keyword_REPLACE)
+	}
+
+	viewKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_VIEW))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	viewIdent := p.CatalogObjectIdentifier()
+
+	columnNames := []ast.Identifier{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				columnName := p.Identifier()
+				columnNames = append(columnNames, columnName)
+			}
+		}
+		p.Expect(')')
+	}
+
+	asKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_AS))
+
+	selectStmt := p.SelectStatement()
+
+	return ast.MakeCreateView(
+		createKeyword,
+		nil,
+		viewKeyword,
+		ifnotexists,
+		viewIdent,
+		columnNames,
+		asKeyword,
+		selectStmt,
+	)
+}
+
+// SelectStatement collects the tokens of a select statement up until the end
+// of the statement, the select itself is never interpreted.
+func (p *PostgresParser) SelectStatement() *ast.Select {
+	p.PushParseContext("select statement")
+	defer p.PopParseContext()
+
+	tokens := []tik.Token{p.Expect(tik.TokenKind_Keyword_SELECT)}
+
+	depth := 0
+	for !p.EndOfFile() {
+		if p.Current().Kind == ';' && depth == 0 {
+			break
+		} else if p.Current().Kind == ')' && depth == 0 {
+			break
+		} else if p.Current().Kind == '(' {
+			depth++
+		} else if p.Current().Kind == ')' {
+			depth--
+		}
+
+		// the select is written back out as it was, a keyword of a select
+		// is lexed as a name and would otherwise come out folded.
+		token := p.Current()
+		if token.Kind == tik.TokenKind_Identifier && !strings.HasPrefix(token.SourceText(), "\"") {
+			token.Text = token.SourceText()
+		}
+		tokens = append(tokens, token)
+		p.Advance()
+	}
+
+	return ast.MakeSelect(tokens)
+}
+
+func (p *PostgresParser) CreateIndexStatement(isUnique bool) *ast.CreateIndex {
+	p.PushParseContext("create index statement")
+	defer p.PopParseContext()
+
+	var uniqueKeyword *ast.Keyword = nil
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	if isUnique {
+		uniqueKeyword = ast.MakeKeyword(p.Expect(tik.TokenKind_Keyword_UNIQUE))
+	}
+
+	indexKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_INDEX))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	indexIdent := p.CatalogObjectIdentifier()
+
+	onKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ON))
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	// btree is the default method, leaving it out means an index written
+	// with and without it are the same.
+	var using *ast.Identifier = nil
+	if _, ok := p.MaybeTokenKind(tik.TokenKind_Keyword_USING); ok {
+		method := p.Identifier()
+		if strings.ToLower(method.Text) != "btree" {
+			using = &method
+		}
+	}
+
+	_, indexedColumns, _ := p.IndexedColumns(true)
+
+	var whereExpr ast.Expr = nil
+	if p.Current().Kind == tik.TokenKind_Keyword_WHERE {
+		p.Advance()
+		whereExpr = parser.Unparen(p.Expr(0))
+	}
+
+	createIndex := ast.MakeCreateIndex(
+		createKeyword,
+		uniqueKeyword,
+		indexKeyword,
+		ifnotexists,
+		indexIdent,
+		onKeyword,
+		tableIdent,
+		indexedColumns,
+		whereExpr,
+	)
+	createIndex.Using = using
+
+	return createIndex
+}
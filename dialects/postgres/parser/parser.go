@@ -0,0 +1,539 @@
+package postgres
+
+import (
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+// DefaultSchema is the schema objects are created in when one is not given.
+const DefaultSchema = "public"
+
+type PostgresParser struct {
+	*parser.Grammar
+}
+
+func NewPostgresParser(lexer *luther.Lexer) *PostgresParser {
+	lexer.Options = luther.Options{
+		SquareBrackets:  true,
+		DollarQuotes:    true,
+		FoldIdentifiers: true,
+	}
+	result := &PostgresParser{}
+	result.Grammar = parser.NewGrammar(lexer, result)
+	return result
+}
+
+// Identifier is a name, unquoted keywords used as names are folded to lower
+// case the same as any other unquoted name.
+func (p *PostgresParser) Identifier() ast.Identifier {
+	fold := p.IsFallbackKeyword()
+	ident := p.Parser.Identifier()
+	if fold {
+		ident.Text = strings.ToLower(ident.Text)
+	}
+	return ident
+}
+
+// CatalogObjectIdentifier is a maybe schema qualified name, the public schema
+// is dropped so that `public.users` and `users` are the same table.
+func (p *PostgresParser) CatalogObjectIdentifier() *ast.CatalogObjectIdentifier {
+	p.PushParseContext("catalog object identifier")
+	defer p.PopParseContext()
+
+	schemaOrObject := p.Identifier()
+
+	if p.Current().Kind != tik.TokenKind_Period {
+		return ast.MakeCatalogObjectIdentifier(nil, schemaOrObject)
+	}
+	p.Advance()
+
+	objectName := p.Identifier()
+	if schemaOrObject.Text == DefaultSchema {
+		return ast.MakeCatalogObjectIdentifier(nil, objectName)
+	}
+
+	return ast.MakeCatalogObjectIdentifier(
+		&schemaOrObject,
+		objectName,
+	)
+}
+
+func (p *PostgresParser) TableDefinition() *ast.TableDefinition {
+	p.PushParseContext("table definition")
+	defer p.PopParseContext()
+
+	lParen := p.Expect('(')
+
+	columnDefs := p.ColumnDefinitions()
+	tableConstraints := p.TableConstraints()
+
+	rParen := p.Closing(')')
+
+	tableConstraints = moveColumnConstraints(columnDefs, tableConstraints)
+
+	return ast.MakeTableDefinition(
+		lParen,
+		columnDefs,
+		tableConstraints,
+		rParen,
+	)
+}
+
+// moveColumnConstraints moves the primary key, unique and foreign key table
+// constraints on a single column onto the column. postgres does not remember
+// which way they were written, so this is how they come back out of the
+// catalog too.
+func moveColumnConstraints(columnDefs []ast.ColumnDefinition, tableConstraints []ast.TableConstraint) []ast.TableConstraint {
+	return slices.DeleteFunc(tableConstraints, func(constraint ast.TableConstraint) bool {
+		var column string
+		var moved ast.ColumnConstraint
+
+		switch constraint := constraint.(type) {
+		case *ast.TableConstraint_PrimaryKey:
+			name, ok := indexedColumnName(constraint.IndexedColumns)
+			if !ok {
+				return false
+			}
+			column = name
+			moved = ast.MakeColumnConstraintPrimaryKey(constraint.Name, constraint.PrimaryKeyword, constraint.KeyKeyword, nil, nil, nil)
+		case *ast.TableConstraint_Unique:
+			name, ok := indexedColumnName(constraint.IndexedColumns)
+			if !ok {
+				return false
+			}
+			column = name
+			moved = ast.MakeColumnConstraintUnique(constraint.Name, nil)
+		case *ast.TableConstraint_ForeignKey:
+			if len(constraint.Columns) != 1 {
+				return false
+			}
+			column = constraint.Columns[0].Text
+			moved = ast.MakeColumnConstraintForeignKey(constraint.Name, &constraint.FkClause)
+		default:
+			return false
+		}
+
+		i := slices.IndexFunc(columnDefs, func(def ast.ColumnDefinition) bool {
+			return def.ColumnName.Text == column
+		})
+		if i < 0 {
+			return false
+		}
+		columnDefs[i].ColumnConstraints = append(columnDefs[i].ColumnConstraints, moved)
+		return true
+	})
+}
+
+func indexedColumnName(columns []ast.IndexedColumn) (string, bool) {
+	if len(columns) != 1 || columns[0].Collation != nil || columns[0].Order != nil {
+		return "", false
+	}
+	ident, ok := columns[0].Subject.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+	return ident.Text, true
+}
+
+// TableConstraint parses a table constraint, unlike sqlite an unnamed
+// constraint is not warned about as postgres gives it a name of its own.
+func (p *PostgresParser) TableConstraint() ast.TableConstraint {
+	p.PushParseContext("table constraint")
+	defer p.PopParseContext()
+
+	constraintName := p.MaybeConstraintName()
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_PRIMARY:
+		return p.TableConstraint_PrimaryKey(constraintName)
+	case tik.TokenKind_Keyword_FOREIGN:
+		return p.TableConstraint_ForeignKey(constraintName)
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.TableConstraint_Unique(constraintName)
+	case tik.TokenKind_Keyword_CHECK:
+		return p.TableConstraint_Check(constraintName)
+	default:
+		err := report.
+			NewReport("parse error").
+			WithLabels([]report.Label{
+				{
+					Source: p.Current().SourceCode,
+					Range:  p.Current().SourceRange,
+					Note:   "unexpected token for table constraint",
+				},
+			})
+		p.ReportError(err)
+		return nil
+	}
+}
+
+// NormalizeColumn is the column without what postgres would not remember
+// about it, the form the differ compares columns in. A primary key, identity
+// or serial column is always not null, so saying so is dropped, and the cast
+// on a literal default to the type of the column is dropped as it is the same
+// default without it.
+func NormalizeColumn(column ast.ColumnDefinition) ast.ColumnDefinition {
+	implicitNotNull := IsSerial(column.TypeName)
+	for _, constraint := range column.ColumnConstraints {
+		switch constraint.(type) {
+		case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Identity:
+			implicitNotNull = true
+		}
+	}
+
+	constraints := []ast.ColumnConstraint{}
+	for _, constraint := range column.ColumnConstraints {
+		switch typ := constraint.(type) {
+		case *ast.ColumnConstraint_NotNull:
+			if implicitNotNull {
+				continue
+			}
+		case *ast.ColumnConstraint_Default:
+			if cast, ok := typ.Default.(*ast.Cast); ok && cast.CastKeyword == nil && isSameType(cast.Type, column.TypeName) {
+				if _, ok := cast.Expr.(*ast.LiteralString); ok {
+					constraint = ast.MakeColumnConstraintDefault(typ.Name, cast.Expr)
+				}
+			}
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	column.ColumnConstraints = constraints
+	return column
+}
+
+func isSameType(a, b ast.TypeName) bool {
+	return a.TypeName.Text == b.TypeName.Text && a.Array == b.Array
+}
+
+// IsSerial reports whether the type is one of the serial pseudo types, which
+// are an integer column with a default from a sequence owned by the column.
+func IsSerial(typeName ast.TypeName) bool {
+	switch typeName.TypeName.Text {
+	case "serial", "bigserial", "smallserial":
+		return true
+	default:
+		return false
+	}
+}
+
+// typeAliases maps the other names of the built in types onto the name
+// postgres prints them as, so `int4` and `integer` are the same type.
+var typeAliases = map[string]string{
+	"int":          "integer",
+	"int4":         "integer",
+	"int2":         "smallint",
+	"int8":         "bigint",
+	"serial4":      "serial",
+	"serial2":      "smallserial",
+	"serial8":      "bigserial",
+	"float":        "double precision",
+	"float8":       "double precision",
+	"float4":       "real",
+	"bool":         "boolean",
+	"varchar":      "character varying",
+	"char varying": "character varying",
+	"char":         "character",
+	"bpchar":       "character",
+	"varbit":       "bit varying",
+	"decimal":      "numeric",
+	"timestamp":    "timestamp without time zone",
+	"timestamptz":  "timestamp with time zone",
+	"time":         "time without time zone",
+	"timetz":       "time with time zone",
+}
+
+// TypeName parses a type, made up of several words for the likes of
+// `double precision`, followed by any arguments, the time zone of a time
+// type and the dimensions of an array.
+func (p *PostgresParser) TypeName() ast.TypeName {
+	p.PushParseContext("type name")
+	defer p.PopParseContext()
+
+	if p.Current().Kind != tik.TokenKind_Identifier {
+		return ast.TypeName{}
+	}
+
+	typeToken := p.Expect(tik.TokenKind_Identifier)
+	if p.Current().Kind == tik.TokenKind_Period {
+		p.Advance()
+		name := p.Identifier()
+		if typeToken.Text != DefaultSchema {
+			name.Text = typeToken.Text + "." + name.Text
+		}
+		typeToken = tik.Token(name)
+	}
+
+	for p.Current().Kind == tik.TokenKind_Identifier && !p.isTimeZone() {
+		next := p.Expect(tik.TokenKind_Identifier)
+		typeToken.Text = typeToken.Text + " " + next.Text
+		typeToken.SourceRange.End = next.SourceRange.End
+		typeToken.TrailingTrivia = next.TrailingTrivia
+	}
+
+	args := []ast.Expr{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				args = append(args, p.Expr(0))
+			}
+		}
+		p.Expect(')')
+	}
+
+	if alias, ok := typeAliases[typeToken.Text]; ok {
+		typeToken.Text = alias
+	}
+
+	if p.isTimeZone() {
+		with := p.Current()
+		p.Advance()
+		p.ExpectWord("time")
+		p.ExpectWord("zone")
+		typeToken.Text = strings.TrimSuffix(strings.TrimSuffix(typeToken.Text, " without time zone"), " with time zone")
+		typeToken.Text = typeToken.Text + " " + strings.ToLower(with.Text) + " time zone"
+	}
+
+	typeName := ast.MakeTypeName(ast.Identifier(typeToken), args)
+
+	// the size of an array is not enforced, `integer[3]` is the same as
+	// `integer[]`.
+	for p.Current().Kind == tik.TokenKind_LBracket {
+		p.Advance()
+		if p.Current().Kind != tik.TokenKind_RBracket {
+			p.Expr(0)
+		}
+		p.Expect(tik.TokenKind_RBracket)
+		typeName.Array++
+	}
+
+	return typeName
+}
+
+func (p *PostgresParser) isTimeZone() bool {
+	if !p.IsWord("with") && p.Current().Kind != tik.TokenKind_Keyword_WITHOUT {
+		return false
+	}
+	return parser.IsWord(p.Peeked(), "time")
+}
+
+func (p *PostgresParser) ColumnConstraint() ast.ColumnConstraint {
+	p.PushParseContext("column constraint")
+	defer p.PopParseContext()
+
+	constraintName := p.MaybeConstraintName()
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_PRIMARY:
+		return p.ColumnConstraint_PrimaryKey(constraintName)
+	case tik.TokenKind_Keyword_NOT:
+		return p.ColumnConstraint_NotNull(constraintName)
+	case tik.TokenKind_Keyword_DEFAULT:
+		return p.ColumnConstraint_Default(constraintName)
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.ColumnConstraint_Unique(constraintName)
+	case tik.TokenKind_Keyword_COLLATE:
+		return p.ColumnConstraint_Collate(constraintName)
+	case tik.TokenKind_Keyword_CHECK:
+		return p.ColumnConstraint_Check(constraintName)
+	case tik.TokenKind_Keyword_REFERENCES:
+		return p.ColumnConstraint_ForeignKey(constraintName)
+	case tik.TokenKind_Keyword_GENERATED:
+		return p.ColumnConstraint_Generated(constraintName)
+	default:
+		{
+			p.ReportError(
+				report.
+					NewReport("parse error").
+					WithLabels([]report.Label{
+						{
+							Source: p.Current().SourceCode,
+							Range:  p.Current().SourceRange,
+							Note:   "unexpected token at start of column constraint",
+						},
+					}),
+			)
+			return nil
+		}
+	}
+}
+
+// ColumnConstraint_Generated parses both kinds of generated column, a
+// computed column `GENERATED ALWAYS AS (expr) STORED` and an identity column
+// `GENERATED { ALWAYS | BY DEFAULT } AS IDENTITY`.
+func (p *PostgresParser) ColumnConstraint_Generated(constraintName *ast.ConstraintName) ast.ColumnConstraint {
+	p.PushParseContext("generated column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_GENERATED)
+
+	always := true
+	if p.IsWord("by") {
+		p.Advance()
+		p.Expect(tik.TokenKind_Keyword_DEFAULT)
+		always = false
+	} else {
+		p.Expect(tik.TokenKind_Keyword_ALWAYS)
+	}
+
+	p.Expect(tik.TokenKind_Keyword_AS)
+
+	if always && p.Current().Kind == '(' {
+		p.Expect('(')
+		expr := p.Expr(0)
+		p.Expect(')')
+
+		storage := ast.MakeKeyword(p.Expect(tik.TokenKind_Keyword_STORED))
+
+		return ast.MakeColumnConstraintGenerated(
+			constraintName,
+			expr,
+			storage,
+		)
+	}
+
+	p.ExpectWord("identity")
+
+	// the options of the sequence are not kept, skip over them.
+	if p.Current().Kind == '(' {
+		depth := 0
+		for !p.EndOfFile() {
+			if p.Current().Kind == '(' {
+				depth++
+			} else if p.Current().Kind == ')' {
+				depth--
+			}
+			p.Advance()
+			if depth == 0 {
+				break
+			}
+		}
+	}
+
+	return ast.MakeColumnConstraintIdentity(
+		constraintName,
+		always,
+	)
+}
+
+// Expr is the pratt parser of the core parser with the addition of the
+// postfix `::` cast, which binds tighter than any other operator.
+func (p *PostgresParser) Expr(minBindingPower int) ast.Expr {
+	lhs := p.Term()
+
+	for !p.EndOfFile() {
+		if p.Current().Kind == tik.TokenKind_DoubleColon {
+			p.Advance()
+			lhs = &ast.Cast{
+				Expr: lhs,
+				Type: p.TypeName(),
+			}
+			continue
+		}
+
+		if p.isDoubleTilde() {
+			bp, _ := p.OperatorBindingPower(tik.Token{Kind: tik.TokenKind_Keyword_LIKE})
+			if bp.L < minBindingPower {
+				break
+			}
+			lhs = ast.MakeBinaryOpExpr(lhs, p.doubleTilde(), p.Expr(bp.R))
+			continue
+		}
+
+		bp, found := p.OperatorBindingPower(p.Current())
+		if !found || bp.L < minBindingPower {
+			break
+		}
+
+		op := p.Current()
+		p.Advance()
+
+		rhs := p.Expr(bp.R)
+
+		lhs = ast.MakeBinaryOpExpr(lhs, op, rhs)
+	}
+
+	return lhs
+}
+
+// isDoubleTilde reports whether the current tokens are `~~`, which is how
+// postgres writes LIKE back out of the catalog.
+func (p *PostgresParser) isDoubleTilde() bool {
+	return p.Current().Kind == '~' &&
+		p.Peeked().Kind == '~' &&
+		p.Current().SourceRange.End == p.Peeked().SourceRange.Start
+}
+
+// doubleTilde consumes `~~` as a LIKE so that it is the same as the LIKE it
+// was written as.
+func (p *PostgresParser) doubleTilde() tik.Token {
+	op := p.Current()
+	p.Advance()
+	op.SourceRange.End = p.Current().SourceRange.End
+	p.Advance()
+
+	op.Kind = tik.TokenKind_Keyword_LIKE
+	op.Text = "LIKE"
+	return op
+}
+
+// Term adds the array constructor, `ARRAY[...]`, to the terms of the shared
+// grammar.
+func (p *PostgresParser) Term() ast.Expr {
+	if p.IsWord("array") && p.Peeked().Kind == tik.TokenKind_LBracket {
+		return p.ArrayConstructor()
+	}
+	return p.Grammar.Term()
+}
+
+func (p *PostgresParser) ArrayConstructor() ast.Expr {
+	p.PushParseContext("array constructor")
+	defer p.PopParseContext()
+
+	arrayKeyword := p.ExpectWord("array")
+	p.Expect(tik.TokenKind_LBracket)
+
+	elements := ast.ExprList{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else if p.Current().Kind == tik.TokenKind_RBracket {
+			break
+		} else {
+			elements = append(elements, p.Expr(0))
+		}
+	}
+
+	p.Expect(tik.TokenKind_RBracket)
+
+	return &ast.ArrayConstructor{
+		ArrayKeyword: arrayKeyword,
+		Elements:     elements,
+	}
+}
+
+func (p *PostgresParser) StringLiteral() *ast.LiteralString {
+	token := p.Expect(tik.TokenKind_StringLiteral)
+	if token.SourceText() != "" && token.SourceText()[0] == '$' {
+		return &ast.LiteralString{
+			Token: token,
+			Value: token.Text,
+		}
+	}
+	return &ast.LiteralString{
+		Token: token,
+		Value: strings.ReplaceAll(token.Text, "''", "'"),
+	}
+}
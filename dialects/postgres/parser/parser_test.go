@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/formatter"
+)
+
+func makeParser(input string) *PostgresParser {
+
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		panic("unable to get caller info")
+	}
+	funcInfo := runtime.FuncForPC(pc)
+	file, _ := funcInfo.FileLine(pc)
+
+	lex := luther.NewLexer(luther.SourceCode{
+		FileName: fmt.Sprintf("%s/%s", file, funcInfo.Name()),
+		Raw:      []rune(input),
+	})
+
+	return NewPostgresParser(lex)
+}
+
+func sqlText(node interface{ ToSql(formatter.Formatter) }) string {
+	builder := strings.Builder{}
+	node.ToSql(formatter.NewCoreFormatter(&builder, 1000, ""))
+	return builder.String()
+}
+
+func TestStatements(t *testing.T) {
+	parser := makeParser(`
+		CREATE SCHEMA billing;
+		CREATE TYPE public.mood AS ENUM ('sad', 'ok', 'happy');
+		CREATE DOMAIN email AS text CHECK (VALUE LIKE '%@%');
+		CREATE TABLE billing.Invoices (
+			id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			total numeric(10, 2) NOT NULL DEFAULT 0,
+			tags text[] DEFAULT '{}'::text[],
+			body text DEFAULT $$it's$$
+		);
+		CREATE INDEX invoices_tags ON billing.invoices USING gin (tags);
+	`)
+
+	statements := parser.Statements()
+	if len(parser.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", parser.Errors())
+	}
+	if len(statements) != 5 {
+		t.Fatalf("expected 5 statements got %d", len(statements))
+	}
+
+	enum := statements[1].(*ast.CreateEnum)
+	if enum.TypeIdentifier.SchemaName != nil || len(enum.Values) != 3 {
+		t.Errorf("unexpected enum: %s", sqlText(enum))
+	}
+
+	table := statements[3].(*ast.CreateTable)
+	if got := table.TableIdentifier.FullyQualifiedName(DefaultSchema); got != `"billing"."invoices"` {
+		t.Errorf("expected unquoted names to be folded, got %s", got)
+	}
+
+	columns := table.TableDefinition.ColumnDefinitions
+	if _, ok := columns[0].ColumnConstraints[0].(*ast.ColumnConstraint_Identity); !ok {
+		t.Errorf("expected an identity column, got %T", columns[0].ColumnConstraints[0])
+	}
+	if columns[2].TypeName.Array != 1 {
+		t.Errorf("expected an array type, got %s", sqlText(&columns[2].TypeName))
+	}
+	if _, ok := columns[2].ColumnConstraints[0].(*ast.ColumnConstraint_Default).Default.(*ast.Cast); !ok {
+		t.Errorf("expected the cast to the column type to be kept as written")
+	}
+	if _, ok := NormalizeColumn(columns[2]).ColumnConstraints[0].(*ast.ColumnConstraint_Default).Default.(*ast.LiteralString); !ok {
+		t.Errorf("expected the cast to the column type to be dropped when normalized")
+	}
+	if body := columns[3].ColumnConstraints[0].(*ast.ColumnConstraint_Default).Default.(*ast.LiteralString); body.Value != "it's" {
+		t.Errorf("unexpected dollar quoted string %q", body.Value)
+	}
+
+	index := statements[4].(*ast.CreateIndex)
+	if index.Using == nil || index.Using.Text != "gin" {
+		t.Errorf("expected the index method to be kept")
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	tests := map[string]string{
+		"int4":                        "integer",
+		"VARCHAR(255)":                "character varying(255)",
+		"timestamptz":                 "timestamp with time zone",
+		"timestamp(3) with time zone": "timestamp(3) with time zone",
+		"time without time zone":      "time without time zone",
+		"double precision[][]":        "double precision[][]",
+		"public.mood":                 "mood",
+	}
+
+	for input, expected := range tests {
+		parser := makeParser(input)
+		typeName := parser.TypeName()
+		if got := sqlText(&typeName); got != expected {
+			t.Errorf("%s: expected %s got %s", input, expected, got)
+		}
+	}
+}
+
+func TestSerialIsNotNull(t *testing.T) {
+	parser := makeParser("CREATE TABLE users (id serial NOT NULL, email text, UNIQUE (email))")
+
+	table := parser.CreateTableStatement()
+	columns := table.TableDefinition.ColumnDefinitions
+
+	if len(columns[0].ColumnConstraints) != 1 {
+		t.Errorf("expected not null to be kept as written")
+	}
+	if len(NormalizeColumn(columns[0]).ColumnConstraints) != 0 {
+		t.Errorf("expected not null to be dropped from a serial column when normalized")
+	}
+	if len(table.TableDefinition.TableConstraints) != 0 {
+		t.Errorf("expected the unique constraint to be moved onto the column")
+	}
+	if _, ok := columns[1].ColumnConstraints[0].(*ast.ColumnConstraint_Unique); !ok {
+		t.Errorf("expected a unique column constraint, got %T", columns[1].ColumnConstraints[0])
+	}
+}
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"fmt"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+func (p *PostgresParser) Statement() ast.Statement {
+	p.PushParseContext("statement")
+	defer p.PopParseContext()
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_CREATE:
+		return p.CreateStatement()
+	case tik.TokenKind_Keyword_BEGIN:
+		return p.BeginStatement()
+	case tik.TokenKind_Keyword_COMMIT:
+		return p.CommitStatement()
+	default:
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   fmt.Sprintf("unknown token at start of sql statement '%s'", p.Current().DebugString()),
+					},
+				}),
+		)
+		return nil
+	}
+}
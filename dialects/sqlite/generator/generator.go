@@ -2,16 +2,18 @@ package sqlite
 
 import (
 	"io"
-	"os"
 	"slices"
+	"strings"
 	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
 	"woodybriggs/justmigrate/core/tik"
 	"woodybriggs/justmigrate/diff"
 	"woodybriggs/justmigrate/formatter"
 )
 
 type SqliteGenerator struct {
-	edits []diff.Edit
+	edits    []diff.Edit
+	warnings []report.Report
 }
 
 func NewSqliteGenerator(edits []diff.Edit) *SqliteGenerator {
@@ -20,33 +22,163 @@ func NewSqliteGenerator(edits []diff.Edit) *SqliteGenerator {
 	}
 }
 
-func (gen *SqliteGenerator) Generate(writer io.Writer) {
+// Migration is what a generator produces, the statements of the migration
+// and the sql they render to.
+type Migration struct {
+	Statements []ast.Statement
+	Sql        string
+	// Warnings are the objects which depend on each other in a cycle, and
+	// so could not be put in order.
+	Warnings []report.Report
+}
 
-	statements := []ast.Statement{}
+// Generate writes the migration to writer.
+func (gen *SqliteGenerator) Generate(writer io.Writer) error {
+	migration, err := gen.Migration()
+	if err != nil {
+		return err
+	}
+	gen.warnings = migration.Warnings
+
+	_, err = io.WriteString(writer, migration.Sql)
+	return err
+}
+
+// Warnings are the warnings of the last migration written by Generate.
+func (gen *SqliteGenerator) Warnings() []report.Report {
+	return gen.warnings
+}
+
+// Migration builds the statements which make the edits, an edit it does not
+// know how to make is returned as a *diff.EditError.
+func (gen *SqliteGenerator) Migration() (*Migration, error) {
+
+	// statements are emitted in three phases so that nothing is created
+	// before the things it depends on, or dropped after them:
+	//   1. triggers, views and indexes which are going away or changing
+	//   2. tables, dropped ones first and new ones before the ones which
+	//      change, as a changed table may come to reference a new one
+	//   3. indexes, views and triggers which are new or changed
+	drops := []ast.Statement{}
+	removedTables := []ast.Statement{}
+	renames := []ast.Statement{}
+	addedTables := []ast.Statement{}
+	tables := []ast.Statement{}
+	creates := []ast.Statement{}
+
+	order := ordering{}
+	rebuilding := false
+	// statements already recreated as part of rebuilding a table
+	recreated := map[ast.Statement]bool{}
 
 	for _, edit := range gen.edits {
 		switch typ := edit.(type) {
 		case *diff.EditAddTable:
 			{
-				statements = append(statements, typ.CreateTable)
+				addedTables = append(addedTables, typ.CreateTable)
 			}
 		case *diff.EditRemoveTable:
 			{
-				statements = append(statements, dropTable(typ.TableIdentifier))
+				removedTables = append(removedTables, typ.CreateTable)
+			}
+		case *diff.EditRenameTable:
+			{
+				renames = append(renames, renameTable(typ.From, typ.To))
 			}
 		case *diff.EditModifyTable:
 			{
-				statements = slices.Concat(statements, alterTable(typ.Target, typ.Edits))
+				if needsRebuild(typ) {
+					rebuilding = true
+					statements, err := rebuildTable(typ, &order)
+					if err != nil {
+						return nil, err
+					}
+					tables = slices.Concat(tables, statements)
+					for _, dependent := range typ.Dependents {
+						recreated[dependent] = true
+					}
+				} else {
+					tables = slices.Concat(tables, alterTable(typ.Target, typ.Edits))
+				}
+			}
+		case *diff.EditAddVirtualTable:
+			{
+				addedTables = append(addedTables, typ.CreateVirtualTable)
+			}
+		case *diff.EditRemoveVirtualTable:
+			{
+				removedTables = append(removedTables, typ.CreateVirtualTable)
+			}
+		case *diff.EditReplaceVirtualTable:
+			{
+				tables = append(tables, dropTable(typ.Target.TableIdentifier), typ.Result)
+			}
+		case *diff.EditAddIndex:
+			{
+				creates = append(creates, typ.CreateIndex)
+			}
+		case *diff.EditRemoveIndex:
+			{
+				drops = append(drops, dropIndex(typ.CreateIndex))
+			}
+		case *diff.EditReplaceIndex:
+			{
+				drops = append(drops, dropIndex(typ.Target))
+				creates = append(creates, typ.Result)
+			}
+		case *diff.EditAddView:
+			{
+				creates = append(creates, typ.CreateView)
+			}
+		case *diff.EditRemoveView:
+			{
+				drops = append(drops, typ.CreateView)
+			}
+		case *diff.EditReplaceView:
+			{
+				drops = append(drops, typ.Target)
+				creates = append(creates, typ.Result)
+			}
+		case *diff.EditAddTrigger:
+			{
+				creates = append(creates, typ.CreateTrigger)
+			}
+		case *diff.EditRemoveTrigger:
+			{
+				drops = append(drops, dropTrigger(typ.CreateTrigger))
+			}
+		case *diff.EditReplaceTrigger:
+			{
+				drops = append(drops, dropTrigger(typ.Target))
+				creates = append(creates, typ.Result)
 			}
 
 		default:
 			{
-				panic("not implemented")
+				return nil, &diff.EditError{Edit: edit, Err: diff.ErrUnsupportedEdit}
 			}
 		}
 	}
 
-	core := formatter.NewCoreFormatter(os.Stderr, 80, "\"\"")
+	creates = slices.DeleteFunc(creates, func(statement ast.Statement) bool {
+		return recreated[statement]
+	})
+
+	statements := slices.Concat(
+		order.drops(drops),
+		order.dropTables(removedTables),
+		renames,
+		order.createTables(addedTables),
+		tables,
+		order.creates(creates),
+	)
+
+	if len(statements) > 0 {
+		statements = inTransaction(statements, rebuilding)
+	}
+
+	sql := strings.Builder{}
+	core := formatter.NewCoreFormatter(&sql, 80, "\"\"")
 
 	for _, statement := range statements {
 		statement.ToSql(core)
@@ -54,12 +186,118 @@ func (gen *SqliteGenerator) Generate(writer io.Writer) {
 		core.Break()
 		core.Break()
 	}
+
+	return &Migration{
+		Statements: statements,
+		Sql:        sql.String(),
+		Warnings:   order.warnings,
+	}, nil
+}
+
+// inTransaction wraps the migration in a transaction. foreign key enforcement
+// can only be changed outside of a transaction, so when a table is rebuilt
+// it is turned off before the transaction, the foreign keys are checked by
+// hand before committing and it is turned back on afterwards. The check only
+// reports the rows breaking a foreign key, the guard after it is what rolls
+// the transaction back, so the script fails on its own when it is run by hand.
+func inTransaction(statements []ast.Statement, rebuilding bool) []ast.Statement {
+	begin := &ast.BeginTransaction{
+		BeginKeyword: keyword(tik.TokenKind_Keyword_BEGIN, "BEGIN"),
+	}
+	commit := &ast.CommitTransaction{
+		CommitKeyword: keyword(tik.TokenKind_Keyword_COMMIT, "COMMIT"),
+	}
+
+	if !rebuilding {
+		return slices.Concat([]ast.Statement{begin}, statements, []ast.Statement{commit})
+	}
+
+	return slices.Concat(
+		[]ast.Statement{
+			pragma("foreign_keys", &ast.LiteralBoolean{
+				Token: tik.Token{Text: "OFF", Kind: tik.TokenKind_Identifier},
+				Value: false,
+			}),
+			begin,
+		},
+		statements,
+		[]ast.Statement{
+			pragma("foreign_key_check", nil),
+		},
+		foreignKeyGuard(),
+		[]ast.Statement{
+			commit,
+			pragma("foreign_keys", &ast.LiteralBoolean{
+				Token: tik.Token{Text: "ON", Kind: tik.TokenKind_Identifier},
+				Value: true,
+			}),
+		},
+	)
+}
+
+// foreignKeyGuard counts the rows breaking a foreign key into a temporary
+// table which only accepts a count of zero, `INSERT OR ROLLBACK` turns a
+// broken foreign key into a failed statement and a rolled back transaction.
+func foreignKeyGuard() []ast.Statement {
+	guard := ast.MakeCatalogObjectIdentifier(nil, identifier("justmigrate_foreign_key_check"))
+	violations := identifier("violations")
+	temporary := keyword(tik.TokenKind_Keyword_TEMPORARY, "TEMP")
+	rollback := keyword(tik.TokenKind_Keyword_ROLLBACK, "ROLLBACK")
+
+	return []ast.Statement{
+		&ast.CreateTable{
+			CreateKeyword:   keyword(tik.TokenKind_Keyword_CREATE, "CREATE"),
+			Temporary:       &temporary,
+			TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+			TableIdentifier: guard,
+			TableDefinition: &ast.TableDefinition{
+				ColumnDefinitions: []ast.ColumnDefinition{
+					{
+						ColumnName: violations,
+						TypeName:   ast.MakeTypeName(identifier("integer"), nil),
+						ColumnConstraints: []ast.ColumnConstraint{
+							&ast.ColumnConstraint_Check{
+								Check: &ast.BinaryOp{
+									Operator: tik.Token{Text: "=", Kind: '='},
+									Lhs:      &ast.ColumnName{Column: violations},
+									Rhs:      &ast.LiteralInteger{Token: tik.Token{Text: "0", Kind: tik.TokenKind_DecimalNumericLiteral}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.InsertSelect{
+			Conflict:        &rollback,
+			TableIdentifier: guard,
+			Columns:         []ast.Identifier{violations},
+			SelectExprs: []ast.Expr{
+				&ast.FunctionCall{
+					Name: identifier("count"),
+					Args: ast.ExprList{&ast.LiteralInteger{Token: tik.Token{Text: "1", Kind: tik.TokenKind_DecimalNumericLiteral}, Value: 1}},
+				},
+			},
+			FromTable: ast.MakeCatalogObjectIdentifier(nil, identifier("pragma_foreign_key_check")),
+		},
+		&ast.DropTable{
+			TableIdentifier: *guard,
+		},
+	}
 }
 
 func alterTable(table *ast.CreateTable, edits []diff.Edit) []ast.Statement {
 
 	statements := []ast.Statement{}
 
+	// renames go first, a column may be added under the name another was
+	// renamed away from.
+	for _, edit := range edits {
+		if typ, ok := edit.(*diff.EditRenameColumn); ok {
+			statements = append(statements, alterTableRenameColumn(table, typ.From, typ.To))
+		}
+	}
+
 	for _, edit := range edits {
 		switch typ := edit.(type) {
 		case *diff.EditAddColumn:
@@ -130,16 +368,61 @@ func alterTableDropColumn(table *ast.CreateTable, column ast.ColumnDefinition) *
 	}
 }
 
+func alterTableRenameColumn(table *ast.CreateTable, from, to ast.Identifier) *ast.AlterTable {
+	column := keyword(tik.TokenKind_Keyword_COLUMN, "COLUMN")
+	return &ast.AlterTable{
+		AlterKeyword:    keyword(tik.TokenKind_Keyword_ALTER, "ALTER"),
+		TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+		TableIdentifier: table.TableIdentifier,
+		Alteration: &ast.RenameColumn{
+			RenameKeyword: keyword(tik.TokenKind_Keyword_RENAME, "RENAME"),
+			ColumnKeyword: &column,
+			ColumnName:    from,
+			ToKeyword:     keyword(tik.TokenKind_Keyword_TO, "TO"),
+			NewName:       to,
+		},
+	}
+}
+
+func renameTable(from, to *ast.CatalogObjectIdentifier) *ast.AlterTable {
+	return &ast.AlterTable{
+		AlterKeyword:    keyword(tik.TokenKind_Keyword_ALTER, "ALTER"),
+		TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+		TableIdentifier: from,
+		Alteration: &ast.RenameTable{
+			RenameKeyword: keyword(tik.TokenKind_Keyword_RENAME, "RENAME"),
+			ToKeyword:     keyword(tik.TokenKind_Keyword_TO, "TO"),
+			NewName:       to.ObjectName,
+		},
+	}
+}
+
+func dropIndex(index *ast.CreateIndex) *ast.DropIndex {
+	return &ast.DropIndex{
+		IfExists:        ifExists(),
+		IndexIdentifier: *index.IndexIdentifier,
+	}
+}
+
+func dropTrigger(trigger *ast.CreateTrigger) *ast.DropTrigger {
+	return &ast.DropTrigger{
+		IfExists:          ifExists(),
+		TriggerIdentifier: *trigger.TriggerIdentifier,
+	}
+}
+
+func dropView(view *ast.CreateView) *ast.DropView {
+	return &ast.DropView{
+		IfExists:       ifExists(),
+		ViewIdentifier: *view.ViewIdentifier,
+	}
+}
+
+// dropTable does not use IF EXISTS, a migration which expects to drop a
+// table that is not there has been generated against a different database
+// and should fail.
 func dropTable(tableIdentifier *ast.CatalogObjectIdentifier) *ast.DropTable {
 	return &ast.DropTable{
-		IfExists: &ast.IfExists{
-			If: ast.Keyword(tik.Token{
-				Text: "IF",
-			}),
-			Exists: ast.Keyword(tik.Token{
-				Text: "EXISTS",
-			}),
-		},
 		TableIdentifier: *tableIdentifier,
 	}
 }
@@ -0,0 +1,281 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	sqliteparser "woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/internal/testmigration"
+	"woodybriggs/justmigrate/internal/testschema"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func parseSchema(t *testing.T, input string) []ast.Statement {
+	return testschema.Parse(t, sqliteparser.NewSqliteParser, input)
+}
+
+func generate(t *testing.T, from, to string) string {
+	sql, err := testmigration.Generate(t, sqliteparser.NewSqliteParser, NewSqliteGenerator, diff.Diff{}, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sql
+}
+
+func expectInOrder(t *testing.T, sql string, parts ...string) {
+	rest := sql
+	for _, part := range parts {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			t.Fatalf("expected %q in order in:\n%s", part, sql)
+		}
+		rest = rest[i+len(part):]
+	}
+}
+
+func TestAddColumnUsesAlterTable(t *testing.T) {
+	sql := generate(t,
+		"CREATE TABLE users (id integer PRIMARY KEY);",
+		"CREATE TABLE users (id integer PRIMARY KEY, name text);",
+	)
+
+	expectInOrder(t, sql, "BEGIN;", `ALTER TABLE "users" ADD COLUMN "name" text;`, "COMMIT;")
+	if strings.Contains(sql, "new_users") {
+		t.Fatalf("expected no rebuild in:\n%s", sql)
+	}
+}
+
+func TestAddTableConstraintRebuildsTable(t *testing.T) {
+	sql := generate(t,
+		`CREATE TABLE teams (id integer PRIMARY KEY);
+		CREATE TABLE users (id integer PRIMARY KEY, team_id integer);
+		CREATE INDEX users_team ON users (team_id);
+		CREATE VIEW team_users AS SELECT id FROM users;`,
+		`CREATE TABLE teams (id integer PRIMARY KEY);
+		CREATE TABLE users (id integer PRIMARY KEY, team_id integer, FOREIGN KEY (team_id) REFERENCES teams (id));
+		CREATE INDEX users_team ON users (team_id);
+		CREATE VIEW team_users AS SELECT id FROM users;`,
+	)
+
+	expectInOrder(t, sql,
+		`PRAGMA "foreign_keys" = OFF;`,
+		"BEGIN;",
+		`DROP VIEW IF EXISTS "team_users";`,
+		`CREATE TABLE "new_users"`,
+		`INSERT INTO "new_users" ("id", "team_id")`,
+		`DROP TABLE "users";`,
+		`ALTER TABLE "new_users" RENAME TO "users";`,
+		`CREATE INDEX "users_team"`,
+		`CREATE VIEW "team_users"`,
+		`PRAGMA "foreign_key_check";`,
+		`INSERT OR ROLLBACK INTO "justmigrate_foreign_key_check"`,
+		"COMMIT;",
+		`PRAGMA "foreign_keys" = ON;`,
+	)
+}
+
+func TestObjectsAreOrderedByDependency(t *testing.T) {
+	sql := generate(t,
+		`CREATE TABLE t (a integer);
+		CREATE VIEW v1 AS SELECT a FROM t;
+		CREATE VIEW v2 AS SELECT a FROM v1;`,
+		`CREATE TABLE t (a integer);
+		CREATE TABLE u (a integer);
+		CREATE VIEW v2 AS SELECT a, 2 FROM v1;
+		CREATE VIEW v1 AS SELECT a, 1 FROM u;
+		CREATE INDEX u_a ON u (a);
+		CREATE TRIGGER u_insert AFTER INSERT ON u BEGIN SELECT 1; END;`,
+	)
+
+	expectInOrder(t, sql,
+		"BEGIN;",
+		`DROP VIEW IF EXISTS "v2";`,
+		`DROP VIEW IF EXISTS "v1";`,
+		`CREATE TABLE "u"`,
+		`CREATE INDEX "u_a"`,
+		`CREATE VIEW "v1"`,
+		`CREATE VIEW "v2"`,
+		`CREATE TRIGGER "u_insert"`,
+		"COMMIT;",
+	)
+}
+
+func TestTablesAreOrderedByForeignKeys(t *testing.T) {
+	sql := generate(t,
+		`CREATE TABLE users (id integer PRIMARY KEY);
+		CREATE TABLE sessions (id integer, user_id integer REFERENCES users (id));`,
+		`CREATE TABLE billing_plan_price (id integer, plan_id integer REFERENCES billing_plan (id));
+		CREATE TABLE billing_plan (id integer PRIMARY KEY);`,
+	)
+
+	expectInOrder(t, sql,
+		`DROP TABLE "sessions";`,
+		`DROP TABLE "users";`,
+		`CREATE TABLE "billing_plan"`,
+		`CREATE TABLE "billing_plan_price"`,
+	)
+}
+
+func TestForeignKeyCycleIsAWarning(t *testing.T) {
+	differ := diff.Diff{}
+	edits, err := differ.DiffSchema(nil, parseSchema(t, `
+		CREATE TABLE a (id integer PRIMARY KEY, b_id integer REFERENCES b (id));
+		CREATE TABLE b (id integer PRIMARY KEY, a_id integer REFERENCES a (id));`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migration, err := NewSqliteGenerator(edits).Migration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migration.Warnings) != 1 {
+		t.Fatalf("expected the cycle to be reported, got %v", migration.Warnings)
+	}
+	expectInOrder(t, migration.Sql, `CREATE TABLE "a"`, `CREATE TABLE "b"`)
+}
+
+func TestRenamedColumnIsCopiedByRebuild(t *testing.T) {
+	sql := generate(t,
+		"CREATE TABLE t (id integer PRIMARY KEY, a text);",
+		`CREATE TABLE t (
+			id integer PRIMARY KEY,
+			-- justmigrate:renamed-from a
+			b text NOT NULL
+		);`,
+	)
+
+	expectInOrder(t, sql,
+		`CREATE TABLE "new_t"`,
+		`INSERT INTO "new_t" ("id", "b") SELECT "id", "a" FROM "t";`,
+	)
+}
+
+func TestChangedTypeIsCastByRebuild(t *testing.T) {
+	sql := generate(t,
+		"CREATE TABLE t (id integer PRIMARY KEY, a text, b text);",
+		`CREATE TABLE t (
+			id integer PRIMARY KEY,
+			a integer,
+			-- justmigrate:using CAST(replace(b, '$', '') AS REAL)
+			b real
+		);`,
+	)
+
+	expectInOrder(t, sql,
+		`CREATE TABLE "new_t"`,
+		`INSERT INTO "new_t" ("id", "a", "b")`,
+		`SELECT "id", CAST("a" AS integer), CAST(replace("b", '$', '') AS REAL)`,
+	)
+}
+
+// TestInvalidConversionIsAnError checks a conversion which does not parse is
+// an error, including those the parser gives up on part way through.
+func TestInvalidConversionIsAnError(t *testing.T) {
+	for _, using := range []string{"a +", "CAST(", "replace(a;)", "CASE WHEN ) THEN 1 END"} {
+		differ := diff.Diff{}
+		edits, err := differ.DiffSchema(
+			parseSchema(t, "CREATE TABLE t (a text);"),
+			parseSchema(t, "CREATE TABLE t (\n-- justmigrate:using "+using+"\na integer);"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewSqliteGenerator(edits).Migration()
+		if !errors.Is(err, diff.ErrInvalidConversion) {
+			t.Errorf("expected an invalid conversion error for %q, got %v", using, err)
+		}
+	}
+}
+
+type unknownEdit struct{ diff.EditAddTable }
+
+func TestUnsupportedEditIsAnError(t *testing.T) {
+	edit := &unknownEdit{}
+	edit.CreateTable = parseSchema(t, "CREATE TABLE t (a integer);")[0].(*ast.CreateTable)
+
+	_, err := NewSqliteGenerator([]diff.Edit{edit}).Migration()
+
+	editErr := &diff.EditError{}
+	if !errors.As(err, &editErr) || !errors.Is(err, diff.ErrUnsupportedEdit) || editErr.Edit != edit {
+		t.Fatalf("expected an unsupported edit error for %v, got %v", edit, err)
+	}
+}
+
+func TestMigrationHasStatements(t *testing.T) {
+	differ := diff.Diff{}
+	edits, err := differ.DiffSchema(nil, parseSchema(t, "CREATE TABLE t (a integer);"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migration, err := NewSqliteGenerator(edits).Migration()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migration.Statements) != 3 {
+		t.Fatalf("expected BEGIN, CREATE TABLE and COMMIT, got %v", migration.Statements)
+	}
+	if _, ok := migration.Statements[1].(*ast.CreateTable); !ok {
+		t.Fatalf("expected a CREATE TABLE, got %T", migration.Statements[1])
+	}
+	expectInOrder(t, migration.Sql, "BEGIN;", `CREATE TABLE "t"`, "COMMIT;")
+}
+
+// TestRenamedTableGoesBackDown runs the migration which renames and rebuilds a
+// table, and then its inverse, against sqlite.
+func TestRenamedTableGoesBackDown(t *testing.T) {
+	current := parseSchema(t, "CREATE TABLE prices (id integer PRIMARY KEY, amount text);")
+	desired := parseSchema(t, `-- justmigrate:renamed-from prices
+		CREATE TABLE plan_prices (id integer PRIMARY KEY, amount text NOT NULL);`)
+
+	differ := diff.Diff{}
+	edits, err := differ.DiffSchema(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inverse, _, err := diff.Invert(edits, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, err := NewSqliteGenerator(edits).Migration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	down, err := NewSqliteGenerator(inverse).Migration()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	for _, script := range []string{
+		"CREATE TABLE prices (id integer PRIMARY KEY, amount text); INSERT INTO prices VALUES (1, '10');",
+		up.Sql,
+		down.Sql,
+	} {
+		if _, err := db.Exec(script); err != nil {
+			t.Fatalf("%v in:\n%s", err, script)
+		}
+	}
+
+	var amount string
+	if err := db.QueryRow("SELECT amount FROM prices WHERE id = 1").Scan(&amount); err != nil {
+		t.Fatal(err)
+	}
+	if amount != "10" {
+		t.Errorf("expected the amount to survive going up and down, got %s", amount)
+	}
+}
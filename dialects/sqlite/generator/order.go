@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"slices"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/diff"
+)
+
+// ordering puts statements in the order of their dependencies with
+// diff.Order, keeping the cycles it comes across as warnings.
+type ordering struct {
+	warnings []report.Report
+}
+
+func (order *ordering) sort(statements []ast.Statement) []ast.Statement {
+	sorted, cycles := diff.Order(statements)
+	order.warnings = append(order.warnings, cycles...)
+	return sorted
+}
+
+// dropTables drops tables before the tables their foreign keys reference.
+func (order *ordering) dropTables(tables []ast.Statement) []ast.Statement {
+	sorted := order.sort(tables)
+	slices.Reverse(sorted)
+
+	result := make([]ast.Statement, 0, len(sorted))
+	for _, table := range sorted {
+		switch typ := table.(type) {
+		case *ast.CreateTable:
+			result = append(result, dropTable(typ.TableIdentifier))
+		case *ast.CreateVirtualTable:
+			result = append(result, dropTable(typ.TableIdentifier))
+		}
+	}
+	return result
+}
+
+// createTables creates tables after the tables their foreign keys
+// reference.
+func (order *ordering) createTables(tables []ast.Statement) []ast.Statement {
+	return order.sort(tables)
+}
+
+// drops drops triggers first, then views, then indexes. views are dropped
+// before any view they select from.
+func (order *ordering) drops(drops []ast.Statement) []ast.Statement {
+	views := []ast.Statement{}
+	result := []ast.Statement{}
+	indexes := []ast.Statement{}
+
+	for _, drop := range drops {
+		switch typ := drop.(type) {
+		case *ast.DropTrigger:
+			result = append(result, typ)
+		case *ast.CreateView:
+			views = append(views, typ)
+		default:
+			indexes = append(indexes, typ)
+		}
+	}
+
+	sorted := order.sort(views)
+	slices.Reverse(sorted)
+	for _, view := range sorted {
+		result = append(result, dropView(view.(*ast.CreateView)))
+	}
+
+	return slices.Concat(result, indexes)
+}
+
+// creates creates indexes first, then views, then triggers. views are
+// created after any view they select from.
+func (order *ordering) creates(creates []ast.Statement) []ast.Statement {
+	indexes := []ast.Statement{}
+	views := []ast.Statement{}
+	triggers := []ast.Statement{}
+
+	for _, create := range creates {
+		switch create.(type) {
+		case *ast.CreateView:
+			views = append(views, create)
+		case *ast.CreateTrigger:
+			triggers = append(triggers, create)
+		default:
+			indexes = append(indexes, create)
+		}
+	}
+
+	return slices.Concat(indexes, order.sort(views), triggers)
+}
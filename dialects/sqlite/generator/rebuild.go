@@ -0,0 +1,321 @@
+package sqlite
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/parser"
+	"woodybriggs/justmigrate/core/tik"
+	sqliteparser "woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/diff"
+)
+
+// sqlite's ALTER TABLE can only add, drop and rename columns, anything else
+// has to go through the table rebuild described at
+// https://www.sqlite.org/lang_altertable.html#otheralter
+//
+//  1. turn off foreign key enforcement
+//  2. begin a transaction
+//  3. create "new_x" in the desired shape
+//  4. copy the rows across with INSERT INTO "new_x" SELECT ... FROM "x"
+//  5. drop "x"
+//  6. rename "new_x" to "x"
+//  7. recreate the indexes, triggers and views which referred to "x"
+//  8. check the foreign keys still hold, rolling back when they do not
+//  9. commit
+// 10. turn foreign key enforcement back on
+//
+// steps 1, 2, 8, 9 and 10 are shared between every table in a migration and
+// are emitted by Generate.
+
+// needsRebuild reports whether the edits to a table can not be expressed with
+// ALTER TABLE alone.
+func needsRebuild(edit *diff.EditModifyTable) bool {
+	for _, e := range edit.Edits {
+		switch typ := e.(type) {
+		case *diff.EditAddColumn:
+			if !canAddColumn(typ.ColumnDefinition) {
+				return true
+			}
+		case *diff.EditRemoveColumn:
+			if !canDropColumn(edit, typ.ColumnDefinition) {
+				return true
+			}
+		case *diff.EditRenameColumn:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// canAddColumn follows the restrictions listed for ADD COLUMN in the sqlite
+// documentation.
+func canAddColumn(column ast.ColumnDefinition) bool {
+	notNull := false
+	var defaultExpr ast.Expr = nil
+
+	for _, constraint := range column.ColumnConstraints {
+		switch typ := constraint.(type) {
+		case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Unique:
+			return false
+		case *ast.ColumnConstraint_Generated:
+			if typ.Storage != nil && strings.EqualFold(typ.Storage.Text, "stored") {
+				return false
+			}
+		case *ast.ColumnConstraint_NotNull:
+			notNull = true
+		case *ast.ColumnConstraint_Default:
+			defaultExpr = typ.Default
+		}
+	}
+
+	switch typ := defaultExpr.(type) {
+	case nil:
+		return !notNull
+	case *ast.LiteralNull:
+		return !notNull
+	case *ast.Parens:
+		return false
+	case *ast.Identifier:
+		// CURRENT_TIME, CURRENT_DATE and CURRENT_TIMESTAMP are not constant.
+		return !strings.HasPrefix(strings.ToLower(typ.Text), "current_")
+	default:
+		return true
+	}
+}
+
+// canDropColumn follows the restrictions listed for DROP COLUMN in the sqlite
+// documentation.
+func canDropColumn(edit *diff.EditModifyTable, column ast.ColumnDefinition) bool {
+	for _, constraint := range column.ColumnConstraints {
+		switch constraint.(type) {
+		case *ast.ColumnConstraint_PrimaryKey,
+			*ast.ColumnConstraint_Unique,
+			*ast.ColumnConstraint_ForeignKey:
+			return false
+		}
+	}
+
+	for _, constraint := range edit.Target.TableDefinition.TableConstraints {
+		if constraintMentionsColumn(constraint, column.ColumnName) {
+			return false
+		}
+	}
+
+	for _, dependent := range edit.Dependents {
+		index, ok := dependent.(*ast.CreateIndex)
+		if !ok {
+			continue
+		}
+		for _, indexedColumn := range index.IndexedColumns {
+			if ident, ok := indexedColumn.Subject.(*ast.Identifier); ok && strings.EqualFold(ident.Text, column.ColumnName.Text) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func constraintMentionsColumn(constraint ast.TableConstraint, column ast.Identifier) bool {
+	isColumn := func(ident ast.Identifier) bool {
+		return strings.EqualFold(ident.Text, column.Text)
+	}
+
+	switch typ := constraint.(type) {
+	case *ast.TableConstraint_PrimaryKey:
+		return slices.ContainsFunc(typ.IndexedColumns, func(indexedColumn ast.IndexedColumn) bool {
+			ident, ok := indexedColumn.Subject.(*ast.Identifier)
+			return ok && isColumn(*ident)
+		})
+	case *ast.TableConstraint_ForeignKey:
+		return slices.ContainsFunc(typ.Columns, isColumn)
+	default:
+		// a check constraint could mention the column anywhere in its
+		// expression, so be conservative.
+		return true
+	}
+}
+
+func rebuildTable(edit *diff.EditModifyTable, order *ordering) ([]ast.Statement, error) {
+	statements := []ast.Statement{}
+
+	table := edit.Target.TableIdentifier
+	newTable := *edit.Result
+	newTable.IfNotExist = nil
+	newTable.TableIdentifier = ast.MakeCatalogObjectIdentifier(
+		table.SchemaName,
+		identifier("new_"+table.ObjectName.Text),
+	)
+
+	// views and triggers on other tables are not dropped along with the
+	// table, but would fail the schema check done by the rename.
+	for _, dependent := range edit.Dependents {
+		switch typ := dependent.(type) {
+		case *ast.CreateView:
+			statements = append(statements, dropView(typ))
+		case *ast.CreateTrigger:
+			statements = append(statements, dropTrigger(typ))
+		}
+	}
+
+	statements = append(statements, &newTable)
+
+	columns, sources := keptColumns(edit)
+	if len(columns) > 0 {
+		conversions := changedTypes(edit)
+		selectExprs := make([]ast.Expr, 0, len(sources))
+		for i, source := range sources {
+			change, ok := conversions[strings.ToLower(columns[i].Text)]
+			if !ok {
+				selectExprs = append(selectExprs, &source)
+				continue
+			}
+			expr, err := convert(source, change)
+			if err != nil {
+				return nil, &diff.EditError{Edit: edit, Err: err}
+			}
+			selectExprs = append(selectExprs, expr)
+		}
+
+		statements = append(statements, &ast.InsertSelect{
+			TableIdentifier: newTable.TableIdentifier,
+			Columns:         columns,
+			SelectExprs:     selectExprs,
+			FromTable:       table,
+		})
+	}
+
+	statements = append(statements,
+		dropTable(table),
+		&ast.AlterTable{
+			AlterKeyword:    keyword(tik.TokenKind_Keyword_ALTER, "ALTER"),
+			TableKeyword:    keyword(tik.TokenKind_Keyword_TABLE, "TABLE"),
+			TableIdentifier: newTable.TableIdentifier,
+			Alteration: &ast.RenameTable{
+				RenameKeyword: keyword(tik.TokenKind_Keyword_RENAME, "RENAME"),
+				ToKeyword:     keyword(tik.TokenKind_Keyword_TO, "TO"),
+				NewName:       table.ObjectName,
+			},
+		},
+	)
+
+	statements = append(statements, order.creates(edit.Dependents)...)
+
+	return statements, nil
+}
+
+// changedTypes are the changes of type in edit by the name of the column in
+// the desired table.
+func changedTypes(edit *diff.EditModifyTable) map[string]*diff.EditChangeColumnType {
+	changes := map[string]*diff.EditChangeColumnType{}
+	for _, e := range edit.Edits {
+		modify, ok := e.(*diff.EditModifyColumn)
+		if !ok {
+			continue
+		}
+		for _, e := range modify.Edits {
+			if change, ok := e.(*diff.EditChangeColumnType); ok {
+				changes[strings.ToLower(modify.Result.ColumnName.Text)] = change
+			}
+		}
+	}
+	return changes
+}
+
+// convert is the expression the values of a column whose type changes are
+// copied across with, the one given by a using annotation or else a CAST to
+// the new type. sqlite would store the values as they are in a column of
+// any type, the cast makes them take the affinity of the new type.
+func convert(source ast.Identifier, change *diff.EditChangeColumnType) (ast.Expr, error) {
+	if change.Using != "" {
+		return parseExpr(change.Using)
+	}
+
+	castKeyword := keyword(tik.TokenKind_Keyword_CAST, "CAST")
+	return &ast.Cast{
+		CastKeyword: &castKeyword,
+		Expr:        &source,
+		Type:        change.To,
+	}, nil
+}
+
+func parseExpr(text string) (ast.Expr, error) {
+	p := sqliteparser.NewSqliteParser(luther.NewLexer(luther.SourceCode{
+		FileName: "using",
+		Raw:      []rune(text),
+	}))
+	expr, errors := parser.ParseFragment(p.Parser, func() ast.Expr { return p.Expr(0) })
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("%w: %s", diff.ErrInvalidConversion, text)
+	}
+	return expr, nil
+}
+
+// keptColumns are the columns of the desired table which already exist in
+// the current table, these are the ones whose data is carried across. The
+// columns are paired with the name they have in the current table, which
+// differs for a renamed column.
+func keptColumns(edit *diff.EditModifyTable) (columns []ast.Identifier, sources []ast.Identifier) {
+	current, desired := edit.Target, edit.Result
+
+	renamedFrom := map[string]ast.Identifier{}
+	for _, e := range edit.Edits {
+		if rename, ok := e.(*diff.EditRenameColumn); ok {
+			renamedFrom[strings.ToLower(rename.To.Text)] = rename.From
+		}
+	}
+
+	for _, column := range desired.TableDefinition.ColumnDefinitions {
+		if column.IsGenerated() {
+			continue
+		}
+
+		source, renamed := renamedFrom[strings.ToLower(column.ColumnName.Text)]
+		if !renamed {
+			source = column.ColumnName
+		}
+
+		kept := slices.ContainsFunc(current.TableDefinition.ColumnDefinitions, func(other ast.ColumnDefinition) bool {
+			return !other.IsGenerated() && strings.EqualFold(other.ColumnName.Text, source.Text)
+		})
+		if kept {
+			columns = append(columns, column.ColumnName)
+			sources = append(sources, source)
+		}
+	}
+
+	return columns, sources
+}
+
+func identifier(name string) ast.Identifier {
+	return ast.Identifier(tik.Token{
+		Text: name,
+		Kind: tik.TokenKind_Identifier,
+	})
+}
+
+func keyword(kind tik.TokenKind, text string) ast.Keyword {
+	return ast.Keyword(tik.Token{
+		Text: text,
+		Kind: kind,
+	})
+}
+
+func ifExists() *ast.IfExists {
+	return &ast.IfExists{
+		If:     keyword(tik.TokenKind_Keyword_IF, "IF"),
+		Exists: keyword(tik.TokenKind_Keyword_EXISTS, "EXISTS"),
+	}
+}
+
+func pragma(name string, value ast.PragmaValue) *ast.Pragma {
+	return ast.MakePragma(
+		keyword(tik.TokenKind_Keyword_PRAMGA, "PRAGMA"),
+		ast.MakeCatalogObjectIdentifier(nil, identifier(name)),
+		value,
+	)
+}
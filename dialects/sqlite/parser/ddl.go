@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"strings"
 	"woodybriggs/justmigrate/core/ast"
 	"woodybriggs/justmigrate/core/report"
 	"woodybriggs/justmigrate/core/tik"
@@ -75,35 +76,367 @@ func (p *SqliteParser) CreateTableStatement(isTemporary bool) *ast.CreateTable {
 	)
 }
 
-func (p *SqliteParser) CreateViewStatement(false bool) ast.Statement {
-	panic("unimplemented")
-}
+func (p *SqliteParser) CreateViewStatement(isTemporary bool) *ast.CreateView {
+	p.PushParseContext("create view statement")
+	defer p.PopParseContext()
+
+	var temporaryKeyword *ast.Keyword = nil
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	if isTemporary {
+		temporaryKeyword = ast.MakeKeyword(p.Expect(tik.TokenKind_Keyword_TEMPORARY))
+	}
 
-func (p *SqliteParser) CreateTriggerStatement(false bool) ast.Statement {
-	panic("unimplemented")
+	viewKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_VIEW))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	viewIdent := p.CatalogObjectIdentifier()
+
+	columnNames := []ast.Identifier{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				columnName := p.Identifier()
+				columnNames = append(columnNames, columnName)
+			}
+		}
+		p.Expect(')')
+	}
+
+	asKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_AS))
+
+	selectStmt := p.SelectStatement()
+
+	return ast.MakeCreateView(
+		createKeyword,
+		temporaryKeyword,
+		viewKeyword,
+		ifnotexists,
+		viewIdent,
+		columnNames,
+		asKeyword,
+		selectStmt,
+	)
 }
 
-func (p *SqliteParser) CreateIndexStatement(isUnique bool) ast.Statement {
-	panic("unimplemented")
+// SelectStatement collects the tokens of a select statement up until the end
+// of the statement, the select itself is never interpreted.
+func (p *SqliteParser) SelectStatement() *ast.Select {
+	p.PushParseContext("select statement")
+	defer p.PopParseContext()
+
+	tokens := []tik.Token{p.Expect(tik.TokenKind_Keyword_SELECT)}
+
+	depth := 0
+	for !p.EndOfFile() {
+		if p.Current().Kind == ';' && depth == 0 {
+			break
+		} else if p.Current().Kind == ')' && depth == 0 {
+			break
+		} else if p.Current().Kind == '(' {
+			depth++
+		} else if p.Current().Kind == ')' {
+			depth--
+		}
+		tokens = append(tokens, p.Current())
+		p.Advance()
+	}
+
+	return ast.MakeSelect(tokens)
 }
 
-func (p *SqliteParser) CreateVirtualTableStatement() ast.Statement {
-	panic("unimplemented")
+func (p *SqliteParser) CreateTriggerStatement(isTemporary bool) *ast.CreateTrigger {
+	p.PushParseContext("create trigger statement")
+	defer p.PopParseContext()
+
+	var temporaryKeyword *ast.Keyword = nil
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	if isTemporary {
+		temporaryKeyword = ast.MakeKeyword(p.Expect(tik.TokenKind_Keyword_TEMPORARY))
+	}
+
+	triggerKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_TRIGGER))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	triggerIdent := p.CatalogObjectIdentifier()
+
+	triggerTime := p.MaybeTriggerTime()
+
+	triggerEvent := p.TriggerEvent()
+
+	p.Expect(tik.TokenKind_Keyword_ON)
+
+	onTable := p.CatalogObjectIdentifier()
+
+	forEachRow := false
+	if p.Current().Kind == tik.TokenKind_Keyword_FOR {
+		p.Advance()
+		p.Expect(tik.TokenKind_Keyword_EACH)
+		p.Expect(tik.TokenKind_Keyword_ROW)
+		forEachRow = true
+	}
+
+	var when ast.Expr = nil
+	if p.Current().Kind == tik.TokenKind_Keyword_WHEN {
+		p.Advance()
+		when = p.Expr(0)
+	}
+
+	p.Expect(tik.TokenKind_Keyword_BEGIN)
+
+	body := []tik.Token{}
+
+	// case expressions are closed with END too, so keep track of them so we
+	// know which END closes the trigger body.
+	depth := 0
+	for !p.EndOfFile() {
+		if p.Current().Kind == tik.TokenKind_Keyword_CASE {
+			depth++
+		} else if p.Current().Kind == tik.TokenKind_Keyword_END {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		body = append(body, p.Current())
+		p.Advance()
+	}
+
+	p.Expect(tik.TokenKind_Keyword_END)
+
+	return ast.MakeCreateTrigger(
+		createKeyword,
+		temporaryKeyword,
+		triggerKeyword,
+		ifnotexists,
+		triggerIdent,
+		triggerTime,
+		triggerEvent,
+		onTable,
+		forEachRow,
+		when,
+		body,
+	)
 }
 
-func (p *SqliteParser) CreateTemporaryStatement() ast.Statement {
-	panic("unimplemented")
+func (p *SqliteParser) MaybeTriggerTime() ast.TriggerTime {
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_BEFORE:
+		return &ast.TriggerTimeBefore{
+			BeforeKeyword: ast.Keyword(p.Expect(tik.TokenKind_Keyword_BEFORE)),
+		}
+	case tik.TokenKind_Keyword_AFTER:
+		return &ast.TriggerTimeAfter{
+			AfterKeyword: ast.Keyword(p.Expect(tik.TokenKind_Keyword_AFTER)),
+		}
+	case tik.TokenKind_Keyword_INSTEAD:
+		return &ast.TriggerTimeInsteadOf{
+			InsteadKeyword: ast.Keyword(p.Expect(tik.TokenKind_Keyword_INSTEAD)),
+			Of:             ast.Keyword(p.Expect(tik.TokenKind_Keyword_OF)),
+		}
+	default:
+		return nil
+	}
 }
 
-func (p *SqliteParser) MaybeIfNotExists() *ast.IfNotExists {
-	if p.Current().Kind != tik.TokenKind_Keyword_IF {
+func (p *SqliteParser) TriggerEvent() ast.TriggerEvent {
+	p.PushParseContext("trigger event")
+	defer p.PopParseContext()
+
+	switch p.Current().Kind {
+	case tik.TokenKind_Keyword_DELETE:
+		return &ast.TriggerEventDelete{
+			DeleteKeyword: ast.Keyword(p.Expect(tik.TokenKind_Keyword_DELETE)),
+		}
+	case tik.TokenKind_Keyword_INSERT:
+		return &ast.TriggerEventInsert{
+			InsertKeyword: ast.Keyword(p.Expect(tik.TokenKind_Keyword_INSERT)),
+		}
+	case tik.TokenKind_Keyword_UPDATE:
+		updateKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_UPDATE))
+		if p.Current().Kind != tik.TokenKind_Keyword_OF {
+			return &ast.TriggerEventUpdate{
+				UpdateKeyword: updateKeyword,
+			}
+		}
+		ofKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_OF))
+
+		columns := []ast.Identifier{p.Identifier()}
+		for p.Current().Kind == ',' {
+			p.Advance()
+			columns = append(columns, p.Identifier())
+		}
+
+		return &ast.TriggerEventUpdateOf{
+			UpdateKeyword: updateKeyword,
+			Of:            ofKeyword,
+			Columns:       columns,
+		}
+	default:
+		p.ReportError(
+			report.NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   "expected 'delete', 'insert' or 'update'",
+					},
+				}).
+				WithMessage("unexpected token when parsing trigger event"),
+		)
 		return nil
 	}
-	ifKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_IF))
-	notKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_NOT))
-	existsKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_EXISTS))
+}
+
+func (p *SqliteParser) CreateIndexStatement(isUnique bool) *ast.CreateIndex {
+	p.PushParseContext("create index statement")
+	defer p.PopParseContext()
+
+	var uniqueKeyword *ast.Keyword = nil
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+
+	if isUnique {
+		uniqueKeyword = ast.MakeKeyword(p.Expect(tik.TokenKind_Keyword_UNIQUE))
+	}
+
+	indexKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_INDEX))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	indexIdent := p.CatalogObjectIdentifier()
+
+	onKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ON))
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	p.Expect('(')
+
+	indexedColumns := []ast.IndexedColumn{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else if p.Current().Kind == ')' {
+			break
+		} else {
+			indexedColumn := p.IndexedColumn(true)
+			indexedColumns = append(indexedColumns, indexedColumn)
+		}
+	}
+
+	p.Expect(')')
+
+	var whereExpr ast.Expr = nil
+	if p.Current().Kind == tik.TokenKind_Keyword_WHERE {
+		p.Advance()
+		whereExpr = p.Expr(0)
+	}
+
+	return ast.MakeCreateIndex(
+		createKeyword,
+		uniqueKeyword,
+		indexKeyword,
+		ifnotexists,
+		indexIdent,
+		onKeyword,
+		tableIdent,
+		indexedColumns,
+		whereExpr,
+	)
+}
+
+func (p *SqliteParser) CreateVirtualTableStatement() *ast.CreateVirtualTable {
+	p.PushParseContext("create virtual table statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CREATE))
+	virtualKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_VIRTUAL))
+	tableKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_TABLE))
+
+	ifnotexists := p.MaybeIfNotExists()
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	usingKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_USING))
+
+	moduleName := p.Identifier()
+
+	args := []string{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		builder := strings.Builder{}
+		depth := 0
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' && depth == 0 {
+				args = append(args, strings.TrimSpace(builder.String()))
+				builder.Reset()
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' && depth == 0 {
+				args = append(args, strings.TrimSpace(builder.String()))
+				break
+			} else if p.Current().Kind == '(' {
+				depth++
+			} else if p.Current().Kind == ')' {
+				depth--
+			}
+			builder.WriteString(p.Current().LeadingTrivia)
+			builder.WriteString(p.Current().SourceText())
+			builder.WriteString(p.Current().TrailingTrivia)
+			p.Advance()
+		}
+		p.Expect(')')
+	}
 
-	return ast.MakeIfNotExists(ifKeyword, notKeyword, existsKeyword)
+	return ast.MakeCreateVirtualTable(
+		createKeyword,
+		virtualKeyword,
+		tableKeyword,
+		ifnotexists,
+		tableIdent,
+		usingKeyword,
+		moduleName,
+		args,
+	)
+}
+
+func (p *SqliteParser) CreateTemporaryStatement() ast.Statement {
+	p.PushParseContext("create temporary statement")
+	defer p.PopParseContext()
+
+	// CREATE TEMP <what>
+	switch p.Lookahead(2).Kind {
+	case tik.TokenKind_Keyword_TABLE:
+		return p.CreateTableStatement(true)
+	case tik.TokenKind_Keyword_VIEW:
+		return p.CreateViewStatement(true)
+	case tik.TokenKind_Keyword_TRIGGER:
+		return p.CreateTriggerStatement(true)
+	default:
+		err := report.
+			NewReport("parse error").
+			WithLabels([]report.Label{
+				{
+					Source: p.Current().SourceCode,
+					Range:  p.Current().SourceRange,
+					Note:   "unknown token for create temporary statement",
+				},
+			})
+		p.ReportError(err)
+		return nil
+	}
 }
 
 func (p *SqliteParser) MaybeTableOptions() *ast.TableOptions {
@@ -1,7 +1,6 @@
 package sqlite
 
 import (
-	"errors"
 	"fmt"
 	"woodybriggs/justmigrate/core/ast"
 	"woodybriggs/justmigrate/core/luther"
@@ -10,17 +9,14 @@ import (
 	"woodybriggs/justmigrate/core/tik"
 )
 
-var ErrNotImplemented = errors.New("not implemented")
-
 type SqliteParser struct {
-	*parser.Parser
+	*parser.Grammar
 }
 
 func NewSqliteParser(lexer *luther.Lexer) *SqliteParser {
-	core := parser.NewParser(lexer)
-	return &SqliteParser{
-		Parser: core,
-	}
+	result := &SqliteParser{}
+	result.Grammar = parser.NewGrammar(lexer, result)
+	return result
 }
 
 func (p *SqliteParser) TableDefinition() *ast.TableDefinition {
@@ -32,7 +28,7 @@ func (p *SqliteParser) TableDefinition() *ast.TableDefinition {
 	columnDefs := p.ColumnDefinitions()
 	tableConstraints := p.TableConstraints()
 
-	rParen := p.Expect(')')
+	rParen := p.Closing(')')
 
 	return ast.MakeTableDefinition(
 		lParen,
@@ -42,27 +38,6 @@ func (p *SqliteParser) TableDefinition() *ast.TableDefinition {
 	)
 }
 
-func (p *SqliteParser) TableConstraints() []ast.TableConstraint {
-	p.PushParseContext("table constraints")
-	defer p.PopParseContext()
-
-	result := []ast.TableConstraint{}
-
-	for !p.EndOfFile() {
-		if p.Current().Kind == '(' {
-			break
-		} else if p.Current().Kind == ',' {
-			p.Advance()
-			continue
-		} else {
-			tableConstraint := p.TableConstraint()
-			result = append(result, tableConstraint)
-		}
-	}
-
-	return result
-}
-
 func (p *SqliteParser) TableConstraint() ast.TableConstraint {
 
 	p.PushParseContext("table constraint")
@@ -89,6 +64,8 @@ func (p *SqliteParser) TableConstraint() ast.TableConstraint {
 	switch p.Current().Kind {
 	case tik.TokenKind_Keyword_PRIMARY:
 		return p.TableConstraint_PrimaryKey(constraintName)
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.TableConstraint_Unique(constraintName)
 	case tik.TokenKind_Keyword_FOREIGN:
 		return p.TableConstraint_ForeignKey(constraintName)
 	case tik.TokenKind_Keyword_CHECK:
@@ -131,7 +108,7 @@ func (p *SqliteParser) TableConstraint_PrimaryKey(constraintName *ast.Constraint
 
 	if autoincrement == nil {
 		for !p.EndOfFile() {
-			if p.Current().Kind == '(' {
+			if p.Current().Kind == ')' {
 				break
 			} else if p.Current().Kind == ',' {
 				p.Advance()
@@ -159,325 +136,62 @@ func (p *SqliteParser) TableConstraint_PrimaryKey(constraintName *ast.Constraint
 	)
 }
 
-func (p *SqliteParser) IndexedColumn(allowExpressions bool) ast.IndexedColumn {
-
-	p.PushParseContext("indexed column")
+func (p *SqliteParser) TableConstraint_Unique(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("unique table constraint")
 	defer p.PopParseContext()
 
-	var expr ast.Expr = nil
-	if allowExpressions {
-		expr = p.Expr(0)
-	} else {
-		tmp := p.Identifier()
-		expr = &tmp
-	}
-
-	collation := p.MaybeCollation()
-
-	order := p.MaybeOrderKeyword()
-
-	return ast.IndexedColumn{
-		Subject:   expr,
-		Collation: collation,
-		Order:     order,
-	}
-}
-
-func (p *SqliteParser) TableConstraint_ForeignKey(constraintName *ast.ConstraintName) ast.TableConstraint {
-	p.PushParseContext("foreign key table constraint")
-	defer p.PopParseContext()
+	uniqueKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_UNIQUE))
 
-	foreign := ast.Keyword(p.Expect(tik.TokenKind_Keyword_FOREIGN))
-	key := ast.Keyword(p.Expect(tik.TokenKind_Keyword_KEY))
+	lParen, indexedCols, rParen := p.IndexedColumns(false)
 
-	lParen := p.Expect('(')
-
-	columnNames := []ast.Identifier{}
-	for !p.EndOfFile() {
-		if p.Current().Kind == ',' {
-			p.Advance()
-			continue
-		} else if p.Current().Kind == ')' {
-			break
-		} else {
-			columnName := p.Identifier()
-			columnNames = append(columnNames, columnName)
-		}
-	}
-
-	rParen := p.Expect(')')
+	conflictClause := p.MaybeConflictClause()
 
-	fkClause := p.ForeignKeyClause()
-	return ast.MakeTableConstraintForeignKey(
+	return ast.MakeTableConstraintUnique(
 		constraintName,
-		foreign,
-		key,
+		uniqueKeyword,
 		lParen,
-		columnNames,
-		rParen,
-		fkClause,
-	)
-}
-
-func (p *SqliteParser) ForeignKeyClause() *ast.ForeignKeyClause {
-
-	referencesKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_REFERENCES))
-
-	foreignTable := p.CatalogObjectIdentifier()
-
-	lParen := p.Expect('(')
-
-	columns := []ast.Identifier{}
-
-	for !p.EndOfFile() {
-		if p.Current().Kind == ',' {
-			p.Advance()
-			continue
-		} else if p.Current().Kind == ')' {
-			break
-		} else {
-			column := p.Identifier()
-			columns = append(columns, column)
-		}
-	}
-
-	rParen := p.Expect(')')
-
-	var deferrable *ast.ForeignKeyDeferrable = nil
-	var matchName *ast.Identifier = nil
-	actions := []ast.ForeignKeyAction{}
-
-	for !p.EndOfFile() {
-		if p.Current().Kind == tik.TokenKind_Keyword_ON {
-			action := p.ForeignKeyAction()
-			actions = append(actions, action)
-		} else if p.Current().Kind == tik.TokenKind_Keyword_MATCH {
-			ident := p.Identifier()
-			matchName = &ident
-		} else if p.Current().Kind == tik.TokenKind_Keyword_NOT {
-			deferrable = p.ForeignKeyDeferrable()
-		} else if p.Current().Kind == tik.TokenKind_Keyword_DEFERRABLE {
-			deferrable = p.ForeignKeyDeferrable()
-		} else {
-			break
-		}
-	}
-
-	return ast.MakeForeignKeyClause(
-		referencesKeyword,
-		*foreignTable,
-		lParen,
-		columns,
+		indexedCols,
 		rParen,
-		actions,
-		matchName,
-		deferrable,
+		conflictClause,
 	)
 }
 
-func (p *SqliteParser) ForeignKeyAction() ast.ForeignKeyAction {
-	onKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ON))
-
-	switch p.Current().Kind {
-	case tik.TokenKind_Keyword_UPDATE:
-		updateKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_UPDATE))
-		do := p.ForeignKeyActionDo()
-		return ast.MakeForeignKeyUpdateAction(
-			onKeyword,
-			updateKeyword,
-			do,
-		)
-	case tik.TokenKind_Keyword_DELETE:
-		deleteKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_DELETE))
-		do := p.ForeignKeyActionDo()
-		return ast.MakeForeignKeyDeleteAction(
-			onKeyword,
-			deleteKeyword,
-			do,
-		)
-	default:
-		p.ReportError(
-			report.NewReport("parse error").
-				WithLabels([]report.Label{
-					{
-						Source: p.Current().SourceCode,
-						Range:  p.Current().SourceRange,
-						Note:   "here",
-					},
-				}).
-				WithMessage("unexpected token when parsing foreign key action"),
-		)
-		return nil
-	}
-}
+// TypeName parses the optional type of a column. sqlite allows the type
+// to be made up of several names, e.g. `unsigned big int`, which are joined
+// into the one identifier.
+func (p *SqliteParser) TypeName() ast.TypeName {
+	p.PushParseContext("type name")
+	defer p.PopParseContext()
 
-func (p *SqliteParser) ForeignKeyActionDo() ast.ForeignKeyActionDo {
-	if p.Current().Kind == tik.TokenKind_Keyword_SET {
-		setKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_SET))
-		if p.Current().Kind == tik.TokenKind_Keyword_NULL {
-			nullKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_NULL))
-			return ast.MakeForeignKeyActionSetNull(setKeyword, nullKeyword)
-		} else if p.Current().Kind == tik.TokenKind_Keyword_DEFAULT {
-			defaultKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_DEFAULT))
-			return ast.MakeForeignKeyActionSetDefault(setKeyword, defaultKeyword)
-		} else {
-			p.ReportError(
-				report.NewReport("parse error").
-					WithLabels([]report.Label{
-						{
-							Source: p.Current().SourceCode,
-							Range:  p.Current().SourceRange,
-							Note:   "here",
-						},
-					}).
-					WithMessage("expected 'null' or 'default' for set action of foreign key action"),
-			)
-			return nil
-		}
-	} else if p.Current().Kind == tik.TokenKind_Keyword_NO {
-		noKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_NO))
-		actionKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_ACTION))
-		return ast.MakeForeignKeyActionNoAction(noKeyword, actionKeyword)
-	} else if p.Current().Kind == tik.TokenKind_Keyword_RESTRICT {
-		restrictKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_RESTRICT))
-		return ast.MakeForeignKeyActionRestrict(restrictKeyword)
-	} else if p.Current().Kind == tik.TokenKind_Keyword_CASCADE {
-		cascadeKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CASCADE))
-		return ast.MakeForeignKeyActionCascade(cascadeKeyword)
-	} else {
-		p.ReportError(
-			report.NewReport("parse error").
-				WithLabels([]report.Label{
-					{
-						Source: p.Current().SourceCode,
-						Range:  p.Current().SourceRange,
-						Note:   "here",
-					},
-				}).
-				WithMessage("expected action: one of ('set null', 'set default', 'no action', 'restrict', 'cascade') for foreign key action"),
-		)
-		return nil
+	if p.Current().Kind != tik.TokenKind_Identifier {
+		return ast.TypeName{}
 	}
-}
 
-func (p *SqliteParser) ForeignKeyDeferrable() *ast.ForeignKeyDeferrable {
-	var notKeyword *ast.Keyword = nil
-	if p.Current().Kind == tik.TokenKind_Keyword_NOT {
-		notKeyword = ast.MakeKeyword(p.Current())
-		p.Advance()
+	typeToken := p.Expect(tik.TokenKind_Identifier)
+	for p.Current().Kind == tik.TokenKind_Identifier {
+		next := p.Expect(tik.TokenKind_Identifier)
+		typeToken.Text = typeToken.Text + " " + next.Text
+		typeToken.SourceRange.End = next.SourceRange.End
+		typeToken.TrailingTrivia = next.TrailingTrivia
 	}
 
-	deferrableKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_DEFERRABLE))
-
-	var initiallyKeyword *ast.Keyword = nil
-	var initiallyValue *ast.Keyword = nil
-	if p.Current().Kind == tik.TokenKind_Keyword_INITIALLY {
-		initiallyKeyword = ast.MakeKeyword(p.Current())
+	args := []ast.Expr{}
+	if p.Current().Kind == '(' {
 		p.Advance()
-
-		switch p.Current().Kind {
-		case tik.TokenKind_Keyword_DEFERRED:
-			initiallyValue = ast.MakeKeyword(p.Current())
-			p.Advance()
-		case tik.TokenKind_Keyword_IMMEDIATE:
-			initiallyValue = ast.MakeKeyword(p.Current())
-			p.Advance()
-		default:
-			p.ReportError(
-				report.
-					NewReport("parse errors").
-					WithLabels([]report.Label{
-						{
-							Source: p.Current().SourceCode,
-							Range:  p.Current().SourceRange,
-							Note:   "here",
-						},
-					}).
-					WithMessage("expected value for 'deferrable initially' one of ('deferred' or 'immediate')"),
-			)
-			return nil
-		}
-	}
-
-	return ast.MakeForeignKeyDeferrable(
-		notKeyword,
-		deferrableKeyword,
-		initiallyKeyword,
-		initiallyValue,
-	)
-}
-
-func (p *SqliteParser) TableConstraint_Check(constraintName *ast.ConstraintName) ast.TableConstraint {
-	checkKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_CHECK))
-
-	lParen := p.Expect('(')
-
-	expr := p.Expr(0)
-
-	rParen := p.Expect(')')
-
-	return ast.MakeTableConstraintCheck(
-		constraintName,
-		checkKeyword,
-		lParen,
-		expr,
-		rParen,
-	)
-}
-
-func (p *SqliteParser) TableOptions() *ast.TableOptions {
-	panic("unimplemented")
-}
-
-func (p *SqliteParser) ColumnDefinitions() []ast.ColumnDefinition {
-	p.PushParseContext("column definitions")
-	defer p.PopParseContext()
-
-	definitions := []ast.ColumnDefinition{}
-
-	for !p.EndOfFile() {
-		if p.Current().Kind == ',' {
-			p.Advance()
-			continue
-		} else if p.Current().Kind == ')' {
-			break
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				args = append(args, p.Expr(0))
+			}
 		}
-
-		columnDef := p.ColumnDefinition()
-		definitions = append(definitions, *columnDef)
-	}
-
-	return definitions
-}
-
-func (p *SqliteParser) ColumnDefinition() *ast.ColumnDefinition {
-	p.PushParseContext("column definition")
-	defer p.PopParseContext()
-
-	columnName := p.Identifier()
-	typeName := p.Identifier()
-	columnConstraints := p.ColumnConstraints()
-
-	return ast.MakeColumnDefinition(
-		columnName,
-		typeName,
-		columnConstraints,
-	)
-}
-
-func (p *SqliteParser) ColumnConstraints() []ast.ColumnConstraint {
-
-	p.PushParseContext("column constraints")
-	defer p.PopParseContext()
-
-	result := []ast.ColumnConstraint{}
-
-	for p.Current().Kind != ',' && p.Current().Kind != ')' && !p.EndOfFile() {
-		columnConstraint := p.ColumnConstraint()
-		result = append(result, columnConstraint)
+		p.Expect(')')
 	}
 
-	return result
+	return ast.MakeTypeName(ast.Identifier(typeToken), args)
 }
 
 func (p *SqliteParser) ColumnConstraint() ast.ColumnConstraint {
@@ -489,20 +203,22 @@ func (p *SqliteParser) ColumnConstraint() ast.ColumnConstraint {
 	switch p.Current().Kind {
 	case tik.TokenKind_Keyword_PRIMARY:
 		return p.ColumnConstraint_PrimaryKey(constraintName)
-	// case tik.TokenKind_Keyword_NOT:
-	// 	return p.ColumnConstraint_NotNull(constraintName)
-	// case tik.TokenKind_Keyword_DEFAULT:
-	// 	return p.ColumnConstraint_Default(constraintName)
-	// case tik.TokenKind_Keyword_UNIQUE:
-	// 	return p.ColumnConstraint_Unique(constraintName)
-	// case tik.TokenKind_Keyword_COLLATE:
-	// 	return p.ColumnConstraint_Collate(constraintName)
-	// case tik.TokenKind_Keyword_CHECK:
-	// 	return p.ColumnConstraint_Check(constraintName)
-	// case tik.TokenKind_Keyword_AS:
-	// 	return p.ColumnConstraint_Generated(constraintName)
-	// case tik.TokenKind_Keyword_GENERATED:
-	// 	return p.ColumnConstraint_Generated(constraintName)
+	case tik.TokenKind_Keyword_NOT:
+		return p.ColumnConstraint_NotNull(constraintName)
+	case tik.TokenKind_Keyword_DEFAULT:
+		return p.ColumnConstraint_Default(constraintName)
+	case tik.TokenKind_Keyword_UNIQUE:
+		return p.ColumnConstraint_Unique(constraintName)
+	case tik.TokenKind_Keyword_COLLATE:
+		return p.ColumnConstraint_Collate(constraintName)
+	case tik.TokenKind_Keyword_CHECK:
+		return p.ColumnConstraint_Check(constraintName)
+	case tik.TokenKind_Keyword_REFERENCES:
+		return p.ColumnConstraint_ForeignKey(constraintName)
+	case tik.TokenKind_Keyword_AS:
+		return p.ColumnConstraint_Generated(constraintName)
+	case tik.TokenKind_Keyword_GENERATED:
+		return p.ColumnConstraint_Generated(constraintName)
 	default:
 		{
 			p.ReportError(
@@ -547,23 +263,49 @@ func (p *SqliteParser) ColumnConstraint_PrimaryKey(constraintName *ast.Constrain
 	)
 }
 
-func (p *SqliteParser) MaybeConstraintName() *ast.ConstraintName {
-	p.PushParseContext("constraint name")
+func (p *SqliteParser) ColumnConstraint_NotNull(constraintName *ast.ConstraintName) *ast.ColumnConstraint_NotNull {
+	p.PushParseContext("not null column constraint")
 	defer p.PopParseContext()
 
-	// we may or may not have CONSTRAINT keyword here so have a look-y and check-y
-	if p.Current().Kind != tik.TokenKind_Keyword_CONSTRAINT {
-		return nil
-	}
-	constraintKeyword := ast.Keyword(p.Current())
-	p.Advance()
+	p.Expect(tik.TokenKind_Keyword_NOT)
+	p.Expect(tik.TokenKind_Keyword_NULL)
 
-	name := p.Identifier()
+	conflictClause := p.MaybeConflictClause()
 
-	return &ast.ConstraintName{
-		ConstraintKeyword: constraintKeyword,
-		Name:              name,
-	}
+	return ast.MakeColumnConstraintNotNull(
+		constraintName,
+		conflictClause,
+	)
+}
+
+func (p *SqliteParser) ColumnConstraint_Default(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Default {
+	p.PushParseContext("default column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_DEFAULT)
+
+	// a default is either a literal, a signed number, or an expression in
+	// parentheses, all of which are terms.
+	defaultExpr := p.Term()
+
+	return ast.MakeColumnConstraintDefault(
+		constraintName,
+		defaultExpr,
+	)
+}
+
+func (p *SqliteParser) ColumnConstraint_Unique(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Unique {
+	p.PushParseContext("unique column constraint")
+	defer p.PopParseContext()
+
+	p.Expect(tik.TokenKind_Keyword_UNIQUE)
+
+	conflictClause := p.MaybeConflictClause()
+
+	return ast.MakeColumnConstraintUnique(
+		constraintName,
+		conflictClause,
+	)
 }
 
 func (p *SqliteParser) MaybeConflictClause() *ast.ConflictClause {
@@ -583,6 +325,7 @@ func (p *SqliteParser) MaybeConflictClause() *ast.ConflictClause {
 		tik.TokenKind_Keyword_REPLACE:
 		{
 			actionKeyword := ast.Keyword(p.Current())
+			p.Advance()
 			return ast.MakeConflictClause(
 				onKeyword,
 				conflictKeyword,
@@ -607,40 +350,15 @@ func (p *SqliteParser) MaybeConflictClause() *ast.ConflictClause {
 	}
 }
 
-func (p *SqliteParser) MaybeOrderKeyword() *ast.Keyword {
-	switch p.Current().Kind {
-	case tik.TokenKind_Keyword_ASC:
-		fallthrough
-	case tik.TokenKind_Keyword_DESC:
-		return ast.MakeKeyword(p.Current())
-	default:
-		return nil
-	}
-}
-
-func (p *SqliteParser) Expr(minBindingPower int) ast.Expr {
-	return p.Parser.Expr(minBindingPower, p)
-}
-
-func (p *SqliteParser) Term() ast.Expr {
-	switch p.Current().Kind {
-	case tik.TokenKind_StringLiteral:
-		return &ast.LiteralString{
-			Token: p.Current(),
-			Value: p.Current().Text,
-		}
-	case tik.TokenKind_Identifier:
-		fallthrough
-	default:
-		panic("expression type not handled")
-	}
-}
-
+// OperatorBindingPower adds the pattern matching operators of sqlite to those
+// of the shared grammar.
 func (p *SqliteParser) OperatorBindingPower(token tik.Token) (bp ast.BindingPower, found bool) {
 	switch token.Kind {
-	case '+':
-		return ast.BindingPower{L: 100, R: 101}, true
+	case tik.TokenKind_Keyword_GLOB,
+		tik.TokenKind_Keyword_REGEXP,
+		tik.TokenKind_Keyword_MATCH:
+		return ast.BindingPower{L: 40, R: 41}, true
 	default:
-		return ast.BindingPower{}, false
+		return p.Grammar.OperatorBindingPower(token)
 	}
 }
@@ -2,9 +2,15 @@ package sqlite
 
 import (
 	"fmt"
+	"os"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"woodybriggs/justmigrate/core/ast"
 	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/formatter"
 )
 
 func makeParser(input string) *SqliteParser {
@@ -41,3 +47,214 @@ func TestParseIdentifier(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatStyle(t *testing.T) {
+	input := `-- people
+create table users ( -- who signed up
+    id integer primary key, -- surrogate
+    -- what they go by
+    "full name" text not null
+);`
+
+	cases := []struct {
+		style    formatter.Style
+		expected string
+	}{
+		{
+			style: formatter.Style{KeywordCase: formatter.KeywordCaseUpper, Quote: formatter.QuoteAsWritten, AlignColumns: true, KeepComments: true},
+			expected: `-- people
+CREATE TABLE users ( -- who signed up
+    id          integer PRIMARY KEY, -- surrogate
+    -- what they go by
+    "full name" text    NOT NULL
+);`,
+		},
+		{
+			style: formatter.Style{KeywordCase: formatter.KeywordCaseLower},
+			expected: `create table "users" (
+    "id" integer primary key,
+    "full name" text not null
+);`,
+		},
+	}
+
+	for _, c := range cases {
+		parser := makeParser(input)
+		statements := parser.Statements()
+		if len(parser.Errors()) > 0 {
+			t.Fatalf("unexpected errors: %v", parser.Errors())
+		}
+
+		builder := strings.Builder{}
+		core := formatter.NewCoreFormatter(&builder, 80, "\"\"").WithStyle(c.style)
+		for _, statement := range statements {
+			core.Trivia(ast.LeadingTrivia(statement))
+			statement.ToSql(core)
+			core.Rune(';')
+		}
+		core.Flush()
+
+		if builder.String() != c.expected {
+			t.Errorf("expected\n%s\ngot\n%s", c.expected, builder.String())
+		}
+	}
+}
+
+// TestForeignKeyFollowedByNotNull checks a NOT after a foreign key clause is
+// the next constraint of the column and not the start of NOT DEFERRABLE.
+func TestForeignKeyFollowedByNotNull(t *testing.T) {
+	parser := makeParser("CREATE TABLE a (b integer REFERENCES t (id) NOT NULL, c integer REFERENCES t NOT DEFERRABLE)")
+
+	createTable := parser.CreateTableStatement(false)
+	if len(parser.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", parser.Errors())
+	}
+
+	columns := createTable.TableDefinition.ColumnDefinitions
+	if len(columns[0].ColumnConstraints) != 2 {
+		t.Fatalf("expected a foreign key and not null constraint, got %v", columns[0].ColumnConstraints)
+	}
+	if _, ok := columns[0].ColumnConstraints[1].(*ast.ColumnConstraint_NotNull); !ok {
+		t.Errorf("expected a not null constraint, got %T", columns[0].ColumnConstraints[1])
+	}
+
+	foreignKey, ok := columns[1].ColumnConstraints[0].(*ast.ColumnConstraint_ForeignKey)
+	if !ok || foreignKey.FkClause.Deferrable == nil {
+		t.Errorf("expected a not deferrable foreign key, got %v", columns[1].ColumnConstraints)
+	}
+}
+
+// roundTripStatements cover the statements and expressions which
+// resources/schema.sql does not.
+const roundTripStatements = `
+PRAGMA foreign_keys = ON;
+BEGIN TRANSACTION;
+CREATE TEMP TABLE IF NOT EXISTS main.accounts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    code text NOT NULL COLLATE NOCASE UNIQUE ON CONFLICT REPLACE,
+    balance real DEFAULT 0.0 CHECK (balance >= -100),
+    kind text DEFAULT 'it''s' CONSTRAINT kind_known CHECK (kind IN ('a', 'b')),
+    label text GENERATED ALWAYS AS (upper(code) || '-' || kind) STORED,
+    parent integer REFERENCES accounts (id) ON DELETE SET NULL ON UPDATE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    flags integer NOT NULL DEFAULT (0x10),
+    CONSTRAINT accounts_code UNIQUE (code COLLATE BINARY DESC, kind),
+    CHECK (CASE WHEN kind = 'a' THEN balance > 0 ELSE NOT balance IS NULL END),
+    FOREIGN KEY (parent, kind) REFERENCES accounts (id, kind) MATCH SIMPLE
+) STRICT, WITHOUT ROWID;
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_label ON accounts (lower(label), code DESC) WHERE balance > 0 AND kind LIKE 'a%';
+CREATE VIEW IF NOT EXISTS positive (id, code) AS SELECT id, "code" FROM accounts WHERE balance > 0;
+CREATE TRIGGER IF NOT EXISTS accounts_touch BEFORE UPDATE OF balance, kind ON accounts FOR EACH ROW WHEN NEW.balance < 0
+BEGIN
+    UPDATE accounts SET flags = flags | 1 WHERE id = NEW.id;
+END;
+CREATE TRIGGER positive_insert INSTEAD OF INSERT ON positive
+BEGIN
+    INSERT INTO accounts (code) VALUES (NEW.code);
+END;
+CREATE VIRTUAL TABLE IF NOT EXISTS search USING fts5(code, label, tokenize = 'porter');
+COMMIT;
+`
+
+// TestRoundTrip prints every statement, parses what was printed and checks
+// that it is the statement which was printed.
+func TestRoundTrip(t *testing.T) {
+	schema, err := os.ReadFile("../../../resources/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	styles := []formatter.Style{
+		{},
+		{KeywordCase: formatter.KeywordCaseLower, Quote: formatter.QuoteAsWritten, AlignColumns: true, KeepComments: true},
+	}
+
+	for _, source := range []string{string(schema), roundTripStatements} {
+		parser := makeParser(source)
+		statements := parser.Statements()
+		if len(parser.Errors()) > 0 {
+			t.Fatalf("unexpected errors: %v", parser.Errors())
+		}
+
+		for _, statement := range statements {
+			for _, style := range styles {
+				builder := strings.Builder{}
+				statement.ToSql(formatter.NewCoreFormatter(&builder, 80, "\"\"").WithStyle(style))
+				printed := builder.String()
+
+				reparser := makeParser(printed + ";")
+				reparsed := reparser.Statements()
+				if len(reparser.Errors()) > 0 || len(reparsed) != 1 {
+					t.Errorf("can not parse what was printed:\n%s", printed)
+					continue
+				}
+
+				if path := astDifference("statement", reflect.ValueOf(statement), reflect.ValueOf(reparsed[0])); path != "" {
+					t.Errorf("%s differs once printed:\n%s", path, printed)
+				}
+			}
+		}
+	}
+}
+
+var tokenType = reflect.TypeOf(tik.Token{})
+
+// astDifference is the path to the first place a and b differ, or empty
+// when they are the same. tokens are compared by their kind and text alone,
+// as where they are and the whitespace around them change when printed.
+func astDifference(path string, a, b reflect.Value) string {
+	if a.Kind() != b.Kind() {
+		return path
+	}
+
+	switch a.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return path
+			}
+			return ""
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			return path
+		}
+		return astDifference(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		if a.Type().ConvertibleTo(tokenType) {
+			aToken := a.Convert(tokenType).Interface().(tik.Token)
+			bToken := b.Convert(tokenType).Interface().(tik.Token)
+			if aToken.Kind != bToken.Kind {
+				return path
+			}
+			if aToken.Kind >= tik.TokenKindOffset_Keywords {
+				if !strings.EqualFold(aToken.Text, bToken.Text) {
+					return path
+				}
+			} else if aToken.Text != bToken.Text {
+				return path
+			}
+			return ""
+		}
+		for i := range a.NumField() {
+			field := a.Type().Field(i)
+			if difference := astDifference(path+"."+field.Name, a.Field(i), b.Field(i)); difference != "" {
+				return difference
+			}
+		}
+		return ""
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return path
+		}
+		for i := range a.Len() {
+			if difference := astDifference(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i)); difference != "" {
+				return difference
+			}
+		}
+		return ""
+	default:
+		if a.Interface() != b.Interface() {
+			return path
+		}
+		return ""
+	}
+}
@@ -1,39 +1,12 @@
 package sqlite
 
 import (
-	"errors"
-	"os"
-	"runtime/debug"
+	"fmt"
 	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
 	"woodybriggs/justmigrate/core/tik"
 )
 
-func (p *SqliteParser) Statements() []ast.Statement {
-	statements := []ast.Statement{}
-
-	for !p.EndOfFile() {
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					if err, isErr := r.(error); isErr && errors.Is(err, ErrNotImplemented) {
-						debug.PrintStack()
-						os.Exit(2)
-					}
-					p.Synchronize([]tik.TokenKind{';'})
-				}
-			}()
-
-			statement := p.Statement()
-			statements = append(statements, statement)
-
-			// if this fails/panics, the defer block above handles it too.
-			p.Expect(';')
-		}()
-	}
-
-	return statements
-}
-
 func (p *SqliteParser) Statement() ast.Statement {
 	p.PushParseContext("statement")
 	defer p.PopParseContext()
@@ -41,7 +14,69 @@ func (p *SqliteParser) Statement() ast.Statement {
 	switch p.Current().Kind {
 	case tik.TokenKind_Keyword_CREATE:
 		return p.CreateStatement()
+	case tik.TokenKind_Keyword_PRAMGA:
+		return p.PragmaStatement()
+	case tik.TokenKind_Keyword_BEGIN:
+		return p.BeginStatement()
+	case tik.TokenKind_Keyword_COMMIT:
+		return p.CommitStatement()
+	default:
+		p.ReportError(
+			report.
+				NewReport("parse error").
+				WithLabels([]report.Label{
+					{
+						Source: p.Current().SourceCode,
+						Range:  p.Current().SourceRange,
+						Note:   fmt.Sprintf("unknown token at start of sql statement '%s'", p.Current().DebugString()),
+					},
+				}),
+		)
+		return nil
+	}
+}
+
+func (p *SqliteParser) PragmaStatement() ast.Statement {
+	p.PushParseContext("pragma statement")
+	defer p.PopParseContext()
+
+	pragmaKeyword := ast.Keyword(p.Expect(tik.TokenKind_Keyword_PRAMGA))
+	pragmaIdentifier := p.CatalogObjectIdentifier()
+
+	switch p.Current().Kind {
+	case '=':
+		p.Advance()
+		return ast.MakePragma(pragmaKeyword, pragmaIdentifier, p.PragmaValue())
+	case '(':
+		p.Advance()
+		value := p.PragmaValue()
+		p.Expect(')')
+		return ast.MakePragma(pragmaKeyword, pragmaIdentifier, value)
+	default:
+		return ast.MakePragma(pragmaKeyword, pragmaIdentifier, nil)
+	}
+}
+
+func (p *SqliteParser) PragmaValue() ast.PragmaValue {
+	p.PushParseContext("pragma value")
+	defer p.PopParseContext()
+
+	switch token := p.Current(); token.Kind {
+	case tik.TokenKind_DecimalNumericLiteral,
+		tik.TokenKind_HexNumericLiteral,
+		tik.TokenKind_BinaryNumericLiteral,
+		tik.TokenKind_OctalNumericLiteral:
+		return p.NumericLiteral().(ast.PragmaValue)
+	case tik.TokenKind_StringLiteral:
+		return p.StringLiteral()
+	case tik.TokenKind_Keyword_TRUE, tik.TokenKind_Keyword_FALSE, tik.TokenKind_Keyword_ON:
+		p.Advance()
+		return &ast.LiteralBoolean{
+			Token: token,
+			Value: token.Kind != tik.TokenKind_Keyword_FALSE,
+		}
 	default:
-		panic("not implemented")
+		ident := p.Identifier()
+		return &ident
 	}
 }
@@ -0,0 +1,30 @@
+package diff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// annotationPattern matches the comments which steer the differ, written
+// in the schema as
+//
+//	-- justmigrate:<name> <argument>
+var annotationPattern = regexp.MustCompile(`--[ \t]*justmigrate:([a-z-]+)[ \t]*([^\r\n]*)`)
+
+type Annotation struct {
+	Name     string
+	Argument string
+}
+
+// Annotations finds the annotations in text, which is either a whole schema
+// or the trivia around a single token.
+func Annotations(text string) []Annotation {
+	result := []Annotation{}
+	for _, match := range annotationPattern.FindAllStringSubmatch(text, -1) {
+		result = append(result, Annotation{
+			Name:     match[1],
+			Argument: strings.TrimSpace(match[2]),
+		})
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"errors"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+)
+
+// ConvertUsing is the annotation which gives the expression the values of a
+// column are converted with when its type changes, written on the line above
+// the column. the expression is in the dialect of the schema and reads the
+// column, by its old name, as it was before the change.
+//
+//	CREATE TABLE products (
+//	    -- justmigrate:using CAST(replace(price, '$', '') AS REAL)
+//	    price real
+//	);
+const ConvertUsing = "using"
+
+var ErrInvalidConversion = errors.New("conversion is not an expression")
+
+// convertUsing finds the using annotation in the trivia in front of a column.
+func convertUsing(trivia string) string {
+	for _, annotation := range Annotations(trivia) {
+		if annotation.Name == ConvertUsing && annotation.Argument != "" {
+			return annotation.Argument
+		}
+	}
+	return ""
+}
+
+// affinity is the kind of value a type holds, worked out from its name by
+// the rules sqlite uses, https://www.sqlite.org/datatype3.html#determination_of_column_affinity,
+// which give a sensible answer for the types of the other dialects as well.
+type affinity int
+
+const (
+	affinityBlob affinity = iota
+	affinityText
+	affinityNumeric
+	affinityInteger
+	affinityReal
+)
+
+func typeAffinity(typeName ast.TypeName) affinity {
+	name := strings.ToUpper(typeName.TypeName.Text)
+	switch {
+	case strings.Contains(name, "INT"):
+		return affinityInteger
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "CLOB"), strings.Contains(name, "TEXT"):
+		return affinityText
+	case name == "", strings.Contains(name, "BLOB"):
+		return affinityBlob
+	case strings.Contains(name, "REAL"), strings.Contains(name, "FLOA"), strings.Contains(name, "DOUB"):
+		return affinityReal
+	default:
+		return affinityNumeric
+	}
+}
+
+// isLossy reports whether converting values of one type to the other can
+// change them, text which is not a number or the fraction of a real.
+func isLossy(from, to ast.TypeName) bool {
+	switch typeAffinity(to) {
+	case affinityInteger:
+		return typeAffinity(from) != affinityInteger
+	case affinityReal, affinityNumeric:
+		switch typeAffinity(from) {
+		case affinityText, affinityBlob:
+			return true
+		}
+	}
+	return false
+}
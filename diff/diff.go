@@ -4,18 +4,45 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"math"
 	"slices"
 	"strings"
 	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/tik"
+	"woodybriggs/justmigrate/formatter"
 )
 
-type Diff struct{}
+type Diff struct {
+	// Renames are the tables and columns known to have been renamed, on top
+	// of the ones annotated in the schema.
+	Renames []Rename
+
+	// NormalizeColumn is a column, or domain, the way the database of the
+	// dialect gives it back, e.g. without the NOT NULL a primary key implies.
+	// columns are compared in that form, nil compares them as written.
+	NormalizeColumn func(column ast.ColumnDefinition) ast.ColumnDefinition
+}
 
 type Edit interface {
 	edit()
 	String() string
 }
 
+// EditError is an error with the edit which caused it, e.g. an edit a
+// generator does not know how to make.
+type EditError struct {
+	Edit Edit
+	Err  error
+}
+
+func (err *EditError) Error() string {
+	return fmt.Sprintf("%s: %T %s", err.Err, err.Edit, strings.TrimSpace(err.Edit.String()))
+}
+
+func (err *EditError) Unwrap() error {
+	return err.Err
+}
+
 type EditRemoveTable struct {
 	*ast.CreateTable
 }
@@ -34,9 +61,25 @@ func (edit *EditAddTable) String() string {
 	return fmt.Sprintf("add table: \"%s\"", edit.TableIdentifier.FullyQualifiedName("main"))
 }
 
+type EditRenameTable struct {
+	From *ast.CatalogObjectIdentifier
+	To   *ast.CatalogObjectIdentifier
+}
+
+func (edit *EditRenameTable) edit() {}
+func (edit *EditRenameTable) String() string {
+	return fmt.Sprintf("rename table: %s to %s", edit.From.FullyQualifiedName("main"), edit.To.FullyQualifiedName("main"))
+}
+
 type EditModifyTable struct {
 	Target *ast.CreateTable
+	Result *ast.CreateTable
 	Edits  []Edit
+
+	// Dependents are the indexes, triggers and views of the desired schema
+	// which refer to the table, a generator which has to recreate the table
+	// also has to recreate these.
+	Dependents []ast.Statement
 }
 
 func (edit *EditModifyTable) edit() {}
@@ -69,8 +112,19 @@ func (edit *EditAddColumn) String() string {
 	return fmt.Sprintf("add column: \"%s\"\n", edit.ColumnName.Text)
 }
 
+type EditRenameColumn struct {
+	From ast.Identifier
+	To   ast.Identifier
+}
+
+func (edit *EditRenameColumn) edit() {}
+func (edit *EditRenameColumn) String() string {
+	return fmt.Sprintf("rename column: \"%s\" to \"%s\"\n", edit.From.Text, edit.To.Text)
+}
+
 type EditModifyColumn struct {
 	Target *ast.ColumnDefinition
+	Result *ast.ColumnDefinition
 	Edits  []Edit
 }
 
@@ -86,6 +140,23 @@ func (edit *EditModifyColumn) String() string {
 	return builder.String()
 }
 
+// EditSetTableOption sets an option of a mysql table, From is nil when the
+// table did not name the option and To is nil when an inverse can not put
+// back an option which was not named, so it is left as it is.
+type EditSetTableOption struct {
+	Name string
+	From ast.Expr
+	To   ast.Expr
+}
+
+func (edit *EditSetTableOption) edit() {}
+func (edit *EditSetTableOption) String() string {
+	if edit.To == nil {
+		return fmt.Sprintf("keep table option: %s\n", edit.Name)
+	}
+	return fmt.Sprintf("set table option: %s=%s\n", edit.Name, sqlText(edit.To))
+}
+
 type EditRemoveTableConstraint struct {
 	ast.TableConstraint
 }
@@ -107,11 +178,17 @@ func (edit *EditAddTableConstraint) String() string {
 type EditChangeColumnType struct {
 	From ast.TypeName
 	To   ast.TypeName
+	// Using is the expression given by a using annotation to convert the
+	// values with, it is empty when they are cast to the new type.
+	Using string
 }
 
 func (edit *EditChangeColumnType) edit() {}
 func (edit *EditChangeColumnType) String() string {
-	return fmt.Sprintf("change column type: from %s to %s\n", edit.From.TypeName.Text, edit.To.TypeName.Text)
+	if edit.Using != "" {
+		return fmt.Sprintf("change column type: from %s to %s using %s\n", sqlText(&edit.From), sqlText(&edit.To), edit.Using)
+	}
+	return fmt.Sprintf("change column type: from %s to %s\n", sqlText(&edit.From), sqlText(&edit.To))
 }
 
 type EditRemoveColumnConstraint struct {
@@ -134,6 +211,7 @@ func (edit *EditAddColumnConstraint) String() string {
 
 type EditModifyColumnConstraint struct {
 	Target ast.ColumnConstraint
+	Result ast.ColumnConstraint
 	Edits  []Edit
 }
 
@@ -141,6 +219,10 @@ func (edit *EditModifyColumnConstraint) String() string {
 	builder := strings.Builder{}
 
 	fmt.Fprintf(&builder, "modify column constraint: \"%T\"\n", edit.Target)
+	if edit.Result != nil {
+		fmt.Fprintf(&builder, "from: %s\n", sqlText(edit.Target))
+		fmt.Fprintf(&builder, "to: %s\n", sqlText(edit.Result))
+	}
 	for _, edit := range edit.Edits {
 		builder.WriteString(edit.String())
 	}
@@ -150,23 +232,221 @@ func (edit *EditModifyColumnConstraint) String() string {
 
 func (edit *EditModifyColumnConstraint) edit() {}
 
+// EditModifyTableConstraint is a constraint of a table which is still there
+// but has changed, no dialect can change one in place so it is dropped and
+// added again.
 type EditModifyTableConstraint struct {
 	Target ast.TableConstraint
-	Edits  []Edit
+	Result ast.TableConstraint
 }
 
 func (edit *EditModifyTableConstraint) edit() {}
 func (edit *EditModifyTableConstraint) String() string {
 	builder := strings.Builder{}
 
-	builder.WriteString(fmt.Sprintf("modify table constraint: \"%T\"\n", edit.Target))
-	for _, edit := range edit.Edits {
-		builder.WriteString(edit.String())
-	}
+	fmt.Fprintf(&builder, "modify table constraint: \"%T\"\n", edit.Target)
+	fmt.Fprintf(&builder, "from: %s\n", sqlText(edit.Target))
+	fmt.Fprintf(&builder, "to: %s\n", sqlText(edit.Result))
 
 	return builder.String()
 }
 
+type EditAddIndex struct {
+	*ast.CreateIndex
+}
+
+func (edit *EditAddIndex) edit() {}
+func (edit *EditAddIndex) String() string {
+	return fmt.Sprintf("add index: \"%s\"", edit.IndexIdentifier.FullyQualifiedName("main"))
+}
+
+type EditRemoveIndex struct {
+	*ast.CreateIndex
+}
+
+func (edit *EditRemoveIndex) edit() {}
+func (edit *EditRemoveIndex) String() string {
+	return fmt.Sprintf("remove index: \"%s\"", edit.IndexIdentifier.FullyQualifiedName("main"))
+}
+
+// EditReplaceIndex is a change to a index that has to be made by dropping and
+// recreating it.
+type EditReplaceIndex struct {
+	Target *ast.CreateIndex
+	Result *ast.CreateIndex
+}
+
+func (edit *EditReplaceIndex) edit() {}
+func (edit *EditReplaceIndex) String() string {
+	return fmt.Sprintf("replace index: \"%s\"", edit.Target.IndexIdentifier.FullyQualifiedName("main"))
+}
+
+type EditAddView struct {
+	*ast.CreateView
+}
+
+func (edit *EditAddView) edit() {}
+func (edit *EditAddView) String() string {
+	return fmt.Sprintf("add view: \"%s\"", edit.ViewIdentifier.FullyQualifiedName("main"))
+}
+
+type EditRemoveView struct {
+	*ast.CreateView
+}
+
+func (edit *EditRemoveView) edit() {}
+func (edit *EditRemoveView) String() string {
+	return fmt.Sprintf("remove view: \"%s\"", edit.ViewIdentifier.FullyQualifiedName("main"))
+}
+
+// EditReplaceView is a change to a view that has to be made by dropping and
+// recreating it.
+type EditReplaceView struct {
+	Target *ast.CreateView
+	Result *ast.CreateView
+}
+
+func (edit *EditReplaceView) edit() {}
+func (edit *EditReplaceView) String() string {
+	return fmt.Sprintf("replace view: \"%s\"", edit.Target.ViewIdentifier.FullyQualifiedName("main"))
+}
+
+type EditAddTrigger struct {
+	*ast.CreateTrigger
+}
+
+func (edit *EditAddTrigger) edit() {}
+func (edit *EditAddTrigger) String() string {
+	return fmt.Sprintf("add trigger: \"%s\"", edit.TriggerIdentifier.FullyQualifiedName("main"))
+}
+
+type EditRemoveTrigger struct {
+	*ast.CreateTrigger
+}
+
+func (edit *EditRemoveTrigger) edit() {}
+func (edit *EditRemoveTrigger) String() string {
+	return fmt.Sprintf("remove trigger: \"%s\"", edit.TriggerIdentifier.FullyQualifiedName("main"))
+}
+
+// EditReplaceTrigger is a change to a trigger that has to be made by dropping and
+// recreating it.
+type EditReplaceTrigger struct {
+	Target *ast.CreateTrigger
+	Result *ast.CreateTrigger
+}
+
+func (edit *EditReplaceTrigger) edit() {}
+func (edit *EditReplaceTrigger) String() string {
+	return fmt.Sprintf("replace trigger: \"%s\"", edit.Target.TriggerIdentifier.FullyQualifiedName("main"))
+}
+
+type EditAddVirtualTable struct {
+	*ast.CreateVirtualTable
+}
+
+func (edit *EditAddVirtualTable) edit() {}
+func (edit *EditAddVirtualTable) String() string {
+	return fmt.Sprintf("add virtual table: \"%s\"", edit.TableIdentifier.FullyQualifiedName("main"))
+}
+
+type EditRemoveVirtualTable struct {
+	*ast.CreateVirtualTable
+}
+
+func (edit *EditRemoveVirtualTable) edit() {}
+func (edit *EditRemoveVirtualTable) String() string {
+	return fmt.Sprintf("remove virtual table: \"%s\"", edit.TableIdentifier.FullyQualifiedName("main"))
+}
+
+// EditReplaceVirtualTable is a change to a virtual table that has to be made by dropping and
+// recreating it.
+type EditReplaceVirtualTable struct {
+	Target *ast.CreateVirtualTable
+	Result *ast.CreateVirtualTable
+}
+
+func (edit *EditReplaceVirtualTable) edit() {}
+func (edit *EditReplaceVirtualTable) String() string {
+	return fmt.Sprintf("replace virtual table: \"%s\"", edit.Target.TableIdentifier.FullyQualifiedName("main"))
+}
+
+type EditAddSchema struct {
+	*ast.CreateSchema
+}
+
+func (edit *EditAddSchema) edit() {}
+func (edit *EditAddSchema) String() string {
+	return fmt.Sprintf("add schema: \"%s\"", edit.SchemaName.Text)
+}
+
+type EditRemoveSchema struct {
+	*ast.CreateSchema
+}
+
+func (edit *EditRemoveSchema) edit() {}
+func (edit *EditRemoveSchema) String() string {
+	return fmt.Sprintf("remove schema: \"%s\"", edit.SchemaName.Text)
+}
+
+type EditAddEnum struct {
+	*ast.CreateEnum
+}
+
+func (edit *EditAddEnum) edit() {}
+func (edit *EditAddEnum) String() string {
+	return fmt.Sprintf("add enum: %s", edit.TypeIdentifier.FullyQualifiedName("public"))
+}
+
+type EditRemoveEnum struct {
+	*ast.CreateEnum
+}
+
+func (edit *EditRemoveEnum) edit() {}
+func (edit *EditRemoveEnum) String() string {
+	return fmt.Sprintf("remove enum: %s", edit.TypeIdentifier.FullyQualifiedName("public"))
+}
+
+// EditReplaceEnum is a change to the values of an enum, which a generator
+// can only make in place when values are added.
+type EditReplaceEnum struct {
+	Target *ast.CreateEnum
+	Result *ast.CreateEnum
+}
+
+func (edit *EditReplaceEnum) edit() {}
+func (edit *EditReplaceEnum) String() string {
+	return fmt.Sprintf("replace enum: %s", edit.Target.TypeIdentifier.FullyQualifiedName("public"))
+}
+
+type EditAddDomain struct {
+	*ast.CreateDomain
+}
+
+func (edit *EditAddDomain) edit() {}
+func (edit *EditAddDomain) String() string {
+	return fmt.Sprintf("add domain: %s", edit.DomainIdentifier.FullyQualifiedName("public"))
+}
+
+type EditRemoveDomain struct {
+	*ast.CreateDomain
+}
+
+func (edit *EditRemoveDomain) edit() {}
+func (edit *EditRemoveDomain) String() string {
+	return fmt.Sprintf("remove domain: %s", edit.DomainIdentifier.FullyQualifiedName("public"))
+}
+
+type EditReplaceDomain struct {
+	Target *ast.CreateDomain
+	Result *ast.CreateDomain
+}
+
+func (edit *EditReplaceDomain) edit() {}
+func (edit *EditReplaceDomain) String() string {
+	return fmt.Sprintf("replace domain: %s", edit.Target.DomainIdentifier.FullyQualifiedName("public"))
+}
+
 type pair[T any] struct {
 	A T
 	B T
@@ -268,6 +548,7 @@ func filterThenMap[T any, U any](seq iter.Seq[T], filterMap func(T) (U, bool)) i
 
 var (
 	ErrArgumentMismatch error = errors.New("arguments a and b do not match")
+	ErrUnsupportedEdit  error = errors.New("unsupported edit")
 )
 
 func filterForCreateTable(value ast.Statement) (*ast.CreateTable, bool) {
@@ -275,12 +556,18 @@ func filterForCreateTable(value ast.Statement) (*ast.CreateTable, bool) {
 	return result, ok
 }
 
+func filterFor[T ast.Statement](value ast.Statement) (T, bool) {
+	result, ok := value.(T)
+	return result, ok
+}
+
 func isSameCreateTable(a, b *ast.CreateTable) bool {
 	return a.TableIdentifier.Eq(b.TableIdentifier)
 }
 
 func (diff *Diff) DiffSchema(a, b []ast.Statement) ([]Edit, error) {
 	edits := []Edit{}
+	desired := b
 
 	// Compare all create table statements
 	{
@@ -290,6 +577,8 @@ func (diff *Diff) DiffSchema(a, b []ast.Statement) ([]Edit, error) {
 		removedTables, addedTables := symmetricDifference(a, b, isSameCreateTable)
 		maybeModifiedTables := intersection(a, b, isSameCreateTable)
 
+		renamedTables := pairRenamed(&removedTables, &addedTables, diff.tableRenames(addedTables), tableName)
+
 		for _, removedTable := range removedTables {
 			edits = append(edits, &EditRemoveTable{removedTable})
 		}
@@ -298,52 +587,231 @@ func (diff *Diff) DiffSchema(a, b []ast.Statement) ([]Edit, error) {
 			edits = append(edits, &EditAddTable{addedTable})
 		}
 
+		for _, renamedTable := range renamedTables {
+			edits = append(edits, &EditRenameTable{
+				From: renamedTable.A.TableIdentifier,
+				To:   renamedTable.B.TableIdentifier,
+			})
+
+			// the rest of the changes are made to the table under its new name
+			renamed := *renamedTable.A
+			renamed.TableIdentifier = renamedTable.B.TableIdentifier
+			maybeModifiedTables = append(maybeModifiedTables, pair[*ast.CreateTable]{A: &renamed, B: renamedTable.B})
+		}
+
 		for _, pair := range maybeModifiedTables {
 			edit := diff.DiffCreateTable(pair.A, pair.B)
 			if edit != nil {
+				edit.Dependents = Dependents(desired, pair.B.TableIdentifier)
 				edits = append(edits, edit)
 			}
 		}
 	}
 
+	// Compare all create virtual table statements
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateVirtualTable) bool { return a.TableIdentifier.Eq(b.TableIdentifier) },
+		isEqualCreateVirtualTable,
+		func(a *ast.CreateVirtualTable) Edit { return &EditRemoveVirtualTable{a} },
+		func(b *ast.CreateVirtualTable) Edit { return &EditAddVirtualTable{b} },
+		func(a, b *ast.CreateVirtualTable) Edit { return &EditReplaceVirtualTable{Target: a, Result: b} },
+	))
+
+	// Compare all create index statements
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateIndex) bool { return a.IndexIdentifier.Eq(b.IndexIdentifier) },
+		isEqualCreateIndex,
+		func(a *ast.CreateIndex) Edit { return &EditRemoveIndex{a} },
+		func(b *ast.CreateIndex) Edit { return &EditAddIndex{b} },
+		func(a, b *ast.CreateIndex) Edit { return &EditReplaceIndex{Target: a, Result: b} },
+	))
+
+	// Compare all create view statements
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateView) bool { return a.ViewIdentifier.Eq(b.ViewIdentifier) },
+		isEqualCreateView,
+		func(a *ast.CreateView) Edit { return &EditRemoveView{a} },
+		func(b *ast.CreateView) Edit { return &EditAddView{b} },
+		func(a, b *ast.CreateView) Edit { return &EditReplaceView{Target: a, Result: b} },
+	))
+
+	// Compare all create trigger statements
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateTrigger) bool { return a.TriggerIdentifier.Eq(b.TriggerIdentifier) },
+		isEqualCreateTrigger,
+		func(a *ast.CreateTrigger) Edit { return &EditRemoveTrigger{a} },
+		func(b *ast.CreateTrigger) Edit { return &EditAddTrigger{b} },
+		func(a, b *ast.CreateTrigger) Edit { return &EditReplaceTrigger{Target: a, Result: b} },
+	))
+
+	// Compare all create schema statements, a schema is only a name so it
+	// is never replaced
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateSchema) bool { return a.SchemaName.Eq(&b.SchemaName) },
+		func(a, b *ast.CreateSchema) bool { return true },
+		func(a *ast.CreateSchema) Edit { return &EditRemoveSchema{a} },
+		func(b *ast.CreateSchema) Edit { return &EditAddSchema{b} },
+		nil,
+	))
+
+	// Compare all create type as enum statements
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateEnum) bool { return a.TypeIdentifier.Eq(b.TypeIdentifier) },
+		isEqualCreateEnum,
+		func(a *ast.CreateEnum) Edit { return &EditRemoveEnum{a} },
+		func(b *ast.CreateEnum) Edit { return &EditAddEnum{b} },
+		func(a, b *ast.CreateEnum) Edit { return &EditReplaceEnum{Target: a, Result: b} },
+	))
+
+	// Compare all create domain statements
+	edits = slices.Concat(edits, diffObjects(a, b,
+		func(a, b *ast.CreateDomain) bool { return a.DomainIdentifier.Eq(b.DomainIdentifier) },
+		func(a, b *ast.CreateDomain) bool {
+			return isEqualCreateDomain(diff.normalizeDomain(a), diff.normalizeDomain(b))
+		},
+		func(a *ast.CreateDomain) Edit { return &EditRemoveDomain{a} },
+		func(b *ast.CreateDomain) Edit { return &EditAddDomain{b} },
+		func(a, b *ast.CreateDomain) Edit { return &EditReplaceDomain{Target: a, Result: b} },
+	))
+
 	return edits, nil
 }
 
+// diffObjects compares the statements of one kind which can only be created
+// or dropped as a whole, i.e. anything that is not a table.
+func diffObjects[T ast.Statement](
+	a, b []ast.Statement,
+	isSame func(a, b T) bool,
+	isEqual func(a, b T) bool,
+	remove func(a T) Edit,
+	add func(b T) Edit,
+	replace func(a, b T) Edit,
+) []Edit {
+	edits := []Edit{}
+
+	objectsA := slices.Collect(filterThenMap(slices.Values(a), filterFor[T]))
+	objectsB := slices.Collect(filterThenMap(slices.Values(b), filterFor[T]))
+
+	removed, added := symmetricDifference(objectsA, objectsB, isSame)
+	maybeModified := intersection(objectsA, objectsB, isSame)
+
+	for _, object := range removed {
+		edits = append(edits, remove(object))
+	}
+
+	for _, object := range added {
+		edits = append(edits, add(object))
+	}
+
+	for _, pair := range maybeModified {
+		if !isEqual(pair.A, pair.B) {
+			edits = append(edits, replace(pair.A, pair.B))
+		}
+	}
+
+	return edits
+}
+
 func isSameColumnDefinition(a, b ast.ColumnDefinition) bool {
 	return a.ColumnName.Eq(&b.ColumnName)
 }
 
+// isSameTableConstraint pairs up the constraints of two versions of a
+// table. constraints which both have a name are paired by it, otherwise a
+// table has one primary key, and a unique, index or foreign key is paired
+// by its columns and a check by its expression.
 func isSameTableConstraint(a, b ast.TableConstraint) bool {
+	if fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) {
+		return false
+	}
+
+	if a, b := tableConstraintName(a), tableConstraintName(b); a != nil && b != nil {
+		return strings.EqualFold(a.Text, b.Text)
+	}
+
 	switch a := a.(type) {
 	case *ast.TableConstraint_PrimaryKey:
-		_, ok := b.(*ast.TableConstraint_PrimaryKey)
-		if !ok {
-			return false
-		}
 		return true
+	case *ast.TableConstraint_Unique:
+		b := b.(*ast.TableConstraint_Unique)
+		return isSameIndexedColumns(a.IndexedColumns, b.IndexedColumns)
+	case *ast.TableConstraint_Index:
+		b := b.(*ast.TableConstraint_Index)
+		return isSameIndexedColumns(a.IndexedColumns, b.IndexedColumns)
 	case *ast.TableConstraint_ForeignKey:
-		b, ok := b.(*ast.TableConstraint_ForeignKey)
-		if !ok {
-			return false
-		}
-		return a.Eq(b)
+		b := b.(*ast.TableConstraint_ForeignKey)
+		return slices.EqualFunc(a.Columns, b.Columns, func(a, b ast.Identifier) bool { return a.Eq(&b) }) &&
+			isSameObjectName(&a.FkClause.ForeignTable, &b.FkClause.ForeignTable)
+	case *ast.TableConstraint_Check:
+		b := b.(*ast.TableConstraint_Check)
+		return isSameExpr(a.Expr, b.Expr)
 	default:
 		return false
 	}
 }
 
-func (diff *Diff) DiffCreateTable(a, b *ast.CreateTable) Edit {
+// tableConstraintName is the name given to a constraint, or nil.
+func tableConstraintName(constraint ast.TableConstraint) *ast.Identifier {
+	var name *ast.ConstraintName
+	switch typ := constraint.(type) {
+	case *ast.TableConstraint_PrimaryKey:
+		name = typ.Name
+	case *ast.TableConstraint_Unique:
+		name = typ.Name
+	case *ast.TableConstraint_ForeignKey:
+		name = typ.Name
+	case *ast.TableConstraint_Check:
+		name = typ.Name
+	case *ast.TableConstraint_Index:
+		return typ.Name
+	}
+	if name == nil {
+		return nil
+	}
+	return &name.Name
+}
+
+func isSameIndexedColumns(a, b []ast.IndexedColumn) bool {
+	return slices.EqualFunc(a, b, func(a, b ast.IndexedColumn) bool {
+		return isSameExpr(a.Subject, b.Subject) &&
+			isSameCollation(a.Collation, b.Collation) &&
+			a.Order.Eq(b.Order)
+	})
+}
+
+func isSameCollation(a, b *ast.Collation) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return strings.EqualFold(a.Name.Text, b.Name.Text)
+}
+
+func (diff *Diff) DiffCreateTable(a, b *ast.CreateTable) *EditModifyTable {
 	edits := []Edit{}
+	table := b.TableIdentifier.ObjectName.Text
 
 	// Compare column definitions
 	{
 
-		a := a.TableDefinition.ColumnDefinitions
-		b := b.TableDefinition.ColumnDefinitions
+		a := diff.normalizeColumns(a.TableDefinition.ColumnDefinitions)
+		b := diff.normalizeColumns(b.TableDefinition.ColumnDefinitions)
 
 		removedColumns, addedColumns := symmetricDifference(a, b, isSameColumnDefinition)
 		maybeModifiedColumns := intersection(a, b, isSameColumnDefinition)
 
+		renamedColumns := pairRenamed(&removedColumns, &addedColumns, diff.columnRenames(table, addedColumns), columnName)
+
+		for _, renamedColumn := range renamedColumns {
+			edits = append(edits, &EditRenameColumn{
+				From: renamedColumn.A.ColumnName,
+				To:   renamedColumn.B.ColumnName,
+			})
+
+			renamed := renamedColumn.A
+			renamed.ColumnName = renamedColumn.B.ColumnName
+			maybeModifiedColumns = append(maybeModifiedColumns, pair[ast.ColumnDefinition]{A: renamed, B: renamedColumn.B})
+		}
+
 		for _, removedColumn := range removedColumns {
 			edits = append(edits, &EditRemoveColumn{removedColumn})
 		}
@@ -384,9 +852,26 @@ func (diff *Diff) DiffCreateTable(a, b *ast.CreateTable) Edit {
 		}
 	}
 
+	// Compare table options, only the options named by the desired schema,
+	// one which is left out is left as it is.
+	if b.TableOptions != nil {
+		for _, option := range b.TableOptions.Options {
+			from, ok := a.TableOptions.Option(option.Name.Text)
+			if ok && isSameOptionValue(from, option.Value) {
+				continue
+			}
+			edits = append(edits, &EditSetTableOption{
+				Name: option.Name.Text,
+				From: from,
+				To:   option.Value,
+			})
+		}
+	}
+
 	if len(edits) > 0 {
 		return &EditModifyTable{
 			Target: a,
+			Result: b,
 			Edits:  edits,
 		}
 	}
@@ -394,28 +879,433 @@ func (diff *Diff) DiffCreateTable(a, b *ast.CreateTable) Edit {
 	return nil
 }
 
+func (diff *Diff) normalizeColumns(columns []ast.ColumnDefinition) []ast.ColumnDefinition {
+	if diff.NormalizeColumn == nil {
+		return columns
+	}
+	result := make([]ast.ColumnDefinition, 0, len(columns))
+	for _, column := range columns {
+		result = append(result, diff.NormalizeColumn(column))
+	}
+	return result
+}
+
+// normalizeDomain normalizes the constraints of a domain like those of a
+// column of its type.
+func (diff *Diff) normalizeDomain(domain *ast.CreateDomain) *ast.CreateDomain {
+	if diff.NormalizeColumn == nil {
+		return domain
+	}
+	normalized := *domain
+	normalized.Constraints = diff.NormalizeColumn(ast.ColumnDefinition{
+		TypeName:          domain.TypeName,
+		ColumnConstraints: domain.Constraints,
+	}).ColumnConstraints
+	return &normalized
+}
+
+// isSameOptionValue compares the values of a table option, the names of
+// engines, character sets and collations are not case sensitive.
+func isSameOptionValue(a, b ast.Expr) bool {
+	if a, ok := a.(*ast.Identifier); ok {
+		if b, ok := b.(*ast.Identifier); ok {
+			return strings.EqualFold(a.Text, b.Text)
+		}
+	}
+	return isSameExpr(a, b)
+}
+
 func (diff *Diff) DiffColumnDefinition(a, b ast.ColumnDefinition) Edit {
 	edits := []Edit{}
 
+	if !isSameTypeName(a.TypeName, b.TypeName) {
+		edits = append(edits, &EditChangeColumnType{
+			From:  a.TypeName,
+			To:    b.TypeName,
+			Using: convertUsing(b.ColumnName.LeadingTrivia),
+		})
+	}
+
+	// Compare column constraints
+	{
+		a := a.ColumnConstraints
+		b := b.ColumnConstraints
+
+		removedConstraints, addedConstraints := symmetricDifference(a, b, isSameColumnConstraint)
+		maybeModifiedConstraints := intersection(a, b, isSameColumnConstraint)
+
+		for _, removedConstraint := range removedConstraints {
+			edits = append(edits, &EditRemoveColumnConstraint{removedConstraint})
+		}
+
+		for _, addedConstraint := range addedConstraints {
+			edits = append(edits, &EditAddColumnConstraint{addedConstraint})
+		}
+
+		for _, pair := range maybeModifiedConstraints {
+			edit := diff.DiffColumnConstraint(pair.A, pair.B)
+			if edit != nil {
+				edits = append(edits, edit)
+			}
+		}
+	}
+
 	if len(edits) == 0 {
 		return nil
 	}
 
 	return &EditModifyColumn{
 		Target: &a,
+		Result: &b,
 		Edits:  edits,
 	}
 }
 
-func (diff *Diff) DiffTableConstraint(a, b ast.TableConstraint) Edit {
-	edits := []Edit{}
+func isSameTypeName(a, b ast.TypeName) bool {
+	if !strings.EqualFold(a.TypeName.Text, b.TypeName.Text) {
+		return false
+	}
 
-	if len(edits) == 0 {
+	// postgres does not enforce the number of dimensions of an array, only
+	// whether it is one.
+	if (a.Array > 0) != (b.Array > 0) {
+		return false
+	}
+
+	if a.Unsigned != b.Unsigned {
+		return false
+	}
+
+	return slices.EqualFunc(a.Args, b.Args, isSameExpr)
+}
+
+// isSameColumnConstraint pairs up the constraints of two versions of a
+// column, a column has at most one of each kind of constraint apart from
+// check and foreign key constraints, which are paired by name when they
+// have one.
+func isSameColumnConstraint(a, b ast.ColumnConstraint) bool {
+	if fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *ast.ColumnConstraint_Check:
+		b := b.(*ast.ColumnConstraint_Check)
+		if a.Name != nil && b.Name != nil {
+			return a.Name.Eq(b.Name)
+		}
+		return isSameExpr(a.Check, b.Check)
+	case *ast.ColumnConstraint_ForeignKey:
+		b := b.(*ast.ColumnConstraint_ForeignKey)
+		if a.Name != nil && b.Name != nil {
+			return a.Name.Eq(b.Name)
+		}
+		return a.FkClause.ForeignTable.Eq(&b.FkClause.ForeignTable)
+	default:
+		return true
+	}
+}
+
+func (diff *Diff) DiffColumnConstraint(a, b ast.ColumnConstraint) Edit {
+	if isEqualColumnConstraint(a, b) {
+		return nil
+	}
+
+	return &EditModifyColumnConstraint{
+		Target: a,
+		Result: b,
+	}
+}
+
+func isSameConstraintName(a, b *ast.ConstraintName) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Eq(b)
+}
+
+func isSameConflictClause(a, b *ast.ConflictClause) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Eq(b)
+}
+
+// isSameExpr compares expressions with ast.ExprEqual, the text sqlite keeps
+// of a default or check is rarely written quite like the schema.
+func isSameExpr(a, b ast.Expr) bool {
+	return ast.ExprEqual(a, b)
+}
+
+// isEqualColumnConstraint compares everything about two constraints of the
+// same kind, where the constraints Eq method only compares their identity.
+func isEqualColumnConstraint(a, b ast.ColumnConstraint) bool {
+	switch a := a.(type) {
+	case *ast.ColumnConstraint_PrimaryKey:
+		b := b.(*ast.ColumnConstraint_PrimaryKey)
+		return isSameConstraintName(a.Name, b.Name) &&
+			a.Order.Eq(b.Order) &&
+			isSameConflictClause(a.ConflictClause, b.ConflictClause) &&
+			a.IsAutoIncrement() == b.IsAutoIncrement()
+	case *ast.ColumnConstraint_Unique:
+		b := b.(*ast.ColumnConstraint_Unique)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameConflictClause(a.ConflictClause, b.ConflictClause)
+	case *ast.ColumnConstraint_NotNull:
+		b := b.(*ast.ColumnConstraint_NotNull)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameConflictClause(a.ConflictClause, b.ConflictClause)
+	case *ast.ColumnConstraint_Default:
+		b := b.(*ast.ColumnConstraint_Default)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameExpr(a.Default, b.Default)
+	case *ast.ColumnConstraint_Collate:
+		b := b.(*ast.ColumnConstraint_Collate)
+		return isSameConstraintName(a.Name, b.Name) &&
+			strings.EqualFold(a.Collate.Text, b.Collate.Text)
+	case *ast.ColumnConstraint_Generated:
+		b := b.(*ast.ColumnConstraint_Generated)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameExpr(a.As, b.As) &&
+			generatedStorage(a) == generatedStorage(b)
+	case *ast.ColumnConstraint_Check:
+		b := b.(*ast.ColumnConstraint_Check)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameExpr(a.Check, b.Check)
+	case *ast.ColumnConstraint_ForeignKey:
+		b := b.(*ast.ColumnConstraint_ForeignKey)
+		return isSameConstraintName(a.Name, b.Name) &&
+			a.FkClause.Eq(&b.FkClause)
+	case *ast.ColumnConstraint_Identity:
+		b := b.(*ast.ColumnConstraint_Identity)
+		return isSameConstraintName(a.Name, b.Name) &&
+			a.Always == b.Always
+	case *ast.ColumnConstraint_OnUpdate:
+		b := b.(*ast.ColumnConstraint_OnUpdate)
+		return isSameExpr(a.Value, b.Value)
+	case *ast.ColumnConstraint_Comment:
+		b := b.(*ast.ColumnConstraint_Comment)
+		return a.Comment.Value == b.Comment.Value
+	case *ast.ColumnConstraint_CharacterSet:
+		b := b.(*ast.ColumnConstraint_CharacterSet)
+		return strings.EqualFold(a.CharacterSet.Text, b.CharacterSet.Text)
+	default:
+		return a.Eq(b)
+	}
+}
+
+// generatedStorage is the storage of a generated column, which is virtual
+// unless stated otherwise.
+func generatedStorage(constraint *ast.ColumnConstraint_Generated) string {
+	if constraint.Storage == nil {
+		return "virtual"
+	}
+	return strings.ToLower(constraint.Storage.Text)
+}
+
+func sqlText(node interface{ ToSql(f formatter.Formatter) }) string {
+	builder := strings.Builder{}
+	node.ToSql(formatter.NewCoreFormatter(&builder, math.MaxInt, "\"\""))
+	return builder.String()
+}
+
+func (diff *Diff) DiffTableConstraint(a, b ast.TableConstraint) Edit {
+	if isEqualTableConstraint(a, b) {
 		return nil
 	}
 
 	return &EditModifyTableConstraint{
 		Target: a,
-		Edits:  edits,
+		Result: b,
+	}
+}
+
+// isEqualTableConstraint compares everything about two constraints of the
+// same kind, the order of the columns of a key included.
+func isEqualTableConstraint(a, b ast.TableConstraint) bool {
+	switch a := a.(type) {
+	case *ast.TableConstraint_PrimaryKey:
+		b := b.(*ast.TableConstraint_PrimaryKey)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameIndexedColumns(a.IndexedColumns, b.IndexedColumns) &&
+			(a.AutoIncrement == nil) == (b.AutoIncrement == nil) &&
+			isSameConflictClause(a.ConflictClause, b.ConflictClause)
+	case *ast.TableConstraint_Unique:
+		b := b.(*ast.TableConstraint_Unique)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameIndexedColumns(a.IndexedColumns, b.IndexedColumns) &&
+			isSameConflictClause(a.ConflictClause, b.ConflictClause)
+	case *ast.TableConstraint_Index:
+		b := b.(*ast.TableConstraint_Index)
+		return isSameIdentifier(a.Name, b.Name) &&
+			isSameKeyword(a.Kind, b.Kind) &&
+			isSameIndexedColumns(a.IndexedColumns, b.IndexedColumns)
+	case *ast.TableConstraint_ForeignKey:
+		b := b.(*ast.TableConstraint_ForeignKey)
+		return isSameConstraintName(a.Name, b.Name) &&
+			slices.EqualFunc(a.Columns, b.Columns, func(a, b ast.Identifier) bool { return a.Eq(&b) }) &&
+			a.FkClause.Eq(&b.FkClause)
+	case *ast.TableConstraint_Check:
+		b := b.(*ast.TableConstraint_Check)
+		return isSameConstraintName(a.Name, b.Name) &&
+			isSameExpr(a.Expr, b.Expr)
+	default:
+		return a.Eq(b)
+	}
+}
+
+func isSameIdentifier(a, b *ast.Identifier) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Eq(b)
+}
+
+func isSameKeyword(a, b *ast.Keyword) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
 	}
+	return strings.EqualFold(a.Text, b.Text)
+}
+
+func isSameObjectName(a, b *ast.CatalogObjectIdentifier) bool {
+	return strings.EqualFold(a.ObjectName.Text, b.ObjectName.Text)
+}
+
+func mentionsObject(tokens []tik.Token, object *ast.CatalogObjectIdentifier) bool {
+	return slices.ContainsFunc(tokens, func(token tik.Token) bool {
+		return token.Kind == tik.TokenKind_Identifier && strings.EqualFold(token.Text, object.ObjectName.Text)
+	})
+}
+
+// Dependents finds the indexes, triggers and views in statements which refer
+// to table, either by being defined on it or by mentioning it in their body.
+func Dependents(statements []ast.Statement, table *ast.CatalogObjectIdentifier) []ast.Statement {
+	result := []ast.Statement{}
+
+	for _, statement := range statements {
+		switch typ := statement.(type) {
+		case *ast.CreateIndex:
+			if isSameObjectName(typ.OnTable, table) {
+				result = append(result, typ)
+			}
+		case *ast.CreateTrigger:
+			if isSameObjectName(typ.OnTable, table) || mentionsObject(typ.Body, table) {
+				result = append(result, typ)
+			}
+		case *ast.CreateView:
+			if mentionsObject(typ.AsSelect.Tokens, table) {
+				result = append(result, typ)
+			}
+		}
+	}
+
+	// a view which selects from a dependent view depends on the table too,
+	// the view in between can not be recreated without it.
+	for i := 0; i < len(result); i++ {
+		view, ok := result[i].(*ast.CreateView)
+		if !ok {
+			continue
+		}
+		for _, statement := range statements {
+			other, ok := statement.(*ast.CreateView)
+			if ok && !slices.Contains(result, statement) && mentionsObject(other.AsSelect.Tokens, view.ViewIdentifier) {
+				result = append(result, other)
+			}
+		}
+	}
+
+	return result
+}
+
+func isEqualCreateVirtualTable(a, b *ast.CreateVirtualTable) bool {
+	return strings.EqualFold(a.ModuleName.Text, b.ModuleName.Text) &&
+		slices.Equal(a.ModuleArgs, b.ModuleArgs)
+}
+
+func isEqualCreateIndex(a, b *ast.CreateIndex) bool {
+	return a.IsUnique() == b.IsUnique() &&
+		isSameObjectName(a.OnTable, b.OnTable) &&
+		indexMethod(a) == indexMethod(b) &&
+		isSameIndexedColumns(a.IndexedColumns, b.IndexedColumns) &&
+		isSameExpr(a.WhereExpr, b.WhereExpr)
+}
+
+// indexMethod is how a postgres index is built, which is a btree unless
+// stated otherwise.
+func indexMethod(index *ast.CreateIndex) string {
+	if index.Using == nil {
+		return "btree"
+	}
+	return strings.ToLower(index.Using.Text)
+}
+
+func isEqualCreateEnum(a, b *ast.CreateEnum) bool {
+	return slices.EqualFunc(a.Values, b.Values, func(a, b ast.LiteralString) bool {
+		return a.Value == b.Value
+	})
+}
+
+func isEqualCreateDomain(a, b *ast.CreateDomain) bool {
+	if !isSameTypeName(a.TypeName, b.TypeName) {
+		return false
+	}
+
+	removed, added := symmetricDifference(a.Constraints, b.Constraints, isSameColumnConstraint)
+	if len(removed) > 0 || len(added) > 0 {
+		return false
+	}
+
+	for _, pair := range intersection(a.Constraints, b.Constraints, isSameColumnConstraint) {
+		if !isEqualColumnConstraint(pair.A, pair.B) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isEqualCreateView(a, b *ast.CreateView) bool {
+	return slices.EqualFunc(a.Columns, b.Columns, func(a, b ast.Identifier) bool {
+		return a.Eq(&b)
+	}) &&
+		isSameTokens(a.AsSelect.Tokens, b.AsSelect.Tokens)
+}
+
+func isEqualCreateTrigger(a, b *ast.CreateTrigger) bool {
+	return fmt.Sprintf("%T", a.TriggerTime) == fmt.Sprintf("%T", b.TriggerTime) &&
+		isSameTriggerEvent(a.TriggerEvent, b.TriggerEvent) &&
+		isSameObjectName(a.OnTable, b.OnTable) &&
+		a.ForEachRow == b.ForEachRow &&
+		isSameExpr(a.When, b.When) &&
+		isSameTokens(a.Body, b.Body)
+}
+
+func isSameTriggerEvent(a, b ast.TriggerEvent) bool {
+	if fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) {
+		return false
+	}
+
+	if a, ok := a.(*ast.TriggerEventUpdateOf); ok {
+		b := b.(*ast.TriggerEventUpdateOf)
+		return slices.EqualFunc(a.Columns, b.Columns, func(a, b ast.Identifier) bool {
+			return strings.EqualFold(a.Text, b.Text)
+		})
+	}
+
+	return true
+}
+
+// isSameTokens compares statements which are held verbatim as tokens, keywords
+// and names are compared without case and whitespace is ignored.
+func isSameTokens(a, b []tik.Token) bool {
+	return slices.EqualFunc(a, b, func(a, b tik.Token) bool {
+		if a.Kind != b.Kind {
+			return false
+		}
+		if a.Kind == tik.TokenKind_StringLiteral {
+			return a.Text == b.Text
+		}
+		return strings.EqualFold(a.Text, b.Text)
+	})
 }
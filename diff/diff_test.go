@@ -0,0 +1,580 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	sqliteparser "woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/internal/testschema"
+)
+
+func parseSchema(t *testing.T, input string) []ast.Statement {
+	return testschema.Parse(t, sqliteparser.NewSqliteParser, input)
+}
+
+func diffSchema(t *testing.T, a, b string) []Edit {
+	differ := Diff{}
+	edits, err := differ.DiffSchema(parseSchema(t, a), parseSchema(t, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return edits
+}
+
+func columnEdits(t *testing.T, a, b string) []Edit {
+	edits := diffSchema(t, a, b)
+	if len(edits) != 1 {
+		t.Fatalf("expected a single edit, got %v", edits)
+	}
+
+	modifyTable, ok := edits[0].(*EditModifyTable)
+	if !ok || len(modifyTable.Edits) != 1 {
+		t.Fatalf("expected a single modified table, got %v", edits)
+	}
+
+	modifyColumn, ok := modifyTable.Edits[0].(*EditModifyColumn)
+	if !ok {
+		t.Fatalf("expected a modified column, got %v", modifyTable.Edits)
+	}
+
+	return modifyColumn.Edits
+}
+
+func TestDiffColumnDefinition(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []Edit
+	}{
+		{
+			name:     "type",
+			a:        "CREATE TABLE t (c integer);",
+			b:        "CREATE TABLE t (c real);",
+			expected: []Edit{&EditChangeColumnType{}},
+		},
+		{
+			name:     "type arguments",
+			a:        "CREATE TABLE t (c varchar(10));",
+			b:        "CREATE TABLE t (c varchar(20));",
+			expected: []Edit{&EditChangeColumnType{}},
+		},
+		{
+			name:     "add not null",
+			a:        "CREATE TABLE t (c text);",
+			b:        "CREATE TABLE t (c text NOT NULL);",
+			expected: []Edit{&EditAddColumnConstraint{}},
+		},
+		{
+			name:     "remove not null",
+			a:        "CREATE TABLE t (c text NOT NULL);",
+			b:        "CREATE TABLE t (c text);",
+			expected: []Edit{&EditRemoveColumnConstraint{}},
+		},
+		{
+			name:     "default",
+			a:        "CREATE TABLE t (c integer DEFAULT 0);",
+			b:        "CREATE TABLE t (c integer DEFAULT 1);",
+			expected: []Edit{&EditModifyColumnConstraint{}},
+		},
+		{
+			name:     "collation",
+			a:        "CREATE TABLE t (c text COLLATE nocase);",
+			b:        "CREATE TABLE t (c text COLLATE rtrim);",
+			expected: []Edit{&EditModifyColumnConstraint{}},
+		},
+		{
+			name:     "generated",
+			a:        "CREATE TABLE t (a integer, c integer AS (a + 1));",
+			b:        "CREATE TABLE t (a integer, c integer AS (a + 1) STORED);",
+			expected: []Edit{&EditModifyColumnConstraint{}},
+		},
+		{
+			name:     "check",
+			a:        "CREATE TABLE t (c integer CHECK (c > 0));",
+			b:        "CREATE TABLE t (c integer CHECK (c > 1));",
+			expected: []Edit{&EditRemoveColumnConstraint{}, &EditAddColumnConstraint{}},
+		},
+		{
+			name:     "primary key autoincrement",
+			a:        "CREATE TABLE t (c integer PRIMARY KEY);",
+			b:        "CREATE TABLE t (c integer PRIMARY KEY AUTOINCREMENT);",
+			expected: []Edit{&EditModifyColumnConstraint{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edits := columnEdits(t, test.a, test.b)
+			if len(edits) != len(test.expected) {
+				t.Fatalf("expected %d edits, got %v", len(test.expected), edits)
+			}
+			for i, edit := range edits {
+				if got, want := typeName(edit), typeName(test.expected[i]); got != want {
+					t.Errorf("edit %d: expected %s, got %s", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffColumnDefinitionIgnoresTypeCase(t *testing.T) {
+	edits := diffSchema(t,
+		"CREATE TABLE t (c INTEGER NOT NULL DEFAULT 0);",
+		"CREATE TABLE t (c integer NOT NULL DEFAULT 0);",
+	)
+	if len(edits) != 0 {
+		t.Fatalf("expected no edits, got %v", edits)
+	}
+}
+
+func typeName(edit Edit) string {
+	return fmt.Sprintf("%T", edit)
+}
+
+func TestDiffTableConstraints(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []Edit
+	}{
+		{
+			name:     "unchanged check",
+			a:        "CREATE TABLE t (a integer, b integer, CHECK (a > 0), PRIMARY KEY (a, b));",
+			b:        "CREATE TABLE t (a integer, b integer, CHECK (a > 0), PRIMARY KEY (a, b));",
+			expected: []Edit{},
+		},
+		{
+			name:     "primary key order",
+			a:        "CREATE TABLE t (a integer, b integer, PRIMARY KEY (a, b));",
+			b:        "CREATE TABLE t (a integer, b integer, PRIMARY KEY (b, a));",
+			expected: []Edit{&EditModifyTableConstraint{}},
+		},
+		{
+			name:     "primary key conflict clause",
+			a:        "CREATE TABLE t (a integer, PRIMARY KEY (a));",
+			b:        "CREATE TABLE t (a integer, PRIMARY KEY (a) ON CONFLICT REPLACE);",
+			expected: []Edit{&EditModifyTableConstraint{}},
+		},
+		{
+			name:     "named check expression",
+			a:        "CREATE TABLE t (a integer, CONSTRAINT positive CHECK (a > 0));",
+			b:        "CREATE TABLE t (a integer, CONSTRAINT positive CHECK (a >= 0));",
+			expected: []Edit{&EditModifyTableConstraint{}},
+		},
+		{
+			name:     "unnamed check expression",
+			a:        "CREATE TABLE t (a integer, CHECK (a > 0));",
+			b:        "CREATE TABLE t (a integer, CHECK (a >= 0));",
+			expected: []Edit{&EditRemoveTableConstraint{}, &EditAddTableConstraint{}},
+		},
+		{
+			name:     "unique given a name",
+			a:        "CREATE TABLE t (a integer, UNIQUE (a));",
+			b:        "CREATE TABLE t (a integer, CONSTRAINT t_a UNIQUE (a));",
+			expected: []Edit{&EditModifyTableConstraint{}},
+		},
+		{
+			name:     "unique columns",
+			a:        "CREATE TABLE t (a integer, b integer, CONSTRAINT t_key UNIQUE (a));",
+			b:        "CREATE TABLE t (a integer, b integer, CONSTRAINT t_key UNIQUE (a, b));",
+			expected: []Edit{&EditModifyTableConstraint{}},
+		},
+		{
+			name:     "renamed unique",
+			a:        "CREATE TABLE t (a integer, CONSTRAINT t_a UNIQUE (a));",
+			b:        "CREATE TABLE t (a integer, CONSTRAINT t_a_key UNIQUE (a));",
+			expected: []Edit{&EditRemoveTableConstraint{}, &EditAddTableConstraint{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edits := diffSchema(t, test.a, test.b)
+			actual := []Edit{}
+			if len(edits) == 1 {
+				actual = edits[0].(*EditModifyTable).Edits
+			}
+
+			if !slices.EqualFunc(actual, test.expected, func(a, b Edit) bool { return typeName(a) == typeName(b) }) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpressionsAreNormalized(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		changed bool
+	}{
+		{name: "default parens", a: "a integer DEFAULT (0)", b: "a integer DEFAULT 0"},
+		{name: "spacing", a: "a integer CHECK (a>1)", b: "a integer CHECK (a > 1)"},
+		{name: "keyword case", a: "a integer CHECK (a > 1 and a < 5)", b: "a integer CHECK (a > 1 AND a < 5)"},
+		{name: "quoting and case", a: `a text DEFAULT (LOWER("x"))`, b: "a text DEFAULT lower(x)"},
+		{name: "quoted case", a: `a text DEFAULT (lower("X"))`, b: "a text DEFAULT lower(x)", changed: true},
+		{name: "redundant inner parens", a: "a integer CHECK (((a > 1)) AND (a < 5))", b: "a integer CHECK (a > 1 AND a < 5)"},
+		{name: "left associative", a: "a integer CHECK ((a - 1) - 2 > 0)", b: "a integer CHECK (a - 1 - 2 > 0)"},
+		{name: "needed parens", a: "a integer CHECK (a - (1 - 2) > 0)", b: "a integer CHECK (a - 1 - 2 > 0)", changed: true},
+		{name: "precedence", a: "a integer CHECK ((a > 1 OR a < 0) AND a != 5)", b: "a integer CHECK (a > 1 OR a < 0 AND a != 5)", changed: true},
+		{name: "numbers", a: "a real DEFAULT 1.0", b: "a real DEFAULT 1.00"},
+		{name: "integers", a: "a integer DEFAULT 0x10", b: "a integer DEFAULT 16"},
+		{name: "large integers", a: "a integer DEFAULT 9007199254740993", b: "a integer DEFAULT 9007199254740992", changed: true},
+		{name: "leading zero", a: "a integer DEFAULT 010", b: "a integer DEFAULT 10"},
+		{name: "different value", a: "a integer DEFAULT 0", b: "a integer DEFAULT 1", changed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edits := diffSchema(t,
+				fmt.Sprintf("CREATE TABLE t (%s);", test.a),
+				fmt.Sprintf("CREATE TABLE t (%s);", test.b),
+			)
+			if changed := len(edits) > 0; changed != test.changed {
+				t.Fatalf("expected changed to be %v, got %v", test.changed, edits)
+			}
+		})
+	}
+}
+
+func TestDiffSchemaObjects(t *testing.T) {
+	edits := diffSchema(t,
+		`CREATE TABLE t (a integer, b integer);
+		CREATE INDEX t_a ON t (a);
+		CREATE INDEX t_b ON t (b);
+		CREATE VIEW v AS SELECT a FROM t;
+		CREATE TRIGGER tr AFTER INSERT ON t BEGIN SELECT 1; END;`,
+		`CREATE TABLE t (a integer, b integer);
+		CREATE UNIQUE INDEX t_a ON t (a);
+		CREATE INDEX t_ab ON t (a, b);
+		CREATE VIEW v AS select a from t;
+		CREATE TRIGGER tr AFTER INSERT ON t BEGIN SELECT 2; END;`,
+	)
+
+	expected := []Edit{
+		&EditRemoveIndex{},
+		&EditAddIndex{},
+		&EditReplaceIndex{},
+		&EditReplaceTrigger{},
+	}
+
+	if len(edits) != len(expected) {
+		t.Fatalf("expected %d edits, got %v", len(expected), edits)
+	}
+	for i, edit := range edits {
+		if got, want := typeName(edit), typeName(expected[i]); got != want {
+			t.Errorf("edit %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestInvert(t *testing.T) {
+	current := parseSchema(t, `CREATE TABLE t (a integer, b integer);
+	CREATE TABLE gone (a integer);`)
+	desired := parseSchema(t, `CREATE TABLE t (a integer, c integer);
+	CREATE TABLE u (a integer);`)
+
+	differ := Diff{}
+	edits, err := differ.DiffSchema(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inverse, warnings, err := Invert(edits, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := differ.DiffSchema(desired, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inverse) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, inverse)
+	}
+	// nested edits may come in a different order, so compare the lines.
+	if got, want := editLines(inverse), editLines(expected); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// b is re-added empty and gone is recreated empty.
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", warnings)
+	}
+	// going down drops c and u.
+	if losses := Unrecoverable(Classify(inverse)); len(losses) != 2 {
+		t.Fatalf("expected 2 losses, got %v", losses)
+	}
+}
+
+func editLines(edits []Edit) []string {
+	lines := []string{}
+	for _, edit := range edits {
+		lines = append(lines, strings.Split(strings.TrimSpace(edit.String()), "\n")...)
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+func TestClassify(t *testing.T) {
+	edits := diffSchema(t,
+		`CREATE TABLE t (a integer, b integer);
+		CREATE TABLE gone (a integer);`,
+		`-- justmigrate:allow-destructive t.b
+		CREATE TABLE t (a integer NOT NULL, c integer);
+		CREATE TABLE u (a integer);
+		CREATE INDEX u_a ON u (a);`,
+	)
+
+	safety := map[string]Safety{}
+	for _, classification := range Classify(edits) {
+		safety[typeName(classification.Edit)] = classification.Safety
+	}
+
+	expected := map[string]Safety{
+		typeName(&EditRemoveTable{}): DataLosing,
+		typeName(&EditAddTable{}):    Safe,
+		typeName(&EditModifyTable{}): DataLosing,
+		typeName(&EditAddIndex{}):    Safe,
+	}
+	for name, want := range expected {
+		if got := safety[name]; got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+
+	approved := ApprovedLosses("-- justmigrate:allow-destructive t.b")
+	blocked := Blocked(Classify(edits), approved)
+	if len(blocked) != 1 || !strings.Contains(blocked[0].Message, "gone") {
+		t.Fatalf("expected only the dropped table to be blocked, got %v", blocked)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	edits := diffSchema(t,
+		"CREATE TABLE t (a text, b real, c integer, d text);",
+		`CREATE TABLE t (
+			a integer,
+			b integer,
+			c real,
+			-- justmigrate:using CAST(replace(d, '$', '') AS REAL)
+			d real
+		);`,
+	)
+
+	conversions := []string{}
+	for _, classification := range Classify(edits) {
+		for _, conversion := range classification.Conversions {
+			conversions = append(conversions, conversion.Column)
+		}
+	}
+	if !slices.Equal(conversions, []string{"t.a", "t.b"}) {
+		t.Fatalf("expected the conversions of t.a and t.b to be lossy, got %v", conversions)
+	}
+
+	using := ""
+	for _, e := range edits[0].(*EditModifyTable).Edits {
+		modify := e.(*EditModifyColumn)
+		if change, ok := modify.Edits[0].(*EditChangeColumnType); ok && modify.Result.ColumnName.Text == "d" {
+			using = change.Using
+		}
+	}
+	if using != "CAST(replace(d, '$', '') AS REAL)" {
+		t.Fatalf("expected the using annotation of d, got %q", using)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	statements := parseSchema(t, `
+		CREATE TABLE billing_plan_price (id integer, plan_id integer REFERENCES billing_plan (id));
+		CREATE VIEW prices AS SELECT * FROM billing_plan_price;
+		CREATE TABLE billing_plan (id integer, FOREIGN KEY (id) REFERENCES billing_plan (id));
+		CREATE INDEX billing_plan_id ON billing_plan (id);`)
+
+	ordered, cycles := Order(statements)
+	if len(cycles) > 0 {
+		t.Fatalf("expected no cycles, got %v", cycles)
+	}
+
+	names := []string{}
+	for _, statement := range ordered {
+		names = append(names, fmt.Sprintf("%T", statement))
+	}
+	expected := []string{"*ast.CreateTable", "*ast.CreateTable", "*ast.CreateView", "*ast.CreateIndex"}
+	if !slices.Equal(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	if ordered[0] != statements[2] || ordered[1] != statements[0] {
+		t.Fatalf("expected billing_plan before billing_plan_price, got %v", ordered)
+	}
+}
+
+func TestOrderCycle(t *testing.T) {
+	statements := parseSchema(t, `
+		CREATE TABLE a (id integer, b_id integer REFERENCES b (id));
+		CREATE TABLE b (id integer, c_id integer REFERENCES c (id));
+		CREATE TABLE c (id integer, b_id integer REFERENCES b (id));`)
+
+	ordered, cycles := Order(statements)
+	if len(cycles) != 1 || !strings.Contains(cycles[0].Message, `"b" -> "c" -> "b"`) {
+		t.Fatalf("expected the cycle between b and c to be reported, got %v", cycles)
+	}
+	if len(cycles[0].Labels) != 2 {
+		t.Fatalf("expected a label on each table in the cycle, got %v", cycles[0].Labels)
+	}
+	// a only waits on b, which is placed first to break the cycle.
+	if ordered[0] != statements[1] || ordered[1] != statements[0] || ordered[2] != statements[2] {
+		t.Fatalf("expected b, a then c, got %v", ordered)
+	}
+}
+
+func TestDiffRenames(t *testing.T) {
+	edits := diffSchema(t,
+		`CREATE TABLE people (id integer PRIMARY KEY, name text);`,
+		`-- justmigrate:renamed-from people
+		CREATE TABLE persons (
+			id integer PRIMARY KEY,
+			-- justmigrate:renamed-from name
+			full_name text NOT NULL
+		);`,
+	)
+
+	if len(edits) != 2 {
+		t.Fatalf("expected a rename and a modified table, got %v", edits)
+	}
+	if _, ok := edits[0].(*EditRenameTable); !ok {
+		t.Fatalf("expected a renamed table, got %v", edits[0])
+	}
+
+	modifyTable := edits[1].(*EditModifyTable)
+	expected := []Edit{&EditRenameColumn{}, &EditModifyColumn{}}
+	if len(modifyTable.Edits) != len(expected) {
+		t.Fatalf("expected %d edits, got %v", len(expected), modifyTable.Edits)
+	}
+	for i, edit := range modifyTable.Edits {
+		if got, want := typeName(edit), typeName(expected[i]); got != want {
+			t.Errorf("edit %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+// TestInvertRenames renames a table back before modifying it under its old
+// name, the generators all make renames before modifying tables.
+func TestInvertRenames(t *testing.T) {
+	current := parseSchema(t, `CREATE TABLE prices (id integer PRIMARY KEY, amount text);`)
+	edits := diffSchema(t,
+		`CREATE TABLE prices (id integer PRIMARY KEY, amount text);`,
+		`-- justmigrate:renamed-from prices
+		CREATE TABLE plan_prices (id integer PRIMARY KEY, amount text NOT NULL);`,
+	)
+
+	inverse, _, err := Invert(edits, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inverse) != 2 {
+		t.Fatalf("expected a rename and a modified table, got %v", inverse)
+	}
+
+	rename, ok := inverse[0].(*EditRenameTable)
+	if !ok || rename.To.ObjectName.Text != "prices" {
+		t.Fatalf("expected plan_prices to be renamed back to prices first, got %v", inverse[0])
+	}
+	modifyTable, ok := inverse[1].(*EditModifyTable)
+	if !ok {
+		t.Fatalf("expected a modified table, got %v", inverse[1])
+	}
+	if name := modifyTable.Target.TableIdentifier.ObjectName.Text; name != "prices" {
+		t.Errorf("expected prices to be modified, got %s", name)
+	}
+	if name := modifyTable.Result.TableIdentifier.ObjectName.Text; name != "prices" {
+		t.Errorf("expected prices to be the result, got %s", name)
+	}
+}
+
+func TestInvertConversions(t *testing.T) {
+	current := parseSchema(t, `CREATE TABLE t (a integer, b text);`)
+	edits := diffSchema(t,
+		`CREATE TABLE t (a integer, b text);`,
+		`CREATE TABLE t (
+			a real,
+			-- justmigrate:using CAST(replace(b, '$', '') AS REAL)
+			b real
+		);`,
+	)
+
+	inverse, warnings, err := Invert(edits, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// b is cast back to text, not to what it was before the using expression.
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"b"`) {
+		t.Fatalf("expected a warning about b, got %v", warnings)
+	}
+	// a goes back from real to integer.
+	unrecoverable := Unrecoverable(Classify(inverse))
+	if len(unrecoverable) != 1 || !strings.Contains(unrecoverable[0], `"a"`) {
+		t.Fatalf("expected the conversion of a, got %v", unrecoverable)
+	}
+}
+
+type unknownEdit struct{}
+
+func (edit *unknownEdit) edit()          {}
+func (edit *unknownEdit) String() string { return "unknown edit" }
+
+func TestInvertUnsupportedEdit(t *testing.T) {
+	_, _, err := Invert([]Edit{&unknownEdit{}}, nil)
+	if !errors.Is(err, ErrUnsupportedEdit) {
+		t.Fatalf("expected %v, got %v", ErrUnsupportedEdit, err)
+	}
+}
+
+func TestSuggestRenames(t *testing.T) {
+	differ := Diff{}
+	edits := diffSchema(t,
+		`CREATE TABLE t (a integer, b text);`,
+		`CREATE TABLE t (a integer, c text);`,
+	)
+
+	suggestions := differ.SuggestRenames(edits)
+	if len(suggestions) != 1 || suggestions[0].String() != "t.b=c" {
+		t.Fatalf("expected t.b=c to be suggested, got %v", suggestions)
+	}
+
+	rename, err := ParseRename("t.b=t.c")
+	if err != nil || rename != suggestions[0] {
+		t.Fatalf("expected %v, got %v, %v", suggestions[0], rename, err)
+	}
+}
+
+func TestDrifts(t *testing.T) {
+	edits := diffSchema(t,
+		`create table users (id integer primary key);
+		 create table old (id integer);`,
+		`create table users (id integer primary key, email text);
+		 create index users_email on users (email);`,
+	)
+
+	drifts := Drifts(edits)
+	got := []string{}
+	for _, drift := range drifts {
+		got = append(got, fmt.Sprintf("%s %s %s", drift.Change, drift.Kind, drift.Object))
+	}
+	slices.Sort(got)
+
+	expected := []string{"added index users_email", "changed table users", "removed table old"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	for _, report := range DriftReports(drifts) {
+		if len(report.Labels) != 1 {
+			t.Errorf("expected %q to be labelled", report.Message)
+		}
+	}
+}
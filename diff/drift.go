@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"fmt"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+)
+
+// Drift is a difference between the schema the migrations leave behind and
+// the schema of the database, found by diffing the first against the second.
+type Drift struct {
+	// Kind is the kind of object, e.g. "table" or "index".
+	Kind string `json:"kind"`
+	// Object is the name of the object.
+	Object string `json:"object"`
+	// Change is "added" for an object only the database has, "removed" for
+	// one only the migrations make, and otherwise "changed" or "renamed".
+	Change string `json:"change"`
+	// Edit is the edit which would make the migrations match the database.
+	Edit string `json:"edit"`
+
+	at ast.Identifier
+}
+
+// Drifts describes edits from the schema the migrations make to the schema
+// of the database.
+func Drifts(edits []Edit) []Drift {
+	drifts := []Drift{}
+	for _, edit := range edits {
+		drift := Drift{Edit: edit.String()}
+
+		var object *ast.CatalogObjectIdentifier
+		switch typ := edit.(type) {
+		case *EditAddTable:
+			drift.Kind, drift.Change, object = "table", "added", typ.TableIdentifier
+		case *EditRemoveTable:
+			drift.Kind, drift.Change, object = "table", "removed", typ.TableIdentifier
+		case *EditRenameTable:
+			drift.Kind, drift.Change, object = "table", "renamed", typ.To
+		case *EditModifyTable:
+			drift.Kind, drift.Change, object = "table", "changed", typ.Result.TableIdentifier
+		case *EditAddVirtualTable:
+			drift.Kind, drift.Change, object = "virtual table", "added", typ.TableIdentifier
+		case *EditRemoveVirtualTable:
+			drift.Kind, drift.Change, object = "virtual table", "removed", typ.TableIdentifier
+		case *EditReplaceVirtualTable:
+			drift.Kind, drift.Change, object = "virtual table", "changed", typ.Result.TableIdentifier
+		case *EditAddIndex:
+			drift.Kind, drift.Change, object = "index", "added", typ.IndexIdentifier
+		case *EditRemoveIndex:
+			drift.Kind, drift.Change, object = "index", "removed", typ.IndexIdentifier
+		case *EditReplaceIndex:
+			drift.Kind, drift.Change, object = "index", "changed", typ.Result.IndexIdentifier
+		case *EditAddView:
+			drift.Kind, drift.Change, object = "view", "added", typ.ViewIdentifier
+		case *EditRemoveView:
+			drift.Kind, drift.Change, object = "view", "removed", typ.ViewIdentifier
+		case *EditReplaceView:
+			drift.Kind, drift.Change, object = "view", "changed", typ.Result.ViewIdentifier
+		case *EditAddTrigger:
+			drift.Kind, drift.Change, object = "trigger", "added", typ.TriggerIdentifier
+		case *EditRemoveTrigger:
+			drift.Kind, drift.Change, object = "trigger", "removed", typ.TriggerIdentifier
+		case *EditReplaceTrigger:
+			drift.Kind, drift.Change, object = "trigger", "changed", typ.Result.TriggerIdentifier
+		case *EditAddEnum:
+			drift.Kind, drift.Change, object = "enum", "added", typ.TypeIdentifier
+		case *EditRemoveEnum:
+			drift.Kind, drift.Change, object = "enum", "removed", typ.TypeIdentifier
+		case *EditReplaceEnum:
+			drift.Kind, drift.Change, object = "enum", "changed", typ.Result.TypeIdentifier
+		case *EditAddDomain:
+			drift.Kind, drift.Change, object = "domain", "added", typ.DomainIdentifier
+		case *EditRemoveDomain:
+			drift.Kind, drift.Change, object = "domain", "removed", typ.DomainIdentifier
+		case *EditReplaceDomain:
+			drift.Kind, drift.Change, object = "domain", "changed", typ.Result.DomainIdentifier
+		case *EditAddSchema:
+			drift.Kind, drift.Change, drift.Object, drift.at = "schema", "added", typ.SchemaName.Text, typ.SchemaName
+		case *EditRemoveSchema:
+			drift.Kind, drift.Change, drift.Object, drift.at = "schema", "removed", typ.SchemaName.Text, typ.SchemaName
+		default:
+			drift.Kind, drift.Change = "object", "changed"
+		}
+
+		if object != nil {
+			drift.Object = object.ObjectName.Text
+			if object.SchemaName != nil {
+				drift.Object = object.SchemaName.Text + "." + drift.Object
+			}
+			drift.at = object.ObjectName
+		}
+
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+// DriftReports reports each drift, labelled in the database, or in the
+// migrations for an object the database does not have.
+func DriftReports(drifts []Drift) []report.Report {
+	reports := []report.Report{}
+	for _, drift := range drifts {
+		var message, note string
+		switch drift.Change {
+		case "added":
+			message = fmt.Sprintf("%s %s is in the database but no migration makes it", drift.Kind, drift.Object)
+			note = "not made by a migration"
+		case "removed":
+			message = fmt.Sprintf("%s %s is made by the migrations but is not in the database", drift.Kind, drift.Object)
+			note = "missing from the database"
+		default:
+			message = fmt.Sprintf("%s %s is not as the migrations leave it", drift.Kind, drift.Object)
+			note = "differs from the migrations"
+		}
+
+		reported := report.NewReport("drift").
+			WithMessage(message).
+			WithNotes([]string{drift.Edit})
+		if drift.at.SourceCode.Raw != nil {
+			reported = reported.WithLabels([]report.Label{{
+				Source: drift.at.SourceCode,
+				Range:  drift.at.SourceRange,
+				Note:   note,
+			}})
+		}
+		reports = append(reports, *reported)
+	}
+	return reports
+}
@@ -0,0 +1,209 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"woodybriggs/justmigrate/core/ast"
+)
+
+// Invert returns the edits which undo edits, taking the schema back to
+// current, along with a warning for everything the inverse can bring back
+// the shape of but not the data, e.g. a dropped column is re-added empty.
+func Invert(edits []Edit, current []ast.Statement) ([]Edit, []string, error) {
+	inverse := make([]Edit, 0, len(edits))
+	renames := []Edit{}
+	warnings := []string{}
+
+	// a renamed table is modified under its new name, going back it is renamed
+	// first, just as it is going forward, and modified under its old name.
+	renamedFrom := map[string]*ast.CatalogObjectIdentifier{}
+	for _, edit := range edits {
+		if rename, ok := edit.(*EditRenameTable); ok {
+			renamedFrom[rename.To.FullyQualifiedName("main")] = rename.From
+		}
+	}
+
+	for _, edit := range slices.Backward(edits) {
+		switch typ := edit.(type) {
+		case *EditModifyTable:
+			warnings = append(warnings, unrestorable(typ)...)
+			if from, ok := renamedFrom[typ.Target.TableIdentifier.FullyQualifiedName("main")]; ok {
+				edit = modifiedUnder(typ, from)
+			}
+		case *EditRemoveTable:
+			warnings = append(warnings, fmt.Sprintf("table %s is recreated without its rows",
+				typ.TableIdentifier.FullyQualifiedName("main")))
+		case *EditRemoveVirtualTable:
+			warnings = append(warnings, fmt.Sprintf("virtual table %s is recreated without its rows",
+				typ.TableIdentifier.FullyQualifiedName("main")))
+		}
+
+		inverted, err := invertEdit(edit, current)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := inverted.(*EditRenameTable); ok {
+			renames = append(renames, inverted)
+		} else {
+			inverse = append(inverse, inverted)
+		}
+	}
+
+	modified := slices.IndexFunc(inverse, func(edit Edit) bool {
+		_, ok := edit.(*EditModifyTable)
+		return ok
+	})
+	if modified < 0 {
+		modified = len(inverse)
+	}
+	return slices.Insert(inverse, modified, renames...), warnings, nil
+}
+
+// modifiedUnder is edit made to the table under another name.
+func modifiedUnder(edit *EditModifyTable, name *ast.CatalogObjectIdentifier) *EditModifyTable {
+	target, result := *edit.Target, *edit.Result
+	target.TableIdentifier = name
+	result.TableIdentifier = name
+	return &EditModifyTable{
+		Target:     &target,
+		Result:     &result,
+		Edits:      edit.Edits,
+		Dependents: edit.Dependents,
+	}
+}
+
+func invertEdit(edit Edit, current []ast.Statement) (Edit, error) {
+	switch typ := edit.(type) {
+	case *EditAddTable:
+		return &EditRemoveTable{typ.CreateTable}, nil
+	case *EditRemoveTable:
+		return &EditAddTable{typ.CreateTable}, nil
+	case *EditRenameTable:
+		return &EditRenameTable{From: typ.To, To: typ.From}, nil
+	case *EditModifyTable:
+		edits, err := invertEdits(typ.Edits, current)
+		return &EditModifyTable{
+			Target:     typ.Result,
+			Result:     typ.Target,
+			Edits:      edits,
+			Dependents: Dependents(current, typ.Target.TableIdentifier),
+		}, err
+	case *EditAddColumn:
+		return &EditRemoveColumn{typ.ColumnDefinition}, nil
+	case *EditRemoveColumn:
+		return &EditAddColumn{typ.ColumnDefinition}, nil
+	case *EditRenameColumn:
+		return &EditRenameColumn{From: typ.To, To: typ.From}, nil
+	case *EditModifyColumn:
+		edits, err := invertEdits(typ.Edits, current)
+		return &EditModifyColumn{
+			Target: typ.Result,
+			Result: typ.Target,
+			Edits:  edits,
+		}, err
+	case *EditChangeColumnType:
+		// the conversion only goes one way, going back casts the values.
+		return &EditChangeColumnType{From: typ.To, To: typ.From}, nil
+	case *EditAddColumnConstraint:
+		return &EditRemoveColumnConstraint{typ.ColumnConstraint}, nil
+	case *EditRemoveColumnConstraint:
+		return &EditAddColumnConstraint{typ.ColumnConstraint}, nil
+	case *EditModifyColumnConstraint:
+		edits, err := invertEdits(typ.Edits, current)
+		return &EditModifyColumnConstraint{
+			Target: typ.Result,
+			Result: typ.Target,
+			Edits:  edits,
+		}, err
+	case *EditAddTableConstraint:
+		return &EditRemoveTableConstraint{typ.TableConstraint}, nil
+	case *EditRemoveTableConstraint:
+		return &EditAddTableConstraint{typ.TableConstraint}, nil
+	case *EditSetTableOption:
+		return &EditSetTableOption{Name: typ.Name, From: typ.To, To: typ.From}, nil
+	case *EditModifyTableConstraint:
+		return &EditModifyTableConstraint{
+			Target: typ.Result,
+			Result: typ.Target,
+		}, nil
+	case *EditAddIndex:
+		return &EditRemoveIndex{typ.CreateIndex}, nil
+	case *EditRemoveIndex:
+		return &EditAddIndex{typ.CreateIndex}, nil
+	case *EditReplaceIndex:
+		return &EditReplaceIndex{Target: typ.Result, Result: typ.Target}, nil
+	case *EditAddView:
+		return &EditRemoveView{typ.CreateView}, nil
+	case *EditRemoveView:
+		return &EditAddView{typ.CreateView}, nil
+	case *EditReplaceView:
+		return &EditReplaceView{Target: typ.Result, Result: typ.Target}, nil
+	case *EditAddTrigger:
+		return &EditRemoveTrigger{typ.CreateTrigger}, nil
+	case *EditRemoveTrigger:
+		return &EditAddTrigger{typ.CreateTrigger}, nil
+	case *EditReplaceTrigger:
+		return &EditReplaceTrigger{Target: typ.Result, Result: typ.Target}, nil
+	case *EditAddVirtualTable:
+		return &EditRemoveVirtualTable{typ.CreateVirtualTable}, nil
+	case *EditRemoveVirtualTable:
+		return &EditAddVirtualTable{typ.CreateVirtualTable}, nil
+	case *EditReplaceVirtualTable:
+		return &EditReplaceVirtualTable{Target: typ.Result, Result: typ.Target}, nil
+	case *EditAddSchema:
+		return &EditRemoveSchema{typ.CreateSchema}, nil
+	case *EditRemoveSchema:
+		return &EditAddSchema{typ.CreateSchema}, nil
+	case *EditAddEnum:
+		return &EditRemoveEnum{typ.CreateEnum}, nil
+	case *EditRemoveEnum:
+		return &EditAddEnum{typ.CreateEnum}, nil
+	case *EditReplaceEnum:
+		return &EditReplaceEnum{Target: typ.Result, Result: typ.Target}, nil
+	case *EditAddDomain:
+		return &EditRemoveDomain{typ.CreateDomain}, nil
+	case *EditRemoveDomain:
+		return &EditAddDomain{typ.CreateDomain}, nil
+	case *EditReplaceDomain:
+		return &EditReplaceDomain{Target: typ.Result, Result: typ.Target}, nil
+	default:
+		return nil, &EditError{Edit: edit, Err: ErrUnsupportedEdit}
+	}
+}
+
+// invertEdits inverts the edits nested inside of a table, column or
+// constraint, their order does not matter to the generators so it is kept.
+func invertEdits(edits []Edit, current []ast.Statement) ([]Edit, error) {
+	result := make([]Edit, 0, len(edits))
+	for _, edit := range edits {
+		inverted, err := invertEdit(edit, current)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, inverted)
+	}
+	return result, nil
+}
+
+// unrestorable warns about the columns of a table whose values going back
+// does not bring back, a column converted with a using expression is only
+// cast back.
+func unrestorable(edit *EditModifyTable) []string {
+	table := edit.Target.TableIdentifier.FullyQualifiedName("main")
+	warnings := []string{}
+	for _, e := range edit.Edits {
+		switch typ := e.(type) {
+		case *EditRemoveColumn:
+			warnings = append(warnings, fmt.Sprintf("column %s.\"%s\" is re-added without its values",
+				table, typ.ColumnName.Text))
+		case *EditModifyColumn:
+			for _, change := range typ.Edits {
+				if change, ok := change.(*EditChangeColumnType); ok && change.Using != "" {
+					warnings = append(warnings, fmt.Sprintf("column %s.\"%s\" is cast back to %s, which does not undo %s",
+						table, typ.Target.ColumnName.Text, sqlText(&change.From), change.Using))
+				}
+			}
+		}
+	}
+	return warnings
+}
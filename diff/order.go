@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+// Order sorts statements so that each one comes after the statements it
+// depends on, a table after the tables its foreign keys reference, a view
+// after the tables and views it selects from, and an index or trigger after
+// its table. the order they were given in is kept where it can be, reversing
+// the result gives the order to drop them in.
+//
+// statements which depend on each other in a cycle are left in the order
+// they were given, and the cycle is reported.
+func Order(statements []ast.Statement) ([]ast.Statement, []report.Report) {
+	dependencies := make([][]int, len(statements))
+	for i, statement := range statements {
+		for j, other := range statements {
+			if i != j && dependsOn(statement, other) {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+
+	result := make([]ast.Statement, 0, len(statements))
+	reports := []report.Report{}
+	placed := make([]bool, len(statements))
+
+	// waitingOn is the first dependency of i still to be placed, or -1.
+	waitingOn := func(i int) int {
+		at := slices.IndexFunc(dependencies[i], func(j int) bool { return !placed[j] })
+		if at < 0 {
+			return -1
+		}
+		return dependencies[i][at]
+	}
+
+	for len(result) < len(statements) {
+		progressed := false
+		for i, statement := range statements {
+			if !placed[i] && waitingOn(i) < 0 {
+				result = append(result, statement)
+				placed[i] = true
+				progressed = true
+				// start over, so that what was waiting on this goes before
+				// anything given after it.
+				break
+			}
+		}
+		if progressed {
+			continue
+		}
+
+		// everything left waits on something else which is left, follow
+		// the dependencies round until one comes back.
+		start := slices.Index(placed, false)
+		cycle := []int{start}
+		for {
+			next := waitingOn(cycle[len(cycle)-1])
+			if at := slices.Index(cycle, next); at >= 0 {
+				cycle = cycle[at:]
+				break
+			}
+			cycle = append(cycle, next)
+		}
+		reports = append(reports, cycleReport(statements, cycle))
+
+		first := slices.Min(cycle)
+		result = append(result, statements[first])
+		placed[first] = true
+	}
+
+	return result, reports
+}
+
+func cycleReport(statements []ast.Statement, cycle []int) report.Report {
+	names := []string{}
+	labels := []report.Label{}
+	for n, i := range cycle {
+		name := objectName(statements[i])
+		next := objectName(statements[cycle[(n+1)%len(cycle)]])
+		names = append(names, fmt.Sprintf("\"%s\"", name.ObjectName.Text))
+		labels = append(labels, report.Label{
+			Source: tik.Token(name.ObjectName).SourceCode,
+			Range:  tik.Token(name.ObjectName).SourceRange,
+			Note:   fmt.Sprintf("depends on \"%s\"", next.ObjectName.Text),
+		})
+	}
+	names = append(names, names[0])
+
+	return *report.NewReport("warning").
+		WithMessage(fmt.Sprintf("%s depend on each other in a cycle", strings.Join(names, " -> "))).
+		WithLabels(labels).
+		WithNotes([]string{
+			"the statements in a cycle are kept in the order they were written",
+		})
+}
+
+// objectName is the name other statements can refer to a statement by, or
+// nil for an index or trigger which nothing refers to.
+func objectName(statement ast.Statement) *ast.CatalogObjectIdentifier {
+	switch typ := statement.(type) {
+	case *ast.CreateTable:
+		return typ.TableIdentifier
+	case *ast.CreateVirtualTable:
+		return typ.TableIdentifier
+	case *ast.CreateView:
+		return typ.ViewIdentifier
+	default:
+		return nil
+	}
+}
+
+// dependsOn reports whether statement has to come after other.
+func dependsOn(statement, other ast.Statement) bool {
+	name := objectName(other)
+	if name == nil {
+		return false
+	}
+
+	switch typ := statement.(type) {
+	case *ast.CreateTable:
+		return slices.ContainsFunc(References(typ), func(reference ast.CatalogObjectIdentifier) bool {
+			return isSameObjectName(&reference, name)
+		})
+	case *ast.CreateView:
+		return mentionsObject(typ.AsSelect.Tokens, name)
+	case *ast.CreateTrigger:
+		return isSameObjectName(typ.OnTable, name) || mentionsObject(typ.Body, name)
+	case *ast.CreateIndex:
+		return isSameObjectName(typ.OnTable, name)
+	default:
+		return false
+	}
+}
+
+// References are the tables the foreign keys of table reference, other than
+// itself.
+func References(table *ast.CreateTable) []ast.CatalogObjectIdentifier {
+	if table.TableDefinition == nil {
+		return nil
+	}
+
+	clauses := []ast.ForeignKeyClause{}
+	for _, column := range table.TableDefinition.ColumnDefinitions {
+		for _, constraint := range column.ColumnConstraints {
+			if foreignKey, ok := constraint.(*ast.ColumnConstraint_ForeignKey); ok {
+				clauses = append(clauses, foreignKey.FkClause)
+			}
+		}
+	}
+	for _, constraint := range table.TableDefinition.TableConstraints {
+		if foreignKey, ok := constraint.(*ast.TableConstraint_ForeignKey); ok {
+			clauses = append(clauses, foreignKey.FkClause)
+		}
+	}
+
+	result := []ast.CatalogObjectIdentifier{}
+	for _, clause := range clauses {
+		if !isSameObjectName(&clause.ForeignTable, table.TableIdentifier) {
+			result = append(result, clause.ForeignTable)
+		}
+	}
+	return result
+}
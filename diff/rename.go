@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+)
+
+// RenamedFrom is the annotation which marks a table or column as renamed,
+// written on the line above its definition.
+//
+//	CREATE TABLE users (
+//	    -- justmigrate:renamed-from name
+//	    full_name text
+//	);
+const RenamedFrom = "renamed-from"
+
+// Rename is a table or column which has been renamed. Without a rename the
+// differ sees the old one removed and a new one added, which loses its data.
+type Rename struct {
+	// Table is the new name of the table a renamed column is in, it is empty
+	// when the table itself is renamed.
+	Table string
+	From  string
+	To    string
+}
+
+// String is the rename in the form taken by the --rename flag, old=new for
+// a table and table.old=new for a column.
+func (rename Rename) String() string {
+	if rename.Table == "" {
+		return fmt.Sprintf("%s=%s", rename.From, rename.To)
+	}
+	return fmt.Sprintf("%s.%s=%s", rename.Table, rename.From, rename.To)
+}
+
+// ParseRename reads a rename in the form taken by the --rename flag.
+func ParseRename(text string) (Rename, error) {
+	from, to, ok := strings.Cut(text, "=")
+	if !ok || from == "" || to == "" {
+		return Rename{}, fmt.Errorf("rename '%s' is not old=new or table.old=new", text)
+	}
+
+	table, column, isColumn := strings.Cut(from, ".")
+	if !isColumn {
+		return Rename{From: from, To: to}, nil
+	}
+
+	// the new name may repeat the table, table.old=table.new
+	if newTable, newColumn, ok := strings.Cut(to, "."); ok {
+		if !strings.EqualFold(newTable, table) {
+			return Rename{}, fmt.Errorf("rename '%s' moves a column to another table", text)
+		}
+		to = newColumn
+	}
+
+	return Rename{Table: table, From: column, To: to}, nil
+}
+
+func tableName(table *ast.CreateTable) string {
+	return table.TableIdentifier.ObjectName.Text
+}
+
+func columnName(column ast.ColumnDefinition) string {
+	return column.ColumnName.Text
+}
+
+// renamedFrom finds the renamed-from annotation in the trivia in front of a
+// definition.
+func renamedFrom(trivia string) (string, bool) {
+	for _, annotation := range Annotations(trivia) {
+		if annotation.Name == RenamedFrom && annotation.Argument != "" {
+			return strings.Trim(annotation.Argument, "\"`[]"), true
+		}
+	}
+	return "", false
+}
+
+func (diff *Diff) tableRenames(added []*ast.CreateTable) []Rename {
+	renames := []Rename{}
+	for _, rename := range diff.Renames {
+		if rename.Table == "" {
+			renames = append(renames, rename)
+		}
+	}
+
+	for _, table := range added {
+		if from, ok := renamedFrom(table.CreateKeyword.LeadingTrivia); ok {
+			renames = append(renames, Rename{From: from, To: tableName(table)})
+		}
+	}
+
+	return renames
+}
+
+func (diff *Diff) columnRenames(table string, added []ast.ColumnDefinition) []Rename {
+	renames := []Rename{}
+	for _, rename := range diff.Renames {
+		if rename.Table != "" && strings.EqualFold(rename.Table, table) {
+			renames = append(renames, rename)
+		}
+	}
+
+	for _, column := range added {
+		if from, ok := renamedFrom(column.ColumnName.LeadingTrivia); ok {
+			renames = append(renames, Rename{Table: table, From: from, To: columnName(column)})
+		}
+	}
+
+	return renames
+}
+
+// pairRenamed takes the objects which renames name out of removed and added,
+// pairing each old one with its new one.
+func pairRenamed[T any](removed, added *[]T, renames []Rename, name func(T) string) pairs[T] {
+	result := pairs[T]{}
+
+	for _, rename := range renames {
+		i := slices.IndexFunc(*removed, func(object T) bool { return strings.EqualFold(name(object), rename.From) })
+		j := slices.IndexFunc(*added, func(object T) bool { return strings.EqualFold(name(object), rename.To) })
+		if i < 0 || j < 0 {
+			continue
+		}
+
+		result = append(result, pair[T]{A: (*removed)[i], B: (*added)[j]})
+		*removed = slices.Delete(*removed, i, i+1)
+		*added = slices.Delete(*added, j, j+1)
+	}
+
+	return result
+}
+
+// SuggestRenames looks through edits for a table or column which is removed
+// while another one with the same definition is added, these are likely to
+// be renames but are left for the user to confirm.
+func (diff *Diff) SuggestRenames(edits []Edit) []Rename {
+	suggestions := []Rename{}
+
+	removedTables := []*ast.CreateTable{}
+	addedTables := []*ast.CreateTable{}
+
+	for _, edit := range edits {
+		switch typ := edit.(type) {
+		case *EditRemoveTable:
+			removedTables = append(removedTables, typ.CreateTable)
+		case *EditAddTable:
+			addedTables = append(addedTables, typ.CreateTable)
+		case *EditModifyTable:
+			removed := []ast.ColumnDefinition{}
+			added := []ast.ColumnDefinition{}
+			for _, e := range typ.Edits {
+				switch e := e.(type) {
+				case *EditRemoveColumn:
+					removed = append(removed, e.ColumnDefinition)
+				case *EditAddColumn:
+					added = append(added, e.ColumnDefinition)
+				}
+			}
+
+			for _, from := range removed {
+				candidates := slices.DeleteFunc(slices.Clone(added), func(to ast.ColumnDefinition) bool {
+					renamed := from
+					renamed.ColumnName = to.ColumnName
+					return diff.DiffColumnDefinition(renamed, to) != nil
+				})
+				if len(candidates) == 1 {
+					suggestions = append(suggestions, Rename{
+						Table: tableName(typ.Result),
+						From:  columnName(from),
+						To:    columnName(candidates[0]),
+					})
+				}
+			}
+		}
+	}
+
+	for _, from := range removedTables {
+		candidates := slices.DeleteFunc(slices.Clone(addedTables), func(to *ast.CreateTable) bool {
+			renamed := *from
+			renamed.TableIdentifier = to.TableIdentifier
+			return diff.DiffCreateTable(&renamed, to) != nil
+		})
+		if len(candidates) == 1 {
+			suggestions = append(suggestions, Rename{
+				From: tableName(from),
+				To:   tableName(candidates[0]),
+			})
+		}
+	}
+
+	return suggestions
+}
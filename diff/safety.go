@@ -0,0 +1,247 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+// Safety is how careful one has to be about applying an edit to a database
+// which is in use.
+type Safety int
+
+const (
+	// Safe edits only add to the schema.
+	Safe Safety = iota
+	// PotentiallyBlocking edits have to read or rewrite every row of a table,
+	// which can take a long time, or fail, on a large table.
+	PotentiallyBlocking
+	// DataLosing edits delete data which can not be brought back.
+	DataLosing
+)
+
+func (safety Safety) String() string {
+	switch safety {
+	case Safe:
+		return "safe"
+	case PotentiallyBlocking:
+		return "potentially blocking"
+	case DataLosing:
+		return "data losing"
+	default:
+		return fmt.Sprintf("Safety(%d)", int(safety))
+	}
+}
+
+// AllowDestructive is the annotation which approves a data losing edit, its
+// argument names the table or table.column being dropped.
+//
+//	-- justmigrate:allow-destructive users.nickname
+const AllowDestructive = "allow-destructive"
+
+// Loss is one piece of data a data losing edit deletes.
+type Loss struct {
+	// Object is the name an annotation approves the loss by, either table or
+	// table.column.
+	Object  string
+	Message string
+	// At is the name of the object in the schema it was read from.
+	At tik.Token
+}
+
+// Conversion is a change of column type which can change the values in it,
+// e.g. text which is not a number becoming 0 when cast to an integer.
+type Conversion struct {
+	// Column is table.column.
+	Column  string
+	Message string
+	// At is the name of the column in the schema it was read from.
+	At tik.Token
+}
+
+type Classification struct {
+	Edit   Edit
+	Safety Safety
+	// Blocking says why the edit is potentially blocking.
+	Blocking    []string
+	Losses      []Loss
+	Conversions []Conversion
+}
+
+// Classify decides how safe each edit is to apply.
+func Classify(edits []Edit) []Classification {
+	// indexes on tables which are added by the same migration are built
+	// while the table is still empty.
+	added := []string{}
+	for _, edit := range edits {
+		if typ, ok := edit.(*EditAddTable); ok {
+			added = append(added, strings.ToLower(typ.TableIdentifier.ObjectName.Text))
+		}
+	}
+
+	result := make([]Classification, 0, len(edits))
+	for _, edit := range edits {
+		result = append(result, classify(edit, added))
+	}
+	return result
+}
+
+func classify(edit Edit, added []string) Classification {
+	classification := Classification{Edit: edit, Safety: Safe}
+
+	lose := func(object string, at ast.Identifier, message string) {
+		classification.Safety = DataLosing
+		classification.Losses = append(classification.Losses, Loss{
+			Object:  object,
+			Message: message,
+			At:      tik.Token(at),
+		})
+	}
+
+	block := func(message string) {
+		classification.Safety = max(classification.Safety, PotentiallyBlocking)
+		classification.Blocking = append(classification.Blocking, message)
+	}
+
+	switch typ := edit.(type) {
+	case *EditRemoveTable:
+		lose(typ.TableIdentifier.ObjectName.Text, typ.TableIdentifier.ObjectName,
+			fmt.Sprintf("dropping table %s deletes its rows", typ.TableIdentifier.FullyQualifiedName("main")))
+	case *EditRemoveVirtualTable:
+		lose(typ.TableIdentifier.ObjectName.Text, typ.TableIdentifier.ObjectName,
+			fmt.Sprintf("dropping virtual table %s deletes its rows", typ.TableIdentifier.FullyQualifiedName("main")))
+	case *EditReplaceVirtualTable:
+		lose(typ.Target.TableIdentifier.ObjectName.Text, typ.Target.TableIdentifier.ObjectName,
+			fmt.Sprintf("recreating virtual table %s deletes its rows", typ.Target.TableIdentifier.FullyQualifiedName("main")))
+	case *EditModifyTable:
+		table := typ.Target.TableIdentifier
+		rewrites := false
+		for _, e := range typ.Edits {
+			switch e := e.(type) {
+			case *EditRemoveColumn:
+				lose(table.ObjectName.Text+"."+e.ColumnName.Text, e.ColumnName,
+					fmt.Sprintf("dropping column %s.\"%s\" deletes its values", table.FullyQualifiedName("main"), e.ColumnName.Text))
+			case *EditAddColumn, *EditRenameColumn:
+			case *EditModifyColumn:
+				rewrites = true
+				for _, change := range e.Edits {
+					change, ok := change.(*EditChangeColumnType)
+					if !ok || change.Using != "" || !isLossy(change.From, change.To) {
+						continue
+					}
+					classification.Conversions = append(classification.Conversions, Conversion{
+						Column: table.ObjectName.Text + "." + e.Result.ColumnName.Text,
+						Message: fmt.Sprintf("converting column %s.\"%s\" from %s to %s can change values which do not convert",
+							table.FullyQualifiedName("main"), e.Result.ColumnName.Text, sqlText(&change.From), sqlText(&change.To)),
+						At: tik.Token(e.Result.ColumnName),
+					})
+				}
+			default:
+				rewrites = true
+			}
+		}
+		if rewrites {
+			block(fmt.Sprintf("changing table %s checks or rewrites every row", table.FullyQualifiedName("main")))
+		}
+	case *EditAddIndex:
+		if slices.Contains(added, strings.ToLower(typ.OnTable.ObjectName.Text)) {
+			break
+		}
+		block(fmt.Sprintf("building index %s reads every row of %s",
+			typ.IndexIdentifier.FullyQualifiedName("main"), typ.OnTable.ObjectName.Text))
+	case *EditReplaceIndex:
+		block(fmt.Sprintf("rebuilding index %s reads every row of %s",
+			typ.Result.IndexIdentifier.FullyQualifiedName("main"), typ.Result.OnTable.ObjectName.Text))
+	}
+
+	return classification
+}
+
+// Unrecoverable describes the data which applying the classified edits
+// deletes, and the values their conversions may change.
+func Unrecoverable(classifications []Classification) []string {
+	messages := []string{}
+	for _, classification := range classifications {
+		for _, loss := range classification.Losses {
+			messages = append(messages, loss.Message)
+		}
+		for _, conversion := range classification.Conversions {
+			messages = append(messages, conversion.Message)
+		}
+	}
+	return messages
+}
+
+// ApprovedLosses are the objects the schema approves the loss of with an
+// allow-destructive annotation, lower cased.
+func ApprovedLosses(schema string) []string {
+	approved := []string{}
+	for _, annotation := range Annotations(schema) {
+		if annotation.Name == AllowDestructive {
+			for _, object := range strings.Fields(annotation.Argument) {
+				approved = append(approved, strings.ToLower(object))
+			}
+		}
+	}
+	return approved
+}
+
+// Blocked reports every loss in classifications which is not approved.
+func Blocked(classifications []Classification, approved []string) []report.Report {
+	reports := []report.Report{}
+
+	for _, classification := range classifications {
+		for _, loss := range classification.Losses {
+			if slices.Contains(approved, strings.ToLower(loss.Object)) {
+				continue
+			}
+			reports = append(reports, *report.NewReport("error").
+				WithMessage(loss.Message).
+				WithLabels([]report.Label{
+					{
+						Source: loss.At.SourceCode,
+						Range:  loss.At.SourceRange,
+						Note:   "not in the schema",
+					},
+				}).
+				WithNotes([]string{
+					fmt.Sprintf("approve it with '-- justmigrate:%s %s' in the schema, or pass --allow-destructive", AllowDestructive, loss.Object),
+				}),
+			)
+		}
+	}
+
+	return reports
+}
+
+// Cautions reports why the edits in classifications are potentially
+// blocking, and the conversions which may not keep every value.
+func Cautions(classifications []Classification) []report.Report {
+	reports := []report.Report{}
+
+	for _, classification := range classifications {
+		for _, reason := range classification.Blocking {
+			reports = append(reports, *report.NewReport("warning").WithMessage(reason))
+		}
+		for _, conversion := range classification.Conversions {
+			reports = append(reports, *report.NewReport("warning").
+				WithMessage(conversion.Message).
+				WithLabels([]report.Label{
+					{
+						Source: conversion.At.SourceCode,
+						Range:  conversion.At.SourceRange,
+						Note:   "changes type here",
+					},
+				}).
+				WithNotes([]string{
+					fmt.Sprintf("convert the values yourself with '-- justmigrate:%s <expression>' above the column", ConvertUsing),
+				}),
+			)
+		}
+	}
+
+	return reports
+}
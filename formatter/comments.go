@@ -0,0 +1,172 @@
+package formatter
+
+import (
+	"strings"
+)
+
+// Comment is a `--` or `/* */` comment found in the whitespace between two
+// tokens.
+type Comment struct {
+	Text string
+	// Trailing comments follow the token before them on the same line,
+	// e.g. `id integer, -- the id`, the rest sit on lines of their own.
+	Trailing bool
+	// BlankBefore and BlankAfter are whether the comment is set apart from
+	// what is around it by an empty line.
+	BlankBefore bool
+	BlankAfter  bool
+}
+
+func (c Comment) endsLine() bool {
+	return strings.HasPrefix(c.Text, "--")
+}
+
+// Comments finds the comments in trivia, the whitespace and comments the
+// lexer keeps either side of a token.
+func Comments(trivia string) []Comment {
+	result := []Comment{}
+	trailing := true
+	newlines := 0
+
+	for i := 0; i < len(trivia); {
+		rest := trivia[i:]
+
+		end := 0
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end = strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			end = strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+		case rest[0] == '\n':
+			trailing = false
+			newlines++
+			if len(result) > 0 && newlines > 1 {
+				result[len(result)-1].BlankAfter = true
+			}
+			i++
+			continue
+		default:
+			i++
+			continue
+		}
+
+		result = append(result, Comment{
+			Text:        strings.TrimRight(rest[:end], " \t\r"),
+			Trailing:    trailing,
+			BlankBefore: newlines > 1,
+		})
+		newlines = 0
+		i += end
+	}
+
+	return result
+}
+
+// Trivia holds on to the comments in trivia until they can be written. The
+// trailing ones are written at the end of the current line, the others on
+// lines of their own before the next text.
+func (f *CoreFormatter) Trivia(trivia string) {
+	if !f.style.KeepComments || f.mode == FormatModeMeasure {
+		return
+	}
+	f.pending = append(f.pending, Comments(trivia)...)
+}
+
+// Flush writes the comments still waiting for the next text, for when
+// there is none, at the end of a file.
+func (f *CoreFormatter) Flush() {
+	if len(f.pending) > 0 {
+		f.pending[len(f.pending)-1].BlankAfter = false
+	}
+	f.writeComments()
+}
+
+// writeTrailingComments writes the trailing comments at the end of the
+// current line, and reports whether the last of them runs to the end of it.
+func (f *CoreFormatter) writeTrailingComments() bool {
+	if f.column == 0 {
+		// there is no line to add them to, e.g. at the start of the file
+		for i := 0; i < len(f.pending) && f.pending[i].Trailing; i++ {
+			f.pending[i].Trailing = false
+		}
+		return false
+	}
+
+	endsLine := false
+	for len(f.pending) > 0 && f.pending[0].Trailing && !endsLine {
+		comment := f.pending[0]
+		f.pending = f.pending[1:]
+
+		f.writeRaw(" ")
+		f.writeRaw(comment.Text)
+		endsLine = comment.endsLine()
+	}
+	return endsLine
+}
+
+// writeComments writes every pending comment ahead of the text which is
+// about to be written.
+func (f *CoreFormatter) writeComments() {
+	if len(f.pending) == 0 {
+		return
+	}
+
+	if f.writeTrailingComments() {
+		f.newline()
+	}
+	if len(f.pending) == 0 {
+		if f.column > 0 {
+			// a block comment in the middle of a line
+			f.writeRaw(" ")
+		}
+		return
+	}
+
+	if f.column > 0 {
+		f.newline()
+	}
+	for _, comment := range f.pending {
+		if comment.BlankBefore {
+			f.blankLine()
+		}
+		f.writeIndent()
+		f.writeRaw(comment.Text)
+		f.newline()
+		if comment.BlankAfter {
+			f.blankLine()
+		}
+	}
+	f.pending = f.pending[:0]
+}
+
+func (f *CoreFormatter) writeRaw(s string) {
+	f.writer.Write([]byte(s))
+	f.column += len(s)
+	f.newlines = 0
+	f.written = true
+}
+
+func (f *CoreFormatter) newline() {
+	f.writer.Write([]byte("\n"))
+	f.column = 0
+	f.newlines++
+}
+
+// blankLine ends the current line and leaves one empty line after it,
+// unless there is one already or nothing has been written yet.
+func (f *CoreFormatter) blankLine() {
+	if !f.written {
+		return
+	}
+	for f.newlines < 2 {
+		f.newline()
+	}
+}
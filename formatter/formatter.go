@@ -8,15 +8,46 @@ import (
 )
 
 type Formatter interface {
-	Identifier(s string)
+	Identifier(s string, quoted bool) // quoted is whether the name was quoted in the source
+	Keyword(s string)
 	Text(s string)
 	Rune(r rune)
 	Space()
-	Line()            // "soft" line (space if fits, newline if broken)
-	Break()           // "hard" newline
-	Indent(fn func()) // increase logical nesting
-	Anchor(fn func()) // align subsequent lines to current column
-	Group(fn func())  // try to fit everything inside on one line
+	Line()                    // "soft" line (space if fits, newline if broken)
+	Break()                   // "hard" newline
+	Indent(fn func())         // increase logical nesting
+	Anchor(fn func())         // align subsequent lines to current column
+	Group(fn func())          // try to fit everything inside on one line
+	Measure(fn func()) int    // width of fn written on one line, nothing is written
+	Pad(width int, fn func()) // pad fn with spaces to width when aligning columns
+	Trivia(trivia string)     // keep the comments in the whitespace around a token
+}
+
+type KeywordCase int
+
+const (
+	KeywordCaseAsWritten KeywordCase = iota
+	KeywordCaseUpper
+	KeywordCaseLower
+)
+
+type QuoteStyle int
+
+const (
+	// QuoteAlways quotes every name, so no name can be mistaken for a keyword.
+	QuoteAlways QuoteStyle = iota
+	// QuoteAsWritten quotes the names which were quoted in the source.
+	QuoteAsWritten
+)
+
+// Style is how a formatter writes what the ast leaves open. The zero value
+// writes keywords as they were given, quotes every name, does not align
+// columns and leaves out comments, which is what a generated migration wants.
+type Style struct {
+	KeywordCase  KeywordCase
+	Quote        QuoteStyle
+	AlignColumns bool
+	KeepComments bool
 }
 
 type FormatMode int
@@ -32,6 +63,7 @@ type CoreFormatter struct {
 	indentStr             string
 	escapeIdentifierStart string
 	escapeIdentifierEnd   string
+	style                 Style
 
 	// State
 	column      int
@@ -44,6 +76,12 @@ type CoreFormatter struct {
 
 	mode        FormatMode
 	groupBroken bool
+
+	// comments waiting for the end of the line, or for the next line to
+	// start, before they are written
+	pending  []Comment
+	newlines int  // newlines written since the last text
+	written  bool // whether any text has been written
 }
 
 func NewCoreFormatter(w io.Writer, maxWidth int, escapeIdentWith string) *CoreFormatter {
@@ -64,7 +102,24 @@ func NewCoreFormatter(w io.Writer, maxWidth int, escapeIdentWith string) *CoreFo
 	}
 }
 
+// WithStyle sets the style the formatter writes in.
+func (f *CoreFormatter) WithStyle(style Style) *CoreFormatter {
+	f.style = style
+	return f
+}
+
+// WithMaxWidth sets the width groups are fitted into.
+func (f *CoreFormatter) WithMaxWidth(maxWidth int) *CoreFormatter {
+	f.maxWidth = maxWidth
+	return f
+}
+
 func (f *CoreFormatter) Space() {
+	if f.mode == FormatModeRender && len(f.pending) > 0 {
+		// comments end with a space, or a line of their own
+		f.writeComments()
+		return
+	}
 	f.Rune(' ')
 }
 
@@ -77,20 +132,43 @@ func (f *CoreFormatter) writeIndent() {
 	}
 }
 
-func (f *CoreFormatter) Identifier(s string) {
+func (f *CoreFormatter) Identifier(s string, quoted bool) {
+	if !quoted && f.style.Quote == QuoteAsWritten {
+		f.Text(s)
+		return
+	}
+	if f.escapeIdentifierEnd != "" {
+		s = strings.ReplaceAll(s, f.escapeIdentifierEnd, f.escapeIdentifierEnd+f.escapeIdentifierEnd)
+	}
 	f.Text(f.escapeIdentifierStart)
 	f.Text(s)
 	f.Text(f.escapeIdentifierEnd)
 }
 
+func (f *CoreFormatter) Keyword(s string) {
+	switch f.style.KeywordCase {
+	case KeywordCaseUpper:
+		f.Text(strings.ToUpper(s))
+	case KeywordCaseLower:
+		f.Text(strings.ToLower(s))
+	default:
+		f.Text(s)
+	}
+}
+
 func (f *CoreFormatter) Text(s string) {
 	if f.mode == FormatModeMeasure {
 		f.column += len(s)
 		return
 	}
+	f.writeComments()
 	f.writeIndent()
 	f.writer.Write([]byte(s))
 	f.column += len(s)
+	if s != "" {
+		f.newlines = 0
+		f.written = true
+	}
 }
 
 func (f *CoreFormatter) Rune(r rune) {
@@ -98,9 +176,12 @@ func (f *CoreFormatter) Rune(r rune) {
 		f.column++
 		return
 	}
+	f.writeComments()
 	f.writeIndent()
 	fmt.Fprintf(f.writer, "%c", r)
 	f.column++
+	f.newlines = 0
+	f.written = true
 }
 
 func (f *CoreFormatter) Line() {
@@ -125,8 +206,8 @@ func (f *CoreFormatter) Break() {
 		return
 	}
 
-	f.writer.Write([]byte("\n"))
-	f.column = 0
+	f.writeTrailingComments()
+	f.newline()
 }
 
 func (f *CoreFormatter) Indent(fn func()) {
@@ -168,6 +249,33 @@ func (f *CoreFormatter) Group(fn func()) {
 	f.groupBroken = prevBroken
 }
 
+func (f *CoreFormatter) Measure(fn func()) int {
+	prevMode := f.mode
+	prevColumn := f.column
+
+	f.mode = FormatModeMeasure
+	f.column = 0
+	fn()
+	width := f.column
+
+	f.mode = prevMode
+	f.column = prevColumn
+
+	return width
+}
+
+func (f *CoreFormatter) Pad(width int, fn func()) {
+	// start from after the indent, which is only written with the text
+	f.Text("")
+	start := f.column
+	fn()
+	if f.style.AlignColumns {
+		for f.column-start < width {
+			f.Space()
+		}
+	}
+}
+
 func (f *CoreFormatter) getPadding() int {
 	// If anchored, use anchor. If not, use indent level.
 	if len(f.anchorStack.Data) > 0 {
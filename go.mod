@@ -3,3 +3,10 @@ module woodybriggs/justmigrate
 go 1.25.5
 
 require github.com/mattn/go-sqlite3 v1.14.32
+
+require (
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/lib/pq v1.10.9
+)
+
+require filippo.io/edwards25519 v1.1.0 // indirect
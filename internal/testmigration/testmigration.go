@@ -0,0 +1,42 @@
+// Package testmigration generates the migrations tests check, it is apart
+// from testschema because the diff package's own tests read schemas too.
+package testmigration
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/diff"
+	"woodybriggs/justmigrate/internal/testschema"
+)
+
+// Generator is the part of a dialect's generator a test needs.
+type Generator interface {
+	Generate(writer io.Writer) error
+}
+
+// Generate diffs the schema from against the schema to with differ, both
+// read with the parser newParser makes, and returns the migration
+// newGenerator writes for the edits.
+func Generate[P testschema.Parser, G Generator](
+	t testing.TB,
+	newParser func(lexer *luther.Lexer) P,
+	newGenerator func(edits []diff.Edit) G,
+	differ diff.Diff,
+	from, to string,
+) (string, error) {
+	t.Helper()
+
+	edits, err := differ.DiffSchema(
+		testschema.Parse(t, newParser, from),
+		testschema.Parse(t, newParser, to),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := strings.Builder{}
+	err = newGenerator(edits).Generate(&builder)
+	return builder.String(), err
+}
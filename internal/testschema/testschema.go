@@ -0,0 +1,32 @@
+// Package testschema reads the schemas tests are written against.
+package testschema
+
+import (
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/report"
+)
+
+// Parser is the part of a dialect's parser a test needs.
+type Parser interface {
+	Statements() []ast.Statement
+	Errors() []report.Report
+}
+
+// Parse reads input with the parser newParser makes for it, any parse error
+// fails the test.
+func Parse[P Parser](t testing.TB, newParser func(lexer *luther.Lexer) P, input string) []ast.Statement {
+	t.Helper()
+
+	parser := newParser(luther.NewLexer(luther.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(input),
+	}))
+
+	statements := parser.Statements()
+	if errors := parser.Errors(); len(errors) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errors)
+	}
+	return statements
+}
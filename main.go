@@ -1,12 +1,10 @@
 package main
 
 import (
-	"fmt"
 	"os"
 	"woodybriggs/justmigrate/core/luther"
 	"woodybriggs/justmigrate/core/report"
 	"woodybriggs/justmigrate/dialects/sqlite/parser"
-	"woodybriggs/justmigrate/parser"
 )
 
 func main() {
@@ -0,0 +1,197 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDuplicateMigration = errors.New("duplicate migration number")
+	ErrMigrationGap       = errors.New("gap in migration numbers")
+)
+
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+// Numbering is how the next migration file in a directory is numbered.
+type Numbering int
+
+const (
+	// NumberSequential numbers files 0001, 0002, 0003 ...
+	NumberSequential Numbering = iota
+	// NumberTimestamp numbers files with the time they were generated, e.g.
+	// 20240102150405, so that migrations from different branches rarely
+	// collide.
+	NumberTimestamp
+)
+
+const timestampLayout = "20060102150405"
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([^.]*)\.(up|down)\.sql$`)
+
+// File is a migration file named NNNN_description.up.sql or
+// NNNN_description.down.sql.
+type File struct {
+	Path        string
+	Number      uint64
+	Digits      string
+	Description string
+	Direction   Direction
+}
+
+// Id is the name the migration is recorded under in the history table, the
+// file name without its direction or extension.
+func (file File) Id() string {
+	return file.Digits + "_" + file.Description
+}
+
+// ParseFileName reports whether the base name of path is a migration file
+// name.
+func ParseFileName(path string) (File, bool) {
+	match := fileNamePattern.FindStringSubmatch(filepath.Base(path))
+	if match == nil {
+		return File{}, false
+	}
+
+	number, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return File{}, false
+	}
+
+	return File{
+		Path:        path,
+		Number:      number,
+		Digits:      match[1],
+		Description: match[2],
+		Direction:   Direction(match[3]),
+	}, true
+}
+
+// FileId is the id of the migration in the file at path, e.g.
+// migrations/0001_init.up.sql is 0001_init. Files which are not named like
+// a migration use their base name without the .sql extension.
+func FileId(path string) string {
+	if file, ok := ParseFileName(path); ok {
+		return file.Id()
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".sql")
+}
+
+// ReadDir lists the migration files in dir ordered by number, up before
+// down. Other files are ignored and a directory that does not exist yet is
+// empty.
+func ReadDir(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := []File{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if file, ok := ParseFileName(filepath.Join(dir, entry.Name())); ok {
+			files = append(files, file)
+		}
+	}
+
+	slices.SortStableFunc(files, func(a, b File) int {
+		if a.Number != b.Number {
+			if a.Number < b.Number {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(string(b.Direction), string(a.Direction))
+	})
+
+	return files, nil
+}
+
+// CheckFiles reports the up migrations which share a number, and for
+// sequentially numbered directories the numbers which are missing.
+func CheckFiles(files []File, numbering Numbering) []error {
+	problems := []error{}
+
+	ups := []File{}
+	for _, file := range files {
+		if file.Direction == Up {
+			ups = append(ups, file)
+		}
+	}
+
+	for i := 1; i < len(ups); i++ {
+		previous, current := ups[i-1], ups[i]
+		switch {
+		case current.Number == previous.Number:
+			problems = append(problems, fmt.Errorf("%w: %s and %s are both %d",
+				ErrDuplicateMigration, filepath.Base(previous.Path), filepath.Base(current.Path), current.Number))
+		case numbering == NumberSequential && current.Number > previous.Number+1:
+			problems = append(problems, fmt.Errorf("%w: nothing between %s and %s",
+				ErrMigrationGap, filepath.Base(previous.Path), filepath.Base(current.Path)))
+		}
+	}
+
+	return problems
+}
+
+// NextNumber is the number of the migration to add after files.
+func NextNumber(files []File, numbering Numbering, now time.Time) uint64 {
+	var last uint64
+	for _, file := range files {
+		last = max(last, file.Number)
+	}
+
+	if numbering == NumberTimestamp {
+		number, _ := strconv.ParseUint(now.UTC().Format(timestampLayout), 10, 64)
+		return max(number, last+1)
+	}
+	return last + 1
+}
+
+// FileName is the name of the migration file numbered number, sequential
+// numbers are padded to four digits.
+func FileName(number uint64, description string, direction Direction) string {
+	return fmt.Sprintf("%04d_%s.%s.sql", number, slug(description), direction)
+}
+
+// slug lower cases the description and replaces anything that is not a
+// letter or digit with an underscore.
+func slug(description string) string {
+	builder := strings.Builder{}
+	underscore := false
+
+	for _, r := range strings.ToLower(description) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			builder.WriteRune(r)
+			underscore = false
+			continue
+		}
+		if !underscore && builder.Len() > 0 {
+			builder.WriteRune('_')
+			underscore = true
+		}
+	}
+
+	result := strings.TrimSuffix(builder.String(), "_")
+	if result == "" {
+		return "migration"
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"0001_init.up.sql",
+		"0001_init.down.sql",
+		"0002_users.up.sql",
+		"0002_accounts.up.sql",
+		"0005_teams.up.sql",
+		"README.md",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 5 {
+		t.Fatalf("expected 5 migration files, got %v", files)
+	}
+	if files[0].Direction != Up || files[1].Direction != Down {
+		t.Fatalf("expected up before down, got %v", files[:2])
+	}
+
+	problems := CheckFiles(files, NumberSequential)
+	if len(problems) != 2 ||
+		!errors.Is(problems[0], ErrDuplicateMigration) ||
+		!errors.Is(problems[1], ErrMigrationGap) {
+		t.Fatalf("expected a duplicate and a gap, got %v", problems)
+	}
+
+	if next := NextNumber(files, NumberSequential, time.Now()); next != 6 {
+		t.Fatalf("expected the next number to be 6, got %d", next)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	name := FileName(7, "Add users' email", Up)
+	if name != "0007_add_users_email.up.sql" {
+		t.Fatalf("unexpected file name %s", name)
+	}
+
+	file, ok := ParseFileName(filepath.Join("migrations", name))
+	if !ok || file.Number != 7 || file.Id() != "0007_add_users_email" {
+		t.Fatalf("unexpected file %v", file)
+	}
+}
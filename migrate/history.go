@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"woodybriggs/justmigrate/database"
+)
+
+// timestamps are stored as fixed width text so they sort in the order they
+// were applied.
+const appliedAtLayout = "2006-01-02 15:04:05.000000000"
+
+type AppliedMigration struct {
+	Id          string
+	Checksum    string
+	AppliedAt   time.Time
+	Duration    time.Duration
+	ToolVersion string
+}
+
+func (runner *Runner) EnsureHistory(ctx context.Context) error {
+	_, err := runner.Conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+database.HistoryTable+` (
+	id varchar(255) PRIMARY KEY NOT NULL,
+	checksum text NOT NULL,
+	applied_at text NOT NULL,
+	duration_ms integer NOT NULL,
+	tool_version text NOT NULL
+)`)
+	return err
+}
+
+// placeholder is the n'th parameter of a query, numbered placeholders are
+// understood by both sqlite and postgres.
+func (runner *Runner) placeholder(n int) string {
+	if runner.Placeholder != nil {
+		return runner.Placeholder(n)
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+func (runner *Runner) Applied(ctx context.Context, id string) (AppliedMigration, bool, error) {
+	row := runner.Conn.QueryRowContext(ctx, `SELECT id, checksum, applied_at, duration_ms, tool_version FROM `+database.HistoryTable+` WHERE id = `+runner.placeholder(1), id)
+
+	migration, err := scanAppliedMigration(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AppliedMigration{}, false, nil
+	}
+	if err != nil {
+		return AppliedMigration{}, false, err
+	}
+
+	return migration, true, nil
+}
+
+// History lists the applied migrations in the order they were applied.
+func (runner *Runner) History(ctx context.Context) ([]AppliedMigration, error) {
+	if err := runner.EnsureHistory(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := runner.Conn.QueryContext(ctx, `SELECT id, checksum, applied_at, duration_ms, tool_version FROM `+database.HistoryTable+` ORDER BY applied_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []AppliedMigration{}
+	for rows.Next() {
+		migration, err := scanAppliedMigration(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, migration)
+	}
+
+	return result, rows.Err()
+}
+
+func (runner *Runner) record(ctx context.Context, migration Migration, duration time.Duration) error {
+	_, err := runner.Conn.ExecContext(ctx,
+		`INSERT INTO `+database.HistoryTable+` (id, checksum, applied_at, duration_ms, tool_version) VALUES (`+runner.placeholders(5)+`)`,
+		migration.Id,
+		migration.Checksum(),
+		time.Now().UTC().Format(appliedAtLayout),
+		duration.Milliseconds(),
+		runner.ToolVersion,
+	)
+	return err
+}
+
+func (runner *Runner) placeholders(count int) string {
+	placeholders := []string{}
+	for n := 1; n <= count; n++ {
+		placeholders = append(placeholders, runner.placeholder(n))
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAppliedMigration(row scanner) (AppliedMigration, error) {
+	migration := AppliedMigration{}
+	appliedAt := ""
+	durationMs := int64(0)
+
+	err := row.Scan(&migration.Id, &migration.Checksum, &appliedAt, &durationMs, &migration.ToolVersion)
+	if err != nil {
+		return AppliedMigration{}, err
+	}
+
+	migration.AppliedAt, err = time.Parse(appliedAtLayout, appliedAt)
+	if err != nil {
+		return AppliedMigration{}, err
+	}
+	migration.Duration = time.Duration(durationMs) * time.Millisecond
+
+	return migration, nil
+}
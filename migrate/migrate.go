@@ -0,0 +1,294 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"woodybriggs/justmigrate/core/luther"
+	"woodybriggs/justmigrate/core/tik"
+)
+
+var (
+	ErrChecksumMismatch    = errors.New("migration has changed since it was applied")
+	ErrForeignKeyViolation = errors.New("migration leaves rows which break a foreign key")
+)
+
+type Migration struct {
+	Id  string
+	Sql string
+}
+
+func (migration Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(migration.Sql))
+	return hex.EncodeToString(sum[:])
+}
+
+type Runner struct {
+	Conn        *sql.Conn
+	ToolVersion string
+
+	// Placeholder is the n'th parameter of a query, numbered from 1. It is
+	// left nil for databases which understand `$1`, mysql only knows `?`.
+	Placeholder func(n int) string
+}
+
+func NewRunner(conn *sql.Conn, toolVersion string) *Runner {
+	return &Runner{
+		Conn:        conn,
+		ToolVersion: toolVersion,
+	}
+}
+
+// Apply runs a migration and records it in the history table, in the same
+// transaction as the migration itself. A migration which has already been
+// applied is skipped, unless it has changed since, which is an error.
+func (runner *Runner) Apply(ctx context.Context, migration Migration) (applied bool, err error) {
+	if err := runner.EnsureHistory(ctx); err != nil {
+		return false, err
+	}
+
+	previous, found, err := runner.Applied(ctx, migration.Id)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		if previous.Checksum != migration.Checksum() {
+			return false, fmt.Errorf("%w: '%s' was applied with checksum %s, it now has checksum %s", ErrChecksumMismatch, migration.Id, previous.Checksum, migration.Checksum())
+		}
+		return false, nil
+	}
+
+	statements := SplitStatements(migration.Id, migration.Sql)
+
+	// a migration may manage its own transaction, e.g. to turn off foreign
+	// keys outside of it, otherwise the whole migration is run in one.
+	managed := false
+	for _, statement := range statements {
+		if statement.Kind == StatementBegin {
+			managed = true
+			break
+		}
+	}
+	if !managed {
+		statements = append(
+			[]Statement{{Kind: StatementBegin, Sql: "BEGIN"}},
+			append(statements, Statement{Kind: StatementCommit, Sql: "COMMIT"})...,
+		)
+	}
+
+	// foreign key enforcement belongs to the connection, a migration which
+	// turns it off, e.g. to rebuild a table, has it put back how it was.
+	if slices.ContainsFunc(statements, func(statement Statement) bool {
+		return statement.Kind == StatementForeignKeys
+	}) {
+		restore, err := runner.saveForeignKeys(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer restore()
+	}
+
+	start := time.Now()
+	inTransaction := false
+	recorded := false
+
+	rollback := func(err error) (bool, error) {
+		if inTransaction {
+			runner.Conn.ExecContext(ctx, "ROLLBACK")
+		}
+		return false, err
+	}
+
+	for _, statement := range statements {
+		switch statement.Kind {
+		case StatementBegin:
+			inTransaction = true
+		case StatementCommit:
+			if !recorded {
+				if err := runner.record(ctx, migration, time.Since(start)); err != nil {
+					return rollback(err)
+				}
+				recorded = true
+			}
+		case StatementRollback:
+			inTransaction = false
+		}
+
+		if statement.Kind == StatementForeignKeyCheck {
+			if err := runner.checkForeignKeys(ctx, statement.Sql); err != nil {
+				return rollback(fmt.Errorf("%s: %w", migration.Id, err))
+			}
+			continue
+		}
+
+		if _, err := runner.Conn.ExecContext(ctx, statement.Sql); err != nil {
+			return rollback(fmt.Errorf("%s: %w\n%s", migration.Id, err, statement.Sql))
+		}
+
+		if statement.Kind == StatementCommit {
+			inTransaction = false
+		}
+	}
+
+	if !recorded {
+		if err := runner.record(ctx, migration, time.Since(start)); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// saveForeignKeys reads whether foreign keys are enforced, and returns a
+// function which puts that back once the migration is done.
+func (runner *Runner) saveForeignKeys(ctx context.Context) (func(), error) {
+	var enabled int
+	if err := runner.Conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		return nil, err
+	}
+	return func() {
+		runner.Conn.ExecContext(ctx, fmt.Sprintf("PRAGMA foreign_keys = %d", enabled))
+	}, nil
+}
+
+// checkForeignKeys runs a foreign key check, which reports the rows breaking
+// a foreign key rather than failing, any row it returns is an error.
+func (runner *Runner) checkForeignKeys(ctx context.Context, query string) error {
+	rows, err := runner.Conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	violations := []string{}
+	for rows.Next() {
+		var table, parent string
+		var rowId sql.NullInt64
+		var fkId int
+		if err := rows.Scan(&table, &rowId, &parent, &fkId); err != nil {
+			return err
+		}
+		if rowId.Valid {
+			violations = append(violations, fmt.Sprintf("%s row %d references a missing %s", table, rowId.Int64, parent))
+		} else {
+			violations = append(violations, fmt.Sprintf("%s references a missing %s", table, parent))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrForeignKeyViolation, strings.Join(violations, ", "))
+	}
+	return nil
+}
+
+type StatementKind int
+
+const (
+	StatementOther StatementKind = iota
+	StatementBegin
+	StatementCommit
+	StatementRollback
+	StatementForeignKeys
+	StatementForeignKeyCheck
+)
+
+type Statement struct {
+	Kind StatementKind
+	Sql  string
+}
+
+// SplitStatements splits a migration into its statements so they can be run
+// one at a time. The semicolons inside of a trigger body do not end the
+// statement.
+func SplitStatements(fileName string, migration string) []Statement {
+	source := luther.SourceCode{
+		FileName: fileName,
+		Raw:      []rune(migration),
+	}
+	lexer := luther.NewLexer(source)
+
+	statements := []Statement{}
+	tokens := []tik.Token{}
+	depth := 0
+
+	flush := func() {
+		if len(tokens) == 0 {
+			return
+		}
+		start := tokens[0].SourceRange.Start
+		end := tokens[len(tokens)-1].SourceRange.End
+		statements = append(statements, Statement{
+			Kind: statementKind(tokens),
+			Sql:  string(source.Raw[start:end]),
+		})
+		tokens = tokens[:0]
+	}
+
+	for token := lexer.NextToken(); token.Kind != tik.TokenKind_EOF; token = lexer.NextToken() {
+		switch token.Kind {
+		case ';':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		case tik.TokenKind_Keyword_BEGIN, tik.TokenKind_Keyword_CASE:
+			// a BEGIN at the start of a statement is a transaction, anywhere
+			// else it opens a trigger body.
+			if len(tokens) > 0 {
+				depth++
+			}
+		case tik.TokenKind_Keyword_END:
+			if depth > 0 {
+				depth--
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	flush()
+
+	return statements
+}
+
+func statementKind(tokens []tik.Token) StatementKind {
+	switch tokens[0].Kind {
+	case tik.TokenKind_Keyword_BEGIN:
+		return StatementBegin
+	case tik.TokenKind_Keyword_COMMIT, tik.TokenKind_Keyword_END:
+		return StatementCommit
+	case tik.TokenKind_Keyword_ROLLBACK:
+		return StatementRollback
+	case tik.TokenKind_Keyword_PRAMGA:
+		name, value := pragma(tokens)
+		switch {
+		case name == "foreign_keys" && value:
+			return StatementForeignKeys
+		case name == "foreign_key_check":
+			return StatementForeignKeyCheck
+		}
+		return StatementOther
+	default:
+		return StatementOther
+	}
+}
+
+// pragma is the name of the pragma a statement runs, without its schema or
+// quotes, and whether it is given a value.
+func pragma(tokens []tik.Token) (name string, value bool) {
+	rest := tokens[1:]
+	if len(rest) > 2 && rest[1].Kind == '.' {
+		rest = rest[2:]
+	}
+	if len(rest) == 0 {
+		return "", false
+	}
+	return strings.ToLower(strings.Trim(rest[0].Text, "\"`[]")), len(rest) > 1
+}
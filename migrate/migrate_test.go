@@ -0,0 +1,185 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newRunner(t *testing.T) *Runner {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewRunner(conn, "test")
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := SplitStatements(t.Name(), `BEGIN;
+CREATE TABLE t (a integer);
+CREATE TRIGGER tr AFTER INSERT ON t BEGIN
+	UPDATE t SET a = CASE WHEN a > 0 THEN a ELSE 0 END;
+END;
+COMMIT;`)
+
+	expected := []StatementKind{StatementBegin, StatementOther, StatementOther, StatementCommit}
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %v", len(expected), statements)
+	}
+	for i, statement := range statements {
+		if statement.Kind != expected[i] {
+			t.Errorf("statement %d: expected kind %d, got %d", i, expected[i], statement.Kind)
+		}
+	}
+}
+
+func TestApplyRecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	runner := newRunner(t)
+	migration := Migration{Id: "0001_init", Sql: "CREATE TABLE t (a integer);"}
+
+	applied, err := runner.Apply(ctx, migration)
+	if err != nil || !applied {
+		t.Fatalf("expected the migration to be applied, got %v, %v", applied, err)
+	}
+
+	applied, err = runner.Apply(ctx, migration)
+	if err != nil || applied {
+		t.Fatalf("expected the migration to be skipped, got %v, %v", applied, err)
+	}
+
+	migration.Sql = "CREATE TABLE t (a integer, b integer);"
+	if _, err := runner.Apply(ctx, migration); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected %v, got %v", ErrChecksumMismatch, err)
+	}
+
+	history, err := runner.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Id != "0001_init" || history[0].ToolVersion != "test" {
+		t.Fatalf("unexpected history %v", history)
+	}
+}
+
+func TestApplyRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	runner := newRunner(t)
+
+	_, err := runner.Apply(ctx, Migration{
+		Id:  "0001_broken",
+		Sql: "CREATE TABLE t (a integer); CREATE TABLE t (a integer);",
+	})
+	if err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+
+	if _, ok, err := runner.Applied(ctx, "0001_broken"); err != nil || ok {
+		t.Fatalf("expected no history for a failed migration, got %v, %v", ok, err)
+	}
+
+	var count int
+	if err := runner.Conn.QueryRowContext(ctx, "select count(*) from sqlite_schema where name = 't'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("expected the failed migration to be rolled back")
+	}
+}
+
+func TestSplitStatementsPragmas(t *testing.T) {
+	statements := SplitStatements(t.Name(), `PRAGMA "foreign_keys" = OFF;
+PRAGMA foreign_keys;
+PRAGMA main.foreign_key_check;
+PRAGMA user_version = 2;`)
+
+	expected := []StatementKind{StatementForeignKeys, StatementOther, StatementForeignKeyCheck, StatementOther}
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %v", len(expected), statements)
+	}
+	for i, statement := range statements {
+		if statement.Kind != expected[i] {
+			t.Errorf("statement %d: expected kind %d, got %d", i, expected[i], statement.Kind)
+		}
+	}
+}
+
+func foreignKeys(t *testing.T, runner *Runner) int {
+	var enabled int
+	if err := runner.Conn.QueryRowContext(context.Background(), "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		t.Fatal(err)
+	}
+	return enabled
+}
+
+// TestApplyForeignKeyCheck rolls back a migration which leaves a row pointing
+// at nothing, foreign keys are put back on as they were before.
+func TestApplyForeignKeyCheck(t *testing.T) {
+	ctx := context.Background()
+	runner := newRunner(t)
+
+	if _, err := runner.Conn.ExecContext(ctx, `PRAGMA foreign_keys = ON;
+CREATE TABLE plans (id integer PRIMARY KEY);
+CREATE TABLE prices (id integer PRIMARY KEY, plan_id integer REFERENCES plans (id));
+INSERT INTO plans VALUES (1);
+INSERT INTO prices VALUES (1, 1);`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := runner.Apply(ctx, Migration{
+		Id: "0001_orphans",
+		Sql: `PRAGMA foreign_keys = OFF;
+BEGIN;
+DELETE FROM plans;
+PRAGMA foreign_key_check;
+COMMIT;`,
+	})
+	if !errors.Is(err, ErrForeignKeyViolation) {
+		t.Fatalf("expected %v, got %v", ErrForeignKeyViolation, err)
+	}
+
+	var count int
+	if err := runner.Conn.QueryRowContext(ctx, "select count(*) from plans").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Error("expected the migration to be rolled back")
+	}
+	if foreignKeys(t, runner) != 1 {
+		t.Error("expected foreign keys to be turned back on")
+	}
+}
+
+// TestApplyRestoresForeignKeys leaves foreign keys off when they were off
+// before, even though the migration turns them on.
+func TestApplyRestoresForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	runner := newRunner(t)
+
+	applied, err := runner.Apply(ctx, Migration{
+		Id: "0001_init",
+		Sql: `PRAGMA foreign_keys = OFF;
+BEGIN;
+CREATE TABLE t (a integer);
+PRAGMA foreign_key_check;
+COMMIT;
+PRAGMA foreign_keys = ON;`,
+	})
+	if err != nil || !applied {
+		t.Fatalf("expected the migration to be applied, got %v, %v", applied, err)
+	}
+	if foreignKeys(t, runner) != 0 {
+		t.Error("expected foreign keys to be left off")
+	}
+}
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/report"
+)
+
+// definition is the object a statement defines. tables, views and virtual
+// tables share one namespace, as do enums and domains.
+type definition struct {
+	kind      string
+	namespace string
+	name      *ast.CatalogObjectIdentifier
+}
+
+func defines(statement ast.Statement) (definition, bool) {
+	switch statement := statement.(type) {
+	case *ast.CreateTable:
+		return definition{"table", "relation", statement.TableIdentifier}, true
+	case *ast.CreateVirtualTable:
+		return definition{"virtual table", "relation", statement.TableIdentifier}, true
+	case *ast.CreateView:
+		return definition{"view", "relation", statement.ViewIdentifier}, true
+	case *ast.CreateIndex:
+		return definition{"index", "index", statement.IndexIdentifier}, true
+	case *ast.CreateTrigger:
+		return definition{"trigger", "trigger", statement.TriggerIdentifier}, true
+	case *ast.CreateEnum:
+		return definition{"enum", "type", statement.TypeIdentifier}, true
+	case *ast.CreateDomain:
+		return definition{"domain", "type", statement.DomainIdentifier}, true
+	}
+	return definition{}, false
+}
+
+func (def definition) key() string {
+	name := strings.ToLower(def.name.ObjectName.Text)
+	if def.name.SchemaName != nil {
+		name = strings.ToLower(def.name.SchemaName.Text) + "." + name
+	}
+	return def.namespace + " " + name
+}
+
+// Duplicates reports every object defined more than once, labelled where it
+// is first defined and where it is defined again, which is usually in two
+// different files.
+func Duplicates(statements []ast.Statement) []report.Report {
+	first := map[string]definition{}
+	reports := []report.Report{}
+
+	for _, statement := range statements {
+		def, ok := defines(statement)
+		if !ok {
+			continue
+		}
+
+		previous, ok := first[def.key()]
+		if !ok {
+			first[def.key()] = def
+			continue
+		}
+
+		reports = append(reports, *report.
+			NewReport("duplicate definition").
+			WithMessage(fmt.Sprintf("%s '%s' is defined more than once", def.kind, def.name.ObjectName.Text)).
+			WithLabels([]report.Label{
+				{
+					Source: previous.name.ObjectName.SourceCode,
+					Range:  previous.name.ObjectName.SourceRange,
+					Note:   fmt.Sprintf("first defined here, as a %s", previous.kind),
+				},
+				{
+					Source: def.name.ObjectName.SourceCode,
+					Range:  def.name.ObjectName.SourceRange,
+					Note:   "defined again here",
+				},
+			}))
+	}
+
+	return reports
+}
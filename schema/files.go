@@ -0,0 +1,144 @@
+// schema reads a schema which is spread over many files.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/core/luther"
+)
+
+var (
+	ErrIncludeCycle   = errors.New("include cycle")
+	ErrIncludeMissing = errors.New("included file does not exist")
+	ErrNoFiles        = errors.New("no .sql files")
+)
+
+// includePattern matches an include directive on a line of its own, the path
+// is relative to the file it is in.
+//
+//	-- include billing/invoices.sql
+var includePattern = regexp.MustCompile(`(?m)^[ \t]*--[ \t]*include[ \t]+(\S+)[ \t]*$`)
+
+// Include is an include directive, Line counts from 1.
+type Include struct {
+	Path string
+	Line int
+}
+
+// Includes lists the include directives of a file in the order they are
+// written.
+func Includes(source luther.SourceCode) []Include {
+	raw := string(source.Raw)
+
+	includes := []Include{}
+	for _, match := range includePattern.FindAllStringSubmatchIndex(raw, -1) {
+		includes = append(includes, Include{
+			Path: raw[match[2]:match[3]],
+			Line: strings.Count(raw[:match[0]], "\n") + 1,
+		})
+	}
+	return includes
+}
+
+// Files reads the files of the schema at pattern, a file, a directory whose
+// .sql files are read in the order of their paths, or a glob. each file
+// comes after the files it includes, and a file is only read once however
+// many times it is included.
+func Files(pattern string) ([]luther.SourceCode, error) {
+	paths, err := expand(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := loader{read: map[string]bool{}}
+	for _, path := range paths {
+		if err := loader.load(path, nil); err != nil {
+			return nil, err
+		}
+	}
+	return loader.files, nil
+}
+
+// expand lists the .sql files of a directory or the files matching a glob,
+// in order.
+func expand(pattern string) ([]string, error) {
+	paths := []string{}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		paths = matches
+	} else if info, err := os.Stat(pattern); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return []string{pattern}, nil
+	} else {
+		err := filepath.WalkDir(pattern, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() && path != pattern && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".sql") {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoFiles, pattern)
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+type loader struct {
+	read  map[string]bool
+	files []luther.SourceCode
+}
+
+// load reads path after the files it includes, stack is the chain of files
+// which included it.
+func (loader *loader) load(path string, stack []string) error {
+	path = filepath.Clean(path)
+	if slices.Contains(stack, path) {
+		return fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(append(stack, path), " -> "))
+	}
+	if loader.read[path] {
+		return nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	source := luther.SourceCode{FileName: path, Raw: []rune(string(raw))}
+
+	stack = append(stack, path)
+	for _, include := range Includes(source) {
+		included := filepath.Join(filepath.Dir(path), include.Path)
+		if _, err := os.Stat(included); err != nil {
+			return fmt.Errorf("%w: %s:%d includes %s", ErrIncludeMissing, path, include.Line, included)
+		}
+		if err := loader.load(included, stack); err != nil {
+			return err
+		}
+	}
+
+	loader.read[path] = true
+	loader.files = append(loader.files, source)
+	return nil
+}
@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"woodybriggs/justmigrate/core/ast"
+	"woodybriggs/justmigrate/core/luther"
+	sqlite "woodybriggs/justmigrate/dialects/sqlite/parser"
+)
+
+func fileNames(files []luther.SourceCode) []string {
+	names := []string{}
+	for _, file := range files {
+		names = append(names, filepath.ToSlash(file.FileName))
+	}
+	return names
+}
+
+// TestFiles reads a directory split by domain, the file both domains include
+// comes first and is only read once.
+func TestFiles(t *testing.T) {
+	expected := []string{
+		"testdata/domains/currencies/currencies.sql",
+		"testdata/domains/billing/invoices.sql",
+		"testdata/domains/exchange_rates/rates.sql",
+	}
+
+	for _, pattern := range []string{"testdata/domains", "testdata/domains/*/*.sql"} {
+		files, err := Files(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names := fileNames(files); !slices.Equal(names, expected) {
+			t.Errorf("%s: expected %v, got %v", pattern, expected, names)
+		}
+	}
+
+	files, err := Files("testdata/domains/billing/invoices.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := fileNames(files); !slices.Equal(names, expected[:2]) {
+		t.Errorf("expected %v, got %v", expected[:2], names)
+	}
+}
+
+func TestFilesIncludeCycle(t *testing.T) {
+	if _, err := Files("testdata/cycle"); !errors.Is(err, ErrIncludeCycle) {
+		t.Errorf("expected ErrIncludeCycle, got %v", err)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	files, err := Files("testdata/duplicates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []ast.Statement{}
+	for _, file := range files {
+		parser := sqlite.NewSqliteParser(luther.NewLexer(file))
+		statements = append(statements, parser.Statements()...)
+		if len(parser.Errors()) > 0 {
+			t.Fatalf("%s does not parse", file.FileName)
+		}
+	}
+
+	reports := Duplicates(statements)
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 duplicate, got %d", len(reports))
+	}
+
+	labels := reports[0].Labels
+	if len(labels) != 2 || labels[0].Source.FileName == labels[1].Source.FileName {
+		t.Errorf("expected labels in both files, got %v", labels)
+	}
+}
@@ -0,0 +1,2 @@
+-- include b.sql
+create table a (id integer);
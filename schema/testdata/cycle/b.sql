@@ -0,0 +1,2 @@
+-- include a.sql
+create table b (id integer);
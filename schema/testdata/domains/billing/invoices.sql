@@ -0,0 +1,7 @@
+-- include ../currencies/currencies.sql
+
+create table invoices (
+    id integer primary key,
+    currency text not null references currencies (code),
+    amount integer not null
+);
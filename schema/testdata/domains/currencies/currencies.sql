@@ -0,0 +1,4 @@
+create table currencies (
+    code text primary key,
+    name text not null
+);
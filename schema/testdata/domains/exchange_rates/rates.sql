@@ -0,0 +1,7 @@
+-- include ../currencies/currencies.sql
+
+create table exchange_rates (
+    "from" text not null references currencies (code),
+    "to" text not null references currencies (code),
+    rate real not null
+);
@@ -0,0 +1,3 @@
+create table posts (id integer);
+
+create view users as select * from posts;